@@ -39,6 +39,8 @@ const (
 	MimetypeDataWithValidator = "data/validator"
 	MimetypeTypedData         = "data/typed"
 	MimetypeClique            = "application/x-clique-header"
+	MimetypeQBFTHeader        = "application/x-qbft-header"
+	MimetypeQBFTMessage       = "application/x-qbft-message"
 	MimetypeTextPlain         = "text/plain"
 )
 
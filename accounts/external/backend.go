@@ -163,9 +163,12 @@ func (api *ExternalSigner) SignData(account accounts.Account, mimeType string, d
 		hexutil.Encode(data)); err != nil {
 		return nil, err
 	}
-	// If V is on 27/28-form, convert to 0/1 for Clique
-	if mimeType == accounts.MimetypeClique && (res[64] == 27 || res[64] == 28) {
-		res[64] -= 27 // Transform V from 27/28 to 0/1 for Clique use
+	// If V is on 27/28-form, convert to 0/1 for Clique and QBFT
+	switch mimeType {
+	case accounts.MimetypeClique, accounts.MimetypeQBFTHeader, accounts.MimetypeQBFTMessage:
+		if res[64] == 27 || res[64] == 28 {
+			res[64] -= 27 // Transform V from 27/28 to 0/1 for Clique and QBFT use
+		}
 	}
 	return res, nil
 }
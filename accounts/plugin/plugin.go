@@ -0,0 +1,388 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package plugin implements an account backend which delegates key management
+// and signing to an out-of-process signer plugin.
+//
+// Plugins are executables speaking JSON-RPC over their standard input and output.
+// They have to implement the following methods in the "plugin" namespace:
+//
+//	plugin_version() string
+//	plugin_list() []address
+//	plugin_derive(path string) address
+//	plugin_signHash(account address, hash bytes) bytes
+//
+// Signatures are expected in the [R || S || V] format, with V being 0 or 1. The
+// Serve function can be used to implement a plugin in Go.
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Scheme is the URL scheme of plugin wallets.
+const Scheme = "plugin"
+
+// requestTimeout is the maximum time to wait for a plugin to answer a request.
+const requestTimeout = 30 * time.Second
+
+var errSignerMismatch = errors.New("plugin signature does not match account")
+
+// Backend is an accounts.Backend exposing a single wallet backed by a signer
+// plugin.
+type Backend struct {
+	wallet *Wallet
+}
+
+// SplitCommand splits a plugin command line into the executable and its arguments
+// the way a POSIX shell would, without any expansion. Arguments are separated by
+// unquoted whitespace. Single quotes preserve everything up to the closing quote,
+// double quotes preserve everything but backslash escapes of '"' and '\\', and an
+// unquoted backslash preserves the next character.
+func SplitCommand(command string) ([]string, error) {
+	var (
+		fields []string
+		field  strings.Builder
+		inArg  bool // whether a field was started, possibly empty ("")
+		quote  rune // active quote character, or 0
+		escape bool // whether the previous character was an escaping backslash
+	)
+	for _, c := range command {
+		switch {
+		case escape:
+			if quote == '"' && c != '"' && c != '\\' {
+				field.WriteRune('\\')
+			}
+			field.WriteRune(c)
+			escape = false
+		case c == '\\' && quote != '\'':
+			escape, inArg = true, true
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			field.WriteRune(c)
+		case c == '\'' || c == '"':
+			quote, inArg = c, true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inArg {
+				fields = append(fields, field.String())
+				field.Reset()
+				inArg = false
+			}
+		default:
+			field.WriteRune(c)
+			inArg = true
+		}
+	}
+	if escape {
+		return nil, errors.New("unterminated escape in plugin command")
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in plugin command", quote)
+	}
+	if inArg {
+		fields = append(fields, field.String())
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("empty plugin command")
+	}
+	return fields, nil
+}
+
+// NewBackend starts the plugin executable with the given arguments, and creates
+// an account backend talking to it over stdin/stdout. The plugin is terminated
+// when the wallet is closed.
+func NewBackend(command string, args ...string) (*Backend, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Stderr = os.Stderr
+
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start signer plugin: %w", err)
+	}
+	closer := func() error {
+		in.Close()
+		return cmd.Wait()
+	}
+	url := accounts.URL{Scheme: Scheme, Path: strings.Join(append([]string{command}, args...), " ")}
+	wallet, err := newWallet(url, out, in, closer)
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, err
+	}
+	return &Backend{wallet: wallet}, nil
+}
+
+// Wallets implements accounts.Backend, returning the single plugin wallet.
+func (b *Backend) Wallets() []accounts.Wallet {
+	return []accounts.Wallet{b.wallet}
+}
+
+// Subscribe implements accounts.Backend. Plugin wallets never come or go, so no
+// events are ever sent.
+func (b *Backend) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
+// Close terminates the plugin.
+func (b *Backend) Close() error {
+	return b.wallet.Close()
+}
+
+// Wallet is an accounts.Wallet whose keys are held by a signer plugin.
+type Wallet struct {
+	url    accounts.URL
+	client *rpc.Client
+	closer func() error
+	status string
+
+	lock      sync.Mutex
+	pinned    []common.Address // Accounts explicitly derived and pinned
+	cache     []common.Address // Accounts last reported by the plugin
+	closed    bool
+	closeOnce sync.Once
+}
+
+// newWallet creates a wallet speaking the plugin protocol over the given streams.
+func newWallet(url accounts.URL, in io.Reader, out io.Writer, closer func() error) (*Wallet, error) {
+	client, err := rpc.DialIO(context.Background(), in, out)
+	if err != nil {
+		return nil, err
+	}
+	w := &Wallet{url: url, client: client, closer: closer}
+
+	var version string
+	if err := w.call(&version, "plugin_version"); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("signer plugin unreachable: %w", err)
+	}
+	w.status = fmt.Sprintf("ok [version=%v]", version)
+	log.Info("Signer plugin started", "url", url, "version", version)
+	return w, nil
+}
+
+// call invokes a plugin method with the default request timeout.
+func (w *Wallet) call(result interface{}, method string, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	return w.client.CallContext(ctx, result, method, args...)
+}
+
+// URL implements accounts.Wallet, returning the URL of the plugin.
+func (w *Wallet) URL() accounts.URL {
+	return w.url
+}
+
+// Status implements accounts.Wallet, returning the version reported by the plugin.
+func (w *Wallet) Status() (string, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.closed {
+		return "closed", accounts.ErrWalletClosed
+	}
+	return w.status, nil
+}
+
+// Open implements accounts.Wallet. The plugin is started when the backend is
+// created, so this is a noop.
+func (w *Wallet) Open(passphrase string) error {
+	return nil
+}
+
+// Close implements accounts.Wallet, terminating the plugin.
+func (w *Wallet) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		w.lock.Lock()
+		w.closed = true
+		w.lock.Unlock()
+
+		// Terminate the plugin first, unblocking the client's reader
+		if w.closer != nil {
+			err = w.closer()
+		}
+		w.client.Close()
+	})
+	return err
+}
+
+// Accounts implements accounts.Wallet, returning the accounts reported by the
+// plugin along with any pinned derived accounts.
+func (w *Wallet) Accounts() []accounts.Account {
+	var addrs []common.Address
+	if err := w.call(&addrs, "plugin_list"); err != nil {
+		log.Error("Signer plugin account listing failed", "err", err)
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.cache = addrs
+	var (
+		accs = make([]accounts.Account, 0, len(addrs)+len(w.pinned))
+		seen = make(map[common.Address]bool)
+	)
+	for _, addr := range append(addrs, w.pinned...) {
+		if seen[addr] {
+			continue
+		}
+		seen[addr] = true
+		accs = append(accs, accounts.Account{Address: addr, URL: w.url})
+	}
+	return accs
+}
+
+// Contains implements accounts.Wallet, returning whether a particular account is
+// or is not managed by the plugin.
+func (w *Wallet) Contains(account accounts.Account) bool {
+	if account.URL != (accounts.URL{}) && account.URL != w.url {
+		return false
+	}
+	w.lock.Lock()
+	cached := w.cache != nil
+	w.lock.Unlock()
+	if !cached {
+		w.Accounts()
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for _, addrs := range [][]common.Address{w.cache, w.pinned} {
+		for _, addr := range addrs {
+			if addr == account.Address {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Derive implements accounts.Wallet, asking the plugin to derive the account at
+// the given path. If pin is set, the account is tracked by the wallet.
+func (w *Wallet) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	var addr common.Address
+	if err := w.call(&addr, "plugin_derive", path.String()); err != nil {
+		return accounts.Account{}, err
+	}
+	if pin {
+		w.lock.Lock()
+		w.pinned = append(w.pinned, addr)
+		w.lock.Unlock()
+	}
+	return accounts.Account{Address: addr, URL: w.url}, nil
+}
+
+// SelfDerive implements accounts.Wallet. Account discovery is not supported by
+// plugin wallets.
+func (w *Wallet) SelfDerive(bases []accounts.DerivationPath, chain ethereum.ChainStateReader) {
+	log.Error("Operation SelfDerive not supported on signer plugins")
+}
+
+// signHash asks the plugin to sign the hash with the given account, and checks
+// that the returned signature was indeed made by that account.
+func (w *Wallet) signHash(account accounts.Account, hash []byte) ([]byte, error) {
+	if !w.Contains(account) {
+		return nil, accounts.ErrUnknownAccount
+	}
+	var sig hexutil.Bytes
+	if err := w.call(&sig, "plugin_signHash", account.Address, hexutil.Bytes(hash)); err != nil {
+		return nil, err
+	}
+	if len(sig) != crypto.SignatureLength {
+		return nil, fmt.Errorf("invalid plugin signature length %d", len(sig))
+	}
+	if sig[crypto.RecoveryIDOffset] == 27 || sig[crypto.RecoveryIDOffset] == 28 {
+		sig[crypto.RecoveryIDOffset] -= 27 // Transform V from 27/28 to 0/1
+	}
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return nil, err
+	}
+	if crypto.PubkeyToAddress(*pub) != account.Address {
+		return nil, errSignerMismatch
+	}
+	return sig, nil
+}
+
+// SignData implements accounts.Wallet, signing keccak256(data).
+func (w *Wallet) SignData(account accounts.Account, mimeType string, data []byte) ([]byte, error) {
+	return w.signHash(account, crypto.Keccak256(data))
+}
+
+// SignDataWithPassphrase implements accounts.Wallet. Plugins manage their own
+// authentication, so passphrases are not supported.
+func (w *Wallet) SignDataWithPassphrase(account accounts.Account, passphrase, mimeType string, data []byte) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// SignText implements accounts.Wallet, signing the hash of the given text with
+// the Ethereum message prefix.
+func (w *Wallet) SignText(account accounts.Account, text []byte) ([]byte, error) {
+	return w.signHash(account, accounts.TextHash(text))
+}
+
+// SignTextWithPassphrase implements accounts.Wallet. Plugins manage their own
+// authentication, so passphrases are not supported.
+func (w *Wallet) SignTextWithPassphrase(account accounts.Account, passphrase string, text []byte) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// SignTx implements accounts.Wallet, signing the transaction with the latest
+// signer for the given chain ID.
+func (w *Wallet) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	signer := types.LatestSignerForChainID(chainID)
+	sig, err := w.signHash(account, signer.Hash(tx).Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSignature(signer, sig)
+}
+
+// SignTxWithPassphrase implements accounts.Wallet. Plugins manage their own
+// authentication, so passphrases are not supported.
+func (w *Wallet) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return nil, accounts.ErrNotSupported
+}
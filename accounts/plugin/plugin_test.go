@@ -0,0 +1,200 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package plugin
+
+import (
+	"errors"
+	"io"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// newTestWallet connects a wallet to an in-process software signer plugin.
+func newTestWallet(t *testing.T, signer Signer) *Wallet {
+	t.Helper()
+
+	// Wire up two pipes, one for each direction
+	pluginIn, walletOut := io.Pipe()
+	walletIn, pluginOut := io.Pipe()
+	go Serve(signer, pluginIn, pluginOut)
+
+	closer := func() error {
+		walletOut.Close()
+		return pluginOut.Close()
+	}
+	w, err := newWallet(accounts.URL{Scheme: Scheme, Path: "test"}, walletIn, walletOut, closer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { w.Close() })
+	return w
+}
+
+func TestPluginWallet(t *testing.T) {
+	t.Parallel()
+
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	w := newTestWallet(t, NewSoftwareSigner(key))
+
+	if status, err := w.Status(); err != nil || status != "ok [version=software/1.0.0]" {
+		t.Fatalf("wrong status: %q, %v", status, err)
+	}
+	accs := w.Accounts()
+	if len(accs) != 1 || accs[0].Address != addr || accs[0].URL != w.URL() {
+		t.Fatalf("wrong accounts: %v", accs)
+	}
+	// Derive a new account and check that it gets tracked
+	derived, err := w.Derive(accounts.DefaultBaseDerivationPath, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !w.Contains(derived) || len(w.Accounts()) != 2 {
+		t.Fatalf("derived account not tracked")
+	}
+	again, _ := w.Derive(accounts.DefaultBaseDerivationPath, false)
+	if again.Address != derived.Address {
+		t.Fatalf("derivation not deterministic: %v != %v", again.Address, derived.Address)
+	}
+	// Sign text and data, and check the recovered signers
+	for _, acc := range []accounts.Account{{Address: addr}, derived} {
+		sig, err := w.SignText(acc, []byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pub, err := crypto.SigToPub(accounts.TextHash([]byte("hello")), sig); err != nil || crypto.PubkeyToAddress(*pub) != acc.Address {
+			t.Fatalf("wrong text signer: %v", err)
+		}
+		sig, err = w.SignData(acc, accounts.MimetypeClique, []byte("data"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pub, err := crypto.SigToPub(crypto.Keccak256([]byte("data")), sig); err != nil || crypto.PubkeyToAddress(*pub) != acc.Address {
+			t.Fatalf("wrong data signer: %v", err)
+		}
+	}
+	// Unknown accounts must be rejected before reaching the plugin
+	if _, err := w.SignText(accounts.Account{Address: common.Address{1}}, nil); !errors.Is(err, accounts.ErrUnknownAccount) {
+		t.Fatalf("unknown account: have %v, want %v", err, accounts.ErrUnknownAccount)
+	}
+	if _, err := w.SignTextWithPassphrase(accounts.Account{Address: addr}, "", nil); !errors.Is(err, accounts.ErrNotSupported) {
+		t.Fatalf("passphrase signing: have %v, want %v", err, accounts.ErrNotSupported)
+	}
+}
+
+func TestPluginWalletSignTx(t *testing.T) {
+	t.Parallel()
+
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	w := newTestWallet(t, NewSoftwareSigner(key))
+
+	chainID := big.NewInt(1337)
+	txs := []*types.Transaction{
+		types.NewTransaction(0, common.Address{0xaa}, big.NewInt(1), 21000, big.NewInt(1), nil),
+		types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: 1, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 21000}),
+	}
+	for i, tx := range txs {
+		signed, err := w.SignTx(accounts.Account{Address: addr}, tx, chainID)
+		if err != nil {
+			t.Fatalf("tx %d: %v", i, err)
+		}
+		sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+		if err != nil {
+			t.Fatalf("tx %d: %v", i, err)
+		}
+		if sender != addr {
+			t.Fatalf("tx %d: wrong sender: have %v, want %v", i, sender, addr)
+		}
+	}
+}
+
+// lyingSigner signs every request with a key unrelated to the account.
+type lyingSigner struct {
+	*SoftwareSigner
+}
+
+func (s lyingSigner) SignHash(account common.Address, hash []byte) ([]byte, error) {
+	key, _ := crypto.GenerateKey()
+	return crypto.Sign(hash, key)
+}
+
+func TestPluginWalletSignerMismatch(t *testing.T) {
+	t.Parallel()
+
+	key, _ := crypto.GenerateKey()
+	w := newTestWallet(t, lyingSigner{NewSoftwareSigner(key)})
+
+	_, err := w.SignText(accounts.Account{Address: crypto.PubkeyToAddress(key.PublicKey)}, []byte("hello"))
+	if !errors.Is(err, errSignerMismatch) {
+		t.Fatalf("have %v, want %v", err, errSignerMismatch)
+	}
+}
+
+func TestPluginWalletClose(t *testing.T) {
+	t.Parallel()
+
+	key, _ := crypto.GenerateKey()
+	w := newTestWallet(t, NewSoftwareSigner(key))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Status(); !errors.Is(err, accounts.ErrWalletClosed) {
+		t.Fatalf("have %v, want %v", err, accounts.ErrWalletClosed)
+	}
+	if _, err := w.SignData(accounts.Account{Address: crypto.PubkeyToAddress(key.PublicKey)}, "", nil); err == nil {
+		t.Fatal("signing succeeded on closed wallet")
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{"softplugin -keyfile key.hex", []string{"softplugin", "-keyfile", "key.hex"}},
+		{"  kms\t--region  eu-west-1 ", []string{"kms", "--region", "eu-west-1"}},
+		{"kms --keys a,b,c", []string{"kms", "--keys", "a,b,c"}},
+		{`kms --labels "x, y" 'p,q r'`, []string{"kms", "--labels", "x, y", "p,q r"}},
+		{`"/opt/my plugin/kms" --name=it\'s`, []string{"/opt/my plugin/kms", "--name=it's"}},
+		{`kms "say \"hi\" \n" 'a\b' ""`, []string{"kms", `say "hi" \n`, `a\b`, ""}},
+		{`kms a""b`, []string{"kms", "ab"}},
+	}
+	for _, tt := range tests {
+		have, err := SplitCommand(tt.command)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.command, err)
+			continue
+		}
+		if !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("%q: have %q, want %q", tt.command, have, tt.want)
+		}
+	}
+	for _, command := range []string{"", "   ", `kms "unterminated`, "kms 'unterminated", `kms trailing\`} {
+		if _, err := SplitCommand(command); err == nil {
+			t.Errorf("%q: expected error", command)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package plugin
+
+import (
+	"io"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Signer is the interface a plugin has to implement to be served via Serve.
+type Signer interface {
+	// Version returns a textual version of the plugin.
+	Version() string
+
+	// Accounts returns the accounts the plugin can sign with.
+	Accounts() ([]common.Address, error)
+
+	// Derive derives the account at the given path.
+	Derive(path accounts.DerivationPath) (common.Address, error)
+
+	// SignHash signs the given 32 byte hash with the account, returning the
+	// signature in [R || S || V] format with V being 0 or 1.
+	SignHash(account common.Address, hash []byte) ([]byte, error)
+}
+
+// pluginAPI exposes a Signer in the "plugin" RPC namespace.
+type pluginAPI struct {
+	signer Signer
+}
+
+func (api *pluginAPI) Version() string {
+	return api.signer.Version()
+}
+
+func (api *pluginAPI) List() ([]common.Address, error) {
+	addrs, err := api.signer.Accounts()
+	if addrs == nil {
+		addrs = []common.Address{} // return [] instead of nil if empty
+	}
+	return addrs, err
+}
+
+func (api *pluginAPI) Derive(path string) (common.Address, error) {
+	p, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return api.signer.Derive(p)
+}
+
+func (api *pluginAPI) SignHash(account common.Address, hash hexutil.Bytes) (hexutil.Bytes, error) {
+	return api.signer.SignHash(account, hash)
+}
+
+// NewServer creates an RPC server exposing the signer via the plugin protocol.
+func NewServer(signer Signer) *rpc.Server {
+	srv := rpc.NewServer()
+	if err := srv.RegisterName(Scheme, &pluginAPI{signer}); err != nil {
+		panic(err) // the API is statically valid
+	}
+	return srv
+}
+
+// Serve runs the plugin protocol for the given signer over the streams, until
+// the input is closed. Plugin executables should call it with os.Stdin and
+// os.Stdout.
+func Serve(signer Signer, in io.Reader, out io.WriteCloser) {
+	srv := NewServer(signer)
+	defer srv.Stop()
+
+	srv.ServeCodec(rpc.NewCodec(&pipeConn{in, out}), 0)
+}
+
+// pipeConn joins a reader and a writer into a connection usable by an RPC codec.
+type pipeConn struct {
+	io.Reader
+	io.WriteCloser
+}
+
+// SetWriteDeadline implements rpc.Conn. Deadlines are not supported on pipes.
+func (c *pipeConn) SetWriteDeadline(time.Time) error {
+	return nil
+}
@@ -0,0 +1,46 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// softplugin is a reference signer plugin backed by a software key. It is meant
+// for testing the plugin protocol, not for production use.
+//
+//	geth --signer.plugin "softplugin -keyfile key.hex"
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/accounts/plugin"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func main() {
+	keyfile := flag.String("keyfile", "", "file containing the hex encoded master key")
+	flag.Parse()
+
+	if *keyfile == "" {
+		fmt.Fprintln(os.Stderr, "-keyfile is required")
+		os.Exit(2)
+	}
+	key, err := crypto.LoadECDSA(*keyfile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load key: %v\n", err)
+		os.Exit(1)
+	}
+	plugin.Serve(plugin.NewSoftwareSigner(key), os.Stdin, os.Stdout)
+}
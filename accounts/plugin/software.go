@@ -0,0 +1,88 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package plugin
+
+import (
+	"crypto/ecdsa"
+	"slices"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// SoftwareSigner is a reference Signer holding its keys in memory. Accounts
+// are derived from the master key by hashing it with the derivation path, which
+// is not BIP-32 compatible. It is meant for testing only.
+type SoftwareSigner struct {
+	master *ecdsa.PrivateKey
+	lock   sync.Mutex
+	keys   map[common.Address]*ecdsa.PrivateKey
+}
+
+// NewSoftwareSigner creates a software signer whose only initial account is
+// the one belonging to the master key.
+func NewSoftwareSigner(master *ecdsa.PrivateKey) *SoftwareSigner {
+	return &SoftwareSigner{
+		master: master,
+		keys:   map[common.Address]*ecdsa.PrivateKey{crypto.PubkeyToAddress(master.PublicKey): master},
+	}
+}
+
+// Version implements Signer.
+func (s *SoftwareSigner) Version() string {
+	return "software/1.0.0"
+}
+
+// Accounts implements Signer, returning the master and all derived accounts.
+func (s *SoftwareSigner) Accounts() ([]common.Address, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	addrs := make([]common.Address, 0, len(s.keys))
+	for addr := range s.keys {
+		addrs = append(addrs, addr)
+	}
+	slices.SortFunc(addrs, common.Address.Cmp)
+	return addrs, nil
+}
+
+// Derive implements Signer, deriving a child key from the master key.
+func (s *SoftwareSigner) Derive(path accounts.DerivationPath) (common.Address, error) {
+	key, err := crypto.ToECDSA(crypto.Keccak256(crypto.FromECDSA(s.master), []byte(path.String())))
+	if err != nil {
+		return common.Address{}, err
+	}
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	s.lock.Lock()
+	s.keys[addr] = key
+	s.lock.Unlock()
+	return addr, nil
+}
+
+// SignHash implements Signer.
+func (s *SoftwareSigner) SignHash(account common.Address, hash []byte) ([]byte, error) {
+	s.lock.Lock()
+	key, ok := s.keys[account]
+	s.lock.Unlock()
+	if !ok {
+		return nil, accounts.ErrUnknownAccount
+	}
+	return crypto.Sign(hash, key)
+}
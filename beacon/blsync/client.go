@@ -17,22 +17,30 @@
 package blsync
 
 import (
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/beacon/light"
 	"github.com/ethereum/go-ethereum/beacon/light/api"
 	"github.com/ethereum/go-ethereum/beacon/light/request"
 	"github.com/ethereum/go-ethereum/beacon/light/sync"
+	"github.com/ethereum/go-ethereum/beacon/params"
 	"github.com/ethereum/go-ethereum/beacon/types"
 	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/ethdb/memorydb"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/urfave/cli/v2"
 )
 
 type Client struct {
+	db           ethdb.KeyValueStore
 	urls         []string
 	customHeader map[string]string
 	chainConfig  *lightClientConfig
@@ -62,34 +70,87 @@ func NewClient(ctx *cli.Context) *Client {
 
 	// create data structures
 	var (
-		db             = memorydb.New()
+		db             = makeDatabase(ctx)
 		threshold      = ctx.Int(utils.BeaconThresholdFlag.Name)
 		committeeChain = light.NewCommitteeChain(db, chainConfig.ChainConfig, threshold, !ctx.Bool(utils.BeaconNoFilterFlag.Name))
-		headTracker    = light.NewHeadTracker(committeeChain, threshold)
+		headTracker    = light.NewHeadTracker(db, committeeChain, threshold)
 	)
-	headSync := sync.NewHeadSync(headTracker, committeeChain)
+	// with several beacon API endpoints, updates are only accepted once two of
+	// the responding ones agree, so that a single faulty endpoint can't feed a
+	// wrong head while an unreachable one doesn't stall the sync
+	urls := ctx.StringSlice(utils.BeaconApiFlag.Name)
+	headSync := sync.NewHeadSync(headTracker, committeeChain, min(len(urls), 2), &mclock.System{})
+
+	// resume from the stored light client state if it's still trusted, unless
+	// a checkpoint was explicitly requested
+	resume := canResume(chainConfig.ChainConfig, committeeChain, headTracker)
+	if ctx.IsSet(utils.BeaconCheckpointFlag.Name) {
+		resume = false
+	}
+	if !resume && chainConfig.Checkpoint == (common.Hash{}) {
+		utils.Fatalf("Beacon checkpoint not specified and no recent light client state available")
+	}
 
 	// set up scheduler and sync modules
 	scheduler := request.NewScheduler()
-	checkpointInit := sync.NewCheckpointInit(committeeChain, chainConfig.Checkpoint)
 	forwardSync := sync.NewForwardUpdateSync(committeeChain)
 	beaconBlockSync := newBeaconBlockSync(headTracker)
 	scheduler.RegisterTarget(headTracker)
 	scheduler.RegisterTarget(committeeChain)
-	scheduler.RegisterModule(checkpointInit, "checkpointInit")
+	if !resume {
+		checkpointInit := sync.NewCheckpointInit(committeeChain, chainConfig.Checkpoint)
+		scheduler.RegisterModule(checkpointInit, "checkpointInit")
+	}
 	scheduler.RegisterModule(forwardSync, "forwardSync")
 	scheduler.RegisterModule(headSync, "headSync")
 	scheduler.RegisterModule(beaconBlockSync, "beaconBlockSync")
 
 	return &Client{
+		db:           db,
 		scheduler:    scheduler,
-		urls:         ctx.StringSlice(utils.BeaconApiFlag.Name),
+		urls:         urls,
 		customHeader: customHeader,
 		chainConfig:  &chainConfig,
 		blockSync:    beaconBlockSync,
 	}
 }
 
+// makeDatabase opens the persistent light client database if a data directory
+// is specified, otherwise an ephemeral in-memory one is used.
+func makeDatabase(ctx *cli.Context) ethdb.KeyValueStore {
+	if !ctx.IsSet(utils.DataDirFlag.Name) {
+		return memorydb.New()
+	}
+	path := filepath.Join(ctx.String(utils.DataDirFlag.Name), "blsync")
+	db, err := rawdb.NewPebbleDBDatabase(path, 16, 16, "blsync/", false, false)
+	if err != nil {
+		utils.Fatalf("Could not open light client database: %v", err)
+	}
+	return db
+}
+
+// canResume checks whether the committee chain loaded from the database can be
+// used as a sync starting point, which is the case if the latest validated
+// finalized header is still within the weak subjectivity period. A stale chain
+// is reset.
+func canResume(config *types.ChainConfig, chain *light.CommitteeChain, headTracker *light.HeadTracker) bool {
+	if _, ok := chain.NextSyncPeriod(); !ok {
+		return false
+	}
+	finalized, ok := headTracker.Finalized()
+	if !ok {
+		return false
+	}
+	currentSlot := config.SlotAt(uint64(time.Now().Unix()))
+	if finalized.Slot+params.WeakSubjectivityPeriod < currentSlot {
+		log.Warn("Stored light client state is outside of the weak subjectivity period", "finalized", finalized.Slot, "current", currentSlot)
+		chain.Reset()
+		return false
+	}
+	log.Info("Resuming from stored light client state", "finalized", finalized.Slot, "hash", finalized.Hash())
+	return true
+}
+
 func (c *Client) SetEngineRPC(engine *rpc.Client) {
 	c.engineRPC = engine
 }
@@ -100,6 +161,9 @@ func (c *Client) Start() error {
 	c.engineClient = startEngineClient(c.chainConfig, c.engineRPC, headCh)
 
 	c.scheduler.Start()
+	// Every endpoint is a separate server of the scheduler, which fails over to
+	// the others when one errors or times out. The head sync cross-checks their
+	// optimistic and finality updates, failing servers that disagree.
 	for _, url := range c.urls {
 		beaconApi := api.NewBeaconLightApi(url, c.customHeader)
 		c.scheduler.RegisterServer(request.NewServer(api.NewApiServer(beaconApi), &mclock.System{}))
@@ -111,5 +175,5 @@ func (c *Client) Stop() error {
 	c.engineClient.stop()
 	c.chainHeadSub.Unsubscribe()
 	c.scheduler.Stop()
-	return nil
+	return c.db.Close()
 }
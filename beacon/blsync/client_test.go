@@ -0,0 +1,76 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package blsync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/beacon/light"
+	"github.com/ethereum/go-ethereum/beacon/params"
+	"github.com/ethereum/go-ethereum/beacon/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+)
+
+// Tests that the stored light client state is only resumed from while the
+// finalized header is within the weak subjectivity period, measured in the
+// slots of the chain.
+func TestCanResume(t *testing.T) {
+	const finalizedSlot = 100
+
+	tests := []struct {
+		name   string
+		age    uint64 // current slot relative to the finalized one
+		stored bool   // whether a finalized header is stored
+		want   bool
+	}{
+		{"no finalized header", 10, false, false},
+		{"recent", params.WeakSubjectivityPeriod - 10, true, true},
+		{"stale", params.WeakSubjectivityPeriod + 10, true, false},
+	}
+	for _, tt := range tests {
+		// Use slots half as long as on the public networks, so that the current
+		// slot is only right if it's computed from the chain config
+		config := (&types.ChainConfig{SecondsPerSlot: 6}).AddFork("GENESIS", 0, []byte{0, 0, 0, 0})
+		config.GenesisTime = uint64(time.Now().Unix()) - (finalizedSlot+tt.age)*config.SecondsPerSlot
+
+		var (
+			db        = memorydb.New()
+			committee = light.GenerateTestCommittee()
+			chain     = light.NewTestCommitteeChain(db, config, 300, false, &mclock.Simulated{})
+		)
+		if err := chain.CheckpointInit(*light.GenerateTestCheckpoint(0, committee)); err != nil {
+			t.Fatalf("%s: failed to initialize committee chain: %v", tt.name, err)
+		}
+		tracker := light.NewHeadTracker(db, chain, 300)
+		if tt.stored {
+			update := light.GenerateTestFinalityUpdate(config, committee, finalizedSlot, finalizedSlot+66, common.Hash{1}, 400)
+			if _, err := tracker.ValidateFinality(update); err != nil {
+				t.Fatalf("%s: failed to validate finality update: %v", tt.name, err)
+			}
+		}
+		if have := canResume(config, chain, tracker); have != tt.want {
+			t.Errorf("%s: resume mismatch: have %v, want %v", tt.name, have, tt.want)
+		}
+		// Stale state must be dropped, so that a checkpoint is synced from
+		if _, ok := chain.NextSyncPeriod(); ok != tt.want && tt.stored {
+			t.Errorf("%s: committee chain retention mismatch: have %v, want %v", tt.name, ok, tt.want)
+		}
+	}
+}
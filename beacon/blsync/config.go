@@ -35,6 +35,7 @@ var (
 		ChainConfig: (&types.ChainConfig{
 			GenesisValidatorsRoot: common.HexToHash("0x4b363db94e286120d76eb905340fdd4e54bfe9f06bf33ff6cf5ad27f511bfe95"),
 			GenesisTime:           1606824023,
+			SecondsPerSlot:        12,
 		}).
 			AddFork("GENESIS", 0, []byte{0, 0, 0, 0}).
 			AddFork("ALTAIR", 74240, []byte{1, 0, 0, 0}).
@@ -48,6 +49,7 @@ var (
 		ChainConfig: (&types.ChainConfig{
 			GenesisValidatorsRoot: common.HexToHash("0xd8ea171f3c94aea21ebc42a1ed61052acf3f9209c00e4efbaaddac09ed9b8078"),
 			GenesisTime:           1655733600,
+			SecondsPerSlot:        12,
 		}).
 			AddFork("GENESIS", 0, []byte{144, 0, 0, 105}).
 			AddFork("ALTAIR", 50, []byte{144, 0, 0, 112}).
@@ -61,6 +63,7 @@ var (
 		ChainConfig: (&types.ChainConfig{
 			GenesisValidatorsRoot: common.HexToHash("0x043db0d9a83813551ee2f33450d23797757d430911a9320530ad8a0eabc43efb"),
 			GenesisTime:           1614588812,
+			SecondsPerSlot:        12,
 		}).
 			AddFork("GENESIS", 0, []byte{0, 0, 16, 32}).
 			AddFork("ALTAIR", 36660, []byte{1, 0, 16, 32}).
@@ -95,9 +98,6 @@ func makeChainConfig(ctx *cli.Context) lightClientConfig {
 		if !ctx.IsSet(utils.BeaconGenesisTimeFlag.Name) {
 			utils.Fatalf("Custom beacon chain config is specified but genesis time is missing")
 		}
-		if !ctx.IsSet(utils.BeaconCheckpointFlag.Name) {
-			utils.Fatalf("Custom beacon chain config is specified but checkpoint is missing")
-		}
 		config.ChainConfig = &types.ChainConfig{
 			GenesisTime: ctx.Uint64(utils.BeaconGenesisTimeFlag.Name),
 		}
@@ -117,7 +117,8 @@ func makeChainConfig(ctx *cli.Context) lightClientConfig {
 			utils.Fatalf("Genesis time is specified but custom beacon chain config is missing")
 		}
 	}
-	// Checkpoint is required with custom chain config and is optional with pre-defined config
+	// Checkpoint is optional with pre-defined config, and with custom chain config
+	// if a recent light client state is stored in the database
 	if ctx.IsSet(utils.BeaconCheckpointFlag.Name) {
 		if c, err := hexutil.Decode(ctx.String(utils.BeaconCheckpointFlag.Name)); err == nil && len(c) <= 32 {
 			copy(config.Checkpoint[:len(c)], c)
@@ -17,6 +17,7 @@
 package engine
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 	"slices"
@@ -58,23 +59,26 @@ type payloadAttributesMarshaling struct {
 
 // ExecutableData is the data necessary to execute an EL payload.
 type ExecutableData struct {
-	ParentHash    common.Hash         `json:"parentHash"    gencodec:"required"`
-	FeeRecipient  common.Address      `json:"feeRecipient"  gencodec:"required"`
-	StateRoot     common.Hash         `json:"stateRoot"     gencodec:"required"`
-	ReceiptsRoot  common.Hash         `json:"receiptsRoot"  gencodec:"required"`
-	LogsBloom     []byte              `json:"logsBloom"     gencodec:"required"`
-	Random        common.Hash         `json:"prevRandao"    gencodec:"required"`
-	Number        uint64              `json:"blockNumber"   gencodec:"required"`
-	GasLimit      uint64              `json:"gasLimit"      gencodec:"required"`
-	GasUsed       uint64              `json:"gasUsed"       gencodec:"required"`
-	Timestamp     uint64              `json:"timestamp"     gencodec:"required"`
-	ExtraData     []byte              `json:"extraData"     gencodec:"required"`
-	BaseFeePerGas *big.Int            `json:"baseFeePerGas" gencodec:"required"`
-	BlockHash     common.Hash         `json:"blockHash"     gencodec:"required"`
-	Transactions  [][]byte            `json:"transactions"  gencodec:"required"`
-	Withdrawals   []*types.Withdrawal `json:"withdrawals"`
-	BlobGasUsed   *uint64             `json:"blobGasUsed"`
-	ExcessBlobGas *uint64             `json:"excessBlobGas"`
+	ParentHash            common.Hash                 `json:"parentHash"    gencodec:"required"`
+	FeeRecipient          common.Address              `json:"feeRecipient"  gencodec:"required"`
+	StateRoot             common.Hash                 `json:"stateRoot"     gencodec:"required"`
+	ReceiptsRoot          common.Hash                 `json:"receiptsRoot"  gencodec:"required"`
+	LogsBloom             []byte                      `json:"logsBloom"     gencodec:"required"`
+	Random                common.Hash                 `json:"prevRandao"    gencodec:"required"`
+	Number                uint64                      `json:"blockNumber"   gencodec:"required"`
+	GasLimit              uint64                      `json:"gasLimit"      gencodec:"required"`
+	GasUsed               uint64                      `json:"gasUsed"       gencodec:"required"`
+	Timestamp             uint64                      `json:"timestamp"     gencodec:"required"`
+	ExtraData             []byte                      `json:"extraData"     gencodec:"required"`
+	BaseFeePerGas         *big.Int                    `json:"baseFeePerGas" gencodec:"required"`
+	BlockHash             common.Hash                 `json:"blockHash"     gencodec:"required"`
+	Transactions          [][]byte                    `json:"transactions"  gencodec:"required"`
+	Withdrawals           []*types.Withdrawal         `json:"withdrawals"`
+	BlobGasUsed           *uint64                     `json:"blobGasUsed"`
+	ExcessBlobGas         *uint64                     `json:"excessBlobGas"`
+	Deposits              types.Deposits              `json:"depositRequests"`
+	WithdrawalRequests    types.WithdrawalRequests    `json:"withdrawalRequests"`
+	ConsolidationRequests types.ConsolidationRequests `json:"consolidationRequests"`
 }
 
 // JSON type overrides for executableData.
@@ -193,7 +197,8 @@ func decodeTransactions(enc [][]byte) ([]*types.Transaction, error) {
 //
 // and that the blockhash of the constructed block matches the parameters. Nil
 // Withdrawals value will propagate through the returned block. Empty
-// Withdrawals value must be passed via non-nil, length 0 value in params.
+// Withdrawals value must be passed via non-nil, length 0 value in params. The
+// same holds for the execution requests.
 func ExecutableDataToBlock(params ExecutableData, versionedHashes []common.Hash, beaconRoot *common.Hash) (*types.Block, error) {
 	txs, err := decodeTransactions(params.Transactions)
 	if err != nil {
@@ -229,6 +234,29 @@ func ExecutableDataToBlock(params ExecutableData, versionedHashes []common.Hash,
 		h := types.DeriveSha(types.Withdrawals(params.Withdrawals), trie.NewStackTrie(nil))
 		withdrawalsRoot = &h
 	}
+	// Similarly, only set requestsRoot if the requests are present. They are
+	// committed to in the order of their types.
+	var (
+		requestsRoot *common.Hash
+		requests     types.Requests
+	)
+	if params.Deposits != nil || params.WithdrawalRequests != nil || params.ConsolidationRequests != nil {
+		if params.Deposits == nil || params.WithdrawalRequests == nil || params.ConsolidationRequests == nil {
+			return nil, errors.New("incomplete execution requests")
+		}
+		requests = make(types.Requests, 0, len(params.Deposits)+len(params.WithdrawalRequests)+len(params.ConsolidationRequests))
+		for _, d := range params.Deposits {
+			requests = append(requests, types.NewRequest(d))
+		}
+		for _, w := range params.WithdrawalRequests {
+			requests = append(requests, types.NewRequest(w))
+		}
+		for _, c := range params.ConsolidationRequests {
+			requests = append(requests, types.NewRequest(c))
+		}
+		h := types.DeriveSha(requests, trie.NewStackTrie(nil))
+		requestsRoot = &h
+	}
 	header := &types.Header{
 		ParentHash:       params.ParentHash,
 		UncleHash:        types.EmptyUncleHash,
@@ -249,8 +277,9 @@ func ExecutableDataToBlock(params ExecutableData, versionedHashes []common.Hash,
 		ExcessBlobGas:    params.ExcessBlobGas,
 		BlobGasUsed:      params.BlobGasUsed,
 		ParentBeaconRoot: beaconRoot,
+		RequestsHash:     requestsRoot,
 	}
-	block := types.NewBlockWithHeader(header).WithBody(types.Body{Transactions: txs, Uncles: nil, Withdrawals: params.Withdrawals})
+	block := types.NewBlockWithHeader(header).WithBody(types.Body{Transactions: txs, Uncles: nil, Withdrawals: params.Withdrawals, Requests: requests})
 	if block.Hash() != params.BlockHash {
 		return nil, fmt.Errorf("blockhash mismatch, want %x, got %x", params.BlockHash, block.Hash())
 	}
@@ -279,6 +308,12 @@ func BlockToExecutableData(block *types.Block, fees *big.Int, sidecars []*types.
 		BlobGasUsed:   block.BlobGasUsed(),
 		ExcessBlobGas: block.ExcessBlobGas(),
 	}
+	// Split the requests of the block into the per-type lists of the payload.
+	if requests := block.Requests(); requests != nil {
+		data.Deposits = requests.Deposits()
+		data.WithdrawalRequests = requests.WithdrawalRequests()
+		data.ConsolidationRequests = requests.ConsolidationRequests()
+	}
 	bundle := BlobsBundleV1{
 		Commitments: make([]hexutil.Bytes, 0),
 		Blobs:       make([]hexutil.Bytes, 0),
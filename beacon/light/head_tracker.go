@@ -22,14 +22,22 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/beacon/types"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
 )
 
+// ErrConflictingFinality is returned when a validated finality update finalizes
+// a different header than an earlier one at the same slot.
+var ErrConflictingFinality = errors.New("conflicting finalized header")
+
 // HeadTracker keeps track of the latest validated head and the "prefetch" head
 // which is the (not necessarily validated) head announced by the majority of
 // servers.
 type HeadTracker struct {
 	lock                sync.RWMutex
+	db                  ethdb.KeyValueStore
 	committeeChain      *CommitteeChain
 	minSignerCount      int
 	optimisticUpdate    types.OptimisticUpdate
@@ -38,14 +46,36 @@ type HeadTracker struct {
 	hasFinalityUpdate   bool
 	prefetchHead        types.HeadInfo
 	changeCounter       uint64
+
+	finalized    types.Header // latest validated finalized header, persisted in db
+	hasFinalized bool
 }
 
-// NewHeadTracker creates a new HeadTracker.
-func NewHeadTracker(committeeChain *CommitteeChain, minSignerCount int) *HeadTracker {
-	return &HeadTracker{
+// NewHeadTracker creates a new HeadTracker. The latest validated finalized
+// header is persisted in the given database and loaded back on creation.
+func NewHeadTracker(db ethdb.KeyValueStore, committeeChain *CommitteeChain, minSignerCount int) *HeadTracker {
+	h := &HeadTracker{
+		db:             db,
 		committeeChain: committeeChain,
 		minSignerCount: minSignerCount,
 	}
+	if enc, err := db.Get(rawdb.FinalizedBeaconKey); err == nil {
+		if err := rlp.DecodeBytes(enc, &h.finalized); err != nil {
+			log.Error("Invalid stored finalized beacon header", "error", err)
+		} else {
+			h.hasFinalized = true
+		}
+	}
+	return h
+}
+
+// Finalized returns the latest validated finalized header, which might have
+// been loaded from the database.
+func (h *HeadTracker) Finalized() (types.Header, bool) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	return h.finalized, h.hasFinalized
 }
 
 // ValidatedOptimistic returns the latest validated optimistic update.
@@ -87,6 +117,10 @@ func (h *HeadTracker) ValidateOptimistic(update types.OptimisticUpdate) (bool, e
 // successfully validated and it is better than the old validated update (higher
 // slot or same slot and more signers) then ValidatedFinality is updated.
 // The boolean return flag signals if ValidatedFinality has been changed.
+//
+// Updates are cross-checked against the previously finalized header: a valid
+// update finalizing a different header at the same slot is rejected with
+// ErrConflictingFinality.
 func (h *HeadTracker) ValidateFinality(update types.FinalityUpdate) (bool, error) {
 	h.lock.Lock()
 	defer h.lock.Unlock()
@@ -95,11 +129,25 @@ func (h *HeadTracker) ValidateFinality(update types.FinalityUpdate) (bool, error
 		return false, err
 	}
 	replace, err := h.validate(update.SignedHeader(), h.finalityUpdate.SignedHeader())
-	if replace {
-		h.finalityUpdate, h.hasFinalityUpdate = update, true
-		h.changeCounter++
+	if !replace {
+		return false, err
 	}
-	return replace, err
+	finalized := update.Finalized.Header
+	if h.hasFinalized && finalized.Slot == h.finalized.Slot && finalized.Hash() != h.finalized.Hash() {
+		log.Error("Conflicting finalized beacon header", "slot", finalized.Slot, "have", h.finalized.Hash(), "got", finalized.Hash())
+		return false, ErrConflictingFinality
+	}
+	h.finalityUpdate, h.hasFinalityUpdate = update, true
+	if !h.hasFinalized || finalized.Slot > h.finalized.Slot {
+		h.finalized, h.hasFinalized = finalized, true
+		if enc, err := rlp.EncodeToBytes(&finalized); err != nil {
+			log.Error("Failed to encode finalized beacon header", "error", err)
+		} else if err := h.db.Put(rawdb.FinalizedBeaconKey, enc); err != nil {
+			log.Error("Failed to store finalized beacon header", "error", err)
+		}
+	}
+	h.changeCounter++
+	return true, err
 }
 
 func (h *HeadTracker) validate(head, oldHead types.SignedHeader) (bool, error) {
@@ -0,0 +1,75 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/beacon/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+)
+
+// Tests that the latest validated finalized header is persisted, and that valid
+// updates finalizing a different header at the same slot are rejected.
+func TestHeadTrackerFinalized(t *testing.T) {
+	var (
+		db        = memorydb.New()
+		committee = tcBase.periods[1].committee
+		chain     = NewTestCommitteeChain(db, &tfBase, 300, false, &mclock.Simulated{})
+	)
+	if err := chain.CheckpointInit(*GenerateTestCheckpoint(1, committee)); err != nil {
+		t.Fatalf("Failed to initialize committee chain: %v", err)
+	}
+	tracker := NewHeadTracker(db, chain, 300)
+	if _, ok := tracker.Finalized(); ok {
+		t.Fatal("Finalized header available before any update")
+	}
+	var (
+		start   = types.SyncPeriodStart(1)
+		update1 = GenerateTestFinalityUpdate(&tfBase, committee, start+64, start+130, common.Hash{1}, 400)
+		update2 = GenerateTestFinalityUpdate(&tfBase, committee, start+64, start+140, common.Hash{2}, 400)
+		update3 = GenerateTestFinalityUpdate(&tfBase, committee, start+128, start+200, common.Hash{3}, 400)
+	)
+	checkFinalized := func(tracker *HeadTracker, want types.Header) {
+		t.Helper()
+		if have, ok := tracker.Finalized(); !ok || have.Hash() != want.Hash() {
+			t.Fatalf("Finalized header mismatch: have slot %d (%v), want slot %d", have.Slot, ok, want.Slot)
+		}
+	}
+	if ok, err := tracker.ValidateFinality(update1); !ok || err != nil {
+		t.Fatalf("Failed to validate finality update: %v", err)
+	}
+	checkFinalized(tracker, update1.Finalized.Header)
+
+	// The finalized header must survive a restart
+	tracker = NewHeadTracker(db, chain, 300)
+	checkFinalized(tracker, update1.Finalized.Header)
+
+	// A different header finalized at the same slot must be rejected
+	if ok, err := tracker.ValidateFinality(update2); ok || err != ErrConflictingFinality {
+		t.Fatalf("Conflicting finality update mismatch: have %v, %v, want false, %v", ok, err, ErrConflictingFinality)
+	}
+	checkFinalized(tracker, update1.Finalized.Header)
+
+	// A later finalized header replaces the stored one
+	if ok, err := tracker.ValidateFinality(update3); !ok || err != nil {
+		t.Fatalf("Failed to validate finality update: %v", err)
+	}
+	checkFinalized(NewHeadTracker(db, chain, 300), update3.Finalized.Header)
+}
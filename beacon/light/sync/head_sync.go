@@ -17,11 +17,21 @@
 package sync
 
 import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/beacon/light"
 	"github.com/ethereum/go-ethereum/beacon/light/request"
 	"github.com/ethereum/go-ethereum/beacon/types"
+	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/log"
 )
 
+// agreementTimeout is the time after which servers not sending anything are not
+// waited for anymore, and after which updates the responding servers don't
+// agree on are accepted from a single server. The updates are still verified
+// against the sync committee.
+const agreementTimeout = time.Minute
+
 type headTracker interface {
 	ValidateOptimistic(update types.OptimisticUpdate) (bool, error)
 	ValidateFinality(head types.FinalityUpdate) (bool, error)
@@ -34,11 +44,24 @@ type headTracker interface {
 // registered servers.
 // It can also postpone the validation of the latest announced signed head
 // until the committee chain is synced up to at least the required period.
+// If a minimum number of agreeing servers is set, signed heads are only
+// validated once that many of the responding servers reported the same one, and
+// servers reporting a conflicting one are failed. Servers silent for
+// agreementTimeout, like unreachable ones, are not waited for, and if no
+// agreement is reached for agreementTimeout, the updates of single servers are
+// accepted until the servers agree again.
 type HeadSync struct {
 	headTracker           headTracker
 	chain                 committeeChain
+	clock                 mclock.Clock
+	minAgreement          int
+	lastSeen              map[request.Server]mclock.AbsTime // registration or latest event of the servers
+	optimisticWait        agreementWait
+	finalityWait          agreementWait
 	nextSyncPeriod        uint64
 	chainInit             bool
+	reportedOptimistic    map[request.Server]types.OptimisticUpdate
+	reportedFinality      map[request.Server]types.FinalityUpdate
 	unvalidatedOptimistic map[request.Server]types.OptimisticUpdate
 	unvalidatedFinality   map[request.Server]types.FinalityUpdate
 	serverHeads           map[request.Server]types.HeadInfo
@@ -57,11 +80,24 @@ type headServerCount struct {
 	headCounter uint64
 }
 
-// NewHeadSync creates a new HeadSync.
-func NewHeadSync(headTracker headTracker, chain committeeChain) *HeadSync {
+// agreementWait tracks since when updates await the agreement of the servers.
+type agreementWait struct {
+	waiting bool
+	since   mclock.AbsTime
+}
+
+// NewHeadSync creates a new HeadSync. Optimistic and finality updates are only
+// validated once minAgreement servers reported them, or all the responding ones
+// if fewer; a value of 0 or 1 accepts updates from any single server.
+func NewHeadSync(headTracker headTracker, chain committeeChain, minAgreement int, clock mclock.Clock) *HeadSync {
 	s := &HeadSync{
 		headTracker:           headTracker,
 		chain:                 chain,
+		clock:                 clock,
+		minAgreement:          minAgreement,
+		lastSeen:              make(map[request.Server]mclock.AbsTime),
+		reportedOptimistic:    make(map[request.Server]types.OptimisticUpdate),
+		reportedFinality:      make(map[request.Server]types.FinalityUpdate),
 		unvalidatedOptimistic: make(map[request.Server]types.OptimisticUpdate),
 		unvalidatedFinality:   make(map[request.Server]types.FinalityUpdate),
 		serverHeads:           make(map[request.Server]types.HeadInfo),
@@ -80,12 +116,16 @@ func (s *HeadSync) Process(requester request.Requester, events []request.Event)
 	}
 
 	for _, event := range events {
+		switch event.Type {
+		case request.EvRegistered, EvNewHead, EvNewOptimisticUpdate, EvNewFinalityUpdate, request.EvResponse:
+			s.lastSeen[event.Server] = s.clock.Now()
+		}
 		switch event.Type {
 		case EvNewHead:
 			s.setServerHead(event.Server, event.Data.(types.HeadInfo))
 		case EvNewOptimisticUpdate:
 			update := event.Data.(types.OptimisticUpdate)
-			s.newOptimisticUpdate(event.Server, update)
+			s.newOptimisticUpdate(requester, event.Server, update)
 			epoch := update.Attested.Epoch()
 			if epoch < s.reqFinalityEpoch[event.Server] {
 				continue
@@ -96,13 +136,16 @@ func (s *HeadSync) Process(requester request.Requester, events []request.Event)
 			requester.Send(event.Server, ReqFinality{})
 			s.reqFinalityEpoch[event.Server] = epoch + 1
 		case EvNewFinalityUpdate:
-			s.newFinalityUpdate(event.Server, event.Data.(types.FinalityUpdate))
+			s.newFinalityUpdate(requester, event.Server, event.Data.(types.FinalityUpdate))
 		case request.EvResponse:
 			_, _, resp := event.RequestInfo()
-			s.newFinalityUpdate(event.Server, resp.(types.FinalityUpdate))
+			s.newFinalityUpdate(requester, event.Server, resp.(types.FinalityUpdate))
 		case request.EvUnregistered:
 			s.setServerHead(event.Server, types.HeadInfo{})
 			delete(s.serverHeads, event.Server)
+			delete(s.lastSeen, event.Server)
+			delete(s.reportedOptimistic, event.Server)
+			delete(s.reportedFinality, event.Server)
 			delete(s.unvalidatedOptimistic, event.Server)
 			delete(s.unvalidatedFinality, event.Server)
 		}
@@ -110,8 +153,16 @@ func (s *HeadSync) Process(requester request.Requester, events []request.Event)
 }
 
 // newOptimisticUpdate handles received optimistic update; either validates it if
-// the chain is properly synced or stores it for further validation.
-func (s *HeadSync) newOptimisticUpdate(server request.Server, optimisticUpdate types.OptimisticUpdate) {
+// the chain is properly synced or stores it for further validation. If updates
+// are cross-checked, it is only processed once enough servers agree on it.
+func (s *HeadSync) newOptimisticUpdate(requester request.Requester, server request.Server, optimisticUpdate types.OptimisticUpdate) {
+	if s.minAgreement > 1 {
+		s.reportedOptimistic[server] = optimisticUpdate
+		if !s.optimisticAgreed(requester, optimisticUpdate) && !s.agreementTimedOut(&s.optimisticWait) {
+			return
+		}
+		s.optimisticWait = agreementWait{}
+	}
 	if !s.chainInit || types.SyncPeriod(optimisticUpdate.SignatureSlot) > s.nextSyncPeriod {
 		s.unvalidatedOptimistic[server] = optimisticUpdate
 		return
@@ -122,17 +173,119 @@ func (s *HeadSync) newOptimisticUpdate(server request.Server, optimisticUpdate t
 }
 
 // newFinalityUpdate handles received finality update; either validates it if
-// the chain is properly synced or stores it for further validation.
-func (s *HeadSync) newFinalityUpdate(server request.Server, finalityUpdate types.FinalityUpdate) {
+// the chain is properly synced or stores it for further validation. Servers
+// announcing a finalized header conflicting with the one validated earlier are
+// failed, so that requests are routed to the other servers.
+func (s *HeadSync) newFinalityUpdate(requester request.Requester, server request.Server, finalityUpdate types.FinalityUpdate) {
+	if s.minAgreement > 1 {
+		s.reportedFinality[server] = finalityUpdate
+		if !s.finalityAgreed(requester, finalityUpdate) && !s.agreementTimedOut(&s.finalityWait) {
+			return
+		}
+		s.finalityWait = agreementWait{}
+	}
 	if !s.chainInit || types.SyncPeriod(finalityUpdate.SignatureSlot) > s.nextSyncPeriod {
 		s.unvalidatedFinality[server] = finalityUpdate
 		return
 	}
 	if _, err := s.headTracker.ValidateFinality(finalityUpdate); err != nil {
+		if err == light.ErrConflictingFinality {
+			requester.Fail(server, "conflicting finality update")
+			return
+		}
 		log.Debug("Error validating finality update", "error", err)
 	}
 }
 
+// requiredAgreement returns the number of servers which need to agree on an
+// update, the minimum agreement or all the responding servers if fewer.
+func (s *HeadSync) requiredAgreement() int {
+	var (
+		now        = s.clock.Now()
+		responding int
+	)
+	for _, seen := range s.lastSeen {
+		if time.Duration(now-seen) < agreementTimeout {
+			responding++
+		}
+	}
+	return min(s.minAgreement, responding)
+}
+
+// agreementTimedOut checks whether updates awaited agreement for agreementTimeout,
+// starting to count from now if they didn't yet. The wait is only reset once
+// the servers agree again.
+func (s *HeadSync) agreementTimedOut(wait *agreementWait) bool {
+	now := s.clock.Now()
+	if !wait.waiting {
+		*wait = agreementWait{waiting: true, since: now}
+		return false
+	}
+	waited := time.Duration(now - wait.since)
+	if waited < agreementTimeout {
+		return false
+	}
+	log.Debug("Servers don't agree on signed heads, accepting update of a single server", "waited", waited)
+	return true
+}
+
+// optimisticAgreed checks whether enough servers reported the same attested
+// header as the given optimistic update. Once they do, servers reporting a
+// different attested header for the same slot are failed.
+func (s *HeadSync) optimisticAgreed(requester request.Requester, update types.OptimisticUpdate) bool {
+	var (
+		hash       = update.Attested.Header.Hash()
+		agreed     int
+		conflicted []request.Server
+	)
+	for server, reported := range s.reportedOptimistic {
+		switch {
+		case reported.Attested.Header.Hash() == hash:
+			agreed++
+		case reported.Attested.Header.Slot == update.Attested.Header.Slot:
+			conflicted = append(conflicted, server)
+		}
+	}
+	if required := s.requiredAgreement(); agreed < required {
+		log.Debug("Optimistic update awaiting agreement", "slot", update.Attested.Header.Slot, "servers", agreed, "required", required)
+		return false
+	}
+	for _, server := range conflicted {
+		delete(s.reportedOptimistic, server)
+		requester.Fail(server, "conflicting optimistic update")
+	}
+	return true
+}
+
+// finalityAgreed checks whether enough servers reported the same attested and
+// finalized header as the given finality update. Once they do, servers reporting
+// a different finalized header for the same slot are failed.
+func (s *HeadSync) finalityAgreed(requester request.Requester, update types.FinalityUpdate) bool {
+	var (
+		attested   = update.Attested.Header.Hash()
+		finalized  = update.Finalized.Header.Hash()
+		agreed     int
+		conflicted []request.Server
+	)
+	for server, reported := range s.reportedFinality {
+		switch {
+		case reported.Attested.Header.Hash() == attested && reported.Finalized.Header.Hash() == finalized:
+			agreed++
+		case reported.Finalized.Header.Slot == update.Finalized.Header.Slot && reported.Finalized.Header.Hash() != finalized:
+			conflicted = append(conflicted, server)
+		}
+	}
+	if required := s.requiredAgreement(); agreed < required {
+		log.Debug("Finality update awaiting agreement", "slot", update.Finalized.Header.Slot, "servers", agreed, "required", required)
+		return false
+	}
+	for _, server := range conflicted {
+		delete(s.reportedFinality, server)
+		requester.Fail(server, "conflicting finality update")
+	}
+	return true
+}
+
 // processUnvalidatedUpdates iterates the list of unvalidated updates and validates
 // those which can be validated.
 func (s *HeadSync) processUnvalidatedUpdates() {
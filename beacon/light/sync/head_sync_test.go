@@ -22,6 +22,7 @@ import (
 	"github.com/ethereum/go-ethereum/beacon/light/request"
 	"github.com/ethereum/go-ethereum/beacon/types"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
 )
 
 var (
@@ -61,7 +62,7 @@ func (t testServer) Name() string {
 func TestValidatedHead(t *testing.T) {
 	chain := &TestCommitteeChain{}
 	ht := &TestHeadTracker{}
-	headSync := NewHeadSync(ht, chain)
+	headSync := NewHeadSync(ht, chain, 0, &mclock.Simulated{})
 	ts := NewTestScheduler(t, headSync)
 
 	ht.ExpValidated(t, 0, nil)
@@ -129,10 +130,95 @@ func TestValidatedHead(t *testing.T) {
 	ht.ExpValidated(t, 10, []types.OptimisticUpdate{testOptUpdate4})
 }
 
+func TestCrossCheckedHead(t *testing.T) {
+	chain := &TestCommitteeChain{}
+	ht := &TestHeadTracker{}
+	headSync := NewHeadSync(ht, chain, 2, &mclock.Simulated{})
+	ts := NewTestScheduler(t, headSync)
+
+	chain.SetNextSyncPeriod(1)
+	ts.AddServer(testServer1, 1)
+	ts.AddServer(testServer2, 1)
+	ts.AddServer(testServer3, 1)
+
+	// testServer3 reports a different attested header for the same slot
+	conflicting := testOptUpdate2
+	conflicting.Attested.Header.StateRoot = common.Hash{0xff}
+	ts.ServerEvent(EvNewOptimisticUpdate, testServer3, conflicting)
+	ts.Run(1, testServer3, ReqFinality{})
+	ht.ExpValidated(t, 1, nil)
+
+	// a single server reporting an update is not enough
+	ts.ServerEvent(EvNewOptimisticUpdate, testServer1, testOptUpdate2)
+	ts.Run(2, testServer1, ReqFinality{})
+	ht.ExpValidated(t, 2, nil)
+
+	// the update is validated once a second server agrees, the other one is failed
+	ts.ServerEvent(EvNewOptimisticUpdate, testServer2, testOptUpdate2)
+	ts.ExpFail(testServer3)
+	ts.Run(3, testServer2, ReqFinality{})
+	ht.ExpValidated(t, 3, []types.OptimisticUpdate{testOptUpdate2})
+
+	// finality updates are cross-checked the same way
+	conflictingFinality := finality(testOptUpdate2)
+	conflictingFinality.Finalized.Header.StateRoot = common.Hash{0xff}
+	ts.RequestEvent(request.EvResponse, ts.Request(1, 1), conflictingFinality)
+	ts.RequestEvent(request.EvResponse, ts.Request(2, 1), finality(testOptUpdate2))
+	ts.Run(4)
+	if _, ok := ht.ValidatedFinality(); ok {
+		t.Errorf("Finality update validated without agreement")
+	}
+	ts.RequestEvent(request.EvResponse, ts.Request(3, 1), finality(testOptUpdate2))
+	ts.ExpFail(testServer3)
+	ts.Run(5)
+	if update, ok := ht.ValidatedFinality(); !ok || update.Finalized.Header != finality(testOptUpdate2).Finalized.Header {
+		t.Errorf("Agreed finality update not validated")
+	}
+}
+
+func TestCrossCheckedHeadServerOffline(t *testing.T) {
+	chain := &TestCommitteeChain{}
+	ht := &TestHeadTracker{}
+	clock := &mclock.Simulated{}
+	headSync := NewHeadSync(ht, chain, 2, clock)
+	ts := NewTestScheduler(t, headSync)
+
+	chain.SetNextSyncPeriod(2)
+	ts.AddServer(testServer1, 1)
+	ts.AddServer(testServer2, 1) // never responds
+
+	// the offline server is waited for until it was silent for agreementTimeout
+	ts.ServerEvent(EvNewOptimisticUpdate, testServer1, testOptUpdate1)
+	ts.Run(1, testServer1, ReqFinality{})
+	ht.ExpValidated(t, 1, nil)
+
+	clock.Run(agreementTimeout)
+	ts.ServerEvent(EvNewOptimisticUpdate, testServer1, testOptUpdate2)
+	ts.Run(2, testServer1, ReqFinality{})
+	ht.ExpValidated(t, 2, []types.OptimisticUpdate{testOptUpdate2})
+
+	// updates of the only responding server are validated right away from then on
+	ts.ServerEvent(EvNewOptimisticUpdate, testServer1, testOptUpdate3)
+	ts.Run(3, testServer1, ReqFinality{})
+	ht.ExpValidated(t, 3, []types.OptimisticUpdate{testOptUpdate3})
+	ts.RequestEvent(request.EvResponse, ts.Request(3, 1), finality(testOptUpdate3))
+	ts.Run(4)
+	if update, ok := ht.ValidatedFinality(); !ok || update.Finalized.Header != finality(testOptUpdate3).Finalized.Header {
+		t.Errorf("Finality update of the only responding server not validated")
+	}
+
+	// once the server is back, the updates are cross-checked again
+	ts.ServerEvent(EvNewHead, testServer2, testHead1)
+	chain.SetNextSyncPeriod(3)
+	ts.ServerEvent(EvNewOptimisticUpdate, testServer1, testOptUpdate4)
+	ts.Run(5, testServer1, ReqFinality{})
+	ht.ExpValidated(t, 5, nil)
+}
+
 func TestPrefetchHead(t *testing.T) {
 	chain := &TestCommitteeChain{}
 	ht := &TestHeadTracker{}
-	headSync := NewHeadSync(ht, chain)
+	headSync := NewHeadSync(ht, chain, 0, &mclock.Simulated{})
 	ts := NewTestScheduler(t, headSync)
 
 	ht.ExpPrefetch(t, 0, testHead0) // no servers registered
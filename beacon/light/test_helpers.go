@@ -25,6 +25,8 @@ import (
 	"github.com/ethereum/go-ethereum/beacon/params"
 	"github.com/ethereum/go-ethereum/beacon/types"
 	"github.com/ethereum/go-ethereum/common"
+	zrntcommon "github.com/protolambda/zrnt/eth2/beacon/common"
+	"github.com/protolambda/zrnt/eth2/beacon/deneb"
 )
 
 func GenerateTestCommittee() *types.SerializedSyncCommittee {
@@ -62,6 +64,37 @@ func GenerateTestSignedHeader(header types.Header, config *types.ChainConfig, co
 	}
 }
 
+// GenerateTestFinalityUpdate creates a finality update finalizing a header at the
+// given slot, signed by the committee at the signature slot. The execution payload
+// headers are identified by the given block hash.
+func GenerateTestFinalityUpdate(config *types.ChainConfig, committee *types.SerializedSyncCommittee, finalizedSlot, signatureSlot uint64, blockHash common.Hash, signerCount int) types.FinalityUpdate {
+	finalized := makeTestHeaderWithExecProof(finalizedSlot, blockHash)
+	attestedHeader, finalityBranch := makeTestHeaderWithMerkleProof(signatureSlot-1, params.StateIndexFinalBlock, merkle.Value(finalized.Hash()))
+	attested := makeTestHeaderWithExecProof(signatureSlot-1, blockHash)
+	attested.StateRoot = attestedHeader.StateRoot
+
+	signedHeader := GenerateTestSignedHeader(attested.Header, config, committee, signatureSlot, signerCount)
+	return types.FinalityUpdate{
+		Attested:       attested,
+		Finalized:      finalized,
+		FinalityBranch: finalityBranch,
+		Signature:      signedHeader.Signature,
+		SignatureSlot:  signatureSlot,
+	}
+}
+
+// makeTestHeaderWithExecProof creates a header whose body root proves an
+// execution payload header with the given block hash.
+func makeTestHeaderWithExecProof(slot uint64, blockHash common.Hash) types.HeaderWithExecProof {
+	payload := types.NewExecutionHeader(&deneb.ExecutionPayloadHeader{BlockHash: zrntcommon.Hash32(blockHash)})
+	body, branch := makeTestHeaderWithMerkleProof(slot, params.BodyIndexExecPayload, payload.PayloadRoot())
+	return types.HeaderWithExecProof{
+		Header:        types.Header{Slot: slot, BodyRoot: body.StateRoot},
+		PayloadHeader: payload,
+		PayloadBranch: branch,
+	}
+}
+
 func GenerateTestCheckpoint(period uint64, committee *types.SerializedSyncCommittee) *types.BootstrapData {
 	header, branch := makeTestHeaderWithMerkleProof(types.SyncPeriodStart(period)+200, params.StateIndexSyncCommittee, merkle.Value(committee.Root()))
 	return &types.BootstrapData{
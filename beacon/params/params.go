@@ -26,6 +26,11 @@ const (
 	SyncCommitteeSize          = 512
 	SyncCommitteeBitmaskSize   = SyncCommitteeSize / 8
 	SyncCommitteeSupermajority = (SyncCommitteeSize*2 + 2) / 3
+
+	// WeakSubjectivityPeriod is the number of slots after which a finalized
+	// header can no longer be trusted as a sync starting point. It is set to the
+	// conservative MIN_VALIDATOR_WITHDRAWABILITY_DELAY of 256 epochs.
+	WeakSubjectivityPeriod = 256 * EpochLength
 )
 
 const (
@@ -120,9 +120,25 @@ func (f Forks) Less(i, j int) bool {
 type ChainConfig struct {
 	GenesisTime           uint64      // Unix timestamp of slot 0
 	GenesisValidatorsRoot common.Hash // Root hash of the genesis validator set, used for signature domain calculation
+	SecondsPerSlot        uint64      // Duration of a slot, DefaultSecondsPerSlot if zero
 	Forks                 Forks
 }
 
+// DefaultSecondsPerSlot is the slot duration of the public beacon chains.
+const DefaultSecondsPerSlot = 12
+
+// SlotAt returns the slot at the given unix timestamp.
+func (c *ChainConfig) SlotAt(time uint64) uint64 {
+	if time <= c.GenesisTime {
+		return 0
+	}
+	secondsPerSlot := c.SecondsPerSlot
+	if secondsPerSlot == 0 {
+		secondsPerSlot = DefaultSecondsPerSlot
+	}
+	return (time - c.GenesisTime) / secondsPerSlot
+}
+
 // ForkAtEpoch returns the latest active fork at the given epoch.
 func (c *ChainConfig) ForkAtEpoch(epoch uint64) Fork {
 	for i := len(c.Forks) - 1; i >= 0; i-- {
@@ -155,7 +171,7 @@ func (c *ChainConfig) AddFork(name string, epoch uint64, version []byte) *ChainC
 }
 
 // LoadForks parses the beacon chain configuration file (config.yaml) and extracts
-// the list of forks.
+// the list of forks, along with the slot duration.
 func (c *ChainConfig) LoadForks(path string) error {
 	file, err := os.ReadFile(path)
 	if err != nil {
@@ -172,6 +188,13 @@ func (c *ChainConfig) LoadForks(path string) error {
 	epochs["GENESIS"] = 0
 
 	for key, value := range config {
+		if key == "SECONDS_PER_SLOT" {
+			v, err := strconv.ParseUint(value, 10, 64)
+			if err != nil || v == 0 {
+				return fmt.Errorf("invalid slot duration %q in beacon chain config file", value)
+			}
+			c.SecondsPerSlot = v
+		}
 		if strings.HasSuffix(key, "_FORK_VERSION") {
 			name := key[:len(key)-len("_FORK_VERSION")]
 			if v, err := hexutil.Decode(value); err == nil {
@@ -42,7 +42,7 @@ func main() {
 		utils.BeaconGenesisRootFlag,
 		utils.BeaconGenesisTimeFlag,
 		utils.BeaconCheckpointFlag,
-		//TODO datadir for optional permanent database
+		utils.DataDirFlag,
 		utils.MainnetFlag,
 		utils.SepoliaFlag,
 		utils.GoerliFlag,
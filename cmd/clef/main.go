@@ -37,6 +37,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/accounts/plugin"
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -126,6 +127,10 @@ var (
 		Name:  "rules",
 		Usage: "Path to the rule file to auto-authorize requests with",
 	}
+	qbftValidatorFlag = &cli.StringFlag{
+		Name:  "qbft.validator",
+		Usage: "QBFT validator account to auto-approve commit seals and consensus messages for (password stored with setpw)",
+	}
 	stdiouiFlag = &cli.BoolFlag{
 		Name: "stdio-ui",
 		Usage: "Use STDIN/STDOUT as a channel for an external UI. " +
@@ -136,6 +141,24 @@ var (
 		Name:  "stdio-ui-test",
 		Usage: "Mechanism to test interface between Clef and UI. Requires 'stdio-ui'.",
 	}
+	thresholdApproversFlag = &cli.StringSliceFlag{
+		Name:  "threshold.approvers",
+		Usage: "Approvers for threshold signing, as <address>@<ui-endpoint> (enables M-of-N approval)",
+	}
+	thresholdFlag = &cli.IntFlag{
+		Name:  "threshold",
+		Usage: "Number of distinct approvers required to sign a request",
+		Value: 1,
+	}
+	thresholdTimeoutFlag = &cli.DurationFlag{
+		Name:  "threshold.timeout",
+		Usage: "Time to wait for the approval threshold to be met before rejecting a request",
+		Value: core.DefaultThresholdTimeout,
+	}
+	thresholdAccountsFlag = &cli.StringSliceFlag{
+		Name:  "threshold.accounts",
+		Usage: "Accounts requiring threshold approval (default: all accounts)",
+	}
 	initCommand = &cli.Command{
 		Action:    initializeSecrets,
 		Name:      "init",
@@ -235,6 +258,7 @@ The gendoc generates example structures of the json-rpc communication types.
 			logLevelFlag,
 			keystoreFlag,
 			utils.LightKDFFlag,
+			utils.SignerPluginFlag,
 			acceptFlag,
 		},
 		Description: `
@@ -271,6 +295,7 @@ func init() {
 		utils.LightKDFFlag,
 		utils.NoUSBFlag,
 		utils.SmartCardDaemonPathFlag,
+		utils.SignerPluginFlag,
 		utils.HTTPListenAddrFlag,
 		utils.HTTPVirtualHostsFlag,
 		utils.IPCDisabledFlag,
@@ -281,8 +306,13 @@ func init() {
 		customDBFlag,
 		auditLogFlag,
 		ruleFlag,
+		qbftValidatorFlag,
 		stdiouiFlag,
 		testFlag,
+		thresholdApproversFlag,
+		thresholdFlag,
+		thresholdTimeoutFlag,
+		thresholdAccountsFlag,
 		advancedMode,
 		acceptFlag,
 	}
@@ -409,11 +439,32 @@ func initInternalApi(c *cli.Context) (*core.UIServerAPI, core.UIClientAPI, error
 		lightKdf                  = c.Bool(utils.LightKDFFlag.Name)
 	)
 	am := core.StartClefAccountManager(ksLoc, true, lightKdf, "")
+	startSignerPlugin(c, am)
+
 	api := core.NewSignerAPI(am, 0, true, ui, nil, false, pwStorage)
 	internalApi := core.NewUIServerAPI(api)
 	return internalApi, ui, nil
 }
 
+// startSignerPlugin adds the signer plugin configured on the command line, if
+// any, to the account manager. Plugins hold their own keys, e.g. in a cloud KMS
+// or an HSM.
+func startSignerPlugin(c *cli.Context, am *accounts.Manager) {
+	if !c.IsSet(utils.SignerPluginFlag.Name) {
+		return
+	}
+	command, err := plugin.SplitCommand(c.String(utils.SignerPluginFlag.Name))
+	if err != nil {
+		utils.Fatalf("Invalid signer plugin: %v", err)
+	}
+	backend, err := plugin.NewBackend(command[0], command[1:]...)
+	if err != nil {
+		utils.Fatalf("Could not start signer plugin: %v", err)
+	}
+	am.AddBackend(backend)
+	log.Info("Signer plugin configured", "cmd", command[0])
+}
+
 func setCredential(ctx *cli.Context) error {
 	if ctx.NArg() < 1 {
 		utils.Fatalf("This command requires an address to be passed as an argument")
@@ -651,8 +702,16 @@ func signer(c *cli.Context) error {
 
 	var (
 		api       core.ExternalAPI
+		auditLog  log.Logger
 		pwStorage storage.Storage = &storage.NoStorage{}
 	)
+	if logfile := c.String(auditLogFlag.Name); logfile != "" {
+		auditLog, err = core.OpenAuditLog(logfile)
+		if err != nil {
+			utils.Fatalf(err.Error())
+		}
+		log.Info("Audit logs configured", "file", logfile)
+	}
 	configDir := c.String(configdirFlag.Name)
 	if stretchedKey, err := readMasterKey(c, ui); err != nil {
 		log.Warn("Failed to open master, rules disabled", "err", err)
@@ -693,6 +752,43 @@ func signer(c *cli.Context) error {
 			}
 		}
 	}
+	// QBFT validators sign without user interaction, within the round timeout
+	if c.IsSet(qbftValidatorFlag.Name) {
+		validator := c.String(qbftValidatorFlag.Name)
+		if !common.IsHexAddress(validator) {
+			utils.Fatalf("Invalid QBFT validator: %q", validator)
+		}
+		ui = rules.NewQBFTRuleset(ui, common.HexToAddress(validator))
+		log.Info("QBFT sealing rule configured", "validator", validator)
+	}
+	// Threshold approval is applied after the rules, so that no rule can bypass it
+	if c.IsSet(thresholdApproversFlag.Name) {
+		config := core.ThresholdConfig{
+			Threshold: c.Int(thresholdFlag.Name),
+			Timeout:   c.Duration(thresholdTimeoutFlag.Name),
+			ChainID:   big.NewInt(c.Int64(chainIdFlag.Name)),
+		}
+		for _, s := range c.StringSlice(thresholdApproversFlag.Name) {
+			approver, err := core.ParseApprover(s)
+			if err != nil {
+				utils.Fatalf("Invalid threshold approver: %v", err)
+			}
+			config.Approvers = append(config.Approvers, approver)
+		}
+		for _, s := range c.StringSlice(thresholdAccountsFlag.Name) {
+			if !common.IsHexAddress(s) {
+				utils.Fatalf("Invalid threshold account: %q", s)
+			}
+			config.Accounts = append(config.Accounts, common.HexToAddress(s))
+		}
+		thresholdUI, err := core.NewThresholdUI(ui, config, auditLog)
+		if err != nil {
+			utils.Fatalf("Could not configure threshold approval: %v", err)
+		}
+		defer thresholdUI.Close()
+		ui = thresholdUI
+		log.Info("Threshold approval configured", "threshold", config.Threshold, "approvers", len(config.Approvers))
+	}
 	var (
 		chainId  = c.Int64(chainIdFlag.Name)
 		ksLoc    = c.String(keystoreFlag.Name)
@@ -705,6 +801,8 @@ func signer(c *cli.Context) error {
 		"light-kdf", lightKdf, "advanced", advanced)
 	am := core.StartClefAccountManager(ksLoc, nousb, lightKdf, scpath)
 	defer am.Close()
+	startSignerPlugin(c, am)
+
 	apiImpl := core.NewSignerAPI(am, chainId, nousb, ui, db, advanced, pwStorage)
 
 	// Establish the bidirectional communication, by creating a new UI backend and registering
@@ -713,12 +811,8 @@ func signer(c *cli.Context) error {
 	api = apiImpl
 
 	// Audit logging
-	if logfile := c.String(auditLogFlag.Name); logfile != "" {
-		api, err = core.NewAuditLogger(logfile, api)
-		if err != nil {
-			utils.Fatalf(err.Error())
-		}
-		log.Info("Audit logs configured", "file", logfile)
+	if auditLog != nil {
+		api = core.NewAuditLoggerWithLog(auditLog, api)
 	}
 	// register signer API with server
 	var (
@@ -0,0 +1,321 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package qbfttest
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	qbfttypes "github.com/ethereum/go-ethereum/consensus/istanbul/qbft/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/rlpx"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var (
+	timeout       = 2 * time.Second  // Timeout of the handshake and of the writes
+	statusTimeout = time.Second      // Time to wait for the status of the node
+	eventTimeout  = 20 * time.Second // Time to wait for a message caused by the tester
+)
+
+var errTimeout = errors.New("timeout")
+
+// message is a message read from the istanbul protocol, with its code relative
+// to the protocol offset.
+type message struct {
+	code uint64
+	data []byte
+}
+
+// Conn represents a connection with the node on the istanbul protocol.
+type Conn struct {
+	*rlpx.Conn
+	suite  *Suite
+	ourKey *ecdsa.PrivateKey
+
+	remote common.Address // Validator of the node, announced or of its node key
+	queue  []message      // Messages read ahead while waiting for the status
+}
+
+// dial connects to the node, performs the protocol handshake and waits for the
+// status of the node. Validators are identified by their node key like in
+// GoQuorum and Besu, unless the node announces another validator in a status.
+// The connection is authenticated with the first validator key of the tester,
+// which is its node key too, so the tester never sends a status.
+// As the node may not have noticed the end of the previous connection yet, the
+// dial is retried if the node reports the tester already connected.
+func (s *Suite) dial() (*Conn, error) {
+	for i := 0; ; i++ {
+		conn, err := s.dialOnce()
+		if errors.Is(err, p2p.DiscAlreadyConnected) && i < 10 {
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		return conn, err
+	}
+}
+
+// dialOnce connects to the node and performs the handshakes.
+func (s *Suite) dialOnce() (*Conn, error) {
+	tcpEndpoint, _ := s.Dest.TCPEndpoint()
+	fd, err := net.Dial("tcp", tcpEndpoint.String())
+	if err != nil {
+		return nil, err
+	}
+	conn := &Conn{
+		Conn:   rlpx.NewConn(fd, s.Dest.Pubkey()),
+		suite:  s,
+		ourKey: s.keys[0],
+		remote: crypto.PubkeyToAddress(*s.Dest.Pubkey()),
+	}
+	if _, err := conn.Handshake(conn.ourKey); err != nil {
+		conn.Conn.Close()
+		return nil, err
+	}
+	if err := conn.handshake(); err != nil {
+		conn.Conn.Close()
+		return nil, fmt.Errorf("handshake failed: %w", err)
+	}
+	if err := conn.waitStatus(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("status exchange failed: %w", err)
+	}
+	return conn, nil
+}
+
+// Close disconnects from the node, and waits for the node to drop the
+// connection to let the next test connect with the same key.
+func (c *Conn) Close() error {
+	c.write(discMsg, []p2p.DiscReason{p2p.DiscRequested})
+	c.SetReadDeadline(time.Now().Add(timeout))
+	for {
+		if _, _, _, err := c.Conn.Read(); err != nil {
+			break
+		}
+	}
+	return c.Conn.Close()
+}
+
+// handshake performs the devp2p handshake, negotiating the istanbul protocol.
+func (c *Conn) handshake() error {
+	pub0 := crypto.FromECDSAPub(&c.ourKey.PublicKey)[1:]
+	ours := &protoHandshake{
+		Version: 5,
+		Caps:    []p2p.Cap{{Name: "istanbul", Version: 100}},
+		ID:      pub0,
+	}
+	if err := c.write(handshakeMsg, ours); err != nil {
+		return fmt.Errorf("write to connection failed: %v", err)
+	}
+	c.SetReadDeadline(time.Now().Add(timeout))
+	code, data, _, err := c.Conn.Read()
+	if err != nil {
+		return fmt.Errorf("error reading handshake: %v", err)
+	}
+	switch code {
+	case handshakeMsg:
+		var theirs protoHandshake
+		if err := rlp.DecodeBytes(data, &theirs); err != nil {
+			return fmt.Errorf("error decoding handshake msg: %v", err)
+		}
+		if theirs.Version >= 5 {
+			c.SetSnappy(true)
+		}
+		for _, cap := range theirs.Caps {
+			if cap.Name == "istanbul" && cap.Version == 100 {
+				return nil
+			}
+		}
+		return fmt.Errorf("could not negotiate istanbul/100 (remote caps: %v)", theirs.Caps)
+	case discMsg:
+		return fmt.Errorf("disconnect received: %w", decodeDisconnect(data))
+	default:
+		return fmt.Errorf("bad handshake: got msg code %d", code)
+	}
+}
+
+// waitStatus waits for the status of the node announcing its validator. Nodes
+// not sending a status are identified by their node key, any message they send
+// in the meantime is queued.
+func (c *Conn) waitStatus() error {
+	msg, err := c.read(time.Now().Add(statusTimeout))
+	if errors.Is(err, errTimeout) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if msg.code != statusMsg {
+		c.queue = append(c.queue, *msg)
+		return nil
+	}
+	return c.readStatus(msg)
+}
+
+// readStatus decodes a status of the node, checking the announced validator
+// against the signature over the node ID.
+func (c *Conn) readStatus(msg *message) error {
+	var status statusPacket
+	if err := rlp.DecodeBytes(msg.data, &status); err != nil {
+		return fmt.Errorf("invalid status: %v", err)
+	}
+	signer, err := istanbul.GetSignatureAddress(statusData(c.suite.Dest.ID()), status.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid status signature: %v", err)
+	}
+	if signer != status.Validator {
+		return fmt.Errorf("status of %x signed by %x", status.Validator, signer)
+	}
+	c.remote = status.Validator
+	return nil
+}
+
+// write sends a message with the given absolute code.
+func (c *Conn) write(code uint64, msg interface{}) error {
+	payload, err := rlp.EncodeToBytes(msg)
+	if err != nil {
+		return err
+	}
+	return c.writeRaw(code, payload)
+}
+
+// writeRaw sends an encoded message with the given absolute code.
+func (c *Conn) writeRaw(code uint64, payload []byte) error {
+	c.SetWriteDeadline(time.Now().Add(timeout))
+	_, err := c.Conn.Write(code, payload)
+	return err
+}
+
+// read reads the next istanbul protocol message, answering the pings of the
+// node until then.
+func (c *Conn) read(deadline time.Time) (*message, error) {
+	if len(c.queue) > 0 {
+		msg := c.queue[0]
+		c.queue = c.queue[1:]
+		return &msg, nil
+	}
+	c.SetReadDeadline(deadline)
+	for {
+		code, data, _, err := c.Conn.Read()
+		if err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				return nil, errTimeout
+			}
+			return nil, err
+		}
+		switch {
+		case code == pingMsg:
+			c.writeRaw(pongMsg, []byte{0xc0})
+		case code == discMsg:
+			return nil, fmt.Errorf("disconnect received: %w", decodeDisconnect(data))
+		case code >= baseProtoLen:
+			return &message{code: code - baseProtoLen, data: data}, nil
+		}
+	}
+}
+
+// readQBFT reads messages until the given function accepts a QBFT message of
+// the node, or until the deadline. Every message of the node is checked for
+// conformance on the way, and the committed blocks are handed to the function
+// as nil messages along with the block.
+func (c *Conn) readQBFT(deadline time.Time, fn func(qbfttypes.QBFTMessage, *newBlockPacket) bool) error {
+	for {
+		msg, err := c.read(deadline)
+		if err != nil {
+			return err
+		}
+		switch {
+		case msg.code == statusMsg:
+			if err := c.readStatus(msg); err != nil {
+				return err
+			}
+
+		case msg.code == newBlockMsg:
+			var packet newBlockPacket
+			if err := rlp.DecodeBytes(msg.data, &packet); err != nil {
+				return fmt.Errorf("invalid block message: %v", err)
+			}
+			if fn(nil, &packet) {
+				return nil
+			}
+
+		default:
+			if _, ok := qbfttypes.MessageCodes()[msg.code]; !ok {
+				return fmt.Errorf("unexpected message code %#x", msg.code)
+			}
+			m, err := c.suite.checkMessage(c, msg.code, msg.data)
+			if err != nil {
+				return err
+			}
+			if fn(m, nil) {
+				return nil
+			}
+		}
+	}
+}
+
+// send signs the QBFT message with the given validator key and sends it.
+func (c *Conn) send(msg qbfttypes.QBFTMessage, key *ecdsa.PrivateKey) error {
+	if err := sign(msg, key); err != nil {
+		return err
+	}
+	return c.write(baseProtoLen+msg.Code(), msg)
+}
+
+// sign signs the QBFT message with the given validator key.
+func sign(msg qbfttypes.QBFTMessage, key *ecdsa.PrivateKey) error {
+	payload, err := msg.EncodePayloadForSigning()
+	if err != nil {
+		return err
+	}
+	sig, err := crypto.Sign(crypto.Keccak256(payload), key)
+	if err != nil {
+		return err
+	}
+	msg.SetSignature(sig)
+	msg.SetSource(crypto.PubkeyToAddress(key.PublicKey))
+	return nil
+}
+
+// decodeDisconnect returns the reason of a disconnect message.
+func decodeDisconnect(data []byte) error {
+	var reason []p2p.DiscReason
+	if err := rlp.DecodeBytes(data, &reason); err != nil || len(reason) == 0 {
+		return fmt.Errorf("invalid disconnect message %x", data)
+	}
+	return reason[0]
+}
+
+// canonical checks that the message encodes back into the given data.
+func canonical(msg interface{}, data []byte) error {
+	enc, err := rlp.EncodeToBytes(msg)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(enc, data) {
+		return fmt.Errorf("non-canonical encoding:\nhave %x\nwant %x", data, enc)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package qbfttest
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Unexported devp2p message codes from p2p/peer.go.
+const (
+	handshakeMsg = 0x00
+	discMsg      = 0x01
+	pingMsg      = 0x02
+	pongMsg      = 0x03
+)
+
+// Unexported devp2p protocol lengths from p2p package.
+const baseProtoLen = 16
+
+// Message codes of the istanbul/100 protocol besides the QBFT messages. The
+// status and the block propagation are specific to geth, other clients are not
+// expected to send them. Geth only sends a status if its validator key differs
+// from its node key.
+const (
+	statusMsg   = 0x00
+	newBlockMsg = 0x07
+)
+
+// Unexported handshake structure from p2p/peer.go.
+type protoHandshake struct {
+	Version    uint64
+	Name       string
+	Caps       []p2p.Cap
+	ListenPort uint64
+	ID         []byte
+	Rest       []rlp.RawValue `rlp:"tail"`
+}
+
+// statusPacket is the network packet announcing the validator address of a node,
+// signed over its node ID to prevent impersonation.
+type statusPacket struct {
+	Validator common.Address
+	Signature []byte
+}
+
+// newBlockPacket is the network packet propagating a committed block.
+type newBlockPacket struct {
+	Block *types.Block
+	TD    *big.Int
+}
+
+// statusData returns the data signed by a validator in the status packet of the
+// node with the given ID.
+func statusData(id enode.ID) []byte {
+	blob, _ := rlp.EncodeToBytes([]interface{}{uint64(statusMsg), id})
+	return blob
+}
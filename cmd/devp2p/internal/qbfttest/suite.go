@@ -0,0 +1,648 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+// Package qbfttest implements a conformance test suite for the QBFT consensus
+// messages exchanged on the istanbul/100 protocol.
+//
+// The tester joins the network of the node as all the validators but the node
+// itself, which must not be able to reach a quorum alone. It drives the rounds
+// of the node with its own messages and checks the messages of the node against
+// the QBFT wire encoding and justification rules.
+//
+// The encoding checked is the one of the qbfttypes package. The suite has only
+// been run against geth, so passing it shows no compatibility with Besu or
+// GoQuorum.
+package qbfttest
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	qbfttypes "github.com/ethereum/go-ethereum/consensus/istanbul/qbft/types"
+	"github.com/ethereum/go-ethereum/consensus/istanbul/validator"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/utesting"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+var (
+	proposeTimeout = 3 * time.Second // Time to wait for a proposal of the node in its rounds
+	quietTimeout   = 3 * time.Second // Time the node must stay quiet after invalid messages
+)
+
+// Suite represents a structure used to test the conformance of a node to the
+// QBFT consensus protocol.
+type Suite struct {
+	Dest   *enode.Node
+	keys   []*ecdsa.PrivateKey
+	valSet istanbul.ValidatorSet
+	quorum int
+
+	target   common.Address    // Validator of the node, learned from its messages
+	sequence uint64            // Latest sequence the node is at
+	round    uint64            // Latest round of the node in the sequence
+	offsets  map[uint64]uint64 // Round robin offset of the proposers by sequence
+}
+
+// NewSuite creates and returns a new QBFT test suite against the node running
+// the chain of the given genesis file. The keys are the validator keys of the
+// tester, the node must hold the key of another validator.
+func NewSuite(dest *enode.Node, genesisFile string, keys []*ecdsa.PrivateKey) (*Suite, error) {
+	blob, err := os.ReadFile(genesisFile)
+	if err != nil {
+		return nil, err
+	}
+	var genesis core.Genesis
+	if err := json.Unmarshal(blob, &genesis); err != nil {
+		return nil, fmt.Errorf("invalid genesis file: %v", err)
+	}
+	extra, err := types.ExtractQBFTExtra(&types.Header{Extra: genesis.ExtraData})
+	if err != nil {
+		return nil, fmt.Errorf("invalid genesis extra-data: %v", err)
+	}
+	// QBFT orders the validators by address bytes to pick the proposers
+	policy := istanbul.NewProposerPolicyByIdAndSortFunc(istanbul.RoundRobin, istanbul.ValidatorSortByByte())
+	valSet := validator.NewSet(extra.Validators, policy)
+
+	if len(keys) == 0 {
+		return nil, errors.New("no validator keys")
+	}
+	seen := make(map[common.Address]bool)
+	for _, key := range keys {
+		addr := crypto.PubkeyToAddress(key.PublicKey)
+		if _, val := valSet.GetByAddress(addr); val == nil {
+			return nil, fmt.Errorf("key of %x is not a validator key", addr)
+		}
+		if seen[addr] {
+			return nil, fmt.Errorf("duplicate key of %x", addr)
+		}
+		seen[addr] = true
+	}
+	quorum := 2*valSet.F() + 1
+	if quorum < 2 {
+		return nil, fmt.Errorf("node reaches quorum alone with %d validators", valSet.Size())
+	}
+	if len(keys)+1 < quorum {
+		return nil, fmt.Errorf("%d keys can't reach quorum of %d with the node", len(keys), quorum)
+	}
+	return &Suite{
+		Dest:    dest,
+		keys:    keys,
+		valSet:  valSet,
+		quorum:  quorum,
+		offsets: make(map[uint64]uint64),
+	}, nil
+}
+
+func (s *Suite) QBFTTests() []utesting.Test {
+	return []utesting.Test{
+		{Name: "Identity", Fn: s.TestIdentity},
+		{Name: "MessageEncoding", Fn: s.TestMessageEncoding},
+		{Name: "RoundChange", Fn: s.TestRoundChange},
+		{Name: "Justification", Fn: s.TestJustification},
+		{Name: "InvalidJustification", Fn: s.TestInvalidJustification},
+		{Name: "BlockImport", Fn: s.TestBlockImport},
+	}
+}
+
+func (s *Suite) TestIdentity(t *utesting.T) {
+	t.Log(`This test performs the istanbul protocol handshake and checks that the
+validator announced by the node, or else its node key, belongs to a validator
+not played by the tester.`)
+
+	conn, err := s.dial()
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	if _, val := s.valSet.GetByAddress(conn.remote); val == nil {
+		t.Fatalf("node identifies as non-validator %x", conn.remote)
+	}
+	if s.ours(conn.remote) {
+		t.Fatalf("node identifies as validator %x of the tester", conn.remote)
+	}
+	s.target = conn.remote
+}
+
+func (s *Suite) TestMessageEncoding(t *utesting.T) {
+	t.Log(`This test checks that the QBFT messages of the node decode, encode back
+into the same bytes and are signed by the validator of the node.`)
+
+	conn, err := s.dial()
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	if err := s.sync(conn); err != nil {
+		t.Fatalf("failed to sync with node: %v", err)
+	}
+	// Move the node to a new round to get a fresh ROUND-CHANGE
+	if _, _, err := s.moveTo(conn, s.round+1); err != nil {
+		t.Fatalf("failed to change round: %v", err)
+	}
+}
+
+func (s *Suite) TestRoundChange(t *utesting.T) {
+	t.Log(`This test sends ROUND-CHANGE messages for a future round and checks that the
+node moves to the lowest future round and broadcasts its own ROUND-CHANGE.`)
+
+	conn, err := s.dial()
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	if err := s.sync(conn); err != nil {
+		t.Fatalf("failed to sync with node: %v", err)
+	}
+	// Skip a round, the node must jump to the round of the tester directly
+	round := s.round + 2
+	rc, _, err := s.moveTo(conn, round)
+	if err != nil {
+		t.Fatalf("failed to change round: %v", err)
+	}
+	if rc.PreparedRound != nil && rc.PreparedRound.Uint64() >= round {
+		t.Fatalf("prepared round %d not below round %d", rc.PreparedRound, round)
+	}
+}
+
+func (s *Suite) TestJustification(t *utesting.T) {
+	t.Log(`This test moves the node to a round it proposes in, and checks the
+justification of its PRE-PREPARE message.`)
+
+	conn, err := s.dial()
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	if err := s.sync(conn); err != nil {
+		t.Fatalf("failed to sync with node: %v", err)
+	}
+	preprepare, err := s.propose(conn)
+	if err != nil {
+		t.Fatalf("failed to get proposal: %v", err)
+	}
+	if len(preprepare.JustificationRoundChanges) < s.quorum {
+		t.Fatalf("PRE-PREPARE justified by %d ROUND-CHANGE messages, want at least %d", len(preprepare.JustificationRoundChanges), s.quorum)
+	}
+}
+
+func (s *Suite) TestInvalidJustification(t *utesting.T) {
+	t.Log(`This test proposes a block in a round of the tester without justification,
+which the node must not prepare, then with a valid justification.`)
+
+	conn, err := s.dial()
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	if err := s.sync(conn); err != nil {
+		t.Fatalf("failed to sync with node: %v", err)
+	}
+	// Get a block to propose from the node, then move to a round of the tester
+	preprepare, err := s.propose(conn)
+	if err != nil {
+		t.Fatalf("failed to get proposal: %v", err)
+	}
+	var (
+		sequence       = s.sequence
+		round, key     = s.nextRound(s.ours)
+		rc, ours, errc = s.moveTo(conn, round)
+	)
+	if errc != nil {
+		t.Fatalf("failed to change round: %v", errc)
+	}
+	if key == nil {
+		t.Fatalf("no round of the tester in sequence %d", sequence)
+	}
+	block := preprepare.Proposal.(*types.Block)
+	if rc.PreparedBlock != nil {
+		block = rc.PreparedBlock
+	}
+	prepared := func(m qbfttypes.QBFTMessage, _ *newBlockPacket) bool {
+		prepare, ok := m.(*qbfttypes.Prepare)
+		return ok && prepare.Source() == s.target && prepare.Round.Uint64() == round && prepare.Digest == block.Hash()
+	}
+	// Send the proposal without justification
+	unjustified := qbfttypes.NewPreprepare(new(big.Int).SetUint64(sequence), new(big.Int).SetUint64(round), block)
+	if err := conn.send(unjustified, key); err != nil {
+		t.Fatalf("failed to send PRE-PREPARE: %v", err)
+	}
+	switch err := conn.readQBFT(time.Now().Add(quietTimeout), prepared); {
+	case err == nil:
+		t.Fatalf("node prepared unjustified proposal in round %d", round)
+	case !errors.Is(err, errTimeout):
+		t.Fatalf("failed to read from node: %v", err)
+	}
+	// Send the proposal justified by the round changes
+	justified := qbfttypes.NewPreprepare(new(big.Int).SetUint64(sequence), new(big.Int).SetUint64(round), block)
+	justified.JustificationRoundChanges = append(justified.JustificationRoundChanges, &rc.SignedRoundChangePayload)
+	for _, m := range ours {
+		justified.JustificationRoundChanges = append(justified.JustificationRoundChanges, &m.SignedRoundChangePayload)
+	}
+	if rc.PreparedBlock != nil {
+		justified.JustificationPrepares = rc.Justification
+	}
+	if err := conn.send(justified, key); err != nil {
+		t.Fatalf("failed to send PRE-PREPARE: %v", err)
+	}
+	if err := conn.readQBFT(time.Now().Add(eventTimeout), prepared); err != nil {
+		t.Fatalf("node did not prepare justified proposal in round %d: %v", round, err)
+	}
+}
+
+func (s *Suite) TestBlockImport(t *utesting.T) {
+	t.Log(`This test prepares and commits a proposal of the node, and checks that the
+node imports the block sealed by the committed seals.`)
+
+	conn, err := s.dial()
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	if err := s.sync(conn); err != nil {
+		t.Fatalf("failed to sync with node: %v", err)
+	}
+	preprepare, err := s.propose(conn)
+	if err != nil {
+		t.Fatalf("failed to get proposal: %v", err)
+	}
+	var (
+		sequence = preprepare.Sequence
+		round    = preprepare.Round
+		block    = preprepare.Proposal.(*types.Block)
+		seal     = block.Header().QBFTHashWithRoundNumber(uint32(round.Uint64())).Bytes()
+	)
+	for _, key := range s.keys {
+		if err := conn.send(qbfttypes.NewPrepare(sequence, round, block.Hash()), key); err != nil {
+			t.Fatalf("failed to send PREPARE: %v", err)
+		}
+	}
+	// Wait for the node to commit, checking its committed seal
+	err = conn.readQBFT(time.Now().Add(eventTimeout), func(m qbfttypes.QBFTMessage, _ *newBlockPacket) bool {
+		commit, ok := m.(*qbfttypes.Commit)
+		return ok && commit.Source() == s.target && commit.Round.Cmp(round) == 0 && commit.Digest == block.Hash()
+	})
+	if err != nil {
+		t.Fatalf("node did not commit proposal: %v", err)
+	}
+	for _, key := range s.keys {
+		committed, err := crypto.Sign(seal, key)
+		if err != nil {
+			t.Fatalf("failed to seal: %v", err)
+		}
+		if err := conn.send(qbfttypes.NewCommit(sequence, round, block.Hash(), committed), key); err != nil {
+			t.Fatalf("failed to send COMMIT: %v", err)
+		}
+	}
+	// Wait for the block to be propagated, or for the node to move to the next
+	// sequence if it doesn't propagate blocks on the istanbul protocol
+	var imported *types.Block
+	err = conn.readQBFT(time.Now().Add(eventTimeout), func(m qbfttypes.QBFTMessage, packet *newBlockPacket) bool {
+		if packet != nil && packet.Block.NumberU64() == sequence.Uint64() {
+			imported = packet.Block
+			return true
+		}
+		return m != nil && m.Source() == s.target && m.View().Sequence.Cmp(sequence) > 0
+	})
+	if err != nil {
+		t.Fatalf("node did not import block %d: %v", sequence, err)
+	}
+	if imported == nil {
+		t.Log("node sent no block, moved to the next sequence")
+		return
+	}
+	if imported.Hash() != block.Hash() {
+		t.Fatalf("imported block %x, want %x", imported.Hash(), block.Hash())
+	}
+	extra, err := types.ExtractQBFTExtra(imported.Header())
+	if err != nil {
+		t.Fatalf("invalid extra-data of imported block: %v", err)
+	}
+	if uint64(extra.Round) != round.Uint64() {
+		t.Fatalf("imported block committed in round %d, want %d", extra.Round, round)
+	}
+	signers := make(map[common.Address]bool)
+	for _, committed := range extra.CommittedSeal {
+		pubkey, err := crypto.SigToPub(seal, committed)
+		if err != nil {
+			t.Fatalf("invalid committed seal %x: %v", committed, err)
+		}
+		signer := crypto.PubkeyToAddress(*pubkey)
+		if _, val := s.valSet.GetByAddress(signer); val == nil {
+			t.Fatalf("block sealed by non-validator %x", signer)
+		}
+		if signers[signer] {
+			t.Fatalf("block sealed twice by %x", signer)
+		}
+		signers[signer] = true
+	}
+	if len(signers) < s.quorum {
+		t.Fatalf("block sealed by %d validators, want at least %d", len(signers), s.quorum)
+	}
+	// The proposers of the next sequence follow the proposer of the block
+	s.learnOffset(sequence.Uint64()+1, uint64(s.valSet.Size())-1, imported.Coinbase())
+}
+
+// ours reports whether the given validator is one of the tester.
+func (s *Suite) ours(addr common.Address) bool {
+	return s.key(addr) != nil
+}
+
+// key returns the key of the given validator of the tester.
+func (s *Suite) key(addr common.Address) *ecdsa.PrivateKey {
+	for _, key := range s.keys {
+		if crypto.PubkeyToAddress(key.PublicKey) == addr {
+			return key
+		}
+	}
+	return nil
+}
+
+// signer returns the validator which signed the given message.
+func (s *Suite) signer(msg qbfttypes.QBFTMessage) (common.Address, error) {
+	payload, err := msg.EncodePayloadForSigning()
+	if err != nil {
+		return common.Address{}, err
+	}
+	signer, err := istanbul.GetSignatureAddress(payload, msg.Signature())
+	if err != nil {
+		return common.Address{}, fmt.Errorf("invalid signature: %v", err)
+	}
+	if _, val := s.valSet.GetByAddress(signer); val == nil {
+		return common.Address{}, fmt.Errorf("signed by non-validator %x", signer)
+	}
+	return signer, nil
+}
+
+// checkMessage decodes a QBFT message received on the connection and checks it
+// for conformance, tracking the view of the node along the way.
+func (s *Suite) checkMessage(conn *Conn, code uint64, data []byte) (qbfttypes.QBFTMessage, error) {
+	msg, err := qbfttypes.Decode(code, data)
+	if err != nil {
+		return nil, fmt.Errorf("message %#x: %v", code, err)
+	}
+	if msg.View().Sequence == nil || msg.View().Round == nil {
+		return nil, fmt.Errorf("message %#x: missing view", code)
+	}
+	if err := canonical(msg, data); err != nil {
+		return nil, fmt.Errorf("message %#x: %v", code, err)
+	}
+	signer, err := s.signer(msg)
+	if err != nil {
+		return nil, fmt.Errorf("message %#x: %v", code, err)
+	}
+	msg.SetSource(signer)
+
+	// Messages of the tester may be gossiped back, don't check them further
+	if s.ours(signer) {
+		return msg, nil
+	}
+	if signer != conn.remote {
+		return nil, fmt.Errorf("message %#x: signed by %x, node identifies as %x", code, signer, conn.remote)
+	}
+	if s.target == (common.Address{}) {
+		s.target = signer
+	}
+	if signer != s.target {
+		return nil, fmt.Errorf("message %#x: signed by %x, node validates with %x", code, signer, s.target)
+	}
+	view := msg.View()
+	if seq := view.Sequence.Uint64(); seq > s.sequence {
+		s.sequence, s.round = seq, view.Round.Uint64()
+	} else if seq == s.sequence && view.Round.Uint64() > s.round {
+		s.round = view.Round.Uint64()
+	}
+	switch msg := msg.(type) {
+	case *qbfttypes.Preprepare:
+		if err := s.checkPreprepare(msg); err != nil {
+			return nil, fmt.Errorf("PRE-PREPARE %v: %v", msg.View(), err)
+		}
+		s.learnOffset(msg.Sequence.Uint64(), msg.Round.Uint64(), signer)
+
+	case *qbfttypes.RoundChange:
+		if err := s.checkRoundChange(msg); err != nil {
+			return nil, fmt.Errorf("ROUND-CHANGE %v: %v", msg.View(), err)
+		}
+	}
+	return msg, nil
+}
+
+// checkPreprepare checks the proposal and the justification of a PRE-PREPARE.
+func (s *Suite) checkPreprepare(msg *qbfttypes.Preprepare) error {
+	if msg.Proposal.Number().Cmp(msg.Sequence) != 0 {
+		return fmt.Errorf("proposal number %d", msg.Proposal.Number())
+	}
+	if msg.Round.Sign() == 0 {
+		if len(msg.JustificationRoundChanges) != 0 || len(msg.JustificationPrepares) != 0 {
+			return errors.New("justification in first round")
+		}
+		return nil
+	}
+	// Check the ROUND-CHANGE messages, and find the highest prepared round
+	var (
+		signers       = make(map[common.Address]bool)
+		preparedRound *big.Int
+		preparedHash  common.Hash
+	)
+	for _, rc := range msg.JustificationRoundChanges {
+		if rc.Sequence.Cmp(msg.Sequence) != 0 || rc.Round.Cmp(msg.Round) != 0 {
+			return fmt.Errorf("justified by ROUND-CHANGE for %v", rc.View())
+		}
+		signer, err := s.signer(rc)
+		if err != nil {
+			return fmt.Errorf("ROUND-CHANGE justification: %v", err)
+		}
+		signers[signer] = true
+
+		if rc.PreparedRound != nil && (preparedRound == nil || rc.PreparedRound.Cmp(preparedRound) > 0) {
+			preparedRound, preparedHash = rc.PreparedRound, rc.PreparedDigest
+		}
+	}
+	if len(signers) < s.quorum {
+		return fmt.Errorf("justified by ROUND-CHANGE of %d validators, want %d", len(signers), s.quorum)
+	}
+	if preparedRound == nil {
+		return nil
+	}
+	// A block was prepared, it must be proposed again with the PREPARE quorum
+	if msg.Proposal.Hash() != preparedHash {
+		return fmt.Errorf("proposal %x, prepared %x in round %d", msg.Proposal.Hash(), preparedHash, preparedRound)
+	}
+	return s.checkPrepares(msg.JustificationPrepares, msg.Sequence, preparedRound, preparedHash)
+}
+
+// checkRoundChange checks the prepared certificate of a ROUND-CHANGE.
+func (s *Suite) checkRoundChange(msg *qbfttypes.RoundChange) error {
+	if msg.PreparedRound == nil {
+		if msg.PreparedBlock != nil || len(msg.Justification) != 0 {
+			return errors.New("prepared certificate without prepared round")
+		}
+		return nil
+	}
+	if msg.PreparedRound.Cmp(msg.Round) >= 0 {
+		return fmt.Errorf("prepared round %d not below round", msg.PreparedRound)
+	}
+	if msg.PreparedBlock == nil {
+		return fmt.Errorf("missing block prepared in round %d", msg.PreparedRound)
+	}
+	return s.checkPrepares(msg.Justification, msg.Sequence, msg.PreparedRound, msg.PreparedDigest)
+}
+
+// checkPrepares checks that the PREPARE messages form a quorum for the block.
+func (s *Suite) checkPrepares(prepares []*qbfttypes.Prepare, sequence, round *big.Int, hash common.Hash) error {
+	signers := make(map[common.Address]bool)
+	for _, prepare := range prepares {
+		if prepare.Sequence.Cmp(sequence) != 0 || prepare.Round.Cmp(round) != 0 || prepare.Digest != hash {
+			return fmt.Errorf("PREPARE justification for %v, digest %x", prepare.View(), prepare.Digest)
+		}
+		signer, err := s.signer(prepare)
+		if err != nil {
+			return fmt.Errorf("PREPARE justification: %v", err)
+		}
+		signers[signer] = true
+	}
+	if len(signers) < s.quorum {
+		return fmt.Errorf("prepared by %d validators, want %d", len(signers), s.quorum)
+	}
+	return nil
+}
+
+// learnOffset records the round robin offset of the proposers in the sequence,
+// from a proposal of the given validator.
+func (s *Suite) learnOffset(sequence, round uint64, proposer common.Address) {
+	idx, _ := s.valSet.GetByAddress(proposer)
+	size := uint64(s.valSet.Size())
+	s.offsets[sequence] = (uint64(idx) + size - round%size) % size
+}
+
+// nextRound returns the first round after the current one proposed by one of
+// the accepted validators, and the key of the proposer if it's the tester.
+func (s *Suite) nextRound(accept func(common.Address) bool) (uint64, *ecdsa.PrivateKey) {
+	offset, ok := s.offsets[s.sequence]
+	if !ok {
+		return s.round + 1, nil
+	}
+	for round := s.round + 1; ; round++ {
+		proposer := s.valSet.GetByIndex((offset + round) % uint64(s.valSet.Size())).Address()
+		if accept(proposer) {
+			return round, s.key(proposer)
+		}
+	}
+}
+
+// sync waits for a message of the node to learn its current view. If the view
+// is known already, the node is moved to the next round to speed things up.
+func (s *Suite) sync(conn *Conn) error {
+	if s.sequence != 0 {
+		if _, err := s.sendRoundChanges(conn, s.round+1); err != nil {
+			return err
+		}
+	}
+	return conn.readQBFT(time.Now().Add(eventTimeout), func(m qbfttypes.QBFTMessage, _ *newBlockPacket) bool {
+		return m != nil && m.Source() == s.target
+	})
+}
+
+// sendRoundChanges sends ROUND-CHANGE messages for the given round of the
+// current sequence with all the keys of the tester.
+func (s *Suite) sendRoundChanges(conn *Conn, round uint64) ([]*qbfttypes.RoundChange, error) {
+	var sent []*qbfttypes.RoundChange
+	for _, key := range s.keys {
+		rc := qbfttypes.NewRoundChange(new(big.Int).SetUint64(s.sequence), new(big.Int).SetUint64(round), nil, nil)
+		if err := conn.send(rc, key); err != nil {
+			return nil, fmt.Errorf("failed to send ROUND-CHANGE: %v", err)
+		}
+		sent = append(sent, rc)
+	}
+	return sent, nil
+}
+
+// moveTo moves the node to the given round of the current sequence, returning
+// the ROUND-CHANGE of the node and the ones of the tester.
+func (s *Suite) moveTo(conn *Conn, round uint64) (*qbfttypes.RoundChange, []*qbfttypes.RoundChange, error) {
+	sequence := s.sequence
+	sent, err := s.sendRoundChanges(conn, round)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		rc      *qbfttypes.RoundChange
+		skipped error
+	)
+	err = conn.readQBFT(time.Now().Add(eventTimeout), func(m qbfttypes.QBFTMessage, _ *newBlockPacket) bool {
+		msg, ok := m.(*qbfttypes.RoundChange)
+		if !ok || msg.Source() != s.target || msg.Sequence.Uint64() != sequence {
+			return false
+		}
+		switch r := msg.Round.Uint64(); {
+		case r == round:
+			rc = msg
+			return true
+		case r > round:
+			skipped = fmt.Errorf("node moved to round %d instead of %d", r, round)
+			return true
+		}
+		return false
+	})
+	if err == nil {
+		err = skipped
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return rc, sent, nil
+}
+
+// propose moves the node to rounds of the current sequence until it proposes a
+// block, and returns its PRE-PREPARE.
+func (s *Suite) propose(conn *Conn) (*qbfttypes.Preprepare, error) {
+	for i := 0; i <= s.valSet.Size(); i++ {
+		round, _ := s.nextRound(func(addr common.Address) bool { return addr == s.target })
+		if _, _, err := s.moveTo(conn, round); err != nil {
+			return nil, err
+		}
+		var preprepare *qbfttypes.Preprepare
+		err := conn.readQBFT(time.Now().Add(proposeTimeout), func(m qbfttypes.QBFTMessage, _ *newBlockPacket) bool {
+			msg, ok := m.(*qbfttypes.Preprepare)
+			if ok && msg.Source() == s.target && msg.Round.Uint64() == round {
+				preprepare = msg
+			}
+			return preprepare != nil
+		})
+		switch {
+		case err == nil:
+			return preprepare, nil
+		case !errors.Is(err, errTimeout):
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("node did not propose in sequence %d", s.sequence)
+}
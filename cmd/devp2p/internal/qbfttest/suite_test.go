@@ -0,0 +1,181 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package qbfttest
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/internal/utesting"
+	"github.com/ethereum/go-ethereum/miner"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestQBFTSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode")
+	}
+	keys := make([]*ecdsa.PrivateKey, 4)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	genesisFile, err := writeGenesis(t.TempDir(), keys)
+	if err != nil {
+		t.Fatalf("could not write genesis: %v", err)
+	}
+	geth, err := runGeth(t.TempDir(), genesisFile, keys[0])
+	if err != nil {
+		t.Fatalf("could not run geth: %v", err)
+	}
+	defer geth.Close()
+
+	suite, err := NewSuite(geth.Server().Self(), genesisFile, keys[1:])
+	if err != nil {
+		t.Fatalf("could not create new test suite: %v", err)
+	}
+	for _, test := range suite.QBFTTests() {
+		t.Run(test.Name, func(t *testing.T) {
+			result := utesting.RunTests([]utesting.Test{{Name: test.Name, Fn: test.Fn}}, os.Stdout)
+			if result[0].Failed {
+				t.Fatal()
+			}
+		})
+	}
+}
+
+// writeGenesis writes the genesis file of a QBFT chain sealed by the given
+// validators.
+func writeGenesis(dir string, keys []*ecdsa.PrivateKey) (string, error) {
+	validators := make([]common.Address, len(keys))
+	for i, key := range keys {
+		validators[i] = crypto.PubkeyToAddress(key.PublicKey)
+	}
+	extra, err := rlp.EncodeToBytes(&types.QBFTExtra{
+		VanityData:    make([]byte, types.IstanbulExtraVanity),
+		Validators:    validators,
+		CommittedSeal: [][]byte{},
+	})
+	if err != nil {
+		return "", err
+	}
+	config := *params.AllEthashProtocolChanges
+	config.Ethash = nil
+	config.ShanghaiTime = nil
+	config.CancunTime = nil
+	config.PragueTime = nil
+	config.VerkleTime = nil
+	config.TerminalTotalDifficulty = nil
+	config.TerminalTotalDifficultyPassed = false
+	config.QBFT = &params.QBFTConfig{
+		EpochLength:           30000,
+		BlockPeriodSeconds:    1,
+		RequestTimeoutSeconds: 2,
+	}
+	genesis := &core.Genesis{
+		Config:     &config,
+		ExtraData:  extra,
+		GasLimit:   30_000_000,
+		Difficulty: big.NewInt(1),
+		Mixhash:    types.IstanbulDigest,
+		Alloc:      types.GenesisAlloc{},
+	}
+	blob, err := json.Marshal(genesis)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "genesis.json")
+	return path, os.WriteFile(path, blob, 0600)
+}
+
+// runGeth creates and starts a geth node validating with the given key. Its node
+// key is random, so the node announces its validator in a status.
+func runGeth(dir string, genesisFile string, key *ecdsa.PrivateKey) (*node.Node, error) {
+	blob, err := os.ReadFile(genesisFile)
+	if err != nil {
+		return nil, err
+	}
+	genesis := new(core.Genesis)
+	if err := json.Unmarshal(blob, genesis); err != nil {
+		return nil, err
+	}
+	stack, err := node.New(&node.Config{
+		DataDir: dir,
+		P2P: p2p.Config{
+			ListenAddr:  "127.0.0.1:0",
+			NoDiscovery: true,
+			MaxPeers:    10,
+			NoDial:      true,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ks := keystore.NewKeyStore(filepath.Join(dir, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+	stack.AccountManager().AddBackend(ks)
+
+	account, err := ks.ImportECDSA(key, "")
+	if err != nil {
+		stack.Close()
+		return nil, err
+	}
+	if err := ks.Unlock(account, ""); err != nil {
+		stack.Close()
+		return nil, err
+	}
+	mcfg := miner.DefaultConfig
+	mcfg.Etherbase = account.Address
+
+	backend, err := eth.New(stack, &ethconfig.Config{
+		Genesis:        genesis,
+		NetworkId:      genesis.Config.ChainID.Uint64(),
+		SyncMode:       downloader.FullSync,
+		DatabaseCache:  10,
+		TrieCleanCache: 10,
+		TrieDirtyCache: 16,
+		TrieTimeout:    time.Minute,
+		Miner:          mcfg,
+	})
+	if err != nil {
+		stack.Close()
+		return nil, err
+	}
+	if err := stack.Start(); err != nil {
+		stack.Close()
+		return nil, err
+	}
+	if err := backend.StartMining(); err != nil {
+		stack.Close()
+		return nil, err
+	}
+	return stack, nil
+}
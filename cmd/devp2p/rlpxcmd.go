@@ -17,11 +17,13 @@
 package main
 
 import (
+	"crypto/ecdsa"
 	"errors"
 	"fmt"
 	"net"
 
 	"github.com/ethereum/go-ethereum/cmd/devp2p/internal/ethtest"
+	"github.com/ethereum/go-ethereum/cmd/devp2p/internal/qbfttest"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
@@ -38,6 +40,7 @@ var (
 			rlpxPingCommand,
 			rlpxEthTestCommand,
 			rlpxSnapTestCommand,
+			rlpxQBFTTestCommand,
 		},
 	}
 	rlpxPingCommand = &cli.Command{
@@ -73,6 +76,19 @@ var (
 			testNodeEngineFlag,
 		},
 	}
+	rlpxQBFTTestCommand = &cli.Command{
+		Name:      "qbft-test",
+		Usage:     "Runs istanbul/100 QBFT protocol tests against a validator node",
+		ArgsUsage: "",
+		Action:    rlpxQBFTTest,
+		Flags: []cli.Flag{
+			testPatternFlag,
+			testTAPFlag,
+			testNodeFlag,
+			testGenesisFlag,
+			testValidatorKeyFlag,
+		},
+	}
 )
 
 func rlpxPing(ctx *cli.Context) error {
@@ -134,6 +150,38 @@ func rlpxSnapTest(ctx *cli.Context) error {
 	return runTests(ctx, suite.SnapTests())
 }
 
+// rlpxQBFTTest runs the QBFT protocol test suite.
+func rlpxQBFTTest(ctx *cli.Context) error {
+	nodeStr := ctx.String(testNodeFlag.Name)
+	if nodeStr == "" {
+		exit(fmt.Errorf("missing -%s", testNodeFlag.Name))
+	}
+	node, err := parseNode(nodeStr)
+	if err != nil {
+		exit(err)
+	}
+	genesis := ctx.String(testGenesisFlag.Name)
+	if genesis == "" {
+		exit(fmt.Errorf("missing -%s", testGenesisFlag.Name))
+	}
+	var keys []*ecdsa.PrivateKey
+	for _, file := range ctx.StringSlice(testValidatorKeyFlag.Name) {
+		key, err := crypto.LoadECDSA(file)
+		if err != nil {
+			exit(fmt.Errorf("can't load validator key: %v", err))
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		exit(fmt.Errorf("missing -%s", testValidatorKeyFlag.Name))
+	}
+	suite, err := qbfttest.NewSuite(node, genesis, keys)
+	if err != nil {
+		exit(err)
+	}
+	return runTests(ctx, suite.QBFTTests())
+}
+
 type testParams struct {
 	node      *enode.Node
 	engineAPI string
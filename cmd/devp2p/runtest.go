@@ -61,6 +61,18 @@ var (
 		Category: flags.TestingCategory,
 	}
 
+	// for qbft tests
+	testGenesisFlag = &cli.StringFlag{
+		Name:     "genesis",
+		Usage:    "Genesis file of the QBFT chain of the test node (required)",
+		Category: flags.TestingCategory,
+	}
+	testValidatorKeyFlag = &cli.StringSliceFlag{
+		Name:     "validatorkey",
+		Usage:    "Key file of a validator run by the tester, may be repeated (required)",
+		Category: flags.TestingCategory,
+	}
+
 	// These two are specific to the discovery tests.
 	testListen1Flag = &cli.StringFlag{
 		Name:     "listen1",
@@ -17,12 +17,15 @@
 package main
 
 import (
+	"bytes"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"os"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/core/asm"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/urfave/cli/v2"
 )
 
@@ -31,6 +34,14 @@ var disasmCommand = &cli.Command{
 	Name:      "disasm",
 	Usage:     "Disassembles evm binary",
 	ArgsUsage: "<file>",
+	Flags: []cli.Flag{
+		InitcodeFlag,
+	},
+}
+
+var InitcodeFlag = &cli.BoolFlag{
+	Name:  "initcode",
+	Usage: "Validate EOF containers as initcode instead of runtime code",
 }
 
 func disasmCmd(ctx *cli.Context) error {
@@ -51,5 +62,29 @@ func disasmCmd(ctx *cli.Context) error {
 
 	code := strings.TrimSpace(in)
 	fmt.Printf("%v\n", code)
+
+	// EOF containers are validated before printing their sections, so that
+	// malformed code isn't mistaken for a valid contract.
+	if script, err := hex.DecodeString(code); err == nil && bytes.HasPrefix(script, []byte{0xef, 0x00}) {
+		if err := validateEOF(script, ctx.Bool(InitcodeFlag.Name)); err != nil {
+			return err
+		}
+	}
 	return asm.PrintDisassembled(code)
 }
+
+// validateEOF parses and validates an EOF container along with all of its
+// subcontainers.
+func validateEOF(script []byte, initcode bool) error {
+	var (
+		container vm.Container
+		jt        = vm.NewEOFInstructionSetForTesting()
+	)
+	if err := container.UnmarshalBinary(script); err != nil {
+		return fmt.Errorf("invalid EOF container: %v", err)
+	}
+	if err := container.ValidateCode(&jt, initcode); err != nil {
+		return fmt.Errorf("invalid EOF container: %v", err)
+	}
+	return nil
+}
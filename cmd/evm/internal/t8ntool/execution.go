@@ -53,19 +53,23 @@ type Prestate struct {
 // ExecutionResult contains the execution status after running a state test, any
 // error that might have occurred and a dump of the final state if requested.
 type ExecutionResult struct {
-	StateRoot            common.Hash           `json:"stateRoot"`
-	TxRoot               common.Hash           `json:"txRoot"`
-	ReceiptRoot          common.Hash           `json:"receiptsRoot"`
-	LogsHash             common.Hash           `json:"logsHash"`
-	Bloom                types.Bloom           `json:"logsBloom"        gencodec:"required"`
-	Receipts             types.Receipts        `json:"receipts"`
-	Rejected             []*rejectedTx         `json:"rejected,omitempty"`
-	Difficulty           *math.HexOrDecimal256 `json:"currentDifficulty" gencodec:"required"`
-	GasUsed              math.HexOrDecimal64   `json:"gasUsed"`
-	BaseFee              *math.HexOrDecimal256 `json:"currentBaseFee,omitempty"`
-	WithdrawalsRoot      *common.Hash          `json:"withdrawalsRoot,omitempty"`
-	CurrentExcessBlobGas *math.HexOrDecimal64  `json:"currentExcessBlobGas,omitempty"`
-	CurrentBlobGasUsed   *math.HexOrDecimal64  `json:"blobGasUsed,omitempty"`
+	StateRoot             common.Hash                 `json:"stateRoot"`
+	TxRoot                common.Hash                 `json:"txRoot"`
+	ReceiptRoot           common.Hash                 `json:"receiptsRoot"`
+	LogsHash              common.Hash                 `json:"logsHash"`
+	Bloom                 types.Bloom                 `json:"logsBloom"        gencodec:"required"`
+	Receipts              types.Receipts              `json:"receipts"`
+	Rejected              []*rejectedTx               `json:"rejected,omitempty"`
+	Difficulty            *math.HexOrDecimal256       `json:"currentDifficulty" gencodec:"required"`
+	GasUsed               math.HexOrDecimal64         `json:"gasUsed"`
+	BaseFee               *math.HexOrDecimal256       `json:"currentBaseFee,omitempty"`
+	WithdrawalsRoot       *common.Hash                `json:"withdrawalsRoot,omitempty"`
+	CurrentExcessBlobGas  *math.HexOrDecimal64        `json:"currentExcessBlobGas,omitempty"`
+	CurrentBlobGasUsed    *math.HexOrDecimal64        `json:"blobGasUsed,omitempty"`
+	RequestsHash          *common.Hash                `json:"requestsRoot,omitempty"`
+	DepositRequests       types.Deposits              `json:"depositRequests,omitempty"`
+	WithdrawalRequests    types.WithdrawalRequests    `json:"withdrawalRequests,omitempty"`
+	ConsolidationRequests types.ConsolidationRequests `json:"consolidationRequests,omitempty"`
 }
 
 type ommer struct {
@@ -196,6 +200,15 @@ func (pre *Prestate) Apply(vmConfig vm.Config, chainConfig *params.ChainConfig,
 		evm := vm.NewEVM(vmContext, vm.TxContext{}, statedb, chainConfig, vmConfig)
 		core.ProcessBeaconBlockRoot(*beaconRoot, evm, statedb)
 	}
+	if pre.Env.Number > 0 && chainConfig.IsHistoryStorage(new(big.Int).SetUint64(pre.Env.Number), pre.Env.Timestamp) {
+		prevNumber := pre.Env.Number - 1
+		prevHash, ok := pre.Env.BlockHashes[math.HexOrDecimal64(prevNumber)]
+		if !ok {
+			return nil, nil, nil, NewError(ErrorMissingBlockhash, fmt.Errorf("previous blockhash %d not provided", prevNumber))
+		}
+		evm := vm.NewEVM(vmContext, vm.TxContext{}, statedb, chainConfig, vmConfig)
+		core.ProcessParentBlockHash(prevHash, evm, statedb)
+	}
 
 	for i := 0; txIt.Next(); i++ {
 		tx, err := txIt.Tx()
@@ -314,6 +327,22 @@ func (pre *Prestate) Apply(vmConfig vm.Config, chainConfig *params.ChainConfig,
 
 		txIndex++
 	}
+	// Gather the execution layer requests of the block after Prague. This is
+	// done in StateProcessor.Process(block, ...) right after the transactions.
+	var requests types.Requests
+	if chainConfig.IsPrague(vmContext.BlockNumber, vmContext.Time) {
+		var allLogs []*types.Log
+		for _, receipt := range receipts {
+			allLogs = append(allLogs, receipt.Logs...)
+		}
+		var (
+			evm = vm.NewEVM(vmContext, vm.TxContext{}, statedb, chainConfig, vm.Config{})
+			err error
+		)
+		if requests, err = core.ProcessRequests(allLogs, evm, statedb); err != nil {
+			return nil, nil, nil, NewError(ErrorEVM, fmt.Errorf("could not process requests: %v", err))
+		}
+	}
 	statedb.IntermediateRoot(chainConfig.IsEIP158(vmContext.BlockNumber))
 	// Add mining reward? (-1 means rewards are disabled)
 	if miningReward >= 0 {
@@ -366,6 +395,13 @@ func (pre *Prestate) Apply(vmConfig vm.Config, chainConfig *params.ChainConfig,
 		h := types.DeriveSha(types.Withdrawals(pre.Env.Withdrawals), trie.NewStackTrie(nil))
 		execRs.WithdrawalsRoot = &h
 	}
+	if requests != nil {
+		h := types.DeriveSha(requests, trie.NewStackTrie(nil))
+		execRs.RequestsHash = &h
+		execRs.DepositRequests = requests.Deposits()
+		execRs.WithdrawalRequests = requests.WithdrawalRequests()
+		execRs.ConsolidationRequests = requests.ConsolidationRequests()
+	}
 	if vmContext.BlobBaseFee != nil {
 		execRs.CurrentExcessBlobGas = (*math.HexOrDecimal64)(&excessBlobGas)
 		execRs.CurrentBlobGasUsed = (*math.HexOrDecimal64)(&blobGasUsed)
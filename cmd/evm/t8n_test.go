@@ -18,6 +18,7 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -672,6 +673,59 @@ func TestB11r(t *testing.T) {
 	}
 }
 
+// Tests that EOF code is executed by the state tests once the fork activating it
+// is reached, and interpreted as legacy code before.
+func TestStatetestEOF(t *testing.T) {
+	t.Parallel()
+	tt := cmdtest.NewTestCmd(t, nil)
+	tt.Run("evm-test", "statetest", "./testdata/eof/state_test.json")
+
+	var results []StatetestResult
+	if err := json.Unmarshal(tt.Output(), &results); err != nil {
+		t.Fatalf("failed to parse results: %v", err)
+	}
+	tt.WaitExit()
+
+	forks := make(map[string]bool)
+	for _, result := range results {
+		if !result.Pass {
+			t.Errorf("fork %s: test failed: %v", result.Fork, result.Error)
+		}
+		forks[result.Fork] = true
+	}
+	if !forks["Prague"] || !forks["Osaka"] || len(forks) != 2 {
+		t.Fatalf("fork mismatch: have %v, want Prague and Osaka", forks)
+	}
+}
+
+// Tests that EOF containers are validated and disassembled section by section.
+func TestDisasmEOF(t *testing.T) {
+	t.Parallel()
+	want, err := os.ReadFile("./testdata/eof/container.exp")
+	if err != nil {
+		t.Fatalf("could not read expected output: %v", err)
+	}
+	tt := cmdtest.NewTestCmd(t, nil)
+	tt.Run("evm-test", "disasm", "./testdata/eof/container.txt")
+	if have := tt.Output(); !bytes.Equal(have, want) {
+		t.Fatalf("output mismatch:\nhave\n%s\nwant\n%s", have, want)
+	}
+	tt.WaitExit()
+	if status := tt.ExitStatus(); status != 0 {
+		t.Fatalf("exit status mismatch: have %d, want 0", status)
+	}
+	// Invalid containers are rejected instead of disassembled
+	tt = cmdtest.NewTestCmd(t, nil)
+	tt.Run("evm-test", "disasm", "./testdata/eof/invalid.txt")
+	tt.WaitExit()
+	if status := tt.ExitStatus(); status != 1 {
+		t.Fatalf("exit status mismatch for invalid container: have %d, want 1", status)
+	}
+	if stderr := tt.StderrText(); !strings.Contains(stderr, "invalid EOF container") {
+		t.Fatalf("missing validation error, stderr: %s", stderr)
+	}
+}
+
 // cmpJson compares the JSON in two byte slices.
 func cmpJson(a, b []byte) (bool, error) {
 	var j, j2 interface{}
@@ -0,0 +1,117 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/stateless"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/urfave/cli/v2"
+)
+
+var witnessCommand = &cli.Command{
+	Action:    witnessCmd,
+	Name:      "witness",
+	Usage:     "Verifies a block statelessly from an execution witness and the block header",
+	ArgsUsage: "<witness> <header>",
+	Description: `
+The witness file contains the output of debug_executionWitness (JSON) or of
+debug_executionWitnessRLP (hex or binary RLP). The header file contains the JSON
+encoded header of the block being verified. The chain configuration is taken from
+the --prestate genesis file, defaulting to mainnet.`,
+}
+
+func witnessCmd(ctx *cli.Context) error {
+	if ctx.Args().Len() != 2 {
+		return errors.New("witness and header file arguments required")
+	}
+	witness, err := readWitness(ctx.Args().Get(0))
+	if err != nil {
+		return fmt.Errorf("failed to load witness: %v", err)
+	}
+	src, err := os.ReadFile(ctx.Args().Get(1))
+	if err != nil {
+		return err
+	}
+	header := new(types.Header)
+	if err := json.Unmarshal(src, header); err != nil {
+		return fmt.Errorf("failed to load header: %v", err)
+	}
+	config := params.MainnetChainConfig
+	if ctx.String(GenesisFlag.Name) != "" {
+		config = readGenesis(ctx.String(GenesisFlag.Name)).Config
+	}
+	// Ensure the witnessed block is the one described by the header, apart from
+	// the two fields that the stateless execution needs to recompute
+	gutted := witness.Block.Header()
+	gutted.Root, gutted.ReceiptHash = header.Root, header.ReceiptHash
+	if gutted.Hash() != header.Hash() {
+		return fmt.Errorf("witness block mismatch: have %x, want %x", gutted.Hash(), header.Hash())
+	}
+	receiptRoot, stateRoot, err := core.ExecuteStateless(config, witness)
+	if err != nil {
+		return fmt.Errorf("stateless execution failed: %v", err)
+	}
+	if receiptRoot != header.ReceiptHash {
+		return fmt.Errorf("receipt root mismatch: have %x, want %x", receiptRoot, header.ReceiptHash)
+	}
+	if stateRoot != header.Root {
+		return fmt.Errorf("state root mismatch: have %x, want %x", stateRoot, header.Root)
+	}
+	fmt.Printf("Block #%d [%x] verified statelessly, pre-state %x, post-state %x\n", header.Number, header.Hash(), witness.Root(), stateRoot)
+	return nil
+}
+
+// readWitness loads a witness from a file containing either its JSON encoding,
+// or its RLP encoding as binary or hex string.
+func readWitness(path string) (*stateless.Witness, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		witness = new(stateless.Witness)
+		trimmed = bytes.TrimSpace(src)
+	)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		err = json.Unmarshal(trimmed, witness)
+	case bytes.HasPrefix(trimmed, []byte(`"`)):
+		var enc hexutil.Bytes
+		if err = json.Unmarshal(trimmed, &enc); err == nil {
+			err = rlp.DecodeBytes(enc, witness)
+		}
+	case bytes.HasPrefix(trimmed, []byte("0x")):
+		err = rlp.DecodeBytes(common.FromHex(string(trimmed)), witness)
+	default:
+		err = rlp.DecodeBytes(src, witness)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return witness, nil
+}
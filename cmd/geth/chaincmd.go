@@ -150,7 +150,11 @@ from Era archives.
 		Flags:     flags.Merge(utils.DatabaseFlags),
 		Description: `
 The export-history command will export blocks and their corresponding receipts
-into Era archives. Eras are typically packaged in steps of 8192 blocks.
+into Era archives. Eras are typically packaged in steps of 8192 blocks. Eras
+starting after the merge are written in the post-merge EraE format, which has
+no total difficulty and a block accumulator over the block hashes only. Unlike
+the beacon block roots, that accumulator can't be verified against the
+consensus layer, only against accumulator roots computed by a trusted node.
 `,
 	}
 	importPreimagesCommand = &cli.Command{
@@ -442,7 +446,7 @@ func importHistory(ctx *cli.Context) error {
 			}
 		}
 		if len(networks) == 0 {
-			return fmt.Errorf("no era1 files found in %s", dir)
+			return fmt.Errorf("no era1 or erae files found in %s", dir)
 		}
 		if len(networks) > 1 {
 			return errors.New("multiple networks found, use a network flag to specify desired network")
@@ -29,12 +29,15 @@ import (
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/external"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/accounts/plugin"
 	"github.com/ethereum/go-ethereum/accounts/scwallet"
 	"github.com/ethereum/go-ethereum/accounts/usbwallet"
 	"github.com/ethereum/go-ethereum/beacon/blsync"
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/eth"
 	"github.com/ethereum/go-ethereum/eth/catalyst"
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
 	"github.com/ethereum/go-ethereum/internal/flags"
@@ -168,7 +171,7 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, gethConfig) {
 }
 
 // makeFullNode loads geth configuration and creates the Ethereum backend.
-func makeFullNode(ctx *cli.Context) *node.Node {
+func makeFullNode(ctx *cli.Context) (*node.Node, *eth.Ethereum) {
 	stack, cfg := makeConfigNode(ctx)
 	if ctx.IsSet(utils.OverrideCancun.Name) {
 		v := ctx.Uint64(utils.OverrideCancun.Name)
@@ -179,12 +182,12 @@ func makeFullNode(ctx *cli.Context) *node.Node {
 		cfg.Eth.OverrideVerkle = &v
 	}
 
-	backend, eth := utils.RegisterEthService(stack, &cfg.Eth)
+	backend, ethBackend := utils.RegisterEthService(stack, &cfg.Eth)
 
 	// Create gauge with geth system and build information
-	if eth != nil { // The 'eth' backend may be nil in light mode
+	if ethBackend != nil { // The 'eth' backend may be nil in light mode
 		var protos []string
-		for _, p := range eth.Protocols() {
+		for _, p := range ethBackend.Protocols() {
 			protos = append(protos, fmt.Sprintf("%v/%d", p.Name, p.Version))
 		}
 		metrics.NewRegisteredGaugeInfo("geth/info", nil).Update(metrics.GaugeInfoValue{
@@ -212,12 +215,15 @@ func makeFullNode(ctx *cli.Context) *node.Node {
 		if len(hex) != common.HashLength {
 			utils.Fatalf("invalid sync target length: have %d, want %d", len(hex), common.HashLength)
 		}
-		utils.RegisterFullSyncTester(stack, eth, common.BytesToHash(hex))
+		utils.RegisterFullSyncTester(stack, ethBackend, common.BytesToHash(hex))
 	}
 
-	if ctx.IsSet(utils.DeveloperFlag.Name) {
+	if _, ok := ethBackend.Engine().(consensus.Istanbul); ok {
+		// QBFT networks seal their own blocks, there is no consensus client.
+		log.Info("Engine API disabled", "reason", "QBFT consensus")
+	} else if ctx.IsSet(utils.DeveloperFlag.Name) {
 		// Start dev mode.
-		simBeacon, err := catalyst.NewSimulatedBeacon(ctx.Uint64(utils.DeveloperPeriodFlag.Name), eth)
+		simBeacon, err := catalyst.NewSimulatedBeacon(ctx.Uint64(utils.DeveloperPeriodFlag.Name), ethBackend)
 		if err != nil {
 			utils.Fatalf("failed to register dev mode catalyst service: %v", err)
 		}
@@ -226,18 +232,18 @@ func makeFullNode(ctx *cli.Context) *node.Node {
 	} else if ctx.IsSet(utils.BeaconApiFlag.Name) {
 		// Start blsync mode.
 		srv := rpc.NewServer()
-		srv.RegisterName("engine", catalyst.NewConsensusAPI(eth))
+		srv.RegisterName("engine", catalyst.NewConsensusAPI(ethBackend))
 		blsyncer := blsync.NewClient(ctx)
 		blsyncer.SetEngineRPC(rpc.DialInProc(srv))
 		stack.RegisterLifecycle(blsyncer)
 	} else {
 		// Launch the engine API for interacting with external consensus client.
-		err := catalyst.Register(stack, eth)
+		err := catalyst.Register(stack, ethBackend)
 		if err != nil {
 			utils.Fatalf("failed to register catalyst service: %v", err)
 		}
 	}
-	return stack
+	return stack, ethBackend
 }
 
 // dumpConfig is the dumpconfig command.
@@ -348,6 +354,17 @@ func setAccountManagerBackends(conf *node.Config, am *accounts.Manager, keydir s
 		}
 	}
 
+	// Signer plugins are separate processes holding their own keys, so they
+	// can be used alongside the local keystore.
+	if len(conf.SignerPlugin) > 0 {
+		log.Info("Using signer plugin", "cmd", conf.SignerPlugin[0])
+		backend, err := plugin.NewBackend(conf.SignerPlugin[0], conf.SignerPlugin[1:]...)
+		if err != nil {
+			return fmt.Errorf("error starting signer plugin: %v", err)
+		}
+		am.AddBackend(backend)
+	}
+
 	// For now, we're using EITHER external signer OR local signers.
 	// If/when we implement some form of lockfile for USB and keystore wallets,
 	// we can have both, but it's very confusing for the user to see the same
@@ -18,6 +18,7 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -41,6 +42,7 @@ import (
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
 	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/pathdb"
 	"github.com/olekukonko/tablewriter"
 	"github.com/urfave/cli/v2"
 )
@@ -83,6 +85,7 @@ Remove blockchain and state databases`,
 			dbMetadataCmd,
 			dbCheckStateContentCmd,
 			dbInspectHistoryCmd,
+			dbConvertSchemeCmd,
 		},
 	}
 	dbInspectCmd = &cli.Command{
@@ -229,6 +232,25 @@ WARNING: This is a low-level operation which may cause database corruption!`,
 		}, utils.NetworkFlags, utils.DatabaseFlags),
 		Description: "This command queries the history of the account or storage slot within the specified block range",
 	}
+	dbConvertSchemeCmd = &cli.Command{
+		Action: convertScheme,
+		Name:   "convert-scheme",
+		Usage:  "Convert the state from hash-based scheme to path-based scheme",
+		Flags: flags.Merge([]cli.Flag{
+			&cli.BoolFlag{
+				Name:  "delete-legacy",
+				Usage: "delete the hash-based trie nodes once the conversion is verified against the snapshot",
+			},
+		}, utils.NetworkFlags, utils.DatabaseFlags),
+		Description: `This command converts the latest persisted hash-based state into the
+path-based layout offline, avoiding a full resync to switch schemes. The chain
+data and the freezer are kept in place.
+
+The state is verified against the snapshot afterwards if it's available. The
+hash-based trie nodes are kept, unless --delete-legacy is specified. Deleting
+them requires the snapshot verification to succeed, the command fails without
+touching them otherwise.`,
+	}
 )
 
 func removeDB(ctx *cli.Context) error {
@@ -929,3 +951,86 @@ func inspectHistory(ctx *cli.Context) error {
 	}
 	return inspectStorage(triedb, start, end, address, slot, ctx.Bool("raw"))
 }
+
+// convertScheme converts the persisted hash-based state into the path-based
+// layout.
+func convertScheme(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	if scheme := rawdb.ReadStateScheme(db); scheme != rawdb.HashScheme {
+		return fmt.Errorf("state scheme is %q, only %q can be converted", scheme, rawdb.HashScheme)
+	}
+	head := rawdb.ReadHeadBlock(db)
+	if head == nil {
+		return errors.New("no head block")
+	}
+	// The state of the head block is usually held in memory only, search the
+	// most recent block with persisted state.
+	header := head.Header()
+	for !rawdb.HasLegacyTrieNode(db, header.Root) {
+		if header.Number.Uint64() == 0 {
+			return errors.New("no persisted state found")
+		}
+		header = rawdb.ReadHeader(db, header.ParentHash, header.Number.Uint64()-1)
+		if header == nil {
+			return errors.New("missing header in the canonical chain")
+		}
+	}
+	if header.Number.Uint64() != head.NumberU64() {
+		log.Warn("Converting state of an older block, the chain will be rewound on startup", "number", header.Number, "head", head.Number())
+	}
+	log.Info("Converting persisted state", "number", header.Number, "hash", header.Hash(), "root", header.Root)
+	if err := pathdb.ConvertHashScheme(db, header.Root); err != nil {
+		log.Error("Failed to convert state", "err", err)
+		return err
+	}
+	if err := pathdb.VerifyPathScheme(db); err != nil {
+		log.Error("Failed to verify converted state", "err", err)
+		return err
+	}
+	verified, err := verifyConvertedState(ctx, db, header.Root)
+	if err != nil {
+		return err
+	}
+	if !ctx.Bool("delete-legacy") {
+		log.Info("Keeping hash-based state, remove it with a resync or offline pruning")
+		return nil
+	}
+	if !verified {
+		return errors.New("converted state not verified against the snapshot, keeping hash-based state")
+	}
+	return pathdb.DeleteHashSchemeNodes(db)
+}
+
+// verifyConvertedState verifies the converted state against the snapshot if
+// it was generated for the same root, and reports whether it was verified.
+func verifyConvertedState(ctx *cli.Context, db ethdb.Database, root common.Hash) (bool, error) {
+	if rawdb.ReadSnapshotRoot(db) != root {
+		log.Warn("Snapshot not available for the converted state, run 'geth snapshot verify-state' after it's generated")
+		return false, nil
+	}
+	triedb := utils.MakeTrieDatabase(ctx, db, false, true, false)
+	defer triedb.Close()
+
+	snapConfig := snapshot.Config{
+		CacheSize:  256,
+		Recovery:   false,
+		NoBuild:    true,
+		AsyncBuild: false,
+	}
+	snaptree, err := snapshot.New(snapConfig, db, triedb, root)
+	if err != nil {
+		log.Error("Failed to open snapshot tree", "err", err)
+		return false, err
+	}
+	if err := snaptree.Verify(root); err != nil {
+		log.Error("Failed to verify state", "root", root, "err", err)
+		return false, err
+	}
+	log.Info("Verified the converted state", "root", root)
+	return true, nil
+}
@@ -0,0 +1,593 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	qbftengine "github.com/ethereum/go-ethereum/consensus/istanbul/qbft/engine"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	qbftCandidateFlag = &cli.StringFlag{
+		Name:  "candidate",
+		Usage: "Address of the account to add to or remove from the validators",
+	}
+	qbftRemoveFlag = &cli.BoolFlag{
+		Name:  "remove",
+		Usage: "Propose to remove the candidate from the validators instead of adding it",
+	}
+	qbftExpiryFlag = &cli.Uint64Flag{
+		Name:  "expiry",
+		Usage: "Block number from which the votes are dropped",
+	}
+	qbftKeyFileFlag = &cli.PathFlag{
+		Name:      "keyfile",
+		Usage:     "Validator key to sign with, either a keystore file or a hex encoded private key",
+		TakesFile: true,
+	}
+	qbftValidatorsFlag = &cli.StringFlag{
+		Name:  "validators",
+		Usage: "Comma separated list of the validator addresses",
+	}
+	qbftVanityFlag = &cli.StringFlag{
+		Name:  "vanity",
+		Usage: "Hex encoded vanity data of at most 32 bytes",
+	}
+	qbftChainIDFlag = &cli.Uint64Flag{
+		Name:  "chainid",
+		Usage: "Chain identifier of the network",
+		Value: 1337,
+	}
+	qbftBlockPeriodFlag = &cli.Uint64Flag{
+		Name:  "blockperiod",
+		Usage: "Minimum time between two blocks in seconds",
+		Value: 5,
+	}
+	qbftEpochFlag = &cli.Uint64Flag{
+		Name:  "epoch",
+		Usage: "Number of blocks after which pending validator votes are reset",
+		Value: 30000,
+	}
+	qbftRequestTimeoutFlag = &cli.Uint64Flag{
+		Name:  "requesttimeout",
+		Usage: "Timeout of the first round of each block in seconds",
+		Value: 10,
+	}
+	qbftGasLimitFlag = &cli.Uint64Flag{
+		Name:  "gaslimit",
+		Usage: "Gas limit of the genesis block",
+		Value: 30_000_000,
+	}
+	qbftAllocFlag = &cli.StringSliceFlag{
+		Name:  "alloc",
+		Usage: "Prefunded account in the form <address>=<balance in wei>, can be repeated",
+	}
+	qbftTransitionsFlag = &cli.PathFlag{
+		Name:      "transitions",
+		Usage:     "JSON file with the list of transitions to include in the chain config",
+		TakesFile: true,
+	}
+
+	qbftCommand = &cli.Command{
+		Name:  "qbft",
+		Usage: "A set of commands to set up QBFT networks and govern their validators",
+		Description: `
+The validator operators of a QBFT network add and remove validators by voting.
+A proposal is created once, shared and signed by each endorsing operator, then
+submitted to the nodes. Each node votes on the proposal until its expiry block
+or the next epoch boundary, whichever comes first, if its own validator signed it.
+
+The genesis and extra commands create the genesis block of a new network and
+inspect the QBFT fields in the extra-data of block headers.`,
+		Subcommands: []*cli.Command{
+			{
+				Name:      "propose",
+				Usage:     "Create a new validator proposal",
+				ArgsUsage: "<proposal file>",
+				Action:    qbftPropose,
+				Flags:     []cli.Flag{qbftCandidateFlag, qbftRemoveFlag, qbftExpiryFlag, qbftChainIDFlag},
+				Description: `
+geth qbft propose --candidate <address> [--remove] --expiry <block> --chainid <id> <proposal file>
+Writes an unsigned proposal to add or remove the candidate to the given file. The
+signatures are bound to the chain id and the expiry block, which must both be set.`,
+			},
+			{
+				Name:      "sign",
+				Usage:     "Endorse a validator proposal",
+				ArgsUsage: "<proposal file>",
+				Action:    qbftSign,
+				Flags:     []cli.Flag{qbftKeyFileFlag, utils.PasswordFileFlag},
+				Description: `
+geth qbft sign --keyfile <key file> [--password <password file>] <proposal file>
+Adds the signature of the validator to the proposal file.`,
+			},
+			{
+				Name:      "inspect",
+				Usage:     "Print a validator proposal and its signers",
+				ArgsUsage: "<proposal file>",
+				Action:    qbftInspect,
+			},
+			{
+				Name:      "submit",
+				Usage:     "Submit a signed validator proposal to a node",
+				ArgsUsage: "<proposal file> <endpoint>",
+				Action:    qbftSubmit,
+				Description: `
+geth qbft submit <proposal file> <endpoint>
+Submits the proposal to the node listening on the given IPC or HTTP endpoint. The
+node votes on the proposal if its validator is among the signers.`,
+			},
+			{
+				Name:      "genesis",
+				Usage:     "Generate the genesis of a new QBFT network",
+				ArgsUsage: "[<genesis file>]",
+				Action:    qbftGenesis,
+				Flags: []cli.Flag{
+					qbftValidatorsFlag, qbftVanityFlag, qbftChainIDFlag, qbftBlockPeriodFlag, qbftEpochFlag,
+					qbftRequestTimeoutFlag, qbftGasLimitFlag, qbftAllocFlag, qbftTransitionsFlag,
+				},
+				Description: `
+geth qbft genesis --validators <address>,... [--transitions <file>] [<genesis file>]
+Writes a genesis with a QBFT chain config to the given file, or prints it if no
+file is given. The initial validators are stored in the extra-data of the block.
+The transitions file holds a JSON list of transitions ordered by block.`,
+			},
+			{
+				Name:  "extra",
+				Usage: "Encode and decode the QBFT header extra-data",
+				Subcommands: []*cli.Command{
+					{
+						Name:   "encode",
+						Usage:  "Encode the extra-data of a genesis block",
+						Action: qbftExtraEncode,
+						Flags:  []cli.Flag{qbftValidatorsFlag, qbftVanityFlag},
+						Description: `
+geth qbft extra encode --validators <address>,... [--vanity <hex>]
+Prints the extra-data of a genesis block sealed by the given validators.`,
+					},
+					{
+						Name:      "decode",
+						Usage:     "Decode the extra-data of a block",
+						ArgsUsage: "<hex|block> [<endpoint>]",
+						Action:    qbftExtraDecode,
+						Description: `
+geth qbft extra decode <hex>
+geth qbft extra decode <block> <endpoint>
+Prints the vanity, validators, vote, round and committed seals in the extra-data.
+The hex input is either the extra-data, or an RLP encoded header or block. If an
+endpoint is given, the header of the block with the given number or hash is
+retrieved from the node. The signers of the committed seals can only be shown if
+the full header is known.`,
+					},
+				},
+			},
+		},
+	}
+)
+
+// readProposal loads a validator proposal from the given file.
+func readProposal(path string) (*istanbul.ValidatorProposal, error) {
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	proposal := new(istanbul.ValidatorProposal)
+	if err := json.Unmarshal(blob, proposal); err != nil {
+		return nil, fmt.Errorf("invalid proposal file: %v", err)
+	}
+	return proposal, nil
+}
+
+// writeProposal stores a validator proposal into the given file.
+func writeProposal(path string, proposal *istanbul.ValidatorProposal) error {
+	blob, err := json.MarshalIndent(proposal, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(blob, '\n'), 0644)
+}
+
+func qbftPropose(ctx *cli.Context) error {
+	if ctx.Args().Len() != 1 {
+		return errors.New("proposal file must be given as the only argument")
+	}
+	candidate := ctx.String(qbftCandidateFlag.Name)
+	if !common.IsHexAddress(candidate) {
+		return fmt.Errorf("invalid candidate address %q", candidate)
+	}
+	if !ctx.IsSet(qbftChainIDFlag.Name) {
+		return errors.New("chain id of the network must be given with --chainid")
+	}
+	if ctx.Uint64(qbftExpiryFlag.Name) == 0 {
+		return errors.New("expiry block of the proposal must be given with --expiry")
+	}
+	proposal := &istanbul.ValidatorProposal{
+		ChainID:   (*hexutil.Big)(new(big.Int).SetUint64(ctx.Uint64(qbftChainIDFlag.Name))),
+		Candidate: common.HexToAddress(candidate),
+		Authorize: !ctx.Bool(qbftRemoveFlag.Name),
+		Expiry:    ctx.Uint64(qbftExpiryFlag.Name),
+	}
+	return writeProposal(ctx.Args().First(), proposal)
+}
+
+// loadValidatorKey loads the validator key either from a keystore file, or from
+// a file holding the hex encoded key like the node key.
+func loadValidatorKey(ctx *cli.Context) (*ecdsa.PrivateKey, error) {
+	path := ctx.Path(qbftKeyFileFlag.Name)
+	if path == "" {
+		return nil, errors.New("validator key file must be given with --keyfile")
+	}
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(blob) {
+		return crypto.LoadECDSA(path)
+	}
+	password := utils.GetPassPhraseWithList("", false, 0, utils.MakePasswordList(ctx))
+	key, err := keystore.DecryptKey(blob, password)
+	if err != nil {
+		return nil, err
+	}
+	return key.PrivateKey, nil
+}
+
+func qbftSign(ctx *cli.Context) error {
+	if ctx.Args().Len() != 1 {
+		return errors.New("proposal file must be given as the only argument")
+	}
+	proposal, err := readProposal(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	key, err := loadValidatorKey(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load the validator key: %v", err)
+	}
+	if err := proposal.Sign(key); err != nil {
+		return err
+	}
+	if _, err := proposal.Signers(); err != nil {
+		return err
+	}
+	if err := writeProposal(ctx.Args().First(), proposal); err != nil {
+		return err
+	}
+	fmt.Printf("Signed proposal as %v\n", crypto.PubkeyToAddress(key.PublicKey))
+	return nil
+}
+
+func qbftInspect(ctx *cli.Context) error {
+	if ctx.Args().Len() != 1 {
+		return errors.New("proposal file must be given as the only argument")
+	}
+	proposal, err := readProposal(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	action := "add"
+	if !proposal.Authorize {
+		action = "remove"
+	}
+	fmt.Printf("Chain ID:  %v\n", proposal.ChainID.ToInt())
+	fmt.Printf("Candidate: %v\n", proposal.Candidate)
+	fmt.Printf("Action:    %s\n", action)
+	fmt.Printf("Expiry:    block %d\n", proposal.Expiry)
+	if len(proposal.Signatures) == 0 {
+		fmt.Println("Signers:   none")
+		return nil
+	}
+	signers, err := proposal.Signers()
+	if err != nil {
+		return err
+	}
+	fmt.Println("Signers:")
+	for _, signer := range signers {
+		fmt.Printf("  %v\n", signer)
+	}
+	return nil
+}
+
+func qbftSubmit(ctx *cli.Context) error {
+	if ctx.Args().Len() != 2 {
+		return errors.New("proposal file and endpoint must be given as arguments")
+	}
+	proposal, err := readProposal(ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	client, err := rpc.Dial(ctx.Args().Get(1))
+	if err != nil {
+		return fmt.Errorf("failed to connect to the node: %v", err)
+	}
+	defer client.Close()
+
+	var voting bool
+	if err := client.CallContext(ctx.Context, &voting, "istanbul_submitProposal", proposal); err != nil {
+		return err
+	}
+	if voting {
+		fmt.Println("Proposal accepted, the node votes on it")
+	} else {
+		fmt.Println("Proposal accepted, the node doesn't vote on it as its validator didn't sign it")
+	}
+	return nil
+}
+
+// parseValidators parses the comma separated validator addresses.
+func parseValidators(ctx *cli.Context) ([]common.Address, error) {
+	list := ctx.String(qbftValidatorsFlag.Name)
+	if list == "" {
+		return nil, errors.New("validators must be given with --validators")
+	}
+	var validators []common.Address
+	for _, addr := range strings.Split(list, ",") {
+		addr = strings.TrimSpace(addr)
+		if !common.IsHexAddress(addr) {
+			return nil, fmt.Errorf("invalid validator address %q", addr)
+		}
+		validators = append(validators, common.HexToAddress(addr))
+	}
+	return validators, nil
+}
+
+// makeGenesisExtra creates the extra-data of a genesis block sealed by the
+// validators given on the command line.
+func makeGenesisExtra(ctx *cli.Context) ([]byte, error) {
+	validators, err := parseValidators(ctx)
+	if err != nil {
+		return nil, err
+	}
+	vanity, err := hexutil.Decode(ctx.String(qbftVanityFlag.Name))
+	if err != nil && !errors.Is(err, hexutil.ErrEmptyString) {
+		return nil, fmt.Errorf("invalid vanity: %v", err)
+	}
+	if len(vanity) > types.IstanbulExtraVanity {
+		return nil, fmt.Errorf("vanity too long: %d bytes, want at most %d", len(vanity), types.IstanbulExtraVanity)
+	}
+	// The engine pads vanity shorter than the fixed size into an empty extra-data
+	header := &types.Header{Extra: vanity}
+	if err := qbftengine.ApplyHeaderQBFTExtra(header, qbftengine.WriteValidators(validators)); err != nil {
+		return nil, err
+	}
+	return header.Extra, nil
+}
+
+func qbftExtraEncode(ctx *cli.Context) error {
+	if ctx.Args().Len() != 0 {
+		return errors.New("unexpected arguments")
+	}
+	extra, err := makeGenesisExtra(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Println(hexutil.Encode(extra))
+	return nil
+}
+
+// decodeExtraInput interprets the hex input of the decode command. It returns
+// the header if the input is an encoded header or block, and the extra-data.
+func decodeExtraInput(input string) (*types.Header, []byte, error) {
+	blob, err := hexutil.Decode(input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid hex input: %v", err)
+	}
+	header := new(types.Header)
+	if err := rlp.DecodeBytes(blob, header); err == nil {
+		return header, header.Extra, nil
+	}
+	block := new(types.Block)
+	if err := rlp.DecodeBytes(blob, block); err == nil {
+		return block.Header(), block.Extra(), nil
+	}
+	return nil, blob, nil
+}
+
+// fetchHeader retrieves the header of the block with the given number or hash
+// from the node listening on the endpoint.
+func fetchHeader(ctx *cli.Context, block string, endpoint string) (*types.Header, error) {
+	client, err := ethclient.Dial(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the node: %v", err)
+	}
+	defer client.Close()
+
+	if len(block) == 2+2*common.HashLength && strings.HasPrefix(block, "0x") {
+		return client.HeaderByHash(ctx.Context, common.HexToHash(block))
+	}
+	number, ok := math.ParseBig256(block)
+	if !ok {
+		return nil, fmt.Errorf("invalid block number or hash %q", block)
+	}
+	return client.HeaderByNumber(ctx.Context, number)
+}
+
+func qbftExtraDecode(ctx *cli.Context) error {
+	var (
+		header *types.Header
+		extra  []byte
+		err    error
+	)
+	switch ctx.Args().Len() {
+	case 1:
+		header, extra, err = decodeExtraInput(ctx.Args().First())
+	case 2:
+		header, err = fetchHeader(ctx, ctx.Args().Get(0), ctx.Args().Get(1))
+		if err == nil {
+			extra = header.Extra
+		}
+	default:
+		return errors.New("extra-data, or block and endpoint must be given as arguments")
+	}
+	if err != nil {
+		return err
+	}
+	qbftExtra, err := types.ExtractQBFTExtra(&types.Header{Extra: extra})
+	if err != nil {
+		return fmt.Errorf("invalid QBFT extra-data: %v", err)
+	}
+	if header != nil {
+		fmt.Printf("Block:      %d (%v)\n", header.Number, header.Hash())
+	}
+	fmt.Printf("Vanity:     %#x\n", qbftExtra.VanityData)
+	if len(qbftExtra.Validators) == 0 {
+		fmt.Println("Validators: none")
+	} else {
+		fmt.Println("Validators:")
+		for _, validator := range qbftExtra.Validators {
+			fmt.Printf("  %v\n", validator)
+		}
+	}
+	switch {
+	case qbftExtra.Vote == nil:
+		fmt.Println("Vote:       none")
+	case qbftExtra.Vote.VoteType == types.QBFTAuthVote:
+		fmt.Printf("Vote:       add %v\n", qbftExtra.Vote.RecipientAddress)
+	default:
+		fmt.Printf("Vote:       remove %v\n", qbftExtra.Vote.RecipientAddress)
+	}
+	fmt.Printf("Round:      %d\n", qbftExtra.Round)
+
+	if len(qbftExtra.CommittedSeal) == 0 {
+		fmt.Println("Seals:      none")
+		return nil
+	}
+	if header == nil {
+		fmt.Printf("Seals:      %d, signers unknown without the header\n", len(qbftExtra.CommittedSeal))
+		return nil
+	}
+	fmt.Println("Seals:")
+	sealHash := qbftengine.PrepareCommittedSeal(header, qbftExtra.Round)
+	for _, seal := range qbftExtra.CommittedSeal {
+		signer, err := istanbul.GetSignatureAddressNoHashing(sealHash, seal)
+		if err != nil {
+			fmt.Printf("  invalid seal %#x: %v\n", seal, err)
+			continue
+		}
+		fmt.Printf("  %v\n", signer)
+	}
+	return nil
+}
+
+// readTransitions loads the list of transitions from the given file, ensuring
+// they are in the block order the chain config requires.
+func readTransitions(path string) ([]params.Transition, error) {
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var transitions []params.Transition
+	if err := json.Unmarshal(blob, &transitions); err != nil {
+		return nil, fmt.Errorf("invalid transitions file: %v", err)
+	}
+	for i, transition := range transitions {
+		if transition.Block == nil {
+			return nil, fmt.Errorf("transition %d: missing block", i)
+		}
+		if i > 0 && transition.Block.Cmp(transitions[i-1].Block) < 0 {
+			return nil, fmt.Errorf("transition %d: block %v before block %v of the previous one", i, transition.Block, transitions[i-1].Block)
+		}
+	}
+	return transitions, nil
+}
+
+// parseAlloc parses the prefunded accounts given on the command line.
+func parseAlloc(ctx *cli.Context) (types.GenesisAlloc, error) {
+	alloc := make(types.GenesisAlloc)
+	for _, entry := range ctx.StringSlice(qbftAllocFlag.Name) {
+		addr, balance, found := strings.Cut(entry, "=")
+		if !found || !common.IsHexAddress(addr) {
+			return nil, fmt.Errorf("invalid alloc %q, want <address>=<balance>", entry)
+		}
+		amount, ok := math.ParseBig256(balance)
+		if !ok {
+			return nil, fmt.Errorf("invalid balance %q", balance)
+		}
+		alloc[common.HexToAddress(addr)] = types.Account{Balance: amount}
+	}
+	return alloc, nil
+}
+
+func qbftGenesis(ctx *cli.Context) error {
+	if ctx.Args().Len() > 1 {
+		return errors.New("only the genesis file may be given as argument")
+	}
+	extra, err := makeGenesisExtra(ctx)
+	if err != nil {
+		return err
+	}
+	alloc, err := parseAlloc(ctx)
+	if err != nil {
+		return err
+	}
+	// QBFT networks run the block based forks, there is no merge
+	config := *params.AllEthashProtocolChanges
+	config.ChainID = new(big.Int).SetUint64(ctx.Uint64(qbftChainIDFlag.Name))
+	config.Ethash = nil
+	config.TerminalTotalDifficultyPassed = false
+	config.QBFT = &params.QBFTConfig{
+		EpochLength:           ctx.Uint64(qbftEpochFlag.Name),
+		BlockPeriodSeconds:    ctx.Uint64(qbftBlockPeriodFlag.Name),
+		RequestTimeoutSeconds: ctx.Uint64(qbftRequestTimeoutFlag.Name),
+	}
+	if path := ctx.Path(qbftTransitionsFlag.Name); path != "" {
+		if config.Transitions, err = readTransitions(path); err != nil {
+			return err
+		}
+	}
+	if err := config.CheckConfigForkOrder(); err != nil {
+		return err
+	}
+	genesis := &core.Genesis{
+		Config:     &config,
+		ExtraData:  extra,
+		GasLimit:   ctx.Uint64(qbftGasLimitFlag.Name),
+		Difficulty: big.NewInt(1),
+		Mixhash:    types.IstanbulDigest,
+		Alloc:      alloc,
+	}
+	blob, err := json.MarshalIndent(genesis, "", "  ")
+	if err != nil {
+		return err
+	}
+	if ctx.Args().Len() == 0 {
+		fmt.Println(string(blob))
+		return nil
+	}
+	return os.WriteFile(ctx.Args().First(), append(blob, '\n'), 0644)
+}
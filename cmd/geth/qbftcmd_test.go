@@ -0,0 +1,106 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	qbftengine "github.com/ethereum/go-ethereum/consensus/istanbul/qbft/engine"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Tests that the decoded extra-data of a sealed header shows the vote, the round
+// and the validators having signed the committed seals.
+func TestQBFTExtraDecode(t *testing.T) {
+	t.Parallel()
+
+	key, _ := crypto.GenerateKey()
+	validator := crypto.PubkeyToAddress(key.PublicKey)
+	candidate := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	extra, _ := rlp.EncodeToBytes(&types.QBFTExtra{
+		VanityData:    make([]byte, types.IstanbulExtraVanity),
+		Validators:    []common.Address{validator},
+		Vote:          &types.ValidatorVote{RecipientAddress: candidate, VoteType: types.QBFTAuthVote},
+		Round:         2,
+		CommittedSeal: [][]byte{},
+	})
+	header := &types.Header{Number: big.NewInt(7), Difficulty: big.NewInt(1), Extra: extra, MixDigest: types.IstanbulDigest}
+	seal, err := crypto.Sign(qbftengine.PrepareCommittedSeal(header, 2), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extra, _ = rlp.EncodeToBytes(&types.QBFTExtra{
+		VanityData:    make([]byte, types.IstanbulExtraVanity),
+		Validators:    []common.Address{validator},
+		Vote:          &types.ValidatorVote{RecipientAddress: candidate, VoteType: types.QBFTAuthVote},
+		Round:         2,
+		CommittedSeal: [][]byte{seal},
+	})
+	header.Extra = extra
+	blob, _ := rlp.EncodeToBytes(header)
+
+	geth := runGeth(t, "qbft", "extra", "decode", hexutil.Encode(blob))
+	geth.Expect(`
+Block:      7 (` + header.Hash().Hex() + `)
+Vanity:     0x0000000000000000000000000000000000000000000000000000000000000000
+Validators:
+  ` + validator.Hex() + `
+Vote:       add ` + candidate.Hex() + `
+Round:      2
+Seals:
+  ` + validator.Hex() + `
+`)
+	geth.ExpectExit()
+
+	// Without the header, the seals can't be attributed
+	geth = runGeth(t, "qbft", "extra", "decode", hexutil.Encode(extra))
+	geth.ExpectRegexp(`Seals:      1, signers unknown without the header`)
+	geth.ExpectExit()
+}
+
+// Tests that generated genesis files can be used to initialize a node.
+func TestQBFTGenesis(t *testing.T) {
+	t.Parallel()
+
+	var (
+		dir         = t.TempDir()
+		genesis     = filepath.Join(dir, "genesis.json")
+		transitions = filepath.Join(dir, "transitions.json")
+	)
+	if err := os.WriteFile(transitions, []byte(`[{"block": 10, "blockperiodseconds": 2}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	geth := runGeth(t, "qbft", "genesis", "--validators", "0x1111111111111111111111111111111111111111",
+		"--transitions", transitions, "--alloc", "0x1111111111111111111111111111111111111111=1000", genesis)
+	geth.ExpectExit()
+
+	datadir := t.TempDir()
+	runGeth(t, "--datadir", datadir, "init", genesis).WaitExit()
+	geth = runGeth(t, "--datadir", datadir, "--networkid", "1337", "--syncmode=full", "--cache", "16",
+		"--maxpeers", "0", "--port", "0", "--authrpc.port", "0", "--nodiscover", "--nat", "none", "--ipcdisable",
+		"--exec", "eth.getBlock(0).extraData", "console")
+	geth.ExpectRegexp(`"0xf83aa00000000000000000000000000000000000000000000000000000000000000000d5941111111111111111111111111111111111111111c080c0"`)
+	geth.ExpectExit()
+}
@@ -25,6 +25,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -402,7 +403,8 @@ func ExportAppendChain(blockchain *core.BlockChain, fn string, first uint64, las
 }
 
 // ExportHistory exports blockchain history into the specified directory,
-// following the Era format.
+// following the Era format. Epochs starting before the merge are written as
+// Era1 archives, later ones in the post-merge EraE format.
 func ExportHistory(bc *core.BlockChain, dir string, first, last, step uint64) error {
 	log.Info("Exporting blockchain history", "dir", dir)
 	if head := bc.CurrentBlock().Number.Uint64(); head < last {
@@ -425,14 +427,26 @@ func ExportHistory(bc *core.BlockChain, dir string, first, last, step uint64) er
 	)
 	for i := first; i <= last; i += step {
 		err := func() error {
-			filename := filepath.Join(dir, era.Filename(network, int(i/step), common.Hash{}))
+			head := bc.GetHeaderByNumber(i)
+			if head == nil {
+				return fmt.Errorf("export failed on #%d: not found", i)
+			}
+			var (
+				postMerge = head.Difficulty.Sign() == 0 && i > 0
+				name      = era.Filename
+				newWriter = era.NewBuilder
+			)
+			if postMerge {
+				name, newWriter = era.PostMergeFilename, era.NewPostMergeBuilder
+			}
+			filename := filepath.Join(dir, name(network, int(i/step), common.Hash{}))
 			f, err := os.Create(filename)
 			if err != nil {
 				return fmt.Errorf("could not create era file: %w", err)
 			}
 			defer f.Close()
 
-			w := era.NewBuilder(f)
+			w := newWriter(f)
 			for j := uint64(0); j < step && j <= last-i; j++ {
 				var (
 					n     = i + j
@@ -445,9 +459,11 @@ func ExportHistory(bc *core.BlockChain, dir string, first, last, step uint64) er
 				if receipts == nil {
 					return fmt.Errorf("export failed on #%d: receipts not found", n)
 				}
-				td := bc.GetTd(block.Hash(), block.NumberU64())
-				if td == nil {
-					return fmt.Errorf("export failed on #%d: total difficulty not found", n)
+				var td *big.Int
+				if !postMerge {
+					if td = bc.GetTd(block.Hash(), block.NumberU64()); td == nil {
+						return fmt.Errorf("export failed on #%d: total difficulty not found", n)
+					}
 				}
 				if err := w.Add(block, receipts, td); err != nil {
 					return err
@@ -458,7 +474,7 @@ func ExportHistory(bc *core.BlockChain, dir string, first, last, step uint64) er
 				return fmt.Errorf("export failed to finalize %d: %w", step/i, err)
 			}
 			// Set correct filename with root.
-			os.Rename(filename, filepath.Join(dir, era.Filename(network, int(i/step), root)))
+			os.Rename(filename, filepath.Join(dir, name(network, int(i/step), root)))
 
 			// Compute checksum of entire Era1.
 			if _, err := f.Seek(0, io.SeekStart); err != nil {
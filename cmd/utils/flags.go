@@ -37,6 +37,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/accounts/plugin"
 	bparams "github.com/ethereum/go-ethereum/beacon/params"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/fdlimit"
@@ -271,6 +272,17 @@ var (
 		Usage:    "Scheme to use for storing ethereum state ('hash' or 'path')",
 		Category: flags.StateCategory,
 	}
+	StatePruneFlag = &cli.BoolFlag{
+		Name:     "state.prune",
+		Usage:    "Garbage collect stale state in the background, only relevant in state.scheme=hash",
+		Category: flags.StateCategory,
+	}
+	StatePruneRateFlag = &cli.IntFlag{
+		Name:     "state.prune.rate",
+		Usage:    "Maximum number of stale state entries deleted per second by the background pruner (0 = unlimited)",
+		Value:    ethconfig.Defaults.OnlinePruningRate,
+		Category: flags.StateCategory,
+	}
 	StateHistoryFlag = &cli.Uint64Flag{
 		Name:     "history.state",
 		Usage:    "Number of recent blocks to retain state history for (default = 90,000 blocks, 0 = entire chain)",
@@ -283,10 +295,20 @@ var (
 		Value:    ethconfig.Defaults.TransactionHistory,
 		Category: flags.StateCategory,
 	}
+	HistoryExpiryFlag = &cli.Uint64Flag{
+		Name:     "history.expiry",
+		Usage:    "Block number below which ancient block bodies and receipts are dropped and served from era files (0 = keep entire chain)",
+		Category: flags.StateCategory,
+	}
+	HistoryEraFlag = &flags.DirectoryFlag{
+		Name:     "history.era",
+		Usage:    "Directory of era1 files serving the expired block history",
+		Category: flags.StateCategory,
+	}
 	// Beacon client light sync settings
 	BeaconApiFlag = &cli.StringSliceFlag{
 		Name:     "beacon.api",
-		Usage:    "Beacon node (CL) light client API URL. This flag can be given multiple times.",
+		Usage:    "Beacon node (CL) light client API URL. This flag can be given multiple times, in which case updates are only accepted once two endpoints agree.",
 		Category: flags.BeaconCategory,
 	}
 	BeaconApiHeaderFlag = &cli.StringSliceFlag{
@@ -479,6 +501,16 @@ var (
 	}
 
 	// Miner settings
+	MiningEnabledFlag = &cli.BoolFlag{
+		Name:     "mine",
+		Usage:    "Enable block sealing as QBFT validator",
+		Category: flags.MinerCategory,
+	}
+	MinerEtherbaseFlag = &cli.StringFlag{
+		Name:     "miner.etherbase",
+		Usage:    "0x prefixed public address of the QBFT validator account sealing blocks",
+		Category: flags.MinerCategory,
+	}
 	MinerGasLimitFlag = &cli.Uint64Flag{
 		Name:     "miner.gaslimit",
 		Usage:    "Target gas ceiling for mined blocks",
@@ -527,6 +559,12 @@ var (
 		Value:    "",
 		Category: flags.AccountCategory,
 	}
+	SignerPluginFlag = &cli.StringFlag{
+		Name:     "signer.plugin",
+		Usage:    "Command line of a signer plugin serving keys over stdin/stdout (arguments split and quoted like in a shell)",
+		Value:    "",
+		Category: flags.AccountCategory,
+	}
 	InsecureUnlockAllowedFlag = &cli.BoolFlag{
 		Name:     "allow-insecure-unlock",
 		Usage:    "Allow insecure account unlocking when account-related RPCs are exposed by http",
@@ -609,6 +647,11 @@ var (
 		Usage:    "Enable state witness generation during block execution. Work in progress flag, don't use.",
 		Category: flags.MiscCategory,
 	}
+	ParallelExecutionFlag = &cli.BoolFlag{
+		Name:     "parallelexec",
+		Usage:    "Enable optimistic parallel transaction execution during block processing (experimental)",
+		Category: flags.VMCategory,
+	}
 
 	// MISC settings
 	SyncTargetFlag = &cli.StringFlag{
@@ -1320,8 +1363,16 @@ func MakeAddress(ks *keystore.KeyStore, account string) (accounts.Account, error
 // setEtherbase retrieves the etherbase from the directly specified command line flags.
 func setEtherbase(ctx *cli.Context, cfg *ethconfig.Config) {
 	if ctx.IsSet(MinerEtherbaseFlag.Name) {
-		log.Warn("Option --miner.etherbase is deprecated as the etherbase is set by the consensus client post-merge")
-		return
+		addr := ctx.String(MinerEtherbaseFlag.Name)
+		if strings.HasPrefix(addr, "0x") || strings.HasPrefix(addr, "0X") {
+			addr = addr[2:]
+		}
+		b, err := hex.DecodeString(addr)
+		if err != nil || len(b) != common.AddressLength {
+			Fatalf("-%s: invalid etherbase address %q", MinerEtherbaseFlag.Name, addr)
+			return
+		}
+		cfg.Miner.Etherbase = common.BytesToAddress(b)
 	}
 	if !ctx.IsSet(MinerPendingFeeRecipientFlag.Name) {
 		return
@@ -1421,6 +1472,13 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if ctx.IsSet(ExternalSignerFlag.Name) {
 		cfg.ExternalSigner = ctx.String(ExternalSignerFlag.Name)
 	}
+	if ctx.IsSet(SignerPluginFlag.Name) {
+		command, err := plugin.SplitCommand(ctx.String(SignerPluginFlag.Name))
+		if err != nil {
+			Fatalf("Invalid signer plugin: %v", err)
+		}
+		cfg.SignerPlugin = command
+	}
 
 	if ctx.IsSet(KeyStoreDirFlag.Name) {
 		cfg.KeyStoreDir = ctx.String(KeyStoreDirFlag.Name)
@@ -1551,9 +1609,6 @@ func setTxPool(ctx *cli.Context, cfg *legacypool.Config) {
 }
 
 func setMiner(ctx *cli.Context, cfg *miner.Config) {
-	if ctx.Bool(MiningEnabledFlag.Name) {
-		log.Warn("The flag --mine is deprecated and will be removed")
-	}
 	if ctx.IsSet(MinerExtraDataFlag.Name) {
 		cfg.ExtraData = []byte(ctx.String(MinerExtraDataFlag.Name))
 	}
@@ -1646,6 +1701,7 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	// Avoid conflicting network flags
 	CheckExclusive(ctx, MainnetFlag, DeveloperFlag, GoerliFlag, SepoliaFlag, HoleskyFlag)
 	CheckExclusive(ctx, DeveloperFlag, ExternalSignerFlag) // Can't use both ephemeral unlocked and external signer
+	CheckExclusive(ctx, ExternalSignerFlag, SignerPluginFlag)
 
 	// Set configurations from CLI flags
 	setEtherbase(ctx, cfg)
@@ -1712,6 +1768,24 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(StateSchemeFlag.Name) {
 		cfg.StateScheme = ctx.String(StateSchemeFlag.Name)
 	}
+	if ctx.IsSet(HistoryExpiryFlag.Name) {
+		cfg.HistoryExpiry = ctx.Uint64(HistoryExpiryFlag.Name)
+	}
+	if ctx.IsSet(HistoryEraFlag.Name) {
+		cfg.HistoryDir = ctx.String(HistoryEraFlag.Name)
+	}
+	if cfg.HistoryExpiry != 0 && cfg.HistoryDir == "" {
+		Fatalf("--%s requires --%s", HistoryExpiryFlag.Name, HistoryEraFlag.Name)
+	}
+	if ctx.IsSet(StatePruneFlag.Name) {
+		cfg.OnlinePruning = ctx.Bool(StatePruneFlag.Name)
+	}
+	if ctx.IsSet(StatePruneRateFlag.Name) {
+		cfg.OnlinePruningRate = ctx.Int(StatePruneRateFlag.Name)
+	}
+	if cfg.OnlinePruning && cfg.NoPruning {
+		Fatalf("--%s is not supported in archive mode", StatePruneFlag.Name)
+	}
 	// Parse transaction history flag, if user is still using legacy config
 	// file with 'TxLookupLimit' configured, copy the value to 'TransactionHistory'.
 	if cfg.TransactionHistory == ethconfig.Defaults.TransactionHistory && cfg.TxLookupLimit != ethconfig.Defaults.TxLookupLimit {
@@ -1768,6 +1842,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(CollectWitnessFlag.Name) {
 		cfg.EnableWitnessCollection = ctx.Bool(CollectWitnessFlag.Name)
 	}
+	if ctx.IsSet(ParallelExecutionFlag.Name) {
+		cfg.EnableParallelExecution = ctx.Bool(ParallelExecutionFlag.Name)
+	}
 
 	if ctx.IsSet(RPCGlobalGasCapFlag.Name) {
 		cfg.RPCGasCap = ctx.Uint64(RPCGlobalGasCapFlag.Name)
@@ -2201,6 +2278,7 @@ func MakeChain(ctx *cli.Context, stack *node.Node, readonly bool) (*core.BlockCh
 	vmcfg := vm.Config{
 		EnablePreimageRecording: ctx.Bool(VMEnableDebugFlag.Name),
 		EnableWitnessCollection: ctx.Bool(CollectWitnessFlag.Name),
+		EnableParallelExecution: ctx.Bool(ParallelExecutionFlag.Name),
 	}
 	if ctx.IsSet(VMTraceFlag.Name) {
 		if name := ctx.String(VMTraceFlag.Name); name != "" {
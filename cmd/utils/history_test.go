@@ -27,6 +27,8 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -45,18 +47,39 @@ var (
 )
 
 func TestHistoryImportAndExport(t *testing.T) {
+	testHistoryImportAndExport(t, false)
+}
+
+// TestHistoryImportAndExportPostMerge checks that history after the merge is
+// exported to and imported from post-merge EraE archives.
+func TestHistoryImportAndExportPostMerge(t *testing.T) {
+	testHistoryImportAndExport(t, true)
+}
+
+func testHistoryImportAndExport(t *testing.T, merged bool) {
+	var (
+		config                  = *params.TestChainConfig
+		engine consensus.Engine = ethash.NewFaker()
+	)
+	if merged {
+		config.TerminalTotalDifficulty = common.Big0
+		engine = beacon.New(ethash.NewFaker())
+	}
 	var (
 		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
 		address = crypto.PubkeyToAddress(key.PublicKey)
 		genesis = &core.Genesis{
-			Config: params.TestChainConfig,
+			Config: &config,
 			Alloc:  types.GenesisAlloc{address: {Balance: big.NewInt(1000000000000000000)}},
 		}
 		signer = types.LatestSigner(genesis.Config)
 	)
 
 	// Generate chain.
-	db, blocks, _ := core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), int(count), func(i int, g *core.BlockGen) {
+	db, blocks, _ := core.GenerateChainWithGenesis(genesis, engine, int(count), func(i int, g *core.BlockGen) {
+		if merged {
+			g.SetPoS()
+		}
 		if i == 0 {
 			return
 		}
@@ -78,7 +101,7 @@ func TestHistoryImportAndExport(t *testing.T) {
 	})
 
 	// Initialize BlockChain.
-	chain, err := core.NewBlockChain(db, nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	chain, err := core.NewBlockChain(db, nil, genesis, nil, engine, vm.Config{}, nil, nil)
 	if err != nil {
 		t.Fatalf("unable to initialize chain: %v", err)
 	}
@@ -128,6 +151,9 @@ func TestHistoryImportAndExport(t *testing.T) {
 				t.Fatalf("error opening era: %v", err)
 			}
 			defer e.Close()
+			if postMerge := chain.GetHeaderByNumber(uint64(i)*step).Difficulty.Sign() == 0 && i > 0; e.PostMerge() != postMerge {
+				t.Fatalf("era %d format mismatch: have post-merge %v, want %v", i, e.PostMerge(), postMerge)
+			}
 			it, err := era.NewIterator(e)
 			if err != nil {
 				t.Fatalf("error making era reader: %v", err)
@@ -171,7 +197,7 @@ func TestHistoryImportAndExport(t *testing.T) {
 	})
 
 	genesis.MustCommit(db2, triedb.NewDatabase(db, triedb.HashDefaults))
-	imported, err := core.NewBlockChain(db2, nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	imported, err := core.NewBlockChain(db2, nil, genesis, nil, engine, vm.Config{}, nil, nil)
 	if err != nil {
 		t.Fatalf("unable to initialize chain: %v", err)
 	}
@@ -290,6 +290,14 @@ func (beacon *Beacon) verifyHeader(chain consensus.ChainHeaderReader, header, pa
 			return err
 		}
 	}
+	// Verify existence / non-existence of requestsHash.
+	prague := chain.Config().IsPrague(header.Number, header.Time)
+	if prague && header.RequestsHash == nil {
+		return errors.New("missing requestsHash")
+	}
+	if !prague && header.RequestsHash != nil {
+		return fmt.Errorf("invalid requestsHash: have %x, expected nil", header.RequestsHash)
+	}
 	return nil
 }
 
@@ -381,6 +389,16 @@ func (beacon *Beacon) FinalizeAndAssemble(chain consensus.ChainHeaderReader, hea
 			return nil, errors.New("withdrawals set before Shanghai activation")
 		}
 	}
+	prague := chain.Config().IsPrague(header.Number, header.Time)
+	if prague {
+		// All blocks after Prague must include the requests generated during
+		// execution, which can't be defaulted here.
+		if body.Requests == nil {
+			return nil, errors.New("missing requests after Prague activation")
+		}
+	} else if body.Requests != nil {
+		return nil, errors.New("requests set before Prague activation")
+	}
 	// Finalize and assemble the block.
 	beacon.Finalize(chain, header, state, body)
 
@@ -17,15 +17,30 @@
 package backend
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"math/big"
+	"slices"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
 	istanbulcommon "github.com/ethereum/go-ethereum/consensus/istanbul/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul/validator"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
+const (
+	timingRounds        = 10    // Number of rounds to report the timeouts of
+	maxProposerSchedule = 1024  // Maximum number of blocks of a proposer schedule
+	maxRewardsRange     = 10000 // Maximum number of blocks to sum the rewards of
+)
+
 // API is a user facing RPC API to dump Istanbul state
 type API struct {
 	chain   consensus.ChainHeaderReader
@@ -40,6 +55,33 @@ type BlockSigners struct {
 	Committers []common.Address
 }
 
+// BlockTiming is the effective timing configuration of a block, after applying
+// the transitions up to it.
+type BlockTiming struct {
+	Number            uint64   `json:"number"`            // Block number the configuration applies to
+	BlockPeriod       uint64   `json:"blockPeriod"`       // Minimum time since the parent block in seconds
+	EmptyBlockPeriod  uint64   `json:"emptyBlockPeriod"`  // Minimum time since the parent block without transactions in seconds
+	RequestTimeout    uint64   `json:"requestTimeout"`    // Timeout of the first round in milliseconds
+	MaxRequestTimeout uint64   `json:"maxRequestTimeout"` // Upper limit of the round timeouts in seconds, zero if uncapped
+	RoundTimeouts     []uint64 `json:"roundTimeouts"`     // Timeouts of the first rounds in milliseconds
+}
+
+// ScheduledProposer is the validator expected to propose a block in its first
+// round.
+type ScheduledProposer struct {
+	Number   uint64         `json:"number"`
+	Proposer common.Address `json:"proposer"`
+}
+
+// RewardSummary is the sum of the block rewards credited to each beneficiary over
+// a range of blocks.
+type RewardSummary struct {
+	From    uint64                          `json:"from"`
+	To      uint64                          `json:"to"`
+	Total   *hexutil.Big                    `json:"total"`
+	Rewards map[common.Address]*hexutil.Big `json:"rewards"`
+}
+
 type Status struct {
 	SigningStatus map[common.Address]int `json:"sealerActivity"`
 	NumBlocks     uint64                 `json:"numBlocks"`
@@ -161,19 +203,21 @@ func (api *API) Candidates() map[common.Address]bool {
 	defer api.backend.candidatesLock.RUnlock()
 
 	proposals := make(map[common.Address]bool)
-	for address, auth := range api.backend.candidates {
-		proposals[address] = auth
+	for address, candidate := range api.backend.candidates {
+		proposals[address] = candidate.authorize
 	}
 	return proposals
 }
 
 // Propose injects a new authorization candidate that the validator will attempt to
-// push through.
+// push through. The vote expires at the next epoch boundary, along with the votes
+// cast by the other validators.
 func (api *API) Propose(address common.Address, auth bool) {
 	api.backend.candidatesLock.Lock()
 	defer api.backend.candidatesLock.Unlock()
 
-	api.backend.candidates[address] = auth
+	expiry := api.backend.voteExpiry(api.chain.CurrentHeader().Number.Uint64() + 1)
+	api.backend.candidates[address] = &candidate{authorize: auth, expiry: expiry}
 }
 
 // Discard drops a currently running candidate, stopping the validator from casting
@@ -185,6 +229,232 @@ func (api *API) Discard(address common.Address) {
 	delete(api.backend.candidates, address)
 }
 
+// SubmitProposal checks a proposal signed by the validators for this network and
+// injects it as a candidate if the local validator endorsed it, returning whether
+// it did. The vote expires at the proposal's expiry or at the next epoch boundary,
+// whichever comes first.
+func (api *API) SubmitProposal(proposal istanbul.ValidatorProposal) (bool, error) {
+	signers, err := proposal.Signers()
+	if err != nil {
+		return false, err
+	}
+	if chainID := api.chain.Config().ChainID; chainID == nil || proposal.ChainID.ToInt().Cmp(chainID) != 0 {
+		return false, fmt.Errorf("%w: have %v, want %v", istanbul.ErrProposalChainMismatch, proposal.ChainID, chainID)
+	}
+	header := api.chain.CurrentHeader()
+	snap, err := api.backend.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return false, err
+	}
+	for _, signer := range signers {
+		if _, v := snap.ValSet.GetByAddress(signer); v == nil {
+			return false, fmt.Errorf("%w: %v", istanbul.ErrUnauthorizedAddress, signer)
+		}
+	}
+	if proposal.Expiry <= header.Number.Uint64()+1 {
+		return false, fmt.Errorf("proposal expired at block %d", proposal.Expiry)
+	}
+	expiry := min(api.backend.voteExpiry(header.Number.Uint64()+1), proposal.Expiry)
+	if !slices.Contains(signers, api.backend.Address()) {
+		return false, nil
+	}
+	api.backend.candidatesLock.Lock()
+	defer api.backend.candidatesLock.Unlock()
+
+	api.backend.candidates[proposal.Candidate] = &candidate{authorize: proposal.Authorize, expiry: expiry}
+	return true, nil
+}
+
+// GetPendingTallies retrieves the votes cast on each candidate which hasn't
+// reached the majority yet at the specified block.
+func (api *API) GetPendingTallies(number *rpc.BlockNumber) (map[common.Address]*PendingTally, error) {
+	// Retrieve the requested block number (or current if none requested)
+	var header *types.Header
+	if number == nil || *number == rpc.LatestBlockNumber {
+		header = api.chain.CurrentHeader()
+	} else {
+		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
+	}
+	// Ensure we have an actually valid block and return the tallies from its snapshot
+	if header == nil {
+		return nil, istanbulcommon.ErrUnknownBlock
+	}
+	snap, err := api.backend.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return snap.pendingTallies(), nil
+}
+
+// GetValidatorChanges retrieves the validators added or removed by votes on the
+// canonical chain within the specified block range (inclusive). The range ends
+// at the latest block if no end is specified.
+func (api *API) GetValidatorChanges(from rpc.BlockNumber, to *rpc.BlockNumber) ([]*ValidatorChange, error) {
+	head := api.chain.CurrentHeader().Number.Uint64()
+
+	start, end := uint64(from.Int64()), head
+	if from < 0 {
+		start = head
+	}
+	if to != nil && *to >= 0 {
+		end = uint64(to.Int64())
+	}
+	if start > end {
+		return nil, errors.New("start block number should be less than end block number")
+	}
+	changes, err := loadValidatorChanges(api.backend.db, start, end)
+	if err != nil {
+		return nil, err
+	}
+	canonical := make([]*ValidatorChange, 0, len(changes))
+	for _, change := range changes {
+		if header := api.chain.GetHeaderByNumber(change.Number); header != nil && header.Hash() == change.Hash {
+			canonical = append(canonical, change)
+		}
+	}
+	return canonical, nil
+}
+
+// GetRewards sums the block rewards credited to each beneficiary from the start
+// block to the end block, both included. The rewards are recomputed from the
+// chain configuration rather than read from the state. In the split mode, every
+// validator of the parent snapshot is credited a share, including the ones which
+// didn't sign the block.
+func (api *API) GetRewards(from rpc.BlockNumber, to *rpc.BlockNumber) (*RewardSummary, error) {
+	head := api.chain.CurrentHeader().Number.Uint64()
+
+	start, end := uint64(from.Int64()), head
+	if from < 0 {
+		start = head
+	}
+	if to != nil && *to >= 0 {
+		end = uint64(to.Int64())
+	}
+	if start > end {
+		return nil, errors.New("start block number should be less than end block number")
+	}
+	if end-start >= maxRewardsRange {
+		return nil, fmt.Errorf("range of %d blocks exceeds the limit of %d", end-start+1, maxRewardsRange)
+	}
+	total := new(big.Int)
+	rewards := make(map[common.Address]*big.Int)
+	for number := max(start, 1); number <= end; number++ {
+		header := api.chain.GetHeaderByNumber(number)
+		if header == nil {
+			return nil, istanbulcommon.ErrUnknownBlock
+		}
+		validators, err := api.backend.rewardValidators(api.chain, header)
+		if err != nil {
+			return nil, err
+		}
+		for _, reward := range api.backend.qbftEngine.Rewards(api.chain, header, validators) {
+			if rewards[reward.Account] == nil {
+				rewards[reward.Account] = new(big.Int)
+			}
+			rewards[reward.Account].Add(rewards[reward.Account], reward.Amount)
+			total.Add(total, reward.Amount)
+		}
+	}
+	summary := &RewardSummary{
+		From:    start,
+		To:      end,
+		Total:   (*hexutil.Big)(total),
+		Rewards: make(map[common.Address]*hexutil.Big, len(rewards)),
+	}
+	for account, amount := range rewards {
+		summary.Rewards[account] = (*hexutil.Big)(amount)
+	}
+	return summary, nil
+}
+
+// GetBlockTiming retrieves the timing configuration in effect at the specified
+// block, or at the next block to be proposed if none or pending is specified.
+func (api *API) GetBlockTiming(number *rpc.BlockNumber) (*BlockTiming, error) {
+	head := api.chain.CurrentHeader().Number.Uint64()
+	target := head + 1
+	if number != nil {
+		switch {
+		case *number >= 0:
+			target = uint64(number.Int64())
+		case *number != rpc.PendingBlockNumber:
+			// Blocks are final once committed, so the latest, safe and finalized
+			// blocks are all the head.
+			target = head
+		}
+	}
+	return blockTiming(api.backend.config, target), nil
+}
+
+// GetProposerSchedule retrieves the validators expected to propose the next
+// blocks, assuming every block is committed in its first round and no validator
+// is voted in or out meanwhile.
+func (api *API) GetProposerSchedule(count uint64) ([]*ScheduledProposer, error) {
+	if count > maxProposerSchedule {
+		return nil, fmt.Errorf("schedule of %d blocks exceeds the limit of %d", count, maxProposerSchedule)
+	}
+	header := api.chain.CurrentHeader()
+	snap, err := api.backend.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	// The proposers follow the author of the last block, none for the genesis
+	var proposer common.Address
+	if header.Number.Sign() > 0 {
+		if proposer, err = api.backend.Author(header); err != nil {
+			return nil, err
+		}
+	}
+	var (
+		config   = api.backend.config
+		valSet   = snap.ValSet.Copy()
+		schedule = make([]*ScheduledProposer, 0, count)
+	)
+	if valSet.Size() == 0 {
+		return nil, errors.New("no validators")
+	}
+	for number := header.Number.Uint64() + 1; uint64(len(schedule)) < count; number++ {
+		valSet.CalcProposer(proposer, 0)
+		proposer = valSet.GetProposer().Address()
+		schedule = append(schedule, &ScheduledProposer{Number: number, Proposer: proposer})
+
+		// Transitions may replace the validators validating the blocks after theirs
+		num := new(big.Int).SetUint64(number)
+		if validators := config.GetValidatorsAt(num); len(validators) > 0 && config.GetValidatorSelectionMode(num) == params.BlockHeaderMode {
+			valSet = validator.NewSet(validators, config.ProposerPolicy)
+		}
+	}
+	return schedule, nil
+}
+
+// CheckTransitions is a dry run of replacing the transitions of the chain
+// config with the given ones. It checks that the transitions already applied to
+// the chain are left unchanged and that the new timing configurations are
+// sound, and returns the timing in effect at each upcoming transition.
+func (api *API) CheckTransitions(transitions []params.Transition) ([]*BlockTiming, error) {
+	head := api.chain.CurrentHeader().Number.Uint64()
+	if err := checkTransitions(api.backend.config.Transitions, transitions, head); err != nil {
+		return nil, err
+	}
+	config := *api.backend.config
+	config.Transitions = transitions
+
+	var timings []*BlockTiming
+	for i, transition := range transitions {
+		if !transition.Block.IsUint64() || transition.Block.Uint64() <= head {
+			continue
+		}
+		timing := blockTiming(&config, transition.Block.Uint64())
+		if timing.MaxRequestTimeout != 0 && timing.MaxRequestTimeout*1000 < timing.RequestTimeout {
+			return nil, fmt.Errorf("transition %d: max request timeout of %ds below request timeout of %dms", i, timing.MaxRequestTimeout, timing.RequestTimeout)
+		}
+		if len(timings) > 0 && timings[len(timings)-1].Number == timing.Number {
+			continue
+		}
+		timings = append(timings, timing)
+	}
+	return timings, nil
+}
+
 func (api *API) Status(startBlockNum *rpc.BlockNumber, endBlockNum *rpc.BlockNumber) (*Status, error) {
 	var (
 		numBlocks   uint64
@@ -268,3 +538,50 @@ func (api *API) IsValidator(blockNum *rpc.BlockNumber) (bool, error) {
 	}
 	return false, nil
 }
+
+// blockTiming returns the timing configuration in effect at the given block.
+func blockTiming(config *istanbul.Config, number uint64) *BlockTiming {
+	c := config.GetConfig(new(big.Int).SetUint64(number))
+
+	timing := &BlockTiming{
+		Number:            number,
+		BlockPeriod:       c.BlockPeriod,
+		EmptyBlockPeriod:  max(c.BlockPeriod, c.EmptyBlockPeriod),
+		RequestTimeout:    c.RequestTimeout,
+		MaxRequestTimeout: c.MaxRequestTimeoutSeconds,
+		RoundTimeouts:     make([]uint64, timingRounds),
+	}
+	for round := range timing.RoundTimeouts {
+		timing.RoundTimeouts[round] = uint64(c.RoundTimeout(uint64(round)).Milliseconds())
+	}
+	return timing
+}
+
+// checkTransitions checks that the given transitions are ordered by block, and
+// that the ones up to the head block are the same as the current ones.
+func checkTransitions(current, transitions []params.Transition, head uint64) error {
+	for i, transition := range transitions {
+		if transition.Block == nil || transition.Block.Sign() < 0 {
+			return fmt.Errorf("transition %d: missing block", i)
+		}
+		if i > 0 && transition.Block.Cmp(transitions[i-1].Block) < 0 {
+			return fmt.Errorf("transition %d: block %v before block %v of the previous one", i, transition.Block, transitions[i-1].Block)
+		}
+	}
+	applied := func(transitions []params.Transition) (list [][]byte) {
+		for _, transition := range transitions {
+			if transition.Block != nil && transition.Block.IsUint64() && transition.Block.Uint64() <= head {
+				blob, _ := json.Marshal(transition)
+				list = append(list, blob)
+			}
+		}
+		return list
+	}
+	have, want := applied(transitions), applied(current)
+	for i := 0; i < len(have) || i < len(want); i++ {
+		if i >= len(have) || i >= len(want) || !bytes.Equal(have[i], want[i]) {
+			return fmt.Errorf("transition %d: changes the chain history up to block %d", i, head)
+		}
+	}
+	return nil
+}
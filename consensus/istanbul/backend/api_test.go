@@ -0,0 +1,364 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/consensus/istanbul/testutils"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Tests that proposals are only voted on if signed by the local validator, and
+// rejected if signed by anyone else than the validators.
+func TestSubmitProposal(t *testing.T) {
+	chain, engine := newBlockChain(1)
+	defer engine.Stop()
+
+	api := &API{chain: chain, backend: engine}
+	candidate := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	chainID := (*hexutil.Big)(chain.Config().ChainID)
+
+	// A proposal without a chain id or an expiry block must not be signed
+	proposal := &istanbul.ValidatorProposal{Candidate: candidate, Authorize: true, Expiry: 10}
+	if err := proposal.Sign(engine.privateKey); !errors.Is(err, istanbul.ErrProposalNoChainID) {
+		t.Fatalf("chainless proposal error mismatch: have %v, want %v", err, istanbul.ErrProposalNoChainID)
+	}
+	proposal = &istanbul.ValidatorProposal{ChainID: chainID, Candidate: candidate, Authorize: true}
+	if err := proposal.Sign(engine.privateKey); !errors.Is(err, istanbul.ErrProposalNoExpiry) {
+		t.Fatalf("unbounded proposal error mismatch: have %v, want %v", err, istanbul.ErrProposalNoExpiry)
+	}
+	// A proposal signed for another network must be rejected
+	proposal = &istanbul.ValidatorProposal{ChainID: (*hexutil.Big)(big.NewInt(12345)), Candidate: candidate, Authorize: true, Expiry: 10}
+	if err := proposal.Sign(engine.privateKey); err != nil {
+		t.Fatalf("failed to sign proposal: %v", err)
+	}
+	if _, err := api.SubmitProposal(*proposal); !errors.Is(err, istanbul.ErrProposalChainMismatch) {
+		t.Fatalf("foreign proposal error mismatch: have %v, want %v", err, istanbul.ErrProposalChainMismatch)
+	}
+	// Rebinding a signed proposal to this network must invalidate the signature
+	proposal.ChainID = chainID
+	if _, err := api.SubmitProposal(*proposal); !errors.Is(err, istanbul.ErrUnauthorizedAddress) {
+		t.Fatalf("replayed proposal error mismatch: have %v, want %v", err, istanbul.ErrUnauthorizedAddress)
+	}
+	// A proposal signed by a non-validator must be rejected
+	outsider, _ := crypto.GenerateKey()
+	proposal = &istanbul.ValidatorProposal{ChainID: chainID, Candidate: candidate, Authorize: true, Expiry: 10}
+	if err := proposal.Sign(outsider); err != nil {
+		t.Fatalf("failed to sign proposal: %v", err)
+	}
+	if _, err := api.SubmitProposal(*proposal); !errors.Is(err, istanbul.ErrUnauthorizedAddress) {
+		t.Fatalf("outsider proposal error mismatch: have %v, want %v", err, istanbul.ErrUnauthorizedAddress)
+	}
+	// A proposal endorsed by the local validator must be voted on until the
+	// earlier of its expiry and the next epoch
+	proposal = &istanbul.ValidatorProposal{ChainID: chainID, Candidate: candidate, Authorize: true, Expiry: 10}
+	if err := proposal.Sign(engine.privateKey); err != nil {
+		t.Fatalf("failed to sign proposal: %v", err)
+	}
+	voting, err := api.SubmitProposal(*proposal)
+	if err != nil {
+		t.Fatalf("failed to submit proposal: %v", err)
+	}
+	if !voting {
+		t.Fatalf("proposal signed by the local validator not voted on")
+	}
+	if have := engine.candidates[candidate]; have == nil || !have.authorize || have.expiry != 10 {
+		t.Errorf("candidate mismatch: have %+v, want authorize with expiry 10", have)
+	}
+	// A duplicate signature must be rejected
+	if err := proposal.Sign(engine.privateKey); err != nil {
+		t.Fatalf("failed to sign proposal: %v", err)
+	}
+	if _, err := api.SubmitProposal(*proposal); !errors.Is(err, istanbul.ErrDuplicateProposalSigner) {
+		t.Fatalf("duplicate signer error mismatch: have %v, want %v", err, istanbul.ErrDuplicateProposalSigner)
+	}
+}
+
+// Tests that locally proposed votes expire at the next epoch boundary.
+func TestProposeVoteExpiry(t *testing.T) {
+	chain, engine := newBlockChain(1)
+	defer engine.Stop()
+
+	api := &API{chain: chain, backend: engine}
+	candidate := common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	api.Propose(candidate, true)
+	if have := engine.candidates[candidate]; have == nil || have.expiry != engine.config.Epoch {
+		t.Fatalf("candidate mismatch: have %+v, want expiry %d", have, engine.config.Epoch)
+	}
+	// The vote is cast while it's not expired
+	header := makeHeader(chain.Genesis(), engine.config)
+	if err := engine.Prepare(chain, header); err != nil {
+		t.Fatalf("failed to prepare header: %v", err)
+	}
+	if voted, _, err := engine.Engine().ReadVote(header); err != nil || voted != candidate {
+		t.Fatalf("vote mismatch: have %v (err %v), want %v", voted, err, candidate)
+	}
+	// The vote is dropped once expired
+	engine.candidates[candidate].expiry = header.Number.Uint64()
+
+	header = makeHeader(chain.Genesis(), engine.config)
+	if err := engine.Prepare(chain, header); err != nil {
+		t.Fatalf("failed to prepare header: %v", err)
+	}
+	if voted, _, err := engine.Engine().ReadVote(header); err != nil || voted != (common.Address{}) {
+		t.Fatalf("vote mismatch: have %v (err %v), want none", voted, err)
+	}
+	if _, ok := engine.candidates[candidate]; ok {
+		t.Errorf("expired candidate not dropped")
+	}
+}
+
+// Tests that the block timing reflects the transitions in effect at the block.
+func TestGetBlockTiming(t *testing.T) {
+	genesis, nodeKeys := testutils.GenesisAndKeys(1)
+	config := copyConfig(istanbul.DefaultConfig)
+	config.BlockPeriod = 2
+	config.RequestTimeout = 4000
+	maxTimeout, emptyPeriod := uint64(30), uint64(8)
+	config.Transitions = []params.Transition{
+		{Block: big.NewInt(5), BlockPeriodSeconds: 1, EmptyBlockPeriodSeconds: &emptyPeriod, MaxRequestTimeoutSeconds: &maxTimeout},
+	}
+	chain, engine := newBlockchainFromConfig(genesis, nodeKeys, config)
+	defer engine.Stop()
+
+	api := &API{chain: chain, backend: engine}
+
+	// The next block is timed by the genesis configuration
+	timing, err := api.GetBlockTiming(nil)
+	if err != nil {
+		t.Fatalf("failed to get block timing: %v", err)
+	}
+	want := &BlockTiming{
+		Number:           1,
+		BlockPeriod:      2,
+		EmptyBlockPeriod: 2,
+		RequestTimeout:   4000,
+		RoundTimeouts:    []uint64{4000, 8000, 16000, 32000, 64000, 128000, 256000, 512000, 1024000, 2048000},
+	}
+	if !reflect.DeepEqual(timing, want) {
+		t.Errorf("next block timing mismatch:\nhave %+v\nwant %+v", timing, want)
+	}
+	pending := rpc.PendingBlockNumber
+	if timing, err = api.GetBlockTiming(&pending); err != nil {
+		t.Fatalf("failed to get block timing: %v", err)
+	}
+	if timing.Number != 1 {
+		t.Errorf("pending block timing number mismatch: have %d, want 1", timing.Number)
+	}
+	latest := rpc.LatestBlockNumber
+	if timing, err = api.GetBlockTiming(&latest); err != nil {
+		t.Fatalf("failed to get block timing: %v", err)
+	}
+	if timing.Number != 0 {
+		t.Errorf("latest block timing number mismatch: have %d, want 0", timing.Number)
+	}
+	// The blocks from the transition on are timed by it
+	number := rpc.BlockNumber(5)
+	if timing, err = api.GetBlockTiming(&number); err != nil {
+		t.Fatalf("failed to get block timing: %v", err)
+	}
+	want = &BlockTiming{
+		Number:            5,
+		BlockPeriod:       1,
+		EmptyBlockPeriod:  8,
+		RequestTimeout:    4000,
+		MaxRequestTimeout: 30,
+		RoundTimeouts:     []uint64{4000, 8000, 16000, 30000, 30000, 30000, 30000, 30000, 30000, 30000},
+	}
+	if !reflect.DeepEqual(timing, want) {
+		t.Errorf("transition block timing mismatch:\nhave %+v\nwant %+v", timing, want)
+	}
+}
+
+// Tests that the proposer schedule rotates through the validators from the
+// first one after the genesis.
+func TestGetProposerSchedule(t *testing.T) {
+	chain, engine := newBlockChain(4)
+	defer engine.Stop()
+
+	api := &API{chain: chain, backend: engine}
+	schedule, err := api.GetProposerSchedule(6)
+	if err != nil {
+		t.Fatalf("failed to get proposer schedule: %v", err)
+	}
+	valSet := engine.Validators(chain.Genesis())
+	if len(schedule) != 6 {
+		t.Fatalf("schedule length mismatch: have %d, want 6", len(schedule))
+	}
+	for i, entry := range schedule {
+		want := valSet.GetByIndex(uint64(i % 4)).Address()
+		if entry.Number != uint64(i+1) || entry.Proposer != want {
+			t.Errorf("entry %d mismatch: have block %d by %x, want block %d by %x", i, entry.Number, entry.Proposer, i+1, want)
+		}
+	}
+	if _, err := api.GetProposerSchedule(maxProposerSchedule + 1); err == nil {
+		t.Errorf("oversized schedule accepted")
+	}
+}
+
+// Tests that transitions can only be replaced without changing the history.
+func TestCheckTransitions(t *testing.T) {
+	genesis, nodeKeys := testutils.GenesisAndKeys(1)
+	config := copyConfig(istanbul.DefaultConfig)
+	config.Transitions = []params.Transition{{Block: big.NewInt(0), BlockPeriodSeconds: 1}}
+
+	chain, engine := newBlockchainFromConfig(genesis, nodeKeys, config)
+	defer engine.Stop()
+
+	api := &API{chain: chain, backend: engine}
+	maxTimeout := uint64(2)
+
+	tests := []struct {
+		transitions []params.Transition
+		timings     []uint64
+		fail        bool
+	}{
+		// Upcoming transitions may be added
+		{
+			transitions: []params.Transition{
+				{Block: big.NewInt(0), BlockPeriodSeconds: 1},
+				{Block: big.NewInt(10), BlockPeriodSeconds: 3},
+				{Block: big.NewInt(10), RequestTimeoutSeconds: 20},
+				{Block: big.NewInt(20), BlockPeriodSeconds: 5},
+			},
+			timings: []uint64{10, 20},
+		},
+		// Applied transitions can't be changed or removed
+		{transitions: []params.Transition{{Block: big.NewInt(0), BlockPeriodSeconds: 2}}, fail: true},
+		{transitions: []params.Transition{}, fail: true},
+		// Transitions must be ordered
+		{
+			transitions: []params.Transition{
+				{Block: big.NewInt(0), BlockPeriodSeconds: 1},
+				{Block: big.NewInt(20), BlockPeriodSeconds: 5},
+				{Block: big.NewInt(10), BlockPeriodSeconds: 3},
+			},
+			fail: true,
+		},
+		// The round timeouts can't be capped below the first one
+		{
+			transitions: []params.Transition{
+				{Block: big.NewInt(0), BlockPeriodSeconds: 1},
+				{Block: big.NewInt(10), MaxRequestTimeoutSeconds: &maxTimeout},
+			},
+			fail: true,
+		},
+	}
+	for i, tt := range tests {
+		timings, err := api.CheckTransitions(tt.transitions)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("test %d: invalid transitions accepted", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: failed to check transitions: %v", i, err)
+			continue
+		}
+		var numbers []uint64
+		for _, timing := range timings {
+			numbers = append(numbers, timing.Number)
+		}
+		if !slices.Equal(numbers, tt.timings) {
+			t.Errorf("test %d: timings mismatch: have %v, want %v", i, numbers, tt.timings)
+		}
+	}
+	if timings, _ := api.CheckTransitions(tests[0].transitions); timings[0].BlockPeriod != 3 || timings[0].RequestTimeout != 20000 {
+		t.Errorf("transition timing mismatch: have %+v", timings[0])
+	}
+}
+
+// Tests that the rewards summary matches the balances credited by the blocks, in
+// both the validator and the split modes.
+func TestGetRewards(t *testing.T) {
+	genesis, nodeKeys := testutils.GenesisAndKeys(1)
+	chainConfig := *genesis.Config
+	split := params.BeneficiaryModeSplit
+	chainConfig.QBFT = &params.QBFTConfig{BlockReward: math.NewHexOrDecimal256(100)}
+	chainConfig.Transitions = []params.Transition{{Block: big.NewInt(2), BeneficiaryMode: &split}}
+	genesis.Config = &chainConfig
+
+	// Drop the block period to commit the blocks back to back
+	config := copyConfig(istanbul.DefaultConfig)
+	config.BlockPeriod = 0
+	chain, engine := newBlockchainFromConfig(genesis, nodeKeys, config)
+	defer engine.Stop()
+
+	parent := chain.Genesis()
+	for i := 0; i < 3; i++ {
+		// Commit the blocks directly, as the core waits for a round timeout before
+		// proposing past the first block
+		header := updateQBFTBlock(makeBlockWithoutSeal(chain, engine, parent), engine.Address()).Header()
+		seal, err := engine.SignCommittedSeal(header, 0)
+		if err != nil {
+			t.Fatalf("failed to sign committed seal: %v", err)
+		}
+		if err := engine.qbftEngine.CommitHeader(header, [][]byte{seal}, big.NewInt(0)); err != nil {
+			t.Fatalf("failed to commit header: %v", err)
+		}
+		block := types.NewBlockWithHeader(header)
+		if _, err := chain.InsertChain(types.Blocks{block}); err != nil {
+			t.Fatalf("failed to insert block %d: %v", i+1, err)
+		}
+		parent = block
+	}
+	api := &API{chain: chain, backend: engine}
+
+	summary, err := api.GetRewards(rpc.BlockNumber(0), nil)
+	if err != nil {
+		t.Fatalf("failed to get rewards: %v", err)
+	}
+	if summary.From != 0 || summary.To != 3 {
+		t.Errorf("range mismatch: have [%d, %d], want [0, 3]", summary.From, summary.To)
+	}
+	if summary.Total.ToInt().Cmp(big.NewInt(300)) != 0 {
+		t.Errorf("total mismatch: have %v, want 300", summary.Total.ToInt())
+	}
+	statedb, err := chain.State()
+	if err != nil {
+		t.Fatalf("failed to get state: %v", err)
+	}
+	if len(summary.Rewards) != 1 {
+		t.Fatalf("beneficiaries mismatch: have %d, want 1", len(summary.Rewards))
+	}
+	for account, amount := range summary.Rewards {
+		if account != engine.Address() {
+			t.Errorf("beneficiary mismatch: have %x, want %x", account, engine.Address())
+		}
+		if balance := statedb.GetBalance(account).ToBig(); balance.Cmp(amount.ToInt()) != 0 {
+			t.Errorf("reward of %x mismatch: have %v, credited %v", account, amount.ToInt(), balance)
+		}
+	}
+	from, to := rpc.BlockNumber(2), rpc.BlockNumber(1)
+	if _, err := api.GetRewards(from, &to); err == nil {
+		t.Errorf("inverted range accepted")
+	}
+}
@@ -18,10 +18,13 @@ package backend
 
 import (
 	"crypto/ecdsa"
+	"errors"
+	"fmt"
 	"math/big"
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/istanbul"
@@ -35,6 +38,7 @@ import (
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
 	lru "github.com/hashicorp/golang-lru"
 )
 
@@ -43,7 +47,19 @@ const (
 	fetcherID = "istanbul"
 )
 
-// New creates an Ethereum backend for Istanbul core engine.
+// SignerFn is a signer callback function to request the keccak256 hash of some
+// data to be signed by the validator key, e.g. accounts.Wallet.SignData of an
+// unlocked keystore account or of an external signer. The mime type describes
+// the data, either a consensus message or a header to seal.
+type SignerFn func(mimeType string, data []byte) ([]byte, error)
+
+// errSignTimeout is returned if the signer function didn't return within the
+// latency budget of the consensus round.
+var errSignTimeout = errors.New("signing timed out")
+
+// New creates an Ethereum backend for Istanbul core engine. The private key may
+// be nil, in which case the node follows the network without validating until
+// a signing key is provided via Authorize.
 func New(config *istanbul.Config, privateKey *ecdsa.PrivateKey, db ethdb.Database) *Backend {
 	// Allocate the snapshot caches and create the engine
 	recents, _ := lru.NewARC(inmemorySnapshots)
@@ -54,27 +70,35 @@ func New(config *istanbul.Config, privateKey *ecdsa.PrivateKey, db ethdb.Databas
 		config:           config,
 		istanbulEventMux: new(event.TypeMux),
 		privateKey:       privateKey,
-		address:          crypto.PubkeyToAddress(privateKey.PublicKey),
 		logger:           log.New(),
 		db:               db,
 		commitCh:         make(chan *types.Block, 1),
 		recents:          recents,
-		candidates:       make(map[common.Address]bool),
+		candidates:       make(map[common.Address]*candidate),
 		coreStarted:      false,
 		recentMessages:   recentMessages,
 		knownMessages:    knownMessages,
 	}
-
+	if privateKey != nil {
+		sb.address = crypto.PubkeyToAddress(privateKey.PublicKey)
+	}
 	sb.qbftEngine = qbftengine.NewEngine(sb.config, sb.address, sb.Sign)
 	return sb
 }
 
+// candidate is a vote the local validator keeps casting until it expires.
+type candidate struct {
+	authorize bool
+	expiry    uint64 // Block number from which the vote is dropped
+}
+
 // ----------------------------------------------------------------------------
 
 type Backend struct {
 	config *istanbul.Config
 
 	privateKey *ecdsa.PrivateKey
+	signFn     SignerFn // Signer function overriding the private key, set by Authorize
 	address    common.Address
 
 	core istanbul.Core
@@ -99,12 +123,14 @@ type Backend struct {
 	coreMu            sync.RWMutex
 
 	// Current list of candidates we are pushing
-	candidates map[common.Address]bool
+	candidates map[common.Address]*candidate
 	// Protects the signer fields
 	candidatesLock sync.RWMutex
 	// Snapshots for recent block to speed up reorgs
 	recents *lru.ARCCache
 
+	auditLock sync.Mutex // Serializes the recording of the validator changes
+
 	// event subscription for ChainHeadEvent event
 	broadcaster consensus.Broadcaster
 
@@ -112,6 +138,27 @@ type Backend struct {
 	knownMessages  *lru.ARCCache // the cache of self messages
 }
 
+// Authorize injects the validator address and the signer function used to sign
+// consensus messages and blocks. It must be called before the engine is started.
+func (sb *Backend) Authorize(address common.Address, signFn SignerFn) error {
+	sb.coreMu.Lock()
+	defer sb.coreMu.Unlock()
+	if sb.coreStarted {
+		return istanbul.ErrStartedEngine
+	}
+	sb.address = address
+	sb.signFn = signFn
+	sb.qbftEngine = qbftengine.NewEngine(sb.config, sb.address, sb.Sign)
+	return nil
+}
+
+// voteExpiry returns the first epoch boundary after the given block, from which
+// on the votes cast up to the block are discarded by the snapshot.
+func (sb *Backend) voteExpiry(number uint64) uint64 {
+	epoch := sb.config.GetConfig(new(big.Int).SetUint64(number)).Epoch
+	return (number/epoch + 1) * epoch
+}
+
 func (sb *Backend) Engine() istanbul.Engine {
 	return sb.qbftEngine
 }
@@ -255,15 +302,67 @@ func (sb *Backend) Verify(proposal istanbul.Proposal) (time.Duration, error) {
 	return sb.Engine().VerifyBlockProposal(sb.chain, block, snap.ValSet)
 }
 
-// Sign implements istanbul.Backend.Sign
+// Sign implements istanbul.Backend.Sign, signing a consensus message
 func (sb *Backend) Sign(data []byte) ([]byte, error) {
-	hashData := crypto.Keccak256(data)
-	return crypto.Sign(hashData, sb.privateKey)
+	return sb.sign(accounts.MimetypeQBFTMessage, data)
 }
 
-// SignWithoutHashing implements istanbul.Backend.SignWithoutHashing and signs input data with the backend's private key without hashing the input data
-func (sb *Backend) SignWithoutHashing(data []byte) ([]byte, error) {
-	return crypto.Sign(data, sb.privateKey)
+// SignCommittedSeal implements istanbul.Backend.SignCommittedSeal, signing the
+// header committed in the given round
+func (sb *Backend) SignCommittedSeal(header *types.Header, round uint32) ([]byte, error) {
+	filtered := types.QBFTFilteredHeaderWithRound(header, round)
+	if filtered == nil {
+		return nil, istanbulcommon.ErrInvalidExtraDataFormat
+	}
+	blob, err := rlp.EncodeToBytes(filtered)
+	if err != nil {
+		return nil, err
+	}
+	return sb.sign(accounts.MimetypeQBFTHeader, blob)
+}
+
+// sign signs the keccak256 hash of the data, either with the private key or with
+// the signer function. Remote signers are given a latency budget of half the
+// round timeout, leaving the other half for the message exchange.
+func (sb *Backend) sign(mimeType string, data []byte) ([]byte, error) {
+	if sb.signFn == nil {
+		if sb.privateKey == nil {
+			return nil, istanbulcommon.ErrUnauthorized
+		}
+		return crypto.Sign(crypto.Keccak256(data), sb.privateKey)
+	}
+	type result struct {
+		sig []byte
+		err error
+	}
+	resCh := make(chan result, 1)
+	go func() {
+		sig, err := sb.signFn(mimeType, data)
+		resCh <- result{sig, err}
+	}()
+	budget := sb.signBudget()
+	timer := time.NewTimer(budget)
+	defer timer.Stop()
+
+	select {
+	case res := <-resCh:
+		return res.sig, res.err
+	case <-timer.C:
+		return nil, fmt.Errorf("%w: no signature within %v", errSignTimeout, budget)
+	}
+}
+
+// signBudget returns the time the signer function is allowed to take, half the
+// round timeout of the next block.
+func (sb *Backend) signBudget() time.Duration {
+	number := big.NewInt(0)
+	if sb.currentBlock != nil {
+		if block := sb.currentBlock(); block != nil {
+			number = new(big.Int).Add(block.Number(), common.Big1)
+		}
+	}
+	timeout := time.Duration(sb.config.GetConfig(number).RequestTimeout) * time.Millisecond
+	return timeout / 2
 }
 
 // CheckSignature implements istanbul.Backend.CheckSignature
@@ -343,7 +442,7 @@ func (sb *Backend) startQBFT() error {
 	sb.logger.Trace("BFT: set ProposerPolicy sorter to ValidatorSortByByteFunc")
 	sb.config.ProposerPolicy.Use(istanbul.ValidatorSortByByte())
 
-	sb.core = qbftcore.New(sb, sb.config)
+	sb.core = qbftcore.New(sb, sb.config, sb.db)
 	if err := sb.core.Start(); err != nil {
 		sb.logger.Error("BFT: failed to activate QBFT", "err", err)
 		return err
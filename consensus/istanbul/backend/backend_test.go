@@ -19,19 +19,24 @@ package backend
 import (
 	"bytes"
 	"crypto/ecdsa"
+	"errors"
 	"math/big"
+	"slices"
 	"sort"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/istanbul"
 
 	istanbulcommon "github.com/ethereum/go-ethereum/consensus/istanbul/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul/testutils"
 	"github.com/ethereum/go-ethereum/consensus/istanbul/validator"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 func TestSign(t *testing.T) {
@@ -52,6 +57,53 @@ func TestSign(t *testing.T) {
 	}
 }
 
+// Tests that signer functions are asked to sign the preimages of the hashes,
+// tagged with their type, and are bounded by the latency budget.
+func TestSignWithSignerFn(t *testing.T) {
+	chain, b := newBlockChain(1)
+	defer b.Stop()
+
+	key, _ := generatePrivateKey()
+	var mimeTypes []string
+	b.signFn = func(mimeType string, data []byte) ([]byte, error) {
+		mimeTypes = append(mimeTypes, mimeType)
+		return crypto.Sign(crypto.Keccak256(data), key)
+	}
+	// Consensus messages sign the hash of the data
+	data := []byte("Here is a string....")
+	sig, err := b.Sign(data)
+	if err != nil {
+		t.Fatalf("failed to sign message: %v", err)
+	}
+	if signer, _ := istanbul.GetSignatureAddress(data, sig); signer != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Errorf("message signer mismatch: have %v, want %v", signer, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	// Commit seals sign the hash of the header with the round
+	header := makeHeader(chain.Genesis(), b.config)
+	if err := b.Prepare(chain, header); err != nil {
+		t.Fatalf("failed to prepare header: %v", err)
+	}
+	seal, err := b.SignCommittedSeal(header, 2)
+	if err != nil {
+		t.Fatalf("failed to sign committed seal: %v", err)
+	}
+	if signer, _ := istanbul.GetSignatureAddressNoHashing(header.QBFTHashWithRoundNumber(2).Bytes(), seal); signer != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Errorf("seal signer mismatch: have %v, want %v", signer, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	if want := []string{accounts.MimetypeQBFTMessage, accounts.MimetypeQBFTHeader}; !slices.Equal(mimeTypes, want) {
+		t.Errorf("mime type mismatch: have %v, want %v", mimeTypes, want)
+	}
+	// Signers exceeding half the round timeout are given up on
+	b.config.RequestTimeout = 100
+	b.signFn = func(mimeType string, data []byte) ([]byte, error) {
+		time.Sleep(time.Second)
+		return crypto.Sign(crypto.Keccak256(data), key)
+	}
+	if _, err := b.Sign(data); !errors.Is(err, errSignTimeout) {
+		t.Errorf("slow signer error mismatch: have %v, want %v", err, errSignTimeout)
+	}
+}
+
 func TestCheckSignature(t *testing.T) {
 	key, _ := generatePrivateKey()
 	data := []byte("Here is a string....")
@@ -189,6 +241,30 @@ func TestGetProposer(t *testing.T) {
 	}
 }
 
+// Tests that empty block proposals must wait for the empty block period, taking
+// effect from the block of the transition changing it.
+func TestVerifyEmptyBlockPeriod(t *testing.T) {
+	genesis, nodeKeys := testutils.GenesisAndKeys(1)
+	config := copyConfig(istanbul.DefaultConfig)
+	config.BlockPeriod = 1
+	config.Transitions = []params.Transition{{Block: big.NewInt(1), EmptyBlockPeriodSeconds: new(uint64)}}
+	*config.Transitions[0].EmptyBlockPeriodSeconds = 10
+
+	chain, engine := newBlockchainFromConfig(genesis, nodeKeys, config)
+	defer engine.Stop()
+
+	block := updateQBFTBlock(makeBlockWithoutSeal(chain, engine, chain.Genesis()), engine.Address())
+	header := block.Header()
+	header.Time = chain.Genesis().Time() + 1
+	if _, err := engine.Verify(block.WithSeal(header)); err != istanbulcommon.ErrInvalidEmptyBlockTimestamp {
+		t.Fatalf("error mismatch: have %v, want %v", err, istanbulcommon.ErrInvalidEmptyBlockTimestamp)
+	}
+	header.Time = chain.Genesis().Time() + 10
+	if _, err := engine.Verify(block.WithSeal(header)); err != nil {
+		t.Fatalf("failed to verify empty block after the empty block period: %v", err)
+	}
+}
+
 // TestQBFTTransitionDeadlock test whether a deadlock occurs when testQBFTBlock is set to 1
 // This was fixed as part of commit 2a8310663ecafc0233758ca7883676bf568e926e
 func TestQBFTTransitionDeadlock(t *testing.T) {
@@ -39,6 +39,7 @@ const (
 	inmemorySnapshots  = 128  // Number of recent vote snapshots to keep in memory
 	inmemoryPeers      = 40
 	inmemoryMessages   = 1024
+	auditBatchSize     = 1024 // Number of blocks whose validator changes are recorded at once
 )
 
 // Author retrieves the Ethereum address of the account that minted the given
@@ -68,6 +69,9 @@ func (sb *Backend) verifyHeader(chain consensus.ChainHeaderReader, header *types
 	if err != nil {
 		return err
 	}
+	if err := checkRewardValidators(chain, header, snap); err != nil {
+		return err
+	}
 
 	return sb.Engine().VerifyHeader(chain, header, parents, snap.ValSet)
 }
@@ -135,23 +139,33 @@ func (sb *Backend) Prepare(chain consensus.ChainHeaderReader, header *types.Head
 	if err != nil {
 		return err
 	}
+	if err := checkRewardValidators(chain, header, snap); err != nil {
+		return err
+	}
 
 	err = sb.Engine().Prepare(chain, header, snap.ValSet)
 	if err != nil {
 		return err
 	}
 
-	// get valid candidate list
-	sb.candidatesLock.RLock()
+	// get valid candidate list, dropping the votes expired at an epoch boundary
+	number := header.Number.Uint64()
+
+	sb.candidatesLock.Lock()
 	var addresses []common.Address
 	var authorizes []bool
-	for address, authorize := range sb.candidates {
-		if snap.checkVote(address, authorize) {
+	for address, candidate := range sb.candidates {
+		if number >= candidate.expiry {
+			sb.logger.Info("BFT: validator vote expired", "candidate", address, "authorize", candidate.authorize, "expiry", candidate.expiry)
+			delete(sb.candidates, address)
+			continue
+		}
+		if snap.checkVote(address, candidate.authorize) {
 			addresses = append(addresses, address)
-			authorizes = append(authorizes, authorize)
+			authorizes = append(authorizes, candidate.authorize)
 		}
 	}
-	sb.candidatesLock.RUnlock()
+	sb.candidatesLock.Unlock()
 
 	if len(addresses) > 0 {
 		index := rand.Intn(len(addresses))
@@ -172,13 +186,48 @@ func (sb *Backend) Prepare(chain consensus.ChainHeaderReader, header *types.Head
 // Note, the block header and state database might be updated to reflect any
 // consensus rules that happen at finalization (e.g. block rewards).
 func (sb *Backend) Finalize(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, body *types.Body) {
-	sb.Engine().Finalize(chain, header, state, body)
+	// The split reward is skipped if the validators are unknown, rather than paid
+	// to the reward account, so that the state root of the block doesn't match.
+	// The header verification rejects the block before that.
+	validators, err := sb.rewardValidators(chain, header)
+	if err != nil {
+		log.Error("QBFT: failed to get the validators sharing the block reward", "number", header.Number, "err", err)
+	}
+	sb.Engine().Finalize(chain, header, state, body, validators)
 }
 
 // FinalizeAndAssemble implements consensus.Engine, ensuring no uncles are set,
 // nor block rewards given, and returns the final block.
 func (sb *Backend) FinalizeAndAssemble(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, body *types.Body, receipts []*types.Receipt) (*types.Block, error) {
-	return sb.Engine().FinalizeAndAssemble(chain, header, state, body, receipts)
+	validators, err := sb.rewardValidators(chain, header)
+	if err != nil {
+		return nil, err
+	}
+	return sb.Engine().FinalizeAndAssemble(chain, header, state, body, receipts, validators)
+}
+
+// rewardValidators returns the validators sharing the reward of the block in the
+// split beneficiary mode, taken from the snapshot of its parent. It returns nil
+// in the other modes.
+func (sb *Backend) rewardValidators(chain consensus.ChainHeaderReader, header *types.Header) (istanbul.ValidatorSet, error) {
+	if mode, _ := chain.Config().GetBeneficiaryMode(header.Number); mode != params.BeneficiaryModeSplit {
+		return nil, nil
+	}
+	snap, err := sb.snapshot(chain, header.Number.Uint64()-1, header.ParentHash, nil)
+	if err != nil {
+		return nil, err
+	}
+	return snap.ValSet, nil
+}
+
+// checkRewardValidators returns an error if the block is rewarded in the split
+// beneficiary mode, but there are no validators in the snapshot of its parent to
+// share the reward.
+func checkRewardValidators(chain consensus.ChainHeaderReader, header *types.Header, snap *Snapshot) error {
+	if mode, _ := chain.Config().GetBeneficiaryMode(header.Number); mode == params.BeneficiaryModeSplit && snap.ValSet.Size() == 0 {
+		return istanbulcommon.ErrNoRewardValidators
+	}
+	return nil
 }
 
 // Seal generates a new block for the given input block with the local miner's
@@ -453,8 +502,7 @@ func (sb *Backend) snapApply(snap *Snapshot, headers []*types.Header) (*Snapshot
 	snapCpy := snap.copy()
 
 	for _, header := range headers {
-		err := sb.snapApplyHeader(snapCpy, header)
-		if err != nil {
+		if _, err := sb.snapApplyHeader(snapCpy, header); err != nil {
 			return nil, err
 		}
 	}
@@ -464,7 +512,84 @@ func (sb *Backend) snapApply(snap *Snapshot, headers []*types.Header) (*Snapshot
 	return snapCpy, nil
 }
 
-func (sb *Backend) snapApplyHeader(snap *Snapshot, header *types.Header) error {
+// RecordValidatorChanges stores the validator changes applied by the canonical
+// chain up to the given head in the audit log. It is meant to be invoked on every
+// new chain head, so that blocks on side chains or rejected ones are left out.
+func (sb *Backend) RecordValidatorChanges(chain consensus.ChainHeaderReader, head *types.Header) error {
+	sb.auditLock.Lock()
+	defer sb.auditLock.Unlock()
+
+	// Resume after the last recorded block, rewinding to the canonical chain if
+	// it was reorged out meanwhile
+	number, hash := readAuditHead(sb.db)
+	for number > 0 {
+		if canon := chain.GetHeaderByNumber(number); canon != nil && canon.Hash() == hash {
+			break
+		}
+		header := chain.GetHeader(hash, number)
+		if header == nil {
+			sb.logger.Warn("BFT: last audited block not found, recording validator changes from genesis", "number", number, "hash", hash)
+			number = 0
+			break
+		}
+		number, hash = number-1, header.ParentHash
+	}
+	if number == 0 {
+		hash = chain.GetHeaderByNumber(0).Hash()
+	}
+	// Record the changes of the following canonical blocks in batches, stopping
+	// early if the chain is reorged meanwhile
+	for number < head.Number.Uint64() {
+		headers := make([]*types.Header, 0, auditBatchSize)
+		for n := number + 1; n <= head.Number.Uint64() && len(headers) < auditBatchSize; n++ {
+			header := chain.GetHeaderByNumber(n)
+			if header == nil || header.ParentHash != hash {
+				break
+			}
+			headers = append(headers, header)
+			hash = header.Hash()
+		}
+		if len(headers) == 0 {
+			return nil
+		}
+		if err := sb.recordValidatorChanges(chain, headers); err != nil {
+			return err
+		}
+		number = headers[len(headers)-1].Number.Uint64()
+		if err := writeAuditHead(sb.db, number, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordValidatorChanges stores the validator changes applied by the given
+// consecutive headers in the audit log.
+func (sb *Backend) recordValidatorChanges(chain consensus.ChainHeaderReader, headers []*types.Header) error {
+	snap, err := sb.snapshot(chain, headers[0].Number.Uint64()-1, headers[0].ParentHash, nil)
+	if err != nil {
+		return err
+	}
+	snap = snap.copy()
+	for _, header := range headers {
+		change, err := sb.snapApplyHeader(snap, header)
+		if err != nil {
+			return err
+		}
+		if change == nil {
+			continue
+		}
+		if err := change.store(sb.db); err != nil {
+			sb.logger.Error("BFT: failed to store validator change", "number", change.Number, "err", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// snapApplyHeader applies the vote of the header to the snapshot, returning the
+// change of the validator set it caused, if any.
+func (sb *Backend) snapApplyHeader(snap *Snapshot, header *types.Header) (*ValidatorChange, error) {
 	logger := sb.snapLogger(snap).New("header.number", header.Number.Uint64(), "header.hash", header.Hash().String())
 
 	logger.Trace("BFT: apply header to voting snapshot")
@@ -480,21 +605,21 @@ func (sb *Backend) snapApplyHeader(snap *Snapshot, header *types.Header) error {
 	validator, err := sb.Engine().Author(header)
 	if err != nil {
 		logger.Error("BFT: invalid header author", "err", err)
-		return err
+		return nil, err
 	}
 
 	logger = logger.New("header.author", validator)
 
 	if _, v := snap.ValSet.GetByAddress(validator); v == nil {
 		logger.Error("BFT: header author is not a validator", "Validators", snap.ValSet, "Author", validator)
-		return istanbulcommon.ErrUnauthorized
+		return nil, istanbulcommon.ErrUnauthorized
 	}
 
 	// Read vote from header
 	candidate, authorize, err := sb.Engine().ReadVote(header)
 	if err != nil {
 		logger.Error("BFT: invalid header vote", "err", err)
-		return err
+		return nil, err
 	}
 
 	logger = logger.New("candidate", candidate.String(), "authorize", authorize)
@@ -522,7 +647,20 @@ func (sb *Backend) snapApplyHeader(snap *Snapshot, header *types.Header) error {
 	}
 
 	// If the vote passed, update the list of validators
+	var change *ValidatorChange
 	if tally := snap.Tally[candidate]; tally.Votes > snap.ValSet.Size()/2 {
+		// Report the change along with the validators who voted it in
+		change = &ValidatorChange{
+			Number:    number,
+			Hash:      header.Hash(),
+			Address:   candidate,
+			Authorize: tally.Authorize,
+		}
+		for _, vote := range snap.Votes {
+			if vote.Address == candidate && vote.Authorize == tally.Authorize {
+				change.Voters = append(change.Voters, vote.Validator)
+			}
+		}
 		if tally.Authorize {
 			logger.Info("BFT: reached majority to add validator")
 			snap.ValSet.AddValidator(candidate)
@@ -552,5 +690,5 @@ func (sb *Backend) snapApplyHeader(snap *Snapshot, header *types.Header) error {
 		}
 		delete(snap.Tally, candidate)
 	}
-	return nil
+	return change, nil
 }
@@ -25,15 +25,19 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/istanbul"
 	istanbulcommon "github.com/ethereum/go-ethereum/consensus/istanbul/common"
 	"github.com/ethereum/go-ethereum/consensus/istanbul/testutils"
+	"github.com/ethereum/go-ethereum/consensus/istanbul/validator"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/triedb"
 )
 
@@ -426,3 +430,112 @@ OUT3:
 		}
 	}
 }
+
+// Tests that nodes holding different subsets of committed seals for a block agree
+// on the state of the blocks in split reward mode, as the reward is shared by the
+// validators instead of the committers.
+func TestSplitRewardsIgnoreSeals(t *testing.T) {
+	genesis, nodeKeys := testutils.GenesisAndKeys(4)
+	chainConfig := *genesis.Config
+	split := params.BeneficiaryModeSplit
+	chainConfig.QBFT = &params.QBFTConfig{BlockReward: math.NewHexOrDecimal256(100), BeneficiaryMode: &split}
+	genesis.Config = &chainConfig
+
+	// Drop the block period to commit the blocks back to back
+	config := copyConfig(istanbul.DefaultConfig)
+	config.BlockPeriod = 0
+
+	chainA, engineA := newBlockchainFromConfig(genesis, nodeKeys, config)
+	defer engineA.Stop()
+	chainB, engineB := newBlockchainFromConfig(genesis, nodeKeys, copyConfig(config))
+	defer engineB.Stop()
+
+	// commit seals a header with the committed seals of the given validators
+	commit := func(header *types.Header, signers ...int) *types.Block {
+		header = types.CopyHeader(header)
+		blob, err := rlp.EncodeToBytes(types.QBFTFilteredHeaderWithRound(header, 0))
+		if err != nil {
+			t.Fatalf("failed to encode header: %v", err)
+		}
+		var seals [][]byte
+		for _, signer := range signers {
+			seal, err := crypto.Sign(crypto.Keccak256(blob), nodeKeys[signer])
+			if err != nil {
+				t.Fatalf("failed to sign committed seal: %v", err)
+			}
+			seals = append(seals, seal)
+		}
+		if err := engineA.qbftEngine.CommitHeader(header, seals, big.NewInt(0)); err != nil {
+			t.Fatalf("failed to commit header: %v", err)
+		}
+		return types.NewBlockWithHeader(header)
+	}
+	// Both nodes import the same blocks, each with a different quorum of seals
+	parent := chainA.Genesis()
+	for i := 0; i < 2; i++ {
+		header := updateQBFTBlock(makeBlockWithoutSeal(chainA, engineA, parent), engineA.Address()).Header()
+
+		blockA, blockB := commit(header, 0, 1, 2), commit(header, 1, 2, 3)
+		if blockA.Hash() != blockB.Hash() {
+			t.Fatalf("block %d: hash depends on the seals", i+1)
+		}
+		if _, err := chainA.InsertChain(types.Blocks{blockA}); err != nil {
+			t.Fatalf("node A: failed to insert block %d: %v", i+1, err)
+		}
+		if _, err := chainB.InsertChain(types.Blocks{blockB}); err != nil {
+			t.Fatalf("node B: failed to insert block %d: %v", i+1, err)
+		}
+		parent = blockA
+	}
+	if rootA, rootB := chainA.CurrentBlock().Root, chainB.CurrentBlock().Root; rootA != rootB {
+		t.Fatalf("state root mismatch: node A %x, node B %x", rootA, rootB)
+	}
+	statedb, err := chainB.State()
+	if err != nil {
+		t.Fatalf("failed to get state: %v", err)
+	}
+	for i, key := range nodeKeys {
+		addr := crypto.PubkeyToAddress(key.PublicKey)
+		if balance := statedb.GetBalance(addr).Uint64(); balance != 50 {
+			t.Errorf("validator %d: balance mismatch: have %d, want 50", i, balance)
+		}
+	}
+}
+
+// Tests that a block is rejected in split reward mode if the validators of its
+// parent are unknown, instead of paying the whole reward to its coinbase.
+func TestSplitRewardsUnknownValidators(t *testing.T) {
+	genesis, nodeKeys := testutils.GenesisAndKeys(4)
+	chainConfig := *genesis.Config
+	split := params.BeneficiaryModeSplit
+	chainConfig.QBFT = &params.QBFTConfig{BlockReward: math.NewHexOrDecimal256(100), BeneficiaryMode: &split}
+	genesis.Config = &chainConfig
+
+	chain, engine := newBlockchainFromConfig(genesis, nodeKeys, copyConfig(istanbul.DefaultConfig))
+	defer engine.Stop()
+
+	header := makeHeader(chain.Genesis(), engine.config)
+	if err := engine.Prepare(chain, header); err != nil {
+		t.Fatalf("known parent: failed to prepare header: %v", err)
+	}
+	header.ParentHash, header.Number = common.Hash{0x01}, big.NewInt(5)
+	if err := engine.VerifyHeader(chain, header); err == nil {
+		t.Fatal("unknown parent: header verification passed")
+	}
+	if err := engine.Prepare(chain, header); err == nil {
+		t.Fatal("unknown parent: header preparation passed")
+	}
+	empty := newSnapshot(0, 0, chain.Genesis().Hash(), validator.NewSet(nil, istanbul.NewRoundRobinProposerPolicy()))
+	if err := checkRewardValidators(chain, header, empty); err != istanbulcommon.ErrNoRewardValidators {
+		t.Fatalf("no validators: error mismatch: have %v, want %v", err, istanbulcommon.ErrNoRewardValidators)
+	}
+	header.Coinbase = engine.Address()
+	statedb, err := chain.State()
+	if err != nil {
+		t.Fatalf("failed to get state: %v", err)
+	}
+	engine.Finalize(chain, header, statedb, &types.Body{})
+	if balance := statedb.GetBalance(header.Coinbase); !balance.IsZero() {
+		t.Fatalf("unknown parent: coinbase rewarded %v", balance)
+	}
+}
@@ -18,6 +18,7 @@ package backend
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/json"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -27,7 +28,9 @@ import (
 )
 
 const (
-	dbKeySnapshotPrefix = "istanbul-snapshot"
+	dbKeySnapshotPrefix        = "istanbul-snapshot"
+	dbKeyValidatorChangePrefix = "istanbul-validator-change"
+	dbKeyValidatorAuditHead    = "istanbul-audit-head"
 )
 
 // Vote represents a single vote that an authorized validator made to modify the
@@ -46,6 +49,84 @@ type Tally struct {
 	Votes     int  `json:"votes"`     // Number of votes until now wanting to pass the proposal
 }
 
+// PendingTally is the state of the voting on a candidate which hasn't reached
+// the majority yet.
+type PendingTally struct {
+	Authorize bool             `json:"authorize"` // Whether the vote is about authorizing or kicking the candidate
+	Votes     int              `json:"votes"`     // Number of votes until now wanting to pass the proposal
+	Required  int              `json:"required"`  // Number of votes needed to pass the proposal
+	Voters    []common.Address `json:"voters"`    // Validators who voted for the proposal
+	Expiry    uint64           `json:"expiry"`    // Block number from which the votes are discarded
+}
+
+// ValidatorChange is an entry of the audit log of the validator set, recording
+// a validator added or removed by the votes of the others.
+type ValidatorChange struct {
+	Number    uint64           `json:"number"`    // Block number the change was applied in
+	Hash      common.Hash      `json:"hash"`      // Block hash the change was applied in
+	Address   common.Address   `json:"address"`   // Account added to or removed from the validators
+	Authorize bool             `json:"authorize"` // Whether the account was added or removed
+	Voters    []common.Address `json:"voters"`    // Validators who voted for the change
+}
+
+// validatorChangeKey = dbKeyValidatorChangePrefix + num (uint64 big endian) + hash
+func validatorChangeKey(number uint64, hash common.Hash) []byte {
+	key := append([]byte(dbKeyValidatorChangePrefix), make([]byte, 8)...)
+	binary.BigEndian.PutUint64(key[len(dbKeyValidatorChangePrefix):], number)
+	return append(key, hash[:]...)
+}
+
+// store inserts the validator change into the database. Changes are stored once
+// their block becomes canonical, but they are kept if it's reorged out later, it's
+// up to the reader to filter them.
+func (c *ValidatorChange) store(db ethdb.KeyValueWriter) error {
+	blob, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return db.Put(validatorChangeKey(c.Number, c.Hash), blob)
+}
+
+// loadValidatorChanges retrieves the validator changes applied in the given
+// range of blocks, on any chain.
+func loadValidatorChanges(db ethdb.Iteratee, from, to uint64) ([]*ValidatorChange, error) {
+	start := make([]byte, 8)
+	binary.BigEndian.PutUint64(start, from)
+
+	it := db.NewIterator([]byte(dbKeyValidatorChangePrefix), start)
+	defer it.Release()
+
+	var changes []*ValidatorChange
+	for it.Next() {
+		change := new(ValidatorChange)
+		if err := json.Unmarshal(it.Value(), change); err != nil {
+			return nil, err
+		}
+		if change.Number > to {
+			break
+		}
+		changes = append(changes, change)
+	}
+	return changes, it.Error()
+}
+
+// readAuditHead retrieves the number and hash of the last block whose validator
+// change was recorded, or the genesis if none was.
+func readAuditHead(db ethdb.KeyValueReader) (uint64, common.Hash) {
+	blob, err := db.Get([]byte(dbKeyValidatorAuditHead))
+	if err != nil || len(blob) != 8+common.HashLength {
+		return 0, common.Hash{}
+	}
+	return binary.BigEndian.Uint64(blob[:8]), common.BytesToHash(blob[8:])
+}
+
+// writeAuditHead stores the number and hash of the last block whose validator
+// change was recorded.
+func writeAuditHead(db ethdb.KeyValueWriter, number uint64, hash common.Hash) error {
+	blob := binary.BigEndian.AppendUint64(nil, number)
+	return db.Put([]byte(dbKeyValidatorAuditHead), append(blob, hash[:]...))
+}
+
 // Snapshot is the state of the authorization voting at a given point in time.
 type Snapshot struct {
 	Epoch uint64 // The number of blocks after which to checkpoint and reset the pending votes
@@ -157,6 +238,31 @@ func (s *Snapshot) uncast(address common.Address, authorize bool) bool {
 	return true
 }
 
+// pendingTallies returns the state of the voting on each candidate which hasn't
+// reached the majority yet. Pending votes are discarded at the next epoch.
+func (s *Snapshot) pendingTallies() map[common.Address]*PendingTally {
+	var (
+		required = s.ValSet.Size()/2 + 1
+		expiry   = (s.Number/s.Epoch + 1) * s.Epoch
+		tallies  = make(map[common.Address]*PendingTally)
+	)
+	for address, tally := range s.Tally {
+		tallies[address] = &PendingTally{
+			Authorize: tally.Authorize,
+			Votes:     tally.Votes,
+			Required:  required,
+			Voters:    []common.Address{},
+			Expiry:    expiry,
+		}
+	}
+	for _, vote := range s.Votes {
+		if tally, ok := tallies[vote.Address]; ok && tally.Authorize == vote.Authorize {
+			tally.Voters = append(tally.Voters, vote.Validator)
+		}
+	}
+	return tallies
+}
+
 // validators retrieves the list of authorized validators in ascending order.
 func (s *Snapshot) validators() []common.Address {
 	validators := make([]common.Address, 0, s.ValSet.Size())
@@ -406,6 +406,138 @@ func TestVoting(t *testing.T) {
 	}
 }
 
+// Tests that the votes on candidates below the majority are reported with their
+// voters, and that the passed votes are recorded in the validator audit log.
+func TestPendingTalliesAndValidatorChanges(t *testing.T) {
+	accounts := newTesterAccountPool()
+
+	validators := []common.Address{accounts.address("A"), accounts.address("B"), accounts.address("C")}
+	genesis := testutils.Genesis(validators)
+	config := copyConfig(istanbul.DefaultConfig)
+
+	chain, backend := newBlockchainFromConfig(genesis, []*ecdsa.PrivateKey{accounts.accounts["A"]}, config)
+	defer backend.Stop()
+
+	votes := []testerVote{
+		{validator: "A", voted: "D", auth: true},
+		{validator: "B", voted: "D", auth: true},
+		{validator: "A", voted: "E", auth: true},
+	}
+	headers := make([]*types.Header, len(votes))
+	for i, vote := range votes {
+		headers[i] = &types.Header{
+			Number:     big.NewInt(int64(i) + 1),
+			Coinbase:   accounts.address(vote.validator),
+			Difficulty: istanbulcommon.DefaultDifficulty,
+			MixDigest:  types.IstanbulDigest,
+			Extra:      genesis.ExtraData,
+		}
+		if i > 0 {
+			headers[i].ParentHash = headers[i-1].Hash()
+		}
+		if err := accounts.writeValidatorVote(headers[i], vote.validator, vote.voted, vote.auth); err != nil {
+			t.Fatalf("failed to write vote %d: %v", i, err)
+		}
+	}
+	head := headers[len(headers)-1]
+	snap, err := backend.snapshot(chain, head.Number.Uint64(), head.Hash(), headers)
+	if err != nil {
+		t.Fatalf("failed to create voting snapshot: %v", err)
+	}
+	tallies := snap.pendingTallies()
+	want := map[common.Address]*PendingTally{
+		accounts.address("E"): {
+			Authorize: true,
+			Votes:     1,
+			Required:  3,
+			Voters:    []common.Address{accounts.address("A")},
+			Expiry:    config.Epoch,
+		},
+	}
+	if !reflect.DeepEqual(tallies, want) {
+		t.Errorf("pending tallies mismatch: have %+v, want %+v", tallies, want)
+	}
+	// Verifying the headers must not record their changes, only canonical blocks do
+	if changes, _ := loadValidatorChanges(backend.db, 0, head.Number.Uint64()); len(changes) != 0 {
+		t.Errorf("validator changes recorded on verification: %+v", changes)
+	}
+	if err := backend.recordValidatorChanges(chain, headers); err != nil {
+		t.Fatalf("failed to record validator changes: %v", err)
+	}
+	changes, err := loadValidatorChanges(backend.db, 0, head.Number.Uint64())
+	if err != nil {
+		t.Fatalf("failed to load validator changes: %v", err)
+	}
+	wantChanges := []*ValidatorChange{{
+		Number:    2,
+		Hash:      headers[1].Hash(),
+		Address:   accounts.address("D"),
+		Authorize: true,
+		Voters:    []common.Address{accounts.address("A"), accounts.address("B")},
+	}}
+	if !reflect.DeepEqual(changes, wantChanges) {
+		t.Errorf("validator changes mismatch: have %+v, want %+v", changes, wantChanges)
+	}
+	if changes, _ := loadValidatorChanges(backend.db, 3, head.Number.Uint64()); len(changes) != 0 {
+		t.Errorf("validator changes out of range: have %+v, want none", changes)
+	}
+}
+
+// Tests that the validator changes are recorded once their block is canonical,
+// and only once.
+func TestRecordValidatorChanges(t *testing.T) {
+	genesis, nodeKeys := testutils.GenesisAndKeys(1)
+
+	// Drop the block period to commit the block right away
+	config := copyConfig(istanbul.DefaultConfig)
+	config.BlockPeriod = 0
+	chain, engine := newBlockchainFromConfig(genesis, nodeKeys, config)
+	defer engine.Stop()
+
+	candidate := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	api := &API{chain: chain, backend: engine}
+	api.Propose(candidate, true)
+
+	header := updateQBFTBlock(makeBlockWithoutSeal(chain, engine, chain.Genesis()), engine.Address()).Header()
+	seal, err := engine.SignCommittedSeal(header, 0)
+	if err != nil {
+		t.Fatalf("failed to sign committed seal: %v", err)
+	}
+	if err := engine.qbftEngine.CommitHeader(header, [][]byte{seal}, big.NewInt(0)); err != nil {
+		t.Fatalf("failed to commit header: %v", err)
+	}
+	block := types.NewBlockWithHeader(header)
+	if _, err := chain.InsertChain(types.Blocks{block}); err != nil {
+		t.Fatalf("failed to insert block: %v", err)
+	}
+	if changes, _ := loadValidatorChanges(engine.db, 0, 1); len(changes) != 0 {
+		t.Fatalf("validator changes recorded before the head is processed: %+v", changes)
+	}
+	want := []*ValidatorChange{{
+		Number:    1,
+		Hash:      block.Hash(),
+		Address:   candidate,
+		Authorize: true,
+		Voters:    []common.Address{engine.Address()},
+	}}
+	// Processing the same head again must not record the changes twice
+	for i := 0; i < 2; i++ {
+		if err := engine.RecordValidatorChanges(chain, chain.CurrentHeader()); err != nil {
+			t.Fatalf("failed to record validator changes: %v", err)
+		}
+		changes, err := loadValidatorChanges(engine.db, 0, 1)
+		if err != nil {
+			t.Fatalf("failed to load validator changes: %v", err)
+		}
+		if !reflect.DeepEqual(changes, want) {
+			t.Errorf("run %d: validator changes mismatch: have %+v, want %+v", i, changes, want)
+		}
+	}
+	if number, hash := readAuditHead(engine.db); number != 1 || hash != block.Hash() {
+		t.Errorf("audit head mismatch: have %d %x, want 1 %x", number, hash, block.Hash())
+	}
+}
+
 func TestSaveAndLoad(t *testing.T) {
 	snap := &Snapshot{
 		Epoch:  5,
@@ -36,6 +36,9 @@ var (
 	// ErrInconsistentValidatorSet = errors.New("non empty uncle hash")
 	// ErrInvalidTimestamp is returned if the timestamp of a block is lower than the previous block's timestamp + the minimum block period.
 	ErrInvalidTimestamp = errors.New("invalid timestamp")
+	// ErrInvalidEmptyBlockTimestamp is returned if the timestamp of a block without transactions
+	// is lower than the previous block's timestamp + the empty block period.
+	ErrInvalidEmptyBlockTimestamp = errors.New("invalid empty block timestamp")
 
 	// ErrInvalidVotingChain is returned if an authorization list is attempted to
 	// be modified via out-of-range or non-contiguous headers.
@@ -97,4 +100,8 @@ var (
 
 	// ErrBlacklistedHash is returned if a block to import is on the blacklist.
 	ErrBlacklistedHash = errors.New("blacklisted hash")
+
+	// ErrNoRewardValidators is returned if a block is rewarded in the split
+	// beneficiary mode, but the validator set of its parent is empty.
+	ErrNoRewardValidators = errors.New("no validators to share the block reward")
 )
@@ -17,8 +17,10 @@
 package istanbul
 
 import (
+	gomath "math"
 	"math/big"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
@@ -129,7 +131,7 @@ type Config struct {
 	Epoch                    uint64                `toml:",omitempty"` // The number of blocks after which to checkpoint and reset the pending votes
 	Ceil2Nby3Block           *big.Int              `toml:",omitempty"` // Number of confirmations required to move from one state to next [2F + 1 to Ceil(2N/3)]
 	AllowedFutureBlockTime   uint64                `toml:",omitempty"` // Max time (in seconds) from current time allowed for blocks, before they're considered future blocks
-	BeneficiaryMode          *string               `toml:",omitempty"` // Mode for setting the beneficiary, either: list, besu, validators (beneficiary list is the list of validators)
+	BeneficiaryMode          *string               `toml:",omitempty"` // Mode for setting the beneficiary, either: fixed, validator, split (shared by the validator set of the block)
 	BlockReward              *math.HexOrDecimal256 `toml:",omitempty"` // Reward
 	MiningBeneficiary        *common.Address       `toml:",omitempty"` // Wallet address that benefits at every new block (besu mode)
 	Validators               []common.Address      `toml:",omitempty"`
@@ -149,6 +151,45 @@ var DefaultConfig = &Config{
 	AllowedFutureBlockTime: 0,
 }
 
+// NewConfig creates the Istanbul engine configuration from the QBFT settings and
+// the transitions of the given chain config.
+func NewConfig(chainConfig *params.ChainConfig) *Config {
+	config := *DefaultConfig
+	config.ProposerPolicy = NewProposerPolicy(DefaultConfig.ProposerPolicy.Id)
+	config.Transitions = chainConfig.Transitions
+
+	qbft := chainConfig.QBFT
+	if qbft == nil {
+		return &config
+	}
+	if qbft.EpochLength != 0 {
+		config.Epoch = qbft.EpochLength
+	}
+	if qbft.BlockPeriodSeconds != 0 {
+		config.BlockPeriod = qbft.BlockPeriodSeconds
+	}
+	if qbft.EmptyBlockPeriodSeconds != nil {
+		config.EmptyBlockPeriod = *qbft.EmptyBlockPeriodSeconds
+	}
+	if qbft.RequestTimeoutSeconds != 0 {
+		// RequestTimeout is on milliseconds
+		config.RequestTimeout = qbft.RequestTimeoutSeconds * 1000
+	}
+	if qbft.MaxRequestTimeoutSeconds != nil {
+		config.MaxRequestTimeoutSeconds = *qbft.MaxRequestTimeoutSeconds
+	}
+	if qbft.Ceil2Nby3Block != nil {
+		config.Ceil2Nby3Block = qbft.Ceil2Nby3Block
+	}
+	config.ProposerPolicy = NewProposerPolicy(ProposerPolicyId(qbft.ProposerPolicy))
+	config.BeneficiaryMode = qbft.BeneficiaryMode
+	config.BlockReward = qbft.BlockReward
+	config.MiningBeneficiary = qbft.MiningBeneficiary
+	config.ValidatorSelectionMode = qbft.ValidatorSelectionMode
+	config.Validators = qbft.Validators
+	return &config
+}
+
 func (c Config) GetConfig(blockNumber *big.Int) Config {
 	newConfig := c
 
@@ -189,6 +230,29 @@ func (c Config) GetConfig(blockNumber *big.Int) Config {
 	return newConfig
 }
 
+// RoundTimeout returns the timeout of the given round, doubling the request
+// timeout at each round up to the max request timeout if there is one.
+func (c Config) RoundTimeout(round uint64) time.Duration {
+	baseTimeout := time.Duration(c.RequestTimeout) * time.Millisecond
+	maxRequestTimeout := time.Duration(c.MaxRequestTimeoutSeconds) * time.Second
+
+	// If the upper limit of the request timeout is capped by small maxRequestTimeout, round can be a quite large number,
+	// which leads to float64 overflow, making its value negative or zero forever after some point.
+	// In this case we cannot simply use math.Pow and have to implement a safeguard on our own, at the cost of performance (which is not important in this case).
+	if maxRequestTimeout > time.Duration(0) {
+		timeout := baseTimeout
+		for i := uint64(0); i < round; i++ {
+			timeout = timeout * 2
+			if timeout > maxRequestTimeout || timeout < baseTimeout {
+				return maxRequestTimeout
+			}
+		}
+		return timeout
+	}
+	// effectively impossible to observe overflow happen when maxRequestTimeout is disabled
+	return baseTimeout * time.Duration(gomath.Pow(2, float64(round)))
+}
+
 func (c Config) GetValidatorSelectionMode(blockNumber *big.Int) string {
 	mode := params.BlockHeaderMode
 	if c.ValidatorSelectionMode != nil {
@@ -20,6 +20,7 @@ import (
 	"math/big"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/naoina/toml"
 	"github.com/stretchr/testify/assert"
@@ -82,3 +83,26 @@ func TestGetConfig(t *testing.T) {
 		}
 	}
 }
+
+func TestRoundTimeout(t *testing.T) {
+	capped := Config{RequestTimeout: 3000, MaxRequestTimeoutSeconds: 20}
+	uncapped := Config{RequestTimeout: 3000}
+
+	tests := []struct {
+		config Config
+		round  uint64
+		want   time.Duration
+	}{
+		{capped, 0, 3 * time.Second},
+		{capped, 2, 12 * time.Second},
+		{capped, 3, 20 * time.Second},
+		{capped, 1000, 20 * time.Second},
+		{uncapped, 0, 3 * time.Second},
+		{uncapped, 4, 48 * time.Second},
+	}
+	for _, test := range tests {
+		if have := test.config.RoundTimeout(test.round); have != test.want {
+			t.Errorf("round %d timeout mismatch (max %ds): have %v, want %v", test.round, test.config.MaxRequestTimeoutSeconds, have, test.want)
+		}
+	}
+}
@@ -10,4 +10,14 @@ var (
 	ErrStoppedEngine = errors.New("stopped engine")
 	// ErrStartedEngine is returned if the engine is already started
 	ErrStartedEngine = errors.New("started engine")
+	// ErrUnsignedProposal is returned if a validator proposal carries no signature
+	ErrUnsignedProposal = errors.New("unsigned proposal")
+	// ErrDuplicateProposalSigner is returned if a validator signed a proposal more than once
+	ErrDuplicateProposalSigner = errors.New("duplicate proposal signer")
+	// ErrProposalNoChainID is returned if a validator proposal isn't bound to a network
+	ErrProposalNoChainID = errors.New("proposal has no chain id")
+	// ErrProposalNoExpiry is returned if a validator proposal has no expiry block
+	ErrProposalNoExpiry = errors.New("proposal has no expiry block")
+	// ErrProposalChainMismatch is returned if a validator proposal is for another network
+	ErrProposalChainMismatch = errors.New("proposal chain id mismatch")
 )
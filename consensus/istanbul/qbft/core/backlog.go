@@ -181,7 +181,7 @@ func (c *core) processBacklog() {
 			logger.Trace("QBFT: post backlog event", "msg", m)
 
 			event.src = src
-			go c.sendEvent(event)
+			c.postEvent(event)
 		}
 	}
 }
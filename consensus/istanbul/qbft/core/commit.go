@@ -40,7 +40,7 @@ func (c *core) broadcastCommit() {
 		header = block.Header()
 	}
 	// Create Commit Seal
-	commitSeal, err := c.backend.SignWithoutHashing(PrepareCommittedSeal(header, uint32(c.currentView().Round.Uint64())))
+	commitSeal, err := c.backend.SignCommittedSeal(header, uint32(c.currentView().Round.Uint64()))
 	if err != nil {
 		logger.Error("QBFT: failed to create COMMIT seal", "sub", sub, "err", err)
 		return
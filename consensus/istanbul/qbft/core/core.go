@@ -23,10 +23,12 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/common/prque"
 	"github.com/ethereum/go-ethereum/consensus/istanbul"
 	qbfttypes "github.com/ethereum/go-ethereum/consensus/istanbul/qbft/types"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	metrics "github.com/ethereum/go-ethereum/metrics"
@@ -38,10 +40,13 @@ var (
 	consensusTimer = metrics.NewRegisteredTimer("consensus/istanbul/core/consensus", nil)
 )
 
-// New creates an Istanbul consensus core
-func New(backend istanbul.Backend, config *istanbul.Config) istanbul.Core {
+// New creates an Istanbul consensus core. The round state is persisted into the
+// given database, to resume the consensus safely after a restart.
+func New(backend istanbul.Backend, config *istanbul.Config, db ethdb.KeyValueStore) istanbul.Core {
 	c := &core{
 		config:             config,
+		db:                 db,
+		clock:              mclock.System{},
 		address:            backend.Address(),
 		state:              StateAcceptRequest,
 		handlerWg:          new(sync.WaitGroup),
@@ -62,6 +67,8 @@ func New(backend istanbul.Backend, config *istanbul.Config) istanbul.Core {
 
 type core struct {
 	config  *istanbul.Config
+	db      ethdb.KeyValueStore // Database of the safety critical round state
+	clock   mclock.Clock        // Time source of the round and proposal timers
 	address common.Address
 	state   State
 	logger  log.Logger
@@ -70,10 +77,11 @@ type core struct {
 	events                *event.TypeMuxSubscription
 	finalCommittedSub     *event.TypeMuxSubscription
 	timeoutSub            *event.TypeMuxSubscription
-	futurePreprepareTimer *time.Timer
+	futurePreprepareTimer mclock.Timer
 
-	valSet     istanbul.ValidatorSet
-	validateFn func([]byte, []byte) (common.Address, error)
+	valSet      istanbul.ValidatorSet
+	validateFn  func([]byte, []byte) (common.Address, error)
+	sendEventFn func(ev interface{}) // Replaces the event mux in the simulator, if set
 
 	backlogs   map[common.Address]*prque.Prque[int64, qbfttypes.QBFTMessage]
 	backlogsMu *sync.Mutex
@@ -83,7 +91,7 @@ type core struct {
 	handlerWg    *sync.WaitGroup
 
 	roundChangeSet   *roundChangeSet
-	roundChangeTimer *time.Timer
+	roundChangeTimer mclock.Timer
 
 	QBFTPreparedPrepares []*qbfttypes.Prepare
 
@@ -93,7 +101,7 @@ type core struct {
 	consensusTimestamp time.Time
 
 	newRoundMutex sync.Mutex
-	newRoundTimer *time.Timer
+	newRoundTimer mclock.Timer
 }
 
 func (c *core) currentView() *istanbul.View {
@@ -117,6 +125,14 @@ func (c *core) IsCurrentProposal(blockHash common.Hash) bool {
 
 // startNewRound starts a new round. if round equals to 0, it means to starts a new sequence
 func (c *core) startNewRound(round *big.Int) {
+	c.enterRound(round, nil)
+}
+
+// enterRound moves to the given round of the current sequence, or to the next
+// sequence if a new block was committed. When starting the next sequence, the
+// round and the prepared certificate of a matching resumed state are restored
+// before anything is persisted, so the stored state never goes backwards.
+func (c *core) enterRound(round *big.Int, resumed *storedRoundState) {
 	c.currentMutex.Lock()
 	defer c.currentMutex.Unlock()
 
@@ -184,10 +200,27 @@ func (c *core) startNewRound(round *big.Int) {
 			Round:    new(big.Int),
 		}
 		c.valSet = c.backend.Validators(lastProposal)
+
+		if resumed != nil && resumed.Sequence != newView.Sequence.Uint64() {
+			resumed = nil
+		}
+		if resumed != nil {
+			newView.Round.SetUint64(resumed.Round + 1)
+			round = newView.Round
+		}
 	}
 
 	// New snapshot for new round
 	c.updateRoundState(newView, c.valSet, roundChange)
+	if resumed != nil {
+		c.roundChangeSet = newRoundChangeSet(c.valSet)
+		c.QBFTPreparedPrepares = nil
+		if resumed.PreparedBlock != nil {
+			c.current.preparedRound = new(big.Int).SetUint64(resumed.PreparedRound)
+			c.current.preparedBlock = resumed.PreparedBlock
+			c.QBFTPreparedPrepares = resumed.Prepares
+		}
+	}
 
 	// Calculate new proposer
 	c.valSet.CalcProposer(lastProposer, newView.Round.Uint64())
@@ -207,6 +240,9 @@ func (c *core) startNewRound(round *big.Int) {
 	}
 	c.roundChangeSet.NewRound(round)
 
+	// Persist the new round before sending any message in it
+	c.storeRoundState()
+
 	if round.Uint64() > 0 {
 		c.newRoundChangeTimer()
 	}
@@ -263,38 +299,10 @@ func (c *core) newRoundChangeTimer() {
 	}
 
 	// set timeout based on the round number
-	baseTimeout := time.Duration(c.config.GetConfig(c.current.Sequence()).RequestTimeout) * time.Millisecond
-	round := c.current.Round().Uint64()
-	maxRequestTimeout := time.Duration(c.config.GetConfig(c.current.Sequence()).MaxRequestTimeoutSeconds) * time.Second
-
-	// If the upper limit of the request timeout is capped by small maxRequestTimeout, round can be a quite large number,
-	// which leads to float64 overflow, making its value negative or zero forever after some point.
-	// In this case we cannot simply use math.Pow and have to implement a safeguard on our own, at the cost of performance (which is not important in this case).
-	var timeout time.Duration
-	if maxRequestTimeout > time.Duration(0) {
-		timeout = baseTimeout
-		for i := uint64(0); i < round; i++ {
-			timeout = timeout * 2
-			if timeout > maxRequestTimeout {
-				timeout = maxRequestTimeout
-				break
-			}
-		}
-		// prevent log storm when unexpected overflow happens
-		if timeout < baseTimeout {
-			c.currentLogger(true, nil).Error("QBFT: Possible request timeout overflow detected, setting timeout value to maxRequestTimeout",
-				"timeout", timeout.Seconds(),
-				"max_request_timeout", maxRequestTimeout.Seconds(),
-			)
-			timeout = maxRequestTimeout
-		}
-	} else {
-		// effectively impossible to observe overflow happen when maxRequestTimeout is disabled
-		timeout = baseTimeout * time.Duration(math.Pow(2, float64(round)))
-	}
+	timeout := c.config.GetConfig(c.current.Sequence()).RoundTimeout(c.current.Round().Uint64())
 
 	c.currentLogger(true, nil).Trace("QBFT: start new ROUND-CHANGE timer", "timeout", timeout.Seconds())
-	c.roundChangeTimer = time.AfterFunc(timeout, func() {
+	c.roundChangeTimer = c.clock.AfterFunc(timeout, func() {
 		c.sendEvent(timeoutEvent{})
 	})
 }
@@ -33,12 +33,13 @@ func (c *core) Start() error {
 	// Tests will handle events itself, so we have to make subscribeEvents()
 	// be able to call in test.
 	c.subscribeEvents()
+
+	// Start a new round from last sequence + 1, before handling any message
+	c.resume()
+
 	c.handlerWg.Add(1)
 	go c.handleEvents()
 
-	// Start a new round from last sequence + 1
-	c.startNewRound(common.Big0)
-
 	return nil
 }
 
@@ -104,67 +105,86 @@ func (c *core) handleEvents() {
 			if !ok {
 				return
 			}
-
 			// A real event arrived, process interesting content
-			switch ev := event.Data.(type) {
-			case istanbul.RequestEvent:
-				// we are block proposer and look to get our block proposal validated by other validators
-				r := &Request{
-					Proposal: ev.Proposal,
-				}
-				err := c.handleRequest(r)
-				if err == errFutureMessage {
-					// store request for later treatment
-					c.storeRequestMsg(r)
-				}
-			case istanbul.MessageEvent:
-				// we received a message from another validator
-				if err := c.handleEncodedMsg(ev.Code, ev.Payload); err != nil {
-					continue
-				}
-
-				// if successfully processed, we gossip message to other validators
-				c.backend.Gossip(c.valSet, ev.Code, ev.Payload)
-			case backlogEvent:
-				// we process again a future message that was backlogged
-				// no need to check signature as it was already node when we first received message
-				if err := c.handleDecodedMessage(ev.msg); err != nil {
-					continue
-				}
-
-				data, err := rlp.EncodeToBytes(ev.msg)
-				if err != nil {
-					c.logger.Error("QBFT: can not encode backlog message", "err", err)
-					continue
-				}
-
-				// if successfully processed, we gossip message to other validators
-				c.backend.Gossip(c.valSet, ev.msg.Code(), data)
-			}
-		case _, ok := <-c.timeoutSub.Chan():
+			c.handleEvent(event.Data)
+		case event, ok := <-c.timeoutSub.Chan():
 			// we received a round change timeout
 			if !ok {
 				return
 			}
-			c.handleTimeoutMsg()
+			c.handleEvent(event.Data)
 		case event, ok := <-c.finalCommittedSub.Chan():
 			// our block proposal got committed
 			if !ok {
 				return
 			}
-			switch event.Data.(type) {
-			case istanbul.FinalCommittedEvent:
-				c.handleFinalCommitted()
-			}
+			c.handleEvent(event.Data)
 		}
 	}
 }
 
+// handleEvent processes a single event of the main handler loop.
+func (c *core) handleEvent(event interface{}) {
+	switch ev := event.(type) {
+	case istanbul.RequestEvent:
+		// we are block proposer and look to get our block proposal validated by other validators
+		r := &Request{
+			Proposal: ev.Proposal,
+		}
+		err := c.handleRequest(r)
+		if err == errFutureMessage {
+			// store request for later treatment
+			c.storeRequestMsg(r)
+		}
+	case istanbul.MessageEvent:
+		// we received a message from another validator
+		if err := c.handleEncodedMsg(ev.Code, ev.Payload); err != nil {
+			return
+		}
+
+		// if successfully processed, we gossip message to other validators
+		c.backend.Gossip(c.valSet, ev.Code, ev.Payload)
+	case backlogEvent:
+		// we process again a future message that was backlogged
+		// no need to check signature as it was already node when we first received message
+		if err := c.handleDecodedMessage(ev.msg); err != nil {
+			return
+		}
+
+		data, err := rlp.EncodeToBytes(ev.msg)
+		if err != nil {
+			c.logger.Error("QBFT: can not encode backlog message", "err", err)
+			return
+		}
+
+		// if successfully processed, we gossip message to other validators
+		c.backend.Gossip(c.valSet, ev.msg.Code(), data)
+	case timeoutEvent:
+		c.handleTimeoutMsg()
+	case istanbul.FinalCommittedEvent:
+		c.handleFinalCommitted()
+	}
+}
+
 // sendEvent sends events to mux
 func (c *core) sendEvent(ev interface{}) {
+	if c.sendEventFn != nil {
+		c.sendEventFn(ev)
+		return
+	}
 	c.backend.EventMux().Post(ev)
 }
 
+// postEvent sends events to mux without blocking, for the events sent from the
+// main handler loop, which has to receive them itself.
+func (c *core) postEvent(ev interface{}) {
+	if c.sendEventFn != nil {
+		c.sendEventFn(ev)
+		return
+	}
+	go c.sendEvent(ev)
+}
+
 func (c *core) handleEncodedMsg(code uint64, data []byte) error {
 	logger := c.logger.New("code", code, "data", data)
 
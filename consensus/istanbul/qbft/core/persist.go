@@ -0,0 +1,102 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	qbfttypes "github.com/ethereum/go-ethereum/consensus/istanbul/qbft/types"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// dbKeyRoundStatePrefix is the database key prefix of the round state, followed
+// by the address of the validator.
+var dbKeyRoundStatePrefix = []byte("istanbul-round-state")
+
+// storedRoundState is the safety critical part of the consensus state. It is
+// written before sending any message depending on it, so a validator restarted
+// after a crash neither sends conflicting messages in a round it already took
+// part in, nor loses the justification of the block it prepared.
+type storedRoundState struct {
+	Sequence      uint64
+	Round         uint64
+	PreparedRound uint64
+	PreparedBlock *types.Block         `rlp:"nil"` // Prepared block, nil if not prepared
+	Prepares      []*qbfttypes.Prepare // Quorum of PREPARE messages for the prepared block
+}
+
+func roundStateKey(validator common.Address) []byte {
+	return append(append([]byte{}, dbKeyRoundStatePrefix...), validator.Bytes()...)
+}
+
+// readRoundState loads the round state of the validator from the database. It
+// returns nil if no state was stored.
+func readRoundState(db ethdb.KeyValueReader, validator common.Address) (*storedRoundState, error) {
+	blob, err := db.Get(roundStateKey(validator))
+	if err != nil || len(blob) == 0 {
+		return nil, nil
+	}
+	state := new(storedRoundState)
+	if err := rlp.DecodeBytes(blob, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// storeRoundState writes the current round and the prepared certificate to the
+// database. The node stops if it can't, as continuing could make it equivocate
+// after a restart.
+func (c *core) storeRoundState() {
+	state := &storedRoundState{
+		Sequence: c.current.Sequence().Uint64(),
+		Round:    c.current.Round().Uint64(),
+	}
+	if c.current.preparedRound != nil && c.current.preparedBlock != nil {
+		state.PreparedRound = c.current.preparedRound.Uint64()
+		state.PreparedBlock = c.current.preparedBlock.(*types.Block)
+		state.Prepares = c.QBFTPreparedPrepares
+	}
+	blob, err := rlp.EncodeToBytes(state)
+	if err != nil {
+		log.Crit("Failed to encode QBFT round state", "err", err)
+	}
+	if err := c.db.Put(roundStateKey(c.address), blob); err != nil {
+		log.Crit("Failed to store QBFT round state", "err", err)
+	}
+}
+
+// resume starts the consensus from the last sequence + 1. If the validator has
+// already taken part in that sequence before a restart, it restores its prepared
+// certificate and enters the round after the stored one directly, so it never
+// sends messages twice in a round. It must run before the event loop starts.
+func (c *core) resume() {
+	stored, err := readRoundState(c.db, c.address)
+	if err != nil {
+		c.logger.Error("QBFT: failed to load stored round state", "err", err)
+		stored = nil
+	}
+	c.enterRound(common.Big0, stored)
+
+	round := c.current.Round()
+	if round.Sign() == 0 {
+		return
+	}
+	c.currentLogger(false, nil).Info("QBFT: resume after restart", "stored.round", stored.Round, "prepared.round", c.current.preparedRound, "next.round", round)
+	c.broadcastRoundChange(round)
+}
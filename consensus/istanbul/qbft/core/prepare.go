@@ -108,6 +108,8 @@ func (c *core) handlePrepare(prepare *qbfttypes.Prepare) error {
 			logger.Debug("QBFT: PREPARE message matches proposal", "proposal", c.current.Proposal().Hash(), "prepare", prepare.Digest)
 			c.current.preparedBlock = c.current.Proposal()
 		}
+		// Persist the prepared certificate before committing to it
+		c.storeRoundState()
 
 		c.setState(StatePrepared)
 		c.broadcastCommit()
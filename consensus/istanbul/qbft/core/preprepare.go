@@ -134,7 +134,7 @@ func (c *core) handlePreprepareMsg(preprepare *qbfttypes.Preprepare) error {
 
 			// start a timer to re-input PRE-PREPARE message as a backlog event
 			c.stopFuturePreprepareTimer()
-			c.futurePreprepareTimer = time.AfterFunc(duration, func() {
+			c.futurePreprepareTimer = c.clock.AfterFunc(duration, func() {
 				_, validator := c.valSet.GetByAddress(preprepare.Source())
 				c.sendEvent(backlogEvent{
 					src: validator,
@@ -79,7 +79,7 @@ func (c *core) handleRequest(request *Request) error {
 				}
 			}
 			if delay > 0 {
-				c.newRoundTimer = time.AfterFunc(delay, func() {
+				c.newRoundTimer = c.clock.AfterFunc(delay, func() {
 					c.newRoundTimer = nil
 					// Start ROUND-CHANGE timer
 					c.newRoundChangeTimer()
@@ -153,7 +153,7 @@ func (c *core) processPendingRequests() {
 		}
 		logger.Debug("QBFT: found pending block proposal request", "proposal.number", r.Proposal.Number(), "proposal.hash", r.Proposal.Hash())
 
-		go c.sendEvent(istanbul.RequestEvent{
+		c.postEvent(istanbul.RequestEvent{
 			Proposal: r.Proposal,
 		})
 	}
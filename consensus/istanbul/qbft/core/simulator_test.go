@@ -0,0 +1,857 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	qbfttypes "github.com/ethereum/go-ethereum/consensus/istanbul/qbft/types"
+	"github.com/ethereum/go-ethereum/consensus/istanbul/validator"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	simStep         = 10 * time.Millisecond // Granularity the simulated clock is advanced with
+	simSyncInterval = time.Second           // Interval of the chain sync between the nodes
+)
+
+// simFaults configures the faults injected into the network before GST.
+type simFaults struct {
+	MinDelay  time.Duration // Minimum delay of a message
+	MaxDelay  time.Duration // Maximum delay of a message, random delays reorder messages
+	DropRate  float64       // Probability of a message being lost
+	CrashRate float64       // Probability of a validator crashing right after sending a message
+	Downtime  time.Duration // Maximum time a crashed validator takes to restart
+}
+
+// simCommit is a block committed by the consensus of a node.
+type simCommit struct {
+	Time   mclock.AbsTime
+	Node   int
+	Number uint64
+	Hash   common.Hash
+	Round  uint64
+}
+
+// simNetwork runs a set of QBFT cores in a single process, connected by an
+// in-memory message bus. All cores share a simulated clock, and every event of
+// a core, including the delivery of messages, is run by that clock one at a
+// time. The outcome of a simulation is thus fully determined by the scenario
+// and the seed of the random source.
+//
+// Before the global stabilization time (GST), messages are delayed, reordered,
+// dropped or cut off by partitions as configured. Afterwards, every message is
+// delivered within the synchronous delay bound.
+type simNetwork struct {
+	clock      *mclock.Simulated
+	rand       *rand.Rand
+	nodes      []*simNode
+	validators []common.Address
+	genesis    *types.Block
+
+	faults    simFaults
+	blocked   map[uint64]bool // Consensus message codes the bus drops
+	partition []int           // Partition of each node, messages only pass within one
+	gst       mclock.AbsTime  // Global stabilization time
+	delta     time.Duration   // Maximum message delay after GST
+
+	commits    []simCommit             // Trace of all commits in order
+	canonical  map[uint64]common.Hash  // First block committed at each height
+	votes      map[simVote]common.Hash // Digest of each message sent by a validator
+	violations []string                // Safety violations detected
+}
+
+// simVote identifies a message a validator may send once per round.
+type simVote struct {
+	Validator common.Address
+	Code      uint64
+	Sequence  uint64
+	Round     uint64
+}
+
+// simNode is a QBFT validator of the simulated network. It implements the
+// istanbul.Backend on top of a trivial chain of the committed blocks.
+type simNode struct {
+	net     *simNetwork
+	index   int
+	key     *ecdsa.PrivateKey
+	address common.Address
+	config  *istanbul.Config
+	db      ethdb.KeyValueStore
+	core    *core
+	mux     *event.TypeMux
+
+	chain    []*types.Block           // Imported blocks, indexed by number
+	requests uint64                   // Number of blocks built
+	known    map[common.Hash]struct{} // Consensus messages received or sent already
+	crashed  bool
+}
+
+// newSimNetwork creates a network of n validators. The validator keys are
+// derived from their index, so the network is the same for each run.
+func newSimNetwork(n int, seed int64) *simNetwork {
+	net := &simNetwork{
+		clock:     new(mclock.Simulated),
+		rand:      rand.New(rand.NewSource(seed)),
+		partition: make([]int, n),
+		delta:     100 * time.Millisecond,
+		blocked:   make(map[uint64]bool),
+		canonical: make(map[uint64]common.Hash),
+		votes:     make(map[simVote]common.Hash),
+	}
+	keys := make([]*ecdsa.PrivateKey, n)
+	for i := range keys {
+		keys[i], _ = crypto.ToECDSA(crypto.Keccak256(big.NewInt(int64(i + 1)).Bytes()))
+		net.validators = append(net.validators, crypto.PubkeyToAddress(keys[i].PublicKey))
+	}
+	net.genesis = types.NewBlockWithHeader(net.header(common.Hash{}, 0, 0, common.Address{}, 0))
+
+	for i, key := range keys {
+		config := *istanbul.DefaultConfig
+		config.RequestTimeout = 1000
+		config.MaxRequestTimeoutSeconds = 8
+		config.ProposerPolicy = istanbul.NewRoundRobinProposerPolicy()
+
+		node := &simNode{
+			net:     net,
+			index:   i,
+			key:     key,
+			address: net.validators[i],
+			config:  &config,
+			db:      &simDB{KeyValueStore: memorydb.New()},
+			mux:     new(event.TypeMux),
+			chain:   []*types.Block{net.genesis},
+		}
+		node.db.(*simDB).node = node
+		node.init()
+		net.nodes = append(net.nodes, node)
+	}
+	return net
+}
+
+// header creates a block header sealed by the validators of the network. The
+// nonce is stored in the vanity, to tell apart the blocks built by a node.
+func (net *simNetwork) header(parent common.Hash, number uint64, time uint64, author common.Address, nonce uint64) *types.Header {
+	vanity := make([]byte, types.IstanbulExtraVanity)
+	binary.BigEndian.PutUint64(vanity, nonce)
+
+	extra, _ := rlp.EncodeToBytes(&types.QBFTExtra{
+		VanityData:    vanity,
+		Validators:    net.validators,
+		CommittedSeal: [][]byte{},
+	})
+	return &types.Header{
+		ParentHash: parent,
+		Coinbase:   author,
+		Number:     new(big.Int).SetUint64(number),
+		Time:       time,
+		Difficulty: big.NewInt(1),
+		Extra:      extra,
+		MixDigest:  types.IstanbulDigest,
+	}
+}
+
+// quorum returns the number of commit seals a block needs.
+func (net *simNetwork) quorum() int {
+	return int(math.Ceil(float64(2*len(net.validators)) / 3))
+}
+
+// start starts the consensus on all nodes, and the periodic chain sync.
+func (net *simNetwork) start() {
+	for _, node := range net.nodes {
+		node.start()
+	}
+}
+
+// setFaults configures the faults injected until the given GST.
+func (net *simNetwork) setFaults(faults simFaults, gst time.Duration) {
+	net.faults = faults
+	net.gst = net.clock.Now().Add(gst)
+}
+
+// split partitions the network, assigning each node the given group.
+func (net *simNetwork) split(groups ...int) {
+	copy(net.partition, groups)
+}
+
+// heal removes all partitions.
+func (net *simNetwork) heal() {
+	for i := range net.partition {
+		net.partition[i] = 0
+	}
+}
+
+// send delivers a message from one node to another over the bus, subject to the
+// faults configured before GST.
+func (net *simNetwork) send(from, to *simNode, deliver func()) {
+	var delay time.Duration
+	if net.clock.Now() < net.gst {
+		if net.partition[from.index] != net.partition[to.index] {
+			return
+		}
+		if net.rand.Float64() < net.faults.DropRate {
+			return
+		}
+		delay = net.faults.MinDelay
+		if spread := net.faults.MaxDelay - net.faults.MinDelay; spread > 0 {
+			delay += time.Duration(net.rand.Int63n(int64(spread)))
+		}
+	} else {
+		delay = time.Duration(net.rand.Int63n(int64(net.delta)))
+	}
+	net.clock.AfterFunc(delay, func() {
+		if !to.crashed {
+			deliver()
+		}
+	})
+}
+
+// run advances the simulated clock by d, or until the condition is met.
+func (net *simNetwork) run(d time.Duration, done func() bool) bool {
+	end := net.clock.Now().Add(d)
+	for net.clock.Now() < end {
+		if done != nil && done() {
+			return true
+		}
+		net.clock.Run(simStep)
+	}
+	return done != nil && done()
+}
+
+// minHeight returns the lowest chain height of the nodes which haven't crashed.
+func (net *simNetwork) minHeight() uint64 {
+	height := uint64(math.MaxUint64)
+	for _, node := range net.nodes {
+		if !node.crashed && node.height() < height {
+			height = node.height()
+		}
+	}
+	return height
+}
+
+// violation records a safety violation.
+func (net *simNetwork) violation(format string, args ...interface{}) {
+	net.violations = append(net.violations, fmt.Sprintf("%v: ", time.Duration(net.clock.Now()))+fmt.Sprintf(format, args...))
+}
+
+// simDB is the database of a node, which loses the writes issued after the node
+// crashed in the middle of handling an event.
+type simDB struct {
+	ethdb.KeyValueStore
+	node  *simNode
+	onPut func(key []byte, value []byte) // Optional hook observing the writes
+}
+
+func (db *simDB) Put(key []byte, value []byte) error {
+	if db.node.crashed {
+		return nil
+	}
+	if db.onPut != nil {
+		db.onPut(key, value)
+	}
+	return db.KeyValueStore.Put(key, value)
+}
+
+// init creates a fresh core on the node's database, dropping all state kept
+// in memory only.
+func (n *simNode) init() {
+	c := New(n, n.config, n.db).(*core)
+	c.clock = n.net.clock
+
+	// Deliver the internal events after the current one like the event loop,
+	// unless the core has been replaced by a restart. The events sent at once
+	// are delivered in a fixed order, as the backlogs are replayed in the order
+	// of a map.
+	var pending []interface{}
+	c.sendEventFn = func(ev interface{}) {
+		if len(pending) == 0 {
+			n.net.clock.AfterFunc(0, func() {
+				events := pending
+				pending = nil
+				slices.SortStableFunc(events, compareSimEvents)
+				for _, ev := range events {
+					if n.core == c {
+						n.handle(ev)
+					}
+				}
+			})
+		}
+		pending = append(pending, ev)
+	}
+	n.core = c
+	n.known = make(map[common.Hash]struct{})
+}
+
+// compareSimEvents orders the internal events of a core, placing the backlogged
+// messages after the other events, sorted by their source.
+func compareSimEvents(a, b interface{}) int {
+	evA, backlogA := a.(backlogEvent)
+	evB, backlogB := b.(backlogEvent)
+	switch {
+	case backlogA && backlogB:
+		return evA.src.Address().Cmp(evB.src.Address())
+	case backlogA:
+		return 1
+	case backlogB:
+		return -1
+	}
+	return 0
+}
+
+// start starts the consensus and the periodic chain sync of the node.
+func (n *simNode) start() {
+	n.core.resume()
+	n.request()
+	n.scheduleSync(n.core)
+}
+
+// post delivers an event to the core through the clock, so that it's handled
+// after the current event like in the event loop.
+func (n *simNode) post(ev interface{}) {
+	n.net.clock.AfterFunc(0, func() { n.handle(ev) })
+}
+
+// handle runs a single event on the core.
+func (n *simNode) handle(ev interface{}) {
+	if !n.crashed {
+		n.core.handleEvent(ev)
+	}
+}
+
+// crash stops the node, it doesn't take part in the network anymore.
+func (n *simNode) crash() {
+	n.crashed = true
+	n.core.stopTimer()
+}
+
+// restart brings a crashed node back, with the chain and the round state it
+// persisted.
+func (n *simNode) restart() {
+	if !n.crashed {
+		return
+	}
+	n.crashed = false
+	n.init()
+	n.start()
+}
+
+func (n *simNode) head() *types.Block {
+	return n.chain[len(n.chain)-1]
+}
+
+func (n *simNode) height() uint64 {
+	return n.head().NumberU64()
+}
+
+// request hands a new block on top of the head to the core, like the miner does
+// whenever the chain head changes.
+func (n *simNode) request() {
+	head := n.head()
+	n.requests++
+	header := n.net.header(head.Hash(), head.NumberU64()+1, head.Time()+1, n.address, n.requests)
+	n.post(istanbul.RequestEvent{Proposal: types.NewBlockWithHeader(header)})
+}
+
+// importBlock appends the block to the chain if it extends the head, checking
+// that it doesn't conflict with an imported one otherwise.
+func (n *simNode) importBlock(block *types.Block) bool {
+	number := block.NumberU64()
+	switch {
+	case number <= n.height():
+		if have := n.chain[number].Hash(); have != block.Hash() {
+			n.net.violation("node %d: block %d %x conflicts with imported %x", n.index, number, block.Hash(), have)
+		}
+		return false
+	case number == n.height()+1 && block.ParentHash() == n.head().Hash():
+		n.chain = append(n.chain, block)
+		return true
+	default:
+		return false
+	}
+}
+
+// advance notifies the core about a new chain head and proposes on top of it.
+func (n *simNode) advance() {
+	n.post(istanbul.FinalCommittedEvent{})
+	n.request()
+	n.announce()
+}
+
+// announce sends the chain to the peers, which import any blocks they miss, as
+// the chain sync does.
+func (n *simNode) announce() {
+	for _, peer := range n.net.nodes {
+		if peer != n {
+			peer := peer
+			n.net.send(n, peer, func() { peer.sync(n) })
+		}
+	}
+}
+
+// sync imports the blocks of the peer beyond the own head.
+func (n *simNode) sync(peer *simNode) {
+	var imported bool
+	for number := n.height() + 1; number <= peer.height(); number++ {
+		if !n.importBlock(peer.chain[number]) {
+			break
+		}
+		imported = true
+	}
+	if imported {
+		n.advance()
+	}
+}
+
+// scheduleSync periodically announces the chain to the peers, until the node
+// crashes or restarts with a new core.
+func (n *simNode) scheduleSync(c *core) {
+	n.net.clock.AfterFunc(simSyncInterval, func() {
+		if !n.crashed && n.core == c {
+			n.announce()
+			n.scheduleSync(c)
+		}
+	})
+}
+
+// receive handles a consensus message arriving from the network.
+func (n *simNode) receive(code uint64, payload []byte) {
+	hash := istanbul.RLPHash(payload)
+	if _, ok := n.known[hash]; ok {
+		return
+	}
+	n.known[hash] = struct{}{}
+	n.handle(istanbul.MessageEvent{Code: code, Payload: payload})
+}
+
+func (n *simNode) Address() common.Address {
+	return n.address
+}
+
+func (n *simNode) Validators(proposal istanbul.Proposal) istanbul.ValidatorSet {
+	return validator.NewSet(n.net.validators, n.config.ProposerPolicy)
+}
+
+func (n *simNode) ParentValidators(proposal istanbul.Proposal) istanbul.ValidatorSet {
+	return validator.NewSet(n.net.validators, n.config.ProposerPolicy)
+}
+
+func (n *simNode) EventMux() *event.TypeMux {
+	return n.mux
+}
+
+// Broadcast sends a message of the node itself, checking that it doesn't
+// conflict with an earlier message sent in the same round.
+func (n *simNode) Broadcast(valSet istanbul.ValidatorSet, code uint64, payload []byte) error {
+	if n.crashed {
+		return nil
+	}
+	if msg, err := qbfttypes.Decode(code, payload); err == nil && code != qbfttypes.RoundChangeCode {
+		var digest common.Hash
+		switch msg := msg.(type) {
+		case *qbfttypes.Preprepare:
+			digest = msg.Proposal.Hash()
+		case *qbfttypes.Prepare:
+			digest = msg.Digest
+		case *qbfttypes.Commit:
+			digest = msg.Digest
+		}
+		view := msg.View()
+		vote := simVote{n.address, code, view.Sequence.Uint64(), view.Round.Uint64()}
+		if have, ok := n.net.votes[vote]; ok && have != digest {
+			n.net.violation("node %d: equivocation in message %d of sequence %d round %d: %x, sent %x before", n.index, code, vote.Sequence, vote.Round, digest, have)
+		}
+		n.net.votes[vote] = digest
+	}
+	n.Gossip(valSet, code, payload)
+
+	// Crash before handling the own message, if it's the node's turn
+	if net := n.net; net.clock.Now() < net.gst && net.rand.Float64() < net.faults.CrashRate {
+		n.crash()
+		net.clock.AfterFunc(time.Duration(net.rand.Int63n(int64(net.faults.Downtime)+1)), n.restart)
+		return nil
+	}
+	n.net.clock.AfterFunc(0, func() {
+		n.handle(istanbul.MessageEvent{Code: code, Payload: payload})
+	})
+	return nil
+}
+
+func (n *simNode) Gossip(valSet istanbul.ValidatorSet, code uint64, payload []byte) error {
+	if n.crashed {
+		return nil
+	}
+	n.known[istanbul.RLPHash(payload)] = struct{}{}
+	if n.net.blocked[code] {
+		return nil
+	}
+	for _, peer := range n.net.nodes {
+		if peer != n {
+			peer := peer
+			n.net.send(n, peer, func() { peer.receive(code, payload) })
+		}
+	}
+	return nil
+}
+
+// Commit checks the commit seals and the agreement with the other nodes before
+// importing the block.
+func (n *simNode) Commit(proposal istanbul.Proposal, seals [][]byte, round *big.Int) error {
+	block := proposal.(*types.Block)
+	number := block.NumberU64()
+
+	signers := make(map[common.Address]bool)
+	sealHash := PrepareCommittedSeal(block.Header(), uint32(round.Uint64()))
+	for _, seal := range seals {
+		signer, err := istanbul.GetSignatureAddressNoHashing(sealHash, seal)
+		if err != nil || signers[signer] {
+			n.net.violation("node %d: invalid or duplicate seal on block %d: %v", n.index, number, err)
+			continue
+		}
+		signers[signer] = true
+	}
+	if len(signers) < n.net.quorum() {
+		n.net.violation("node %d: block %d committed with %d seals, want %d", n.index, number, len(signers), n.net.quorum())
+	}
+	if hash, ok := n.net.canonical[number]; ok && hash != block.Hash() {
+		n.net.violation("node %d: committed block %d %x, another node committed %x", n.index, number, block.Hash(), hash)
+	} else {
+		n.net.canonical[number] = block.Hash()
+	}
+	n.net.commits = append(n.net.commits, simCommit{n.net.clock.Now(), n.index, number, block.Hash(), round.Uint64()})
+	n.config.ProposerPolicy.ClearRegistry()
+
+	if n.importBlock(block) {
+		n.advance()
+	}
+	return nil
+}
+
+// Verify accepts blocks on top of the own chain only.
+func (n *simNode) Verify(proposal istanbul.Proposal) (time.Duration, error) {
+	block := proposal.(*types.Block)
+	number := block.NumberU64()
+	if number == 0 || number > n.height()+1 || n.chain[number-1].Hash() != block.ParentHash() {
+		return 0, consensus.ErrUnknownAncestor
+	}
+	return 0, nil
+}
+
+func (n *simNode) Sign(data []byte) ([]byte, error) {
+	return crypto.Sign(crypto.Keccak256(data), n.key)
+}
+
+func (n *simNode) SignCommittedSeal(header *types.Header, round uint32) ([]byte, error) {
+	return crypto.Sign(PrepareCommittedSeal(header, round), n.key)
+}
+
+func (n *simNode) CheckSignature(data []byte, addr common.Address, sig []byte) error {
+	signer, err := istanbul.GetSignatureAddress(data, sig)
+	if err != nil {
+		return err
+	}
+	if signer != addr {
+		return errInvalidSigner
+	}
+	return nil
+}
+
+func (n *simNode) LastProposal() (istanbul.Proposal, common.Address) {
+	head := n.head()
+	return head, head.Coinbase()
+}
+
+func (n *simNode) HasPropsal(hash common.Hash, number *big.Int) bool {
+	return number.Uint64() <= n.height() && n.chain[number.Uint64()].Hash() == hash
+}
+
+func (n *simNode) GetProposer(number uint64) common.Address {
+	if number > n.height() {
+		return common.Address{}
+	}
+	return n.chain[number].Coinbase()
+}
+
+func (n *simNode) HasBadProposal(hash common.Hash) bool {
+	return false
+}
+
+func (n *simNode) Close() error {
+	return nil
+}
+
+// check fails the test if any safety violation was detected, or if the nodes
+// haven't reached the given height.
+func (net *simNetwork) check(t *testing.T, height uint64) {
+	t.Helper()
+	for _, violation := range net.violations {
+		t.Error("safety violation:", violation)
+	}
+	if have := net.minHeight(); have < height {
+		t.Errorf("no liveness: minimum height %d, want %d", have, height)
+	}
+}
+
+// Tests that a network without faults commits a block per round 0.
+func TestSimulationSynchronous(t *testing.T) {
+	net := newSimNetwork(4, 1)
+	net.start()
+	net.run(time.Minute, func() bool { return net.minHeight() >= 10 })
+	net.check(t, 10)
+
+	for _, commit := range net.commits {
+		if commit.Round != 0 {
+			t.Errorf("node %d: block %d committed in round %d", commit.Node, commit.Number, commit.Round)
+		}
+	}
+}
+
+// Tests that runs with the same seed produce the same commits.
+func TestSimulationDeterministic(t *testing.T) {
+	run := func() []simCommit {
+		net := newSimNetwork(4, 7)
+		net.setFaults(simFaults{MaxDelay: 500 * time.Millisecond, DropRate: 0.2}, 20*time.Second)
+		net.start()
+		net.run(30*time.Second, nil)
+		return net.commits
+	}
+	first, second := run(), run()
+	if len(first) == 0 {
+		t.Fatal("no blocks committed")
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("commits differ between runs:\nfirst:  %v\nsecond: %v", first, second)
+	}
+}
+
+// Tests that a network split without a quorum stalls, and resumes after the
+// partition heals.
+func TestSimulationPartition(t *testing.T) {
+	net := newSimNetwork(4, 1)
+	net.setFaults(simFaults{MaxDelay: 50 * time.Millisecond}, 30*time.Second)
+	net.start()
+	net.run(time.Minute, func() bool { return net.minHeight() >= 3 })
+
+	// Blocks in flight may still get committed right after the split
+	net.split(0, 0, 1, 1)
+	net.run(5*time.Second, nil)
+	committed := len(net.canonical)
+	net.run(20*time.Second, nil)
+	if have := len(net.canonical); have != committed {
+		t.Errorf("partitioned network progressed from height %d to %d", committed, have)
+	}
+	stalled := net.minHeight()
+	net.heal()
+	net.run(time.Minute, func() bool { return net.minHeight() >= stalled+5 })
+	net.check(t, stalled+5)
+}
+
+// Tests that the network stays safe under random faults before GST, and that
+// it makes progress after GST, with up to F crashed validators.
+func TestSimulationRandomized(t *testing.T) {
+	scenarios := 20
+	if testing.Short() {
+		scenarios = 5
+	}
+	for seed := int64(0); seed < int64(scenarios); seed++ {
+		seed := seed
+		t.Run(fmt.Sprintf("seed-%d", seed), func(t *testing.T) {
+			t.Parallel()
+
+			rng := rand.New(rand.NewSource(seed))
+			size := 4 + rng.Intn(4)
+			net := newSimNetwork(size, seed)
+
+			faults := simFaults{
+				MinDelay: time.Duration(rng.Intn(50)) * time.Millisecond,
+				MaxDelay: time.Duration(50+rng.Intn(2000)) * time.Millisecond,
+				DropRate: rng.Float64() * 0.3,
+			}
+			gst := time.Duration(10+rng.Intn(30)) * time.Second
+			net.setFaults(faults, gst)
+
+			// Crash up to F validators at random times before GST
+			crashes := rng.Intn((size-1)/3 + 1)
+			for _, i := range rng.Perm(size)[:crashes] {
+				node := net.nodes[i]
+				net.clock.AfterFunc(time.Duration(rng.Int63n(int64(gst))), node.crash)
+			}
+			// Split the network a few times before GST
+			for i := 0; i < rng.Intn(4); i++ {
+				at := time.Duration(rng.Int63n(int64(gst)))
+				groups := make([]int, size)
+				for j := range groups {
+					groups[j] = rng.Intn(2)
+				}
+				net.clock.AfterFunc(at, func() { net.split(groups...) })
+				net.clock.AfterFunc(at+time.Duration(rng.Int63n(int64(5*time.Second))), net.heal)
+			}
+			t.Logf("validators %d, crashes %d, faults %+v, gst %v", size, crashes, faults, gst)
+
+			net.start()
+			net.run(gst, nil)
+			net.heal()
+
+			target := net.minHeight() + 5
+			net.run(2*time.Minute, func() bool { return net.minHeight() >= target })
+			net.check(t, target)
+		})
+	}
+}
+
+// Tests that validators restarted after preparing a block keep its certificate,
+// so the network commits that block even if all of them restarted.
+func TestSimulationRestartPrepared(t *testing.T) {
+	net := newSimNetwork(4, 1)
+	net.blocked[qbfttypes.CommitCode] = true
+	net.start()
+	net.run(time.Minute, func() bool {
+		for _, node := range net.nodes {
+			if node.core.current.preparedBlock == nil {
+				return false
+			}
+		}
+		return true
+	})
+	prepared := net.nodes[0].core.current.preparedBlock.Hash()
+
+	for _, node := range net.nodes {
+		node.crash()
+	}
+	net.run(time.Second, nil)
+	for _, node := range net.nodes {
+		round := node.core.current.Round().Uint64()
+		node.restart()
+
+		c := node.core
+		if c.current.preparedBlock == nil || c.current.preparedBlock.Hash() != prepared {
+			t.Fatalf("node %d: prepared block not restored", node.index)
+		}
+		if len(c.QBFTPreparedPrepares) < net.quorum() {
+			t.Fatalf("node %d: prepared certificate not restored: %d prepares", node.index, len(c.QBFTPreparedPrepares))
+		}
+		if have := c.current.Round().Uint64(); have <= round {
+			t.Fatalf("node %d: resumed in round %d, want above %d", node.index, have, round)
+		}
+	}
+	delete(net.blocked, qbfttypes.CommitCode)
+	net.run(time.Minute, func() bool { return net.minHeight() >= 3 })
+	net.check(t, 3)
+
+	if net.canonical[1] != prepared {
+		t.Errorf("committed block %x, want prepared %x", net.canonical[1], prepared)
+	}
+}
+
+// Tests that a restarting validator never persists a round state older than the
+// stored one, so crashing again during the restart can't lose its certificate.
+func TestSimulationRestartStoresNoRegression(t *testing.T) {
+	net := newSimNetwork(4, 1)
+	net.blocked[qbfttypes.CommitCode] = true
+	net.start()
+	net.run(time.Minute, func() bool {
+		for _, node := range net.nodes {
+			if node.core.current.preparedBlock == nil {
+				return false
+			}
+		}
+		return true
+	})
+	for _, node := range net.nodes {
+		node.crash()
+	}
+	net.run(time.Second, nil)
+	for _, node := range net.nodes {
+		round := node.core.current.Round().Uint64()
+
+		var stored []*storedRoundState
+		node.db.(*simDB).onPut = func(key []byte, value []byte) {
+			state := new(storedRoundState)
+			if err := rlp.DecodeBytes(value, state); err != nil {
+				t.Fatalf("node %d: failed to decode stored round state: %v", node.index, err)
+			}
+			stored = append(stored, state)
+		}
+		node.restart()
+		node.db.(*simDB).onPut = nil
+
+		if len(stored) == 0 {
+			t.Fatalf("node %d: no round state stored on restart", node.index)
+		}
+		for i, state := range stored {
+			if state.Round <= round {
+				t.Errorf("node %d: write %d stored round %d, want above %d", node.index, i, state.Round, round)
+			}
+			if state.PreparedBlock == nil {
+				t.Errorf("node %d: write %d dropped the prepared certificate", node.index, i)
+			}
+		}
+	}
+}
+
+// Tests that validators crashing mid-round and restarting never send conflicting
+// messages, and that the network recovers after GST.
+func TestSimulationCrashRestart(t *testing.T) {
+	scenarios := 10
+	if testing.Short() {
+		scenarios = 3
+	}
+	for seed := int64(0); seed < int64(scenarios); seed++ {
+		seed := seed
+		t.Run(fmt.Sprintf("seed-%d", seed), func(t *testing.T) {
+			t.Parallel()
+
+			rng := rand.New(rand.NewSource(seed))
+			size := 4 + rng.Intn(4)
+			net := newSimNetwork(size, seed)
+
+			faults := simFaults{
+				MaxDelay:  time.Duration(50+rng.Intn(1000)) * time.Millisecond,
+				DropRate:  rng.Float64() * 0.1,
+				CrashRate: 0.02 + rng.Float64()*0.1,
+				Downtime:  3 * time.Second,
+			}
+			gst := time.Duration(20+rng.Intn(20)) * time.Second
+			net.setFaults(faults, gst)
+			t.Logf("validators %d, faults %+v, gst %v", size, faults, gst)
+
+			net.start()
+			net.run(gst+faults.Downtime, nil)
+
+			target := net.minHeight() + 5
+			net.run(2*time.Minute, func() bool { return net.minHeight() >= target })
+			net.check(t, target)
+		})
+	}
+}
@@ -2,7 +2,6 @@ package qbftengine
 
 import (
 	"bytes"
-	"fmt"
 	"math/big"
 	"time"
 
@@ -15,6 +14,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
 	"github.com/holiman/uint256"
@@ -84,7 +84,7 @@ func writeRoundNumber(round *big.Int) ApplyQBFTExtra {
 
 func (e *Engine) VerifyBlockProposal(chain consensus.ChainHeaderReader, block *types.Block, validators istanbul.ValidatorSet) (time.Duration, error) {
 	// check block body
-	txnHash := types.DeriveSha(block.Transactions(), new(trie.Trie))
+	txnHash := types.DeriveSha(block.Transactions(), trie.NewStackTrie(nil))
 	if txnHash != block.Header().TxHash {
 		return 0, istanbulcommon.ErrMismatchTxhashes
 	}
@@ -96,23 +96,26 @@ func (e *Engine) VerifyBlockProposal(chain consensus.ChainHeaderReader, block *t
 
 	// verify the header of proposed block
 	err := e.VerifyHeader(chain, block.Header(), nil, validators)
-	if err == nil || err == istanbulcommon.ErrEmptyCommittedSeals {
-		// ignore errEmptyCommittedSeals error because we don't have the committed seals yet
-		return 0, nil
-	} else if err == consensus.ErrFutureBlock {
+	if err == consensus.ErrFutureBlock {
 		return time.Until(time.Unix(int64(block.Header().Time), 0)), consensus.ErrFutureBlock
+	} else if err != nil && err != istanbulcommon.ErrEmptyCommittedSeals {
+		// ignore errEmptyCommittedSeals error because we don't have the committed seals yet
+		return 0, err
 	}
 
-	parentHeader := chain.GetHeaderByHash(block.ParentHash())
-	config := e.cfg.GetConfig(parentHeader.Number)
-
+	// Empty blocks must wait for the empty block period, which applies like the
+	// block period from the block of the transition changing it
+	config := e.cfg.GetConfig(block.Number())
 	if config.EmptyBlockPeriod > config.BlockPeriod && len(block.Transactions()) == 0 {
+		parentHeader := chain.GetHeaderByHash(block.ParentHash())
+		if parentHeader == nil {
+			return 0, consensus.ErrUnknownAncestor
+		}
 		if block.Header().Time < parentHeader.Time+config.EmptyBlockPeriod {
-			return 0, fmt.Errorf("empty block verification fail")
+			return 0, istanbulcommon.ErrInvalidEmptyBlockTimestamp
 		}
 	}
-
-	return 0, err
+	return 0, nil
 }
 
 func (e *Engine) VerifyHeader(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header, validators istanbul.ValidatorSet) error {
@@ -372,19 +375,19 @@ func WriteValidators(validators []common.Address) ApplyQBFTExtra {
 //
 // Note, the block header and state database might be updated to reflect any
 // consensus rules that happen at finalization (e.g. block rewards).
-func (e *Engine) Finalize(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, body *types.Body) {
+func (e *Engine) Finalize(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, body *types.Body, validators istanbul.ValidatorSet) {
 	// Accumulate any block and uncle rewards and commit the final state root
-	e.accumulateRewards(chain, state, header)
+	e.accumulateRewards(chain, state, header, validators)
 	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
 	header.UncleHash = nilUncleHash
 }
 
 // FinalizeAndAssemble implements consensus.Engine, ensuring no uncles are set,
 // nor block rewards given, and returns the final block.
-func (e *Engine) FinalizeAndAssemble(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, body *types.Body, receipts []*types.Receipt) (*types.Block, error) {
-	e.Finalize(chain, header, state, body)
+func (e *Engine) FinalizeAndAssemble(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, body *types.Body, receipts []*types.Receipt, validators istanbul.ValidatorSet) (*types.Block, error) {
+	e.Finalize(chain, header, state, body, validators)
 	// Assemble and return the final block for sealing
-	return types.NewBlock(header, body, receipts, new(trie.Trie)), nil
+	return types.NewBlock(header, body, receipts, trie.NewStackTrie(nil)), nil
 }
 
 // Seal generates a new block for the given input block with the local miner's
@@ -544,17 +547,52 @@ func setExtra(h *types.Header, qbftExtra *types.QBFTExtra) error {
 	return nil
 }
 
-// AccumulateRewards credits the beneficiary of the given block with a reward.
-func (e *Engine) accumulateRewards(chain consensus.ChainHeaderReader, state *state.StateDB, header *types.Header) {
+// Reward is the part of a block reward credited to an account.
+type Reward struct {
+	Account common.Address
+	Amount  *big.Int
+}
+
+// Rewards returns the rewards credited when finalizing the given block. In split
+// mode, the validators of the block, as given by the snapshot of its parent, share
+// the reward and the remainder of the division goes to the reward account of the
+// block. The committed seals aren't used, as they differ between the nodes and
+// are not part of the block hash. Nothing is paid in split mode if the validators
+// are not given.
+func (e *Engine) Rewards(chain consensus.ChainHeaderReader, header *types.Header, validators istanbul.ValidatorSet) []Reward {
 	blockReward := chain.Config().GetBlockReward(header.Number)
-	if blockReward.Cmp(big.NewInt(0)) > 0 {
-		coinbase := header.Coinbase
-		if (coinbase == common.Address{}) {
-			coinbase = e.signer
+	if blockReward.Sign() <= 0 {
+		return nil
+	}
+	coinbase := header.Coinbase
+	if (coinbase == common.Address{}) {
+		coinbase = e.signer
+	}
+	rewardAccount, _ := chain.Config().GetRewardAccount(header.Number, coinbase)
+
+	if mode, _ := chain.Config().GetBeneficiaryMode(header.Number); mode == params.BeneficiaryModeSplit {
+		if validators == nil || validators.Size() == 0 {
+			return nil
+		}
+		share, remainder := new(big.Int).QuoRem(&blockReward, big.NewInt(int64(validators.Size())), new(big.Int))
+
+		rewards := make([]Reward, 0, validators.Size()+1)
+		for _, validator := range validators.List() {
+			rewards = append(rewards, Reward{Account: validator.Address(), Amount: share})
 		}
-		rewardAccount, _ := chain.Config().GetRewardAccount(header.Number, coinbase)
-		log.Trace("QBFT: accumulate rewards to", "rewardAccount", rewardAccount, "blockReward", blockReward)
+		if remainder.Sign() > 0 {
+			rewards = append(rewards, Reward{Account: rewardAccount, Amount: remainder})
+		}
+		return rewards
+	}
+	return []Reward{{Account: rewardAccount, Amount: &blockReward}}
+}
+
+// AccumulateRewards credits the beneficiaries of the given block with a reward.
+func (e *Engine) accumulateRewards(chain consensus.ChainHeaderReader, state *state.StateDB, header *types.Header, validators istanbul.ValidatorSet) {
+	for _, reward := range e.Rewards(chain, header, validators) {
+		log.Trace("QBFT: accumulate rewards to", "rewardAccount", reward.Account, "blockReward", reward.Amount)
 
-		state.AddBalance(rewardAccount, uint256.MustFromBig(&blockReward), tracing.BalanceIncreaseRewardMineBlock)
+		state.AddBalance(reward.Account, uint256.MustFromBig(reward.Amount), tracing.BalanceIncreaseRewardQBFTBlock)
 	}
 }
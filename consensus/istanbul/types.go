@@ -61,8 +61,9 @@ type Backend interface {
 	// Sign signs input data with the backend's private key
 	Sign([]byte) ([]byte, error)
 
-	// SignWithoutHashing sign input data with the backend's private key without hashing the input data
-	SignWithoutHashing([]byte) ([]byte, error)
+	// SignCommittedSeal signs the header committed in the given round with the
+	// backend's private key
+	SignCommittedSeal(header *types.Header, round uint32) ([]byte, error)
 
 	// CheckSignature verifies the signature by checking if it's signed by
 	// the given validator
@@ -111,8 +112,8 @@ type Engine interface {
 	VerifyUncles(chain consensus.ChainReader, block *types.Block) error
 	VerifySeal(chain consensus.ChainHeaderReader, header *types.Header, validators ValidatorSet) error
 	Prepare(chain consensus.ChainHeaderReader, header *types.Header, validators ValidatorSet) error
-	Finalize(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, body *types.Body)
-	FinalizeAndAssemble(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, body *types.Body, receipts []*types.Receipt) (*types.Block, error)
+	Finalize(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, body *types.Body, validators ValidatorSet)
+	FinalizeAndAssemble(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, body *types.Body, receipts []*types.Receipt, validators ValidatorSet) (*types.Block, error)
 	Seal(chain consensus.ChainHeaderReader, block *types.Block, validators ValidatorSet) (*types.Block, error)
 	SealHash(header *types.Header) common.Hash
 	CalcDifficulty(chain consensus.ChainHeaderReader, time uint64, parent *types.Header) *big.Int
@@ -0,0 +1,101 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package istanbul
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// ValidatorProposal is a request to add or remove a validator, shared between the
+// validator operators to be signed by each of them. A node votes on the proposal
+// if its own validator is among the signers.
+//
+// The signatures cover the chain identifier and an absolute expiry block, so an
+// endorsement can neither be replayed on another network nor after it expired.
+type ValidatorProposal struct {
+	ChainID    *hexutil.Big    `json:"chainId"`    // Chain identifier of the network the proposal is for
+	Candidate  common.Address  `json:"candidate"`  // Account being voted on to change its authorization
+	Authorize  bool            `json:"authorize"`  // Whether to authorize or deauthorize the candidate
+	Expiry     uint64          `json:"expiry"`     // Block number from which the votes are dropped
+	Signatures []hexutil.Bytes `json:"signatures"` // Signatures of the validators endorsing the proposal
+}
+
+// SigHash returns the hash signed by the validators endorsing the proposal.
+func (p *ValidatorProposal) SigHash() common.Hash {
+	chainID := new(big.Int)
+	if p.ChainID != nil {
+		chainID = p.ChainID.ToInt()
+	}
+	blob, _ := rlp.EncodeToBytes([]interface{}{chainID, p.Candidate, p.Authorize, p.Expiry})
+	return crypto.Keccak256Hash(blob)
+}
+
+// validate checks that the proposal is bound to a network and an expiry block.
+func (p *ValidatorProposal) validate() error {
+	if p.ChainID == nil || p.ChainID.ToInt().Sign() <= 0 {
+		return ErrProposalNoChainID
+	}
+	if p.Expiry == 0 {
+		return ErrProposalNoExpiry
+	}
+	return nil
+}
+
+// Sign adds the signature of the given validator key to the proposal.
+func (p *ValidatorProposal) Sign(key *ecdsa.PrivateKey) error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+	sig, err := crypto.Sign(p.SigHash().Bytes(), key)
+	if err != nil {
+		return err
+	}
+	p.Signatures = append(p.Signatures, sig)
+	return nil
+}
+
+// Signers recovers the addresses of the validators endorsing the proposal, in
+// the order of their signatures.
+func (p *ValidatorProposal) Signers() ([]common.Address, error) {
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	if len(p.Signatures) == 0 {
+		return nil, ErrUnsignedProposal
+	}
+	hash := p.SigHash()
+
+	signers := make([]common.Address, 0, len(p.Signatures))
+	for _, sig := range p.Signatures {
+		signer, err := GetSignatureAddressNoHashing(hash.Bytes(), sig)
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(signers, signer) {
+			return nil, ErrDuplicateProposalSigner
+		}
+		signers = append(signers, signer)
+	}
+	return signers, nil
+}
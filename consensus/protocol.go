@@ -4,6 +4,8 @@ package consensus
 import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/p2p"
 )
 
 // Constants to match up protocol versions and messages
@@ -47,6 +49,32 @@ type Protocol struct {
 	Lengths map[uint]uint64
 }
 
+// Handler should be implemented if the consensus needs to handle and send peer messages
+type Handler interface {
+	// Protocol returns the devp2p sub-protocol the consensus messages are exchanged on
+	Protocol() Protocol
+
+	// NewChainHead handles a new head block comes
+	NewChainHead() error
+
+	// HandleMsg handles a message from peer
+	HandleMsg(address common.Address, data p2p.Msg) (bool, error)
+
+	// SetBroadcaster sets the broadcaster to send message to peers
+	SetBroadcaster(Broadcaster)
+}
+
+// Istanbul is a consensus engine to avoid byzantine failure
+type Istanbul interface {
+	Engine
+
+	// Start starts the engine
+	Start(chain ChainHeaderReader, currentBlock func() *types.Block, hasBadBlock func(db ethdb.Reader, hash common.Hash) bool) error
+
+	// Stop stops the engine
+	Stop() error
+}
+
 // Broadcaster defines the interface to enqueue blocks to fetcher and find peer
 type Broadcaster interface {
 	// Enqueue add a block into fetcher queue
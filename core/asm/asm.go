@@ -18,12 +18,17 @@
 package asm
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/core/vm"
 )
 
+// eofMagic is the prefix identifying EOF containers.
+var eofMagic = []byte{0xef, 0x00}
+
 // Iterator for disassembled EVM instructions
 type instructionIterator struct {
 	code    []byte
@@ -32,6 +37,7 @@ type instructionIterator struct {
 	op      vm.OpCode
 	error   error
 	started bool
+	eof     bool
 }
 
 // NewInstructionIterator creates a new instruction iterator.
@@ -41,6 +47,15 @@ func NewInstructionIterator(code []byte) *instructionIterator {
 	return it
 }
 
+// NewEOFInstructionIterator creates a new instruction iterator for a code
+// section of an EOF container, where immediate arguments are not restricted
+// to PUSH instructions.
+func NewEOFInstructionIterator(code []byte) *instructionIterator {
+	it := NewInstructionIterator(code)
+	it.eof = true
+	return it
+}
+
 // Next returns true if there is a next instruction and moves on.
 func (it *instructionIterator) Next() bool {
 	if it.error != nil || uint64(len(it.code)) <= it.pc {
@@ -65,7 +80,21 @@ func (it *instructionIterator) Next() bool {
 	}
 
 	it.op = vm.OpCode(it.code[it.pc])
-	if it.op.IsPush() {
+	if it.eof {
+		var (
+			a = uint64(vm.Immediates(it.op))
+			u = it.pc + 1 + a
+		)
+		if it.op == vm.RJUMPV && u <= uint64(len(it.code)) {
+			// The jump table size is encoded in the first immediate byte.
+			u += 2 * (uint64(it.code[it.pc+1]) + 1)
+		}
+		if uint64(len(it.code)) < u {
+			it.error = fmt.Errorf("incomplete instruction at %v", it.pc)
+			return false
+		}
+		it.arg = it.code[it.pc+1 : u]
+	} else if it.op.IsPush() {
 		a := uint64(it.op) - uint64(vm.PUSH0)
 		u := it.pc + 1 + a
 		if uint64(len(it.code)) <= it.pc || uint64(len(it.code)) < u {
@@ -100,33 +129,73 @@ func (it *instructionIterator) Arg() []byte {
 }
 
 // PrintDisassembled pretty-print all disassembled EVM instructions to stdout.
+// EOF containers are printed along with each of their code sections.
 func PrintDisassembled(code string) error {
 	script, err := hex.DecodeString(code)
 	if err != nil {
 		return err
 	}
-
-	it := NewInstructionIterator(script)
-	for it.Next() {
-		if it.Arg() != nil && 0 < len(it.Arg()) {
-			fmt.Printf("%05x: %v %#x\n", it.PC(), it.Op(), it.Arg())
-		} else {
-			fmt.Printf("%05x: %v\n", it.PC(), it.Op())
-		}
+	instrs, err := Disassemble(script)
+	if err != nil {
+		return err
 	}
-	return it.Error()
+	for _, instr := range instrs {
+		fmt.Print(instr)
+	}
+	return nil
 }
 
 // Disassemble returns all disassembled EVM instructions in human-readable format.
+// If the script is an EOF container, the container layout is listed first,
+// followed by the instructions of every code section.
 func Disassemble(script []byte) ([]string, error) {
-	instrs := make([]string, 0)
+	if bytes.HasPrefix(script, eofMagic) {
+		var c vm.Container
+		if err := c.UnmarshalBinary(script); err != nil {
+			return nil, err
+		}
+		return disassembleContainer(&c, 0)
+	}
+	return disassemble(NewInstructionIterator(script), "")
+}
+
+// disassembleContainer disassembles the code sections of an EOF container,
+// recursing into its subcontainers.
+func disassembleContainer(c *vm.Container, depth int) ([]string, error) {
+	var (
+		indent = strings.Repeat("  ", depth)
+		instrs = make([]string, 0)
+	)
+	for _, line := range strings.Split(c.String(), "\n") {
+		instrs = append(instrs, indent+line+"\n")
+	}
+	for i, code := range c.CodeSections() {
+		instrs = append(instrs, fmt.Sprintf("%sCode section %d:\n", indent, i))
+		section, err := disassemble(NewEOFInstructionIterator(code), indent)
+		if err != nil {
+			return nil, err
+		}
+		instrs = append(instrs, section...)
+	}
+	for i, sub := range c.SubContainers() {
+		instrs = append(instrs, fmt.Sprintf("%sSubcontainer %d:\n", indent, i))
+		section, err := disassembleContainer(sub, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		instrs = append(instrs, section...)
+	}
+	return instrs, nil
+}
 
-	it := NewInstructionIterator(script)
+// disassemble formats all instructions of the given iterator.
+func disassemble(it *instructionIterator, indent string) ([]string, error) {
+	instrs := make([]string, 0)
 	for it.Next() {
 		if it.Arg() != nil && 0 < len(it.Arg()) {
-			instrs = append(instrs, fmt.Sprintf("%05x: %v %#x\n", it.PC(), it.Op(), it.Arg()))
+			instrs = append(instrs, fmt.Sprintf("%s%05x: %v %#x\n", indent, it.PC(), it.Op(), it.Arg()))
 		} else {
-			instrs = append(instrs, fmt.Sprintf("%05x: %v\n", it.PC(), it.Op()))
+			instrs = append(instrs, fmt.Sprintf("%s%05x: %v\n", indent, it.PC(), it.Op()))
 		}
 	}
 	if err := it.Error(); err != nil {
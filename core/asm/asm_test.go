@@ -17,6 +17,7 @@
 package asm
 
 import (
+	"strings"
 	"testing"
 
 	"encoding/hex"
@@ -56,3 +57,58 @@ func TestInstructionIterator(t *testing.T) {
 		}
 	}
 }
+
+// Tests iterating over EOF code sections, where immediates are not limited to
+// PUSH instructions.
+func TestEOFInstructionIterator(t *testing.T) {
+	for i, tc := range []struct {
+		want    int
+		code    string
+		wantErr string
+	}{
+		{2, "e0000000", ""},                            // rjump, stop
+		{3, "5fe20100000000" + "00", ""},               // push0, rjumpv with two entries, stop
+		{2, "e300015f", ""},                            // callf, push0
+		{0, "e2010000", "incomplete instruction at 0"}, // truncated jump table
+		{0, "d100", "incomplete instruction at 0"},     // truncated dataloadn
+	} {
+		var (
+			have    int
+			code, _ = hex.DecodeString(tc.code)
+			it      = NewEOFInstructionIterator(code)
+		)
+		for it.Next() {
+			have++
+		}
+		var haveErr = ""
+		if it.Error() != nil {
+			haveErr = it.Error().Error()
+		}
+		if haveErr != tc.wantErr {
+			t.Errorf("test %d: encountered error: %q want %q", i, haveErr, tc.wantErr)
+			continue
+		}
+		if have != tc.want {
+			t.Errorf("test %d: wrong instruction count, have %d want %d", i, have, tc.want)
+		}
+	}
+}
+
+// Tests disassembling an EOF container.
+func TestDisassembleEOF(t *testing.T) {
+	code, _ := hex.DecodeString("ef000101000402000100050400000000800001e000005f")
+	if _, err := Disassemble(code); err == nil {
+		t.Fatal("expected error for truncated container")
+	}
+	code, _ = hex.DecodeString("ef0001010004020001000404000000008000016001e400")
+	instrs, err := Disassemble(code)
+	if err != nil {
+		t.Fatalf("failed to disassemble: %v", err)
+	}
+	have := strings.Join(instrs, "")
+	for _, want := range []string{"Code section 0:\n", "00000: PUSH1 0x01\n", "00002: RETF\n", "00003: STOP\n"} {
+		if !strings.Contains(have, want) {
+			t.Errorf("missing %q in disassembly:\n%s", want, have)
+		}
+	}
+}
@@ -83,8 +83,23 @@ func (v *BlockValidator) ValidateBody(block *types.Block) error {
 		return errors.New("withdrawals present in block body")
 	}
 
+	// Requests are present after the Prague fork.
+	if header.RequestsHash != nil {
+		if block.Requests() == nil {
+			return errors.New("missing requests in block body")
+		}
+		if hash := types.DeriveSha(block.Requests(), trie.NewStackTrie(nil)); hash != *header.RequestsHash {
+			return fmt.Errorf("requests root hash mismatch (header value %x, calculated %x)", *header.RequestsHash, hash)
+		}
+	} else if block.Requests() != nil {
+		return errors.New("requests present in block body")
+	}
+
 	// Blob transactions may be present after the Cancun fork.
-	var blobs int
+	var (
+		blobs     int
+		sizeLimit = v.config.GetTransactionSizeLimit(header.Number)
+	)
 	for i, tx := range block.Transactions() {
 		// Count the number of blobs to validate against the header's blobGasUsed
 		blobs += len(tx.BlobHashes())
@@ -93,6 +108,10 @@ func (v *BlockValidator) ValidateBody(block *types.Block) error {
 		if tx.BlobTxSidecar() != nil {
 			return fmt.Errorf("unexpected blob sidecar in transaction at index %d", i)
 		}
+		// Ensure the transaction fits in the size limit configured for the chain.
+		if sizeLimit != 0 && tx.Size() > sizeLimit {
+			return fmt.Errorf("%w: transaction at index %d size %d, limit %d", ErrTxSizeLimitExceeded, i, tx.Size(), sizeLimit)
+		}
 
 		// The individual checks for blob validity (version-check + not empty)
 		// happens in StateTransition.
@@ -120,25 +139,33 @@ func (v *BlockValidator) ValidateBody(block *types.Block) error {
 }
 
 // ValidateState validates the various changes that happen after a state transition,
-// such as amount of used gas, the receipt roots and the state root itself.
-func (v *BlockValidator) ValidateState(block *types.Block, statedb *state.StateDB, receipts types.Receipts, usedGas uint64, stateless bool) error {
+// such as amount of used gas, the receipt roots, the requests and the state root
+// itself.
+func (v *BlockValidator) ValidateState(block *types.Block, statedb *state.StateDB, res *ProcessResult, stateless bool) error {
 	header := block.Header()
-	if block.GasUsed() != usedGas {
-		return fmt.Errorf("invalid gas used (remote: %d local: %d)", block.GasUsed(), usedGas)
+	if block.GasUsed() != res.GasUsed {
+		return fmt.Errorf("invalid gas used (remote: %d local: %d)", block.GasUsed(), res.GasUsed)
 	}
 	// Validate the received block's bloom with the one derived from the generated receipts.
 	// For valid blocks this should always validate to true.
-	rbloom := types.CreateBloom(receipts)
+	rbloom := types.CreateBloom(res.Receipts)
 	if rbloom != header.Bloom {
 		return fmt.Errorf("invalid bloom (remote: %x  local: %x)", header.Bloom, rbloom)
 	}
+	// Validate the requests generated during execution against the ones in the
+	// block body, which have already been checked against the header.
+	if header.RequestsHash != nil {
+		if hash := types.DeriveSha(res.Requests, trie.NewStackTrie(nil)); hash != *header.RequestsHash {
+			return fmt.Errorf("invalid requests hash (remote: %x local: %x)", *header.RequestsHash, hash)
+		}
+	}
 	// In stateless mode, return early because the receipt and state root are not
 	// provided through the witness, rather the cross validator needs to return it.
 	if stateless {
 		return nil
 	}
 	// The receipt Trie's root (R = (Tr [[H1, R1], ... [Hn, Rn]]))
-	receiptSha := types.DeriveSha(receipts, trie.NewStackTrie(nil))
+	receiptSha := types.DeriveSha(res.Receipts, trie.NewStackTrie(nil))
 	if receiptSha != header.ReceiptHash {
 		return fmt.Errorf("invalid receipt root hash (remote: %x local: %x)", header.ReceiptHash, receiptSha)
 	}
@@ -36,6 +36,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/state/pruner"
 	"github.com/ethereum/go-ethereum/core/state/snapshot"
 	"github.com/ethereum/go-ethereum/core/stateless"
 	"github.com/ethereum/go-ethereum/core/tracing"
@@ -43,6 +44,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/internal/era"
 	"github.com/ethereum/go-ethereum/internal/syncx"
 	"github.com/ethereum/go-ethereum/internal/version"
 	"github.com/ethereum/go-ethereum/log"
@@ -140,6 +142,10 @@ type CacheConfig struct {
 	Preimages           bool          // Whether to store preimage of trie key to the disk
 	StateHistory        uint64        // Number of blocks from head whose state histories are reserved.
 	StateScheme         string        // Scheme used to store ethereum states and merkle tree nodes on top
+	HistoryExpiry       uint64        // Block number below which ancient bodies and receipts are dropped (0 = keep all)
+	HistoryDir          string        // Directory of era files serving the expired block history
+	OnlinePruning       bool          // Whether to garbage collect stale hash-scheme state in the background
+	OnlinePruningRate   int           // Maximum number of stale state entries deleted per second (0 = unlimited)
 
 	SnapshotNoBuild bool // Whether the background generation is allowed
 	SnapshotWait    bool // Wait for snapshot construction on startup. TODO(karalabe): This is a dirty hack for testing, nuke it
@@ -219,6 +225,9 @@ type BlockChain struct {
 	triedb        *triedb.Database                 // The database handler for maintaining trie nodes.
 	stateCache    state.Database                   // State database to reuse between imports (contains state cache)
 	txIndexer     *txIndexer                       // Transaction indexer, might be nil if not enabled
+	history       *era.Store                       // Era archive serving expired history, might be nil if not enabled
+	historyTail   uint64                           // First block whose body and receipts are retained in the database
+	pruner        *pruner.OnlinePruner             // Online state pruner, might be nil if not enabled
 
 	hc            *HeaderChain
 	rmLogsFeed    event.Feed
@@ -268,8 +277,22 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 	if cacheConfig == nil {
 		cacheConfig = defaultCacheConfig
 	}
+	// Set up the online pruner if it's enabled, all state writes have to go
+	// through it in order to protect them from a running pruning cycle.
+	var (
+		diskdb       = db
+		onlinePruner *pruner.OnlinePruner
+	)
+	if cacheConfig.OnlinePruning {
+		if cacheConfig.StateScheme == rawdb.HashScheme {
+			onlinePruner = pruner.NewOnlinePruner(db, pruner.OnlineConfig{RateLimit: cacheConfig.OnlinePruningRate})
+			diskdb = onlinePruner.Database()
+		} else {
+			log.Warn("Online state pruning is only supported by the hash scheme", "scheme", cacheConfig.StateScheme)
+		}
+	}
 	// Open trie database with provided config
-	triedb := triedb.NewDatabase(db, cacheConfig.triedbConfig(genesis != nil && genesis.IsVerkle()))
+	triedb := triedb.NewDatabase(diskdb, cacheConfig.triedbConfig(genesis != nil && genesis.IsVerkle()))
 
 	// Setup the genesis block, commit the provided genesis specification
 	// to database if the genesis block is not present yet, or load the
@@ -278,6 +301,10 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 	if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
 		return nil, genesisErr
 	}
+	// Ensure the custom precompiles activated by the config are all available
+	if err := vm.ValidatePrecompiles(chainConfig); err != nil {
+		return nil, err
+	}
 	log.Info("")
 	log.Info(strings.Repeat("-", 153))
 	for _, line := range strings.Split(chainConfig.Description(), "\n") {
@@ -310,7 +337,7 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 	}
 	bc.flushInterval.Store(int64(cacheConfig.TrieTimeLimit))
 	bc.forker = NewForkChoice(bc, shouldPreserve)
-	bc.stateCache = state.NewDatabaseWithNodeDB(bc.db, bc.triedb)
+	bc.stateCache = state.NewDatabaseWithNodeDB(diskdb, bc.triedb)
 	bc.validator = NewBlockValidator(chainConfig, bc)
 	bc.prefetcher = newStatePrefetcher(chainConfig, bc.hc)
 	bc.processor = NewStateProcessor(chainConfig, bc.hc)
@@ -460,13 +487,93 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 		rawdb.WriteChainConfig(db, genesisHash, chainConfig)
 	}
 
+	// Expire the ancient history if it's enabled.
+	if err := bc.setupHistory(); err != nil {
+		return nil, err
+	}
 	// Start tx indexer if it's enabled.
 	if txLookupLimit != nil {
 		bc.txIndexer = newTxIndexer(*txLookupLimit, bc)
 	}
+	// Start the online state pruner if it's enabled.
+	if onlinePruner != nil {
+		bc.pruner = onlinePruner
+		bc.pruner.Start(bc.triedb, bc.retainRecentState)
+	}
 	return bc, nil
 }
 
+// retainRecentState pins the states of the recent blocks and the snapshot disk
+// layer in the trie database and returns their roots, starting with the head
+// state, along with a function releasing them. It's used by the online pruner to
+// pick the states to retain. The states are referenced rather than flushed, so
+// that they stay subject to the in-memory garbage collection once released.
+//
+// The oldest recent state is the only one flushed, just like on shutdown. The
+// states persisted before are swept by the pruner, so without it the disk would
+// hold no complete state until the next flush, and a crash in between would lose
+// all of them.
+func (bc *BlockChain) retainRecentState() ([]common.Hash, func(), error) {
+	if !bc.chainmu.TryLock() {
+		return nil, nil, errChainStopped
+	}
+	defer bc.chainmu.Unlock()
+
+	// Snap synced state is written bypassing the pruner's protection, so hold
+	// off until the sync is done and the chain processes blocks on its own.
+	head := bc.CurrentBlock()
+	if head.Number.Sign() == 0 || head.Number.Cmp(bc.CurrentSnapBlock().Number) < 0 || rawdb.ReadSnapSyncStatusFlag(bc.db) == rawdb.StateSyncRunning {
+		return nil, nil, pruner.ErrChainSyncing
+	}
+	if !bc.HasState(head.Root) {
+		return nil, nil, fmt.Errorf("state of head block %d is not available", head.Number)
+	}
+	var (
+		roots []common.Hash
+		seen  = make(map[common.Hash]struct{})
+	)
+	retain := func(root common.Hash) {
+		if _, ok := seen[root]; ok {
+			return
+		}
+		seen[root] = struct{}{}
+		bc.triedb.Reference(root, common.Hash{})
+		roots = append(roots, root)
+	}
+	// Retain the states still referenced from memory, the ones a reorg or the
+	// shutdown might need, and the snapshot disk layer.
+	var oldest *types.Header
+	for i := uint64(0); i < state.TriesInMemory && i <= head.Number.Uint64(); i++ {
+		header := bc.GetHeaderByNumber(head.Number.Uint64() - i)
+		if header == nil || !bc.HasState(header.Root) {
+			continue
+		}
+		retain(header.Root)
+		oldest = header
+	}
+	if bc.snaps != nil {
+		if root := bc.snaps.DiskRoot(); root != (common.Hash{}) && bc.HasState(root) {
+			retain(root)
+		}
+	}
+	release := func() {
+		for _, root := range roots {
+			bc.triedb.Dereference(root)
+		}
+	}
+	// Persist the oldest recent state, the pruner marks its nodes while they are
+	// written, so it survives the sweep.
+	if !rawdb.HasLegacyTrieNode(bc.db, oldest.Root) {
+		log.Info("Persisting state for online pruning", "number", oldest.Number, "root", oldest.Root)
+		if err := bc.triedb.Commit(oldest.Root, false); err != nil {
+			release()
+			return nil, nil, err
+		}
+		bc.lastWrite = max(bc.lastWrite, oldest.Number.Uint64())
+	}
+	return roots, release, nil
+}
+
 // empty returns an indicator whether the blockchain is empty.
 // Note, it's a special case that we connect a non-empty ancient
 // database with an empty node, so that we can plugin the ancient
@@ -1090,6 +1197,12 @@ func (bc *BlockChain) stopWithoutSaving() {
 	close(bc.quit)
 	bc.StopInsert()
 
+	// Signal shutdown to the online pruner, the progress of a running cycle is
+	// persisted and resumed after a restart.
+	if bc.pruner != nil {
+		bc.pruner.Stop()
+	}
+
 	// Now wait for all chain modifications to end and persistent goroutines to exit.
 	//
 	// Note: Close waits for the mutex to become available, i.e. any running chain
@@ -1156,6 +1269,10 @@ func (bc *BlockChain) Stop() {
 	if bc.logger != nil && bc.logger.OnClose != nil {
 		bc.logger.OnClose()
 	}
+	// Close the era archive of the expired history.
+	if bc.history != nil {
+		bc.history.Close()
+	}
 	// Close the trie database, release all the held resources as the last step.
 	if err := bc.triedb.Close(); err != nil {
 		log.Error("Failed to close trie database", "err", err)
@@ -1538,6 +1655,18 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	return nil
 }
 
+// WriteBlockAndSetHead writes the given block and all associated state to the database,
+// and applies the block as the new chain head. It's used by consensus engines which
+// seal the blocks locally, avoiding the re-execution of an already processed block.
+func (bc *BlockChain) WriteBlockAndSetHead(block *types.Block, receipts []*types.Receipt, logs []*types.Log, state *state.StateDB, emitHeadEvent bool) (status WriteStatus, err error) {
+	if !bc.chainmu.TryLock() {
+		return NonStatTy, errChainStopped
+	}
+	defer bc.chainmu.Unlock()
+
+	return bc.writeBlockAndSetHead(block, receipts, logs, state, emitHeadEvent)
+}
+
 // writeBlockAndSetHead is the internal implementation of WriteBlockAndSetHead.
 // This function expects the chain mutex to be held.
 func (bc *BlockChain) writeBlockAndSetHead(block *types.Block, receipts []*types.Receipt, logs []*types.Log, state *state.StateDB, emitHeadEvent bool) (status WriteStatus, err error) {
@@ -1924,23 +2053,23 @@ func (bc *BlockChain) processBlock(block *types.Block, statedb *state.StateDB, s
 
 	// Process block using the parent state as reference point
 	pstart := time.Now()
-	receipts, logs, usedGas, err := bc.processor.Process(block, statedb, bc.vmConfig)
+	res, err := bc.processor.Process(block, statedb, bc.vmConfig)
 	if err != nil {
-		bc.reportBlock(block, receipts, err)
+		bc.reportBlock(block, nil, err)
 		return nil, err
 	}
 	ptime := time.Since(pstart)
 
 	vstart := time.Now()
-	if err := bc.validator.ValidateState(block, statedb, receipts, usedGas, false); err != nil {
-		bc.reportBlock(block, receipts, err)
+	if err := bc.validator.ValidateState(block, statedb, res, false); err != nil {
+		bc.reportBlock(block, res.Receipts, err)
 		return nil, err
 	}
 	vtime := time.Since(vstart)
 
 	if witness := statedb.Witness(); witness != nil {
 		if err = bc.validator.ValidateWitness(witness, block.ReceiptHash(), block.Root()); err != nil {
-			bc.reportBlock(block, receipts, err)
+			bc.reportBlock(block, res.Receipts, err)
 			return nil, fmt.Errorf("cross verification failed: %v", err)
 		}
 	}
@@ -1968,9 +2097,9 @@ func (bc *BlockChain) processBlock(block *types.Block, statedb *state.StateDB, s
 	)
 	if !setHead {
 		// Don't set the head, only insert the block
-		err = bc.writeBlockWithState(block, receipts, statedb)
+		err = bc.writeBlockWithState(block, res.Receipts, statedb)
 	} else {
-		status, err = bc.writeBlockAndSetHead(block, receipts, logs, statedb, false)
+		status, err = bc.writeBlockAndSetHead(block, res.Receipts, res.Logs, statedb, false)
 	}
 	if err != nil {
 		return nil, err
@@ -1984,7 +2113,7 @@ func (bc *BlockChain) processBlock(block *types.Block, statedb *state.StateDB, s
 	blockWriteTimer.Update(time.Since(wstart) - max(statedb.AccountCommits, statedb.StorageCommits) /* concurrent */ - statedb.SnapshotCommits - statedb.TrieDBCommits)
 	blockInsertTimer.UpdateSince(start)
 
-	return &blockProcessingResult{usedGas: usedGas, procTime: proctime, status: status}, nil
+	return &blockProcessingResult{usedGas: res.GasUsed, procTime: proctime, status: status}, nil
 }
 
 // insertSideChain is called when an import batch hits upon a pruned ancestor
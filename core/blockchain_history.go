@@ -0,0 +1,169 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/era"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// setupHistory opens the era archive configured for serving the expired block
+// history and drops the ancient bodies and receipts below the configured expiry
+// boundary. Only the blocks covered by both the ancient store and the era files
+// are expired, so that no history becomes unavailable.
+func (bc *BlockChain) setupHistory() error {
+	bc.historyTail, _ = rawdb.ReadAncientHistoryTail(bc.db)
+	if bc.cacheConfig.HistoryDir == "" {
+		if bc.cacheConfig.HistoryExpiry != 0 {
+			return errors.New("history expiry requires an era directory")
+		}
+		return nil
+	}
+	network, ok := params.NetworkNames[bc.chainConfig.ChainID.String()]
+	if !ok {
+		network = bc.chainConfig.ChainID.String()
+	}
+	store, err := era.NewStore(bc.cacheConfig.HistoryDir, network)
+	if err != nil {
+		return fmt.Errorf("failed to open era archive: %w", err)
+	}
+	bc.history = store
+
+	expiry := bc.cacheConfig.HistoryExpiry
+	if expiry == 0 {
+		return nil
+	}
+	if head := store.Head(); expiry > head {
+		log.Warn("Era archive does not cover history expiry", "expiry", expiry, "covered", head)
+		expiry = head
+	}
+	frozen, err := bc.db.Ancients()
+	if err != nil {
+		return err
+	}
+	expiry = min(expiry, frozen)
+
+	// Make sure the archive holds the canonical chain before dropping anything
+	if bc.historyTail < expiry {
+		start := time.Now()
+		log.Info("Verifying era archive before expiring history", "from", bc.historyTail, "to", expiry)
+		if err := store.Verify(bc.historyTail, expiry, func(number uint64) common.Hash {
+			return rawdb.ReadCanonicalHash(bc.db, number)
+		}); err != nil {
+			return fmt.Errorf("era archive does not match the chain: %w", err)
+		}
+		log.Info("Verified era archive", "from", bc.historyTail, "to", expiry, "elapsed", common.PrettyDuration(time.Since(start)))
+	}
+	old, err := rawdb.ExpireAncientHistory(bc.db, expiry)
+	if err != nil {
+		return fmt.Errorf("failed to expire ancient history: %w", err)
+	}
+	if bc.historyTail, err = rawdb.ReadAncientHistoryTail(bc.db); err != nil {
+		return err
+	}
+	if bc.historyTail > old {
+		log.Info("Expired ancient block history", "from", old, "to", bc.historyTail, "archive", bc.cacheConfig.HistoryDir)
+	}
+	return nil
+}
+
+// expired reports whether the body and receipts of the given block were dropped
+// from the database and need to be served from the era archive.
+func (bc *BlockChain) expired(number uint64) bool {
+	return bc.history != nil && number < bc.historyTail
+}
+
+// readHistoryBlock retrieves an expired block from the era archive, checking
+// that it matches the requested hash.
+func (bc *BlockChain) readHistoryBlock(hash common.Hash, number uint64) *types.Block {
+	if !bc.expired(number) {
+		return nil
+	}
+	block, err := bc.history.GetBlockByNumber(number)
+	if err != nil {
+		log.Error("Failed to read block from era archive", "number", number, "err", err)
+		return nil
+	}
+	if block.Hash() != hash {
+		return nil
+	}
+	return block
+}
+
+// readHistoryBody retrieves the body of an expired canonical block from the era
+// archive, or nil if the block is not expired or not archived.
+func (bc *BlockChain) readHistoryBody(number uint64) *types.Body {
+	block := bc.readHistoryBlock(rawdb.ReadCanonicalHash(bc.db, number), number)
+	if block == nil {
+		return nil
+	}
+	return block.Body()
+}
+
+// readHistoryTransaction retrieves an indexed transaction of an expired block
+// from the era archive, along with its position in the chain.
+func (bc *BlockChain) readHistoryTransaction(hash common.Hash, number uint64) (*types.Transaction, common.Hash, uint64) {
+	blockHash := rawdb.ReadCanonicalHash(bc.db, number)
+	block := bc.readHistoryBlock(blockHash, number)
+	if block == nil {
+		return nil, common.Hash{}, 0
+	}
+	for i, tx := range block.Transactions() {
+		if tx.Hash() == hash {
+			return tx, blockHash, uint64(i)
+		}
+	}
+	log.Error("Transaction not found in era archive", "number", number, "hash", blockHash, "txhash", hash)
+	return nil, common.Hash{}, 0
+}
+
+// readHistoryReceipts retrieves the receipts of an expired block from the era
+// archive, deriving all the non-consensus fields.
+func (bc *BlockChain) readHistoryReceipts(hash common.Hash, number uint64) types.Receipts {
+	block := bc.readHistoryBlock(hash, number)
+	if block == nil {
+		return nil
+	}
+	receipts, err := bc.history.GetReceiptsByNumber(number)
+	if err != nil {
+		log.Error("Failed to read receipts from era archive", "number", number, "err", err)
+		return nil
+	}
+	baseFee := block.BaseFee()
+	if baseFee == nil {
+		baseFee = big.NewInt(0)
+	}
+	var blobGasPrice *big.Int
+	if excess := block.ExcessBlobGas(); excess != nil {
+		blobGasPrice = eip4844.CalcBlobFee(*excess)
+	}
+	if err := receipts.DeriveFields(bc.chainConfig, hash, number, block.Time(), baseFee, blobGasPrice, block.Transactions()); err != nil {
+		log.Error("Failed to derive archived receipts fields", "hash", hash, "number", number, "err", err)
+		return nil
+	}
+	return receipts
+}
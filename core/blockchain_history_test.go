@@ -0,0 +1,227 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/era"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that ancient bodies and receipts below the expiry boundary are dropped
+// from the database and transparently served from era files instead.
+func TestHistoryExpiry(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{Config: params.TestChainConfig, Alloc: types.GenesisAlloc{address: {Balance: big.NewInt(params.Ether)}}}
+		signer  = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, receipts := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 64, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(address), common.Address{0xaa}, big.NewInt(1), params.TxGas, b.BaseFee(), nil), signer, key)
+		b.AddTx(tx)
+	})
+	db, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create temp freezer db: %v", err)
+	}
+	defer db.Close()
+
+	chain, _ := NewBlockChain(db, DefaultCacheConfigWithScheme(rawdb.HashScheme), gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	headers := make([]*types.Header, len(blocks))
+	for i, block := range blocks {
+		headers[i] = block.Header()
+	}
+	if n, err := chain.InsertHeaderChain(headers); err != nil {
+		t.Fatalf("failed to insert header %d: %v", n, err)
+	}
+	if n, err := chain.InsertReceiptChain(blocks, receipts, 48); err != nil {
+		t.Fatalf("failed to insert receipt %d: %v", n, err)
+	}
+	// Archive the whole chain into an era file.
+	dir := t.TempDir()
+	f, err := os.CreateTemp(dir, "era")
+	if err != nil {
+		t.Fatal(err)
+	}
+	builder := era.NewBuilder(f)
+	for i := uint64(0); i <= uint64(len(blocks)); i++ {
+		block := chain.GetBlockByNumber(i)
+		if err := builder.Add(block, chain.GetReceiptsByHash(block.Hash()), chain.GetTd(block.Hash(), i)); err != nil {
+			t.Fatalf("failed to archive block %d: %v", i, err)
+		}
+	}
+	root, err := builder.Finalize()
+	if err != nil {
+		t.Fatalf("failed to finalize era: %v", err)
+	}
+	f.Close()
+	if err := os.Rename(f.Name(), filepath.Join(dir, era.Filename("mainnet", 0, root))); err != nil {
+		t.Fatal(err)
+	}
+	chain.Stop()
+
+	// Reopen the chain with history expiry, the boundary must be capped at the
+	// frozen blocks.
+	config := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	config.HistoryExpiry = 56
+	config.HistoryDir = dir
+	chain, err = NewBlockChain(db, config, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to reopen chain: %v", err)
+	}
+	defer chain.Stop()
+
+	frozen, _ := db.Ancients()
+	if frozen == 0 || frozen >= config.HistoryExpiry {
+		t.Fatalf("unexpected frozen block count %d", frozen)
+	}
+	if tail, _ := rawdb.ReadAncientHistoryTail(db); tail != frozen {
+		t.Fatalf("history tail mismatch: have %d, want %d", tail, frozen)
+	}
+	for i, block := range blocks {
+		var (
+			hash   = block.Hash()
+			number = block.NumberU64()
+		)
+		if expired := rawdb.ReadBody(db, hash, number) == nil; expired != (number < frozen) {
+			t.Fatalf("block %d: expiry mismatch: have %v, want %v", number, expired, number < frozen)
+		}
+		if have := chain.GetBlock(hash, number); have == nil || have.Hash() != hash || len(have.Transactions()) != 1 {
+			t.Fatalf("block %d: failed to retrieve block", number)
+		}
+		if body := chain.GetBodyRLP(hash); len(body) == 0 {
+			t.Fatalf("block %d: failed to retrieve body rlp", number)
+		}
+		have := chain.GetReceiptsByHash(hash)
+		if len(have) != len(receipts[i]) {
+			t.Fatalf("block %d: receipt count mismatch: have %d, want %d", number, len(have), len(receipts[i]))
+		}
+		for j, receipt := range have {
+			want := receipts[i][j]
+			if receipt.TxHash != want.TxHash || receipt.CumulativeGasUsed != want.CumulativeGasUsed || receipt.BlockHash != hash {
+				t.Fatalf("block %d: receipt %d mismatch", number, j)
+			}
+		}
+	}
+	// Headers must be retained.
+	if header := chain.GetHeaderByNumber(1); header == nil {
+		t.Fatal("expired header")
+	}
+	// The transaction indexer must index the expired blocks from the era archive,
+	// and their transactions must be retrievable.
+	rawdb.IndexTransactions(db, chain.readHistoryBody, 0, uint64(len(blocks))+1, nil, false)
+	if tail := rawdb.ReadTxIndexTail(db); tail == nil || *tail != 0 {
+		t.Fatalf("transaction index tail mismatch: have %v, want 0", tail)
+	}
+	for _, block := range blocks {
+		tx := block.Transactions()[0]
+		lookup, have, err := chain.GetTransactionLookup(tx.Hash())
+		if err != nil || have == nil {
+			t.Fatalf("block %d: failed to look up transaction: %v", block.NumberU64(), err)
+		}
+		if have.Hash() != tx.Hash() || lookup.BlockHash != block.Hash() || lookup.BlockIndex != block.NumberU64() || lookup.Index != 0 {
+			t.Fatalf("block %d: transaction lookup mismatch: have %+v", block.NumberU64(), lookup)
+		}
+	}
+	// Unindexing must drop the entries of the expired blocks too.
+	rawdb.UnindexTransactions(db, chain.readHistoryBody, 0, frozen, nil, false)
+	for _, block := range blocks {
+		number := block.NumberU64()
+		if indexed := rawdb.ReadTxLookupEntry(db, block.Transactions()[0].Hash()) != nil; indexed != (number >= frozen) {
+			t.Fatalf("block %d: transaction index mismatch: have %v, want %v", number, indexed, number >= frozen)
+		}
+	}
+}
+
+// Tests that history isn't expired if the era files don't match the chain.
+func TestHistoryExpiryMismatch(t *testing.T) {
+	var (
+		gspec  = &Genesis{Config: params.TestChainConfig}
+		engine = ethash.NewFaker()
+	)
+	_, blocks, receipts := GenerateChainWithGenesis(gspec, engine, 64, nil)
+	_, forked, forkedReceipts := GenerateChainWithGenesis(gspec, engine, 64, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{0xaa})
+	})
+	db, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create temp freezer db: %v", err)
+	}
+	defer db.Close()
+
+	chain, _ := NewBlockChain(db, DefaultCacheConfigWithScheme(rawdb.HashScheme), gspec, nil, engine, vm.Config{}, nil, nil)
+	headers := make([]*types.Header, len(blocks))
+	for i, block := range blocks {
+		headers[i] = block.Header()
+	}
+	if n, err := chain.InsertHeaderChain(headers); err != nil {
+		t.Fatalf("failed to insert header %d: %v", n, err)
+	}
+	if n, err := chain.InsertReceiptChain(blocks, receipts, 48); err != nil {
+		t.Fatalf("failed to insert receipt %d: %v", n, err)
+	}
+	chain.Stop()
+
+	// Archive a different chain into an era file.
+	dir := t.TempDir()
+	f, err := os.CreateTemp(dir, "era")
+	if err != nil {
+		t.Fatal(err)
+	}
+	builder := era.NewBuilder(f)
+	td := new(big.Int).Set(gspec.ToBlock().Difficulty())
+	if err := builder.Add(gspec.ToBlock(), nil, new(big.Int).Set(td)); err != nil {
+		t.Fatalf("failed to archive genesis: %v", err)
+	}
+	for i, block := range forked {
+		td.Add(td, block.Difficulty())
+		if err := builder.Add(block, forkedReceipts[i], new(big.Int).Set(td)); err != nil {
+			t.Fatalf("failed to archive block %d: %v", block.NumberU64(), err)
+		}
+	}
+	root, err := builder.Finalize()
+	if err != nil {
+		t.Fatalf("failed to finalize era: %v", err)
+	}
+	f.Close()
+	if err := os.Rename(f.Name(), filepath.Join(dir, era.Filename("mainnet", 0, root))); err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	config.HistoryExpiry = 56
+	config.HistoryDir = dir
+	if _, err := NewBlockChain(db, config, gspec, nil, engine, vm.Config{}, nil, nil); err == nil {
+		t.Fatal("history expired into mismatching era files")
+	}
+	if tail, _ := rawdb.ReadAncientHistoryTail(db); tail != 0 {
+		t.Fatalf("history tail mismatch: have %d, want 0", tail)
+	}
+	if rawdb.ReadBody(db, blocks[0].Hash(), 1) == nil {
+		t.Fatal("block 1 body expired")
+	}
+}
@@ -106,7 +106,11 @@ func (bc *BlockChain) GetBody(hash common.Hash) *types.Body {
 	}
 	body := rawdb.ReadBody(bc.db, hash, *number)
 	if body == nil {
-		return nil
+		block := bc.readHistoryBlock(hash, *number)
+		if block == nil {
+			return nil
+		}
+		body = block.Body()
 	}
 	// Cache the found body for next time and return
 	bc.bodyCache.Add(hash, body)
@@ -126,7 +130,14 @@ func (bc *BlockChain) GetBodyRLP(hash common.Hash) rlp.RawValue {
 	}
 	body := rawdb.ReadBodyRLP(bc.db, hash, *number)
 	if len(body) == 0 {
-		return nil
+		block := bc.readHistoryBlock(hash, *number)
+		if block == nil {
+			return nil
+		}
+		var err error
+		if body, err = rlp.EncodeToBytes(block.Body()); err != nil {
+			return nil
+		}
 	}
 	// Cache the found body for next time and return
 	bc.bodyRLPCache.Add(hash, body)
@@ -164,7 +175,9 @@ func (bc *BlockChain) GetBlock(hash common.Hash, number uint64) *types.Block {
 	}
 	block := rawdb.ReadBlock(bc.db, hash, number)
 	if block == nil {
-		return nil
+		if block = bc.readHistoryBlock(hash, number); block == nil {
+			return nil
+		}
 	}
 	// Cache the found block for next time and return
 	bc.blockCache.Add(block.Hash(), block)
@@ -224,7 +237,9 @@ func (bc *BlockChain) GetReceiptsByHash(hash common.Hash) types.Receipts {
 	}
 	receipts := rawdb.ReadReceipts(bc.db, hash, *number, header.Time, bc.chainConfig)
 	if receipts == nil {
-		return nil
+		if receipts = bc.readHistoryReceipts(hash, *number); receipts == nil {
+			return nil
+		}
 	}
 	bc.receiptsCache.Add(hash, receipts)
 	return receipts
@@ -273,7 +288,18 @@ func (bc *BlockChain) GetTransactionLookup(hash common.Hash) (*rawdb.LegacyTxLoo
 	if item, exist := bc.txLookupCache.Get(hash); exist {
 		return item.lookup, item.transaction, nil
 	}
-	tx, blockHash, blockNumber, txIndex := rawdb.ReadTransaction(bc.db, hash)
+	var (
+		tx          *types.Transaction
+		blockHash   common.Hash
+		blockNumber uint64
+		txIndex     uint64
+	)
+	if number := rawdb.ReadTxLookupEntry(bc.db, hash); number != nil && bc.expired(*number) {
+		blockNumber = *number
+		tx, blockHash, txIndex = bc.readHistoryTransaction(hash, blockNumber)
+	} else {
+		tx, blockHash, blockNumber, txIndex = rawdb.ReadTransaction(bc.db, hash)
+	}
 	if tx == nil {
 		progress, err := bc.TxIndexProgress()
 		if err != nil {
@@ -17,11 +17,14 @@
 package core
 
 import (
+	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"math/big"
 	"math/rand"
 	"os"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
@@ -33,6 +36,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/state/pruner"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -40,6 +44,7 @@ import (
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
 	"github.com/holiman/uint256"
 )
 
@@ -163,13 +168,13 @@ func testBlockChainImport(chain types.Blocks, blockchain *BlockChain) error {
 		if err != nil {
 			return err
 		}
-		receipts, _, usedGas, err := blockchain.processor.Process(block, statedb, vm.Config{})
+		res, err := blockchain.processor.Process(block, statedb, vm.Config{})
 		if err != nil {
-			blockchain.reportBlock(block, receipts, err)
+			blockchain.reportBlock(block, nil, err)
 			return err
 		}
-		if err = blockchain.validator.ValidateState(block, statedb, receipts, usedGas, false); err != nil {
-			blockchain.reportBlock(block, receipts, err)
+		if err = blockchain.validator.ValidateState(block, statedb, res, false); err != nil {
+			blockchain.reportBlock(block, res.Receipts, err)
 			return err
 		}
 
@@ -4220,3 +4225,397 @@ func TestEIP3651(t *testing.T) {
 		t.Fatalf("sender balance incorrect: expected %d, got %d", expected, actual)
 	}
 }
+
+func TestEIP6110(t *testing.T) {
+	var (
+		engine = beacon.NewFaker()
+
+		// A sender who makes transactions, has some funds
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		funds   = new(big.Int).Mul(common.Big1, big.NewInt(params.Ether))
+		deposit = common.HexToAddress("0x00000000219ab540356cbb839cbe05303d7705fa")
+		config  = *params.MergedTestChainConfig
+		gspec   = &Genesis{
+			Config: &config,
+			Alloc: types.GenesisAlloc{
+				addr: {Balance: funds},
+				// The deposit contract emits a DepositEvent with the
+				// calldata as its pre-encoded payload.
+				deposit: {
+					Code: append(append([]byte{
+						byte(vm.PUSH2), 0x02, 0x40, // size
+						byte(vm.PUSH0), // offset
+						byte(vm.PUSH0), // dest offset
+						byte(vm.CALLDATACOPY),
+						byte(vm.PUSH32),
+					}, depositTopic.Bytes()...), []byte{
+						byte(vm.PUSH2), 0x02, 0x40, // size
+						byte(vm.PUSH0), // offset
+						byte(vm.LOG1),
+						byte(vm.STOP),
+					}...),
+					Nonce:   0,
+					Balance: big.NewInt(0),
+				},
+			},
+		}
+	)
+	gspec.Config.PragueTime = u64(0)
+	gspec.Config.DepositContractAddress = deposit
+	signer := types.LatestSigner(gspec.Config)
+
+	// packDeposit lays out a deposit in the ABI encoding of the deposit event.
+	packDeposit := func(d *types.Deposit) []byte {
+		data := make([]byte, 576)
+		copy(data[192:240], d.PublicKey[:])
+		copy(data[288:320], d.WithdrawalCredentials[:])
+		binary.LittleEndian.PutUint64(data[352:360], d.Amount)
+		copy(data[416:512], d.Signature[:])
+		binary.LittleEndian.PutUint64(data[544:552], d.Index)
+		return data
+	}
+	want := []*types.Deposit{
+		{PublicKey: [48]byte{0x01}, WithdrawalCredentials: common.Hash{0x02}, Amount: 32000000000, Signature: [96]byte{0x03}, Index: 0},
+		{PublicKey: [48]byte{0x04}, WithdrawalCredentials: common.Hash{0x05}, Amount: 1000000000, Signature: [96]byte{0x06}, Index: 1},
+	}
+	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, 1, func(i int, b *BlockGen) {
+		for j, d := range want {
+			tx := types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+				ChainID:   gspec.Config.ChainID,
+				Nonce:     uint64(j),
+				To:        &deposit,
+				Gas:       500000,
+				GasFeeCap: newGwei(5),
+				GasTipCap: big.NewInt(2),
+				Data:      packDeposit(d),
+			})
+			b.AddTx(tx)
+		}
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+
+	block := chain.GetBlockByNumber(1)
+	if block == nil {
+		t.Fatalf("failed to retrieve block 1")
+	}
+	// Verify the deposit requests match the expected ones.
+	deposits := block.Requests().Deposits()
+	if len(deposits) != len(want) {
+		t.Fatalf("wrong number of deposits: got %d, want %d", len(deposits), len(want))
+	}
+	for i, d := range deposits {
+		if !reflect.DeepEqual(d, want[i]) {
+			t.Fatalf("deposit %d mismatch: got %+v, want %+v", i, d, want[i])
+		}
+	}
+	if hash := types.DeriveSha(block.Requests(), trie.NewStackTrie(nil)); block.Header().RequestsHash == nil || hash != *block.Header().RequestsHash {
+		t.Fatalf("requests hash mismatch: got %v, want %x", block.Header().RequestsHash, hash)
+	}
+}
+
+// Tests that transactions don't pay for gas while gas price is disabled by the
+// chain transitions, and that the contract size limit follows the transitions.
+func TestGaslessTransition(t *testing.T) {
+	var (
+		bb       = common.HexToAddress("0x000000000000000000000000000000000000bbbb")
+		engine   = beacon.NewFaker()
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr     = crypto.PubkeyToAddress(key.PublicKey)
+		funds    = big.NewInt(1000000)
+		disabled = false
+		enabled  = true
+		config   = *params.MergedTestChainConfig
+		gspec    = &Genesis{
+			Config:   &config,
+			GasLimit: 30_000_000,
+			Alloc:    types.GenesisAlloc{addr: {Balance: funds}},
+		}
+		codeSize = 30 * 1024 // above the default limit, below the configured one
+	)
+	config.Transitions = []params.Transition{
+		{Block: big.NewInt(0), GasPriceEnabled: &disabled, ContractSizeLimit: 32},
+		{Block: big.NewInt(2), GasPriceEnabled: &enabled},
+	}
+	signer := types.LatestSigner(gspec.Config)
+
+	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, 1, func(i int, b *BlockGen) {
+		b.AddTx(types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   gspec.Config.ChainID,
+			Nonce:     0,
+			To:        &bb,
+			Gas:       params.TxGas,
+			GasFeeCap: new(big.Int),
+			GasTipCap: new(big.Int),
+			Value:     big.NewInt(1),
+		}))
+		// Deploy a contract returning codeSize zero bytes
+		b.AddTx(types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   gspec.Config.ChainID,
+			Nonce:     1,
+			Gas:       10_000_000,
+			GasFeeCap: new(big.Int),
+			GasTipCap: new(big.Int),
+			Data:      []byte{byte(vm.PUSH2), byte(codeSize >> 8), byte(codeSize), byte(vm.PUSH0), byte(vm.RETURN)},
+		}))
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	block := chain.GetBlockByNumber(1)
+	if block.BaseFee().Sign() == 0 {
+		t.Fatalf("expected non-zero base fee")
+	}
+	receipts := chain.GetReceiptsByHash(block.Hash())
+	if len(receipts) != 2 || receipts[1].Status != types.ReceiptStatusSuccessful {
+		t.Fatalf("contract creation failed")
+	}
+	state, _ := chain.State()
+
+	// The sender only paid for the transferred value, the miner got nothing
+	if have, want := state.GetBalance(addr).ToBig(), new(big.Int).Sub(funds, common.Big1); have.Cmp(want) != 0 {
+		t.Fatalf("sender balance incorrect: have %d, want %d", have, want)
+	}
+	if have := state.GetBalance(block.Coinbase()); !have.IsZero() {
+		t.Fatalf("miner balance incorrect: have %d, want 0", have)
+	}
+	if have := state.GetCodeSize(receipts[1].ContractAddress); have != codeSize {
+		t.Fatalf("contract code size incorrect: have %d, want %d", have, codeSize)
+	}
+	// Once gas price is enabled, zero priced transactions are invalid
+	header := &types.Header{
+		ParentHash: block.Hash(),
+		Number:     big.NewInt(2),
+		Time:       block.Time() + 10,
+		GasLimit:   block.GasLimit(),
+		BaseFee:    block.BaseFee(),
+		Difficulty: new(big.Int),
+		MixDigest:  common.Hash{},
+	}
+	msg := &Message{
+		From:      addr,
+		To:        &bb,
+		Nonce:     2,
+		Value:     big.NewInt(1),
+		GasLimit:  params.TxGas,
+		GasPrice:  new(big.Int),
+		GasFeeCap: new(big.Int),
+		GasTipCap: new(big.Int),
+	}
+	evm := vm.NewEVM(NewEVMBlockContext(header, chain, nil), NewEVMTxContext(msg), state, chain.Config(), vm.Config{})
+	if _, err := ApplyMessage(evm, msg, new(GasPool).AddGas(header.GasLimit)); !errors.Is(err, ErrFeeCapTooLow) {
+		t.Fatalf("zero priced transaction error mismatch: have %v, want %v", err, ErrFeeCapTooLow)
+	}
+}
+
+// Tests that blocks containing transactions above the size limit configured by
+// the chain transitions are rejected.
+func TestTransactionSizeLimitTransition(t *testing.T) {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		config = *params.TestChainConfig
+		gspec  = &Genesis{
+			Config: &config,
+			Alloc:  types.GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	config.Transitions = []params.Transition{
+		{Block: big.NewInt(2), TransactionSizeLimit: 1},
+	}
+	// Include a 2KB transaction in both blocks, the second one exceeds the limit
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 2, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), common.Address{0xbb}, big.NewInt(1), 100000, b.header.BaseFee, make([]byte, 2048)), signer, key)
+		b.AddTx(tx)
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	n, err := chain.InsertChain(blocks)
+	if !errors.Is(err, ErrTxSizeLimitExceeded) {
+		t.Fatalf("oversized transaction error mismatch: have %v, want %v", err, ErrTxSizeLimitExceeded)
+	}
+	if n != 1 {
+		t.Fatalf("wrong block rejected: have %d, want 1", n)
+	}
+}
+
+// Tests that the online pruner is told to retain the states of the recent
+// blocks, that they are pinned in memory rather than flushed, and that it's held
+// off until the chain processes blocks itself.
+func TestRetainRecentState(t *testing.T) {
+	var (
+		gspec        = &Genesis{Config: params.TestChainConfig, BaseFee: big.NewInt(params.InitialBaseFee)}
+		_, blocks, _ = GenerateChainWithGenesis(gspec, ethash.NewFaker(), state.TriesInMemory+2, nil)
+		config       = DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	)
+	config.SnapshotLimit = 0 // Keep the snapshot disk layer out of the retained states
+
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), config, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, _, err := chain.retainRecentState(); !errors.Is(err, pruner.ErrChainSyncing) {
+		t.Fatalf("empty chain error mismatch: have %v, want %v", err, pruner.ErrChainSyncing)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	roots, release, err := chain.retainRecentState()
+	if err != nil {
+		t.Fatalf("failed to retain recent state: %v", err)
+	}
+	if len(roots) != state.TriesInMemory {
+		t.Fatalf("retained state count mismatch: have %d, want %d", len(roots), state.TriesInMemory)
+	}
+	for i, root := range roots {
+		want := blocks[len(blocks)-1-i].Root()
+		if root != want {
+			t.Errorf("retained state %d mismatch: have %x, want %x", i, root, want)
+		}
+	}
+	if rawdb.HasLegacyTrieNode(chain.db, roots[0]) {
+		t.Errorf("head state flushed to disk")
+	}
+	// The oldest retained state is persisted to survive the sweep
+	if !rawdb.HasLegacyTrieNode(chain.db, roots[len(roots)-1]) {
+		t.Errorf("oldest retained state not flushed to disk")
+	}
+	// The other retained states outlive the chain's own reference until released
+	pinned := roots[len(roots)-2]
+	chain.triedb.Dereference(pinned)
+	if !chain.HasState(pinned) {
+		t.Fatalf("retained state garbage collected before release")
+	}
+	release()
+	if chain.HasState(pinned) {
+		t.Errorf("released state not garbage collected")
+	}
+}
+
+// Tests that a complete state is left on disk after an online pruning cycle swept
+// the state flushed before, so that a crash before the next flush doesn't lose
+// all the state.
+func TestOnlinePruningCrash(t *testing.T) {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		gspec  = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   types.GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer       = types.LatestSigner(gspec.Config)
+		_, blocks, _ = GenerateChainWithGenesis(gspec, ethash.NewFaker(), state.TriesInMemory+20, func(i int, b *BlockGen) {
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), common.Address{byte(i), 1}, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+			b.AddTx(tx)
+		})
+		config = DefaultCacheConfigWithScheme(rawdb.HashScheme)
+		db     = rawdb.NewMemoryDatabase()
+		prune  = pruner.NewOnlinePruner(db, pruner.OnlineConfig{BloomSize: 256})
+	)
+	config.SnapshotLimit = 0 // Keep the snapshot disk layer out of the retained states
+
+	chain, err := NewBlockChain(prune.Database(), config, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	// Flush an early state, like the periodic flush of the chain does, and keep
+	// the more recent ones in memory only.
+	if _, err := chain.InsertChain(blocks[:10]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	flushed := blocks[9].Root()
+	if err := chain.triedb.Commit(flushed, false); err != nil {
+		t.Fatalf("failed to flush state: %v", err)
+	}
+	chain.lastWrite = blocks[9].NumberU64()
+
+	if _, err := chain.InsertChain(blocks[10:]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if err := prune.Prune(chain.triedb, chain.retainRecentState); err != nil {
+		t.Fatalf("pruning failed: %v", err)
+	}
+	if rawdb.HasLegacyTrieNode(db, flushed) {
+		t.Fatalf("stale flushed state was not pruned")
+	}
+	// Crash without flushing the trie database and restart the chain
+	chain.stopWithoutSaving()
+
+	chain, err = NewBlockChain(db, config, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to recreate chain: %v", err)
+	}
+	defer chain.Stop()
+
+	head := chain.CurrentBlock()
+	if want := blocks[len(blocks)-state.TriesInMemory].Header(); head.Hash() != want.Hash() {
+		t.Fatalf("chain rewound to wrong block: have %d, want %d", head.Number, want.Number)
+	}
+	if err := checkStateComplete(db, head.Root); err != nil {
+		t.Fatalf("state of head block %d is incomplete: %v", head.Number, err)
+	}
+}
+
+// checkStateComplete iterates the entire state with the given root from disk,
+// and returns an error if any trie node or contract code is missing.
+func checkStateComplete(db ethdb.Database, root common.Hash) error {
+	tdb := triedb.NewDatabase(db, triedb.HashDefaults)
+	accTrie, err := trie.NewStateTrie(trie.StateTrieID(root), tdb)
+	if err != nil {
+		return err
+	}
+	accIt, err := accTrie.NodeIterator(nil)
+	if err != nil {
+		return err
+	}
+	for accIt.Next(true) {
+		if !accIt.Leaf() {
+			continue
+		}
+		account, err := types.FullAccount(accIt.LeafBlob())
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(account.CodeHash, types.EmptyCodeHash.Bytes()) && !rawdb.HasCode(db, common.BytesToHash(account.CodeHash)) {
+			return fmt.Errorf("missing code %x", account.CodeHash)
+		}
+		if account.Root == types.EmptyRootHash {
+			continue
+		}
+		id := trie.StorageTrieID(root, common.BytesToHash(accIt.LeafKey()), account.Root)
+		storageTrie, err := trie.NewStateTrie(id, tdb)
+		if err != nil {
+			return err
+		}
+		storageIt, err := storageTrie.NodeIterator(nil)
+		if err != nil {
+			return err
+		}
+		for storageIt.Next(true) {
+		}
+		if err := storageIt.Error(); err != nil {
+			return err
+		}
+	}
+	return accIt.Error()
+}
@@ -105,6 +105,34 @@ func (b *BlockGen) SetParentBeaconRoot(root common.Hash) {
 	ProcessBeaconBlockRoot(root, vmenv, b.statedb)
 }
 
+// processParentBlockHash stores the parent hash of the generated block in the
+// EIP-2935 history storage contract.
+func (b *BlockGen) processParentBlockHash() {
+	var (
+		blockContext = NewEVMBlockContext(b.header, b.cm, &b.header.Coinbase)
+		vmenv        = vm.NewEVM(blockContext, vm.TxContext{}, b.statedb, b.cm.config, vm.Config{})
+	)
+	ProcessParentBlockHash(b.header.ParentHash, vmenv, b.statedb)
+}
+
+// collectRequests gathers the EIP-7685 requests of the generated block from the
+// logs of its receipts and the request system contracts.
+func (b *BlockGen) collectRequests() types.Requests {
+	var logs []*types.Log
+	for _, r := range b.receipts {
+		logs = append(logs, r.Logs...)
+	}
+	var (
+		blockContext = NewEVMBlockContext(b.header, b.cm, &b.header.Coinbase)
+		vmenv        = vm.NewEVM(blockContext, vm.TxContext{}, b.statedb, b.cm.config, vm.Config{})
+	)
+	requests, err := ProcessRequests(logs, vmenv, b.statedb)
+	if err != nil {
+		panic(fmt.Sprintf("failed to collect requests: %v", err))
+	}
+	return requests
+}
+
 // addTx adds a transaction to the generated block. If no coinbase has
 // been set, the block's coinbase is set to the zero address.
 //
@@ -341,12 +369,18 @@ func GenerateChain(config *params.ChainConfig, parent *types.Block, engine conse
 		if config.DAOForkSupport && config.DAOForkBlock != nil && config.DAOForkBlock.Cmp(b.header.Number) == 0 {
 			misc.ApplyDAOHardFork(statedb)
 		}
+		if config.IsHistoryStorage(b.header.Number, b.header.Time) {
+			b.processParentBlockHash()
+		}
 		// Execute any user modifications to the block
 		if gen != nil {
 			gen(i, b)
 		}
 
 		body := types.Body{Transactions: b.txs, Uncles: b.uncles, Withdrawals: b.withdrawals}
+		if config.IsPrague(b.header.Number, b.header.Time) {
+			body.Requests = b.collectRequests()
+		}
 		block, err := b.engine.FinalizeAndAssemble(cm, b.header, statedb, &body, b.receipts)
 		if err != nil {
 			panic(err)
@@ -435,15 +469,9 @@ func GenerateVerkleChain(config *params.ChainConfig, parent *types.Block, engine
 		// Save pre state for proof generation
 		// preState := statedb.Copy()
 
-		// TODO uncomment when the 2935 PR is merged
-		// if config.IsPrague(b.header.Number, b.header.Time) {
-		// if !config.IsPrague(b.parent.Number(), b.parent.Time()) {
-		// Transition case: insert all 256 ancestors
-		// 		InsertBlockHashHistoryAtEip2935Fork(statedb, b.header.Number.Uint64()-1, b.header.ParentHash, chainreader)
-		// 	} else {
-		// 		ProcessParentBlockHash(statedb, b.header.Number.Uint64()-1, b.header.ParentHash)
-		// 	}
-		// }
+		if config.IsHistoryStorage(b.header.Number, b.header.Time) {
+			b.processParentBlockHash()
+		}
 		// Execute any user modifications to the block
 		if gen != nil {
 			gen(i, b)
@@ -453,6 +481,9 @@ func GenerateVerkleChain(config *params.ChainConfig, parent *types.Block, engine
 			Uncles:       b.uncles,
 			Withdrawals:  b.withdrawals,
 		}
+		if config.IsPrague(b.header.Number, b.header.Time) {
+			body.Requests = b.collectRequests()
+		}
 		block, err := b.engine.FinalizeAndAssemble(cm, b.header, statedb, body, b.receipts)
 		if err != nil {
 			panic(err)
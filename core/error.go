@@ -26,6 +26,10 @@ var (
 	// ErrKnownBlock is returned when a block to import is already known locally.
 	ErrKnownBlock = errors.New("block already known")
 
+	// ErrTxSizeLimitExceeded is returned if a block contains a transaction larger
+	// than the transaction size limit configured for the chain.
+	ErrTxSizeLimitExceeded = errors.New("transaction size limit exceeded")
+
 	// ErrNoGenesis is returned when there is no Genesis Block.
 	ErrNoGenesis = errors.New("genesis not found in chain")
 
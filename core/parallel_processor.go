@@ -0,0 +1,459 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/holiman/uint256"
+)
+
+var (
+	parallelMergedMeter     = metrics.NewRegisteredMeter("chain/parallel/merged", nil)
+	parallelReexecutedMeter = metrics.NewRegisteredMeter("chain/parallel/reexecuted", nil)
+)
+
+// stateKeyKind is the type of account field a stateKey refers to.
+type stateKeyKind uint8
+
+const (
+	balanceKey stateKeyKind = iota
+	nonceKey
+	codeKey
+	storageKey
+)
+
+// stateKey identifies a single piece of state accessed by a transaction.
+type stateKey struct {
+	addr common.Address
+	kind stateKeyKind
+	slot common.Hash // only set for storage keys
+}
+
+// trackedState wraps a StateDB and records the state accessed by the transaction
+// executed on top of it.
+//
+// Balance changes are recorded as deltas, so that transactions crediting the same
+// account (e.g. the coinbase) don't conflict with each other. Nonce and storage
+// changes are recorded as absolute writes. In speculative mode, operations which
+// change the structure of an account (creation, destruction, code deployment)
+// can't be merged back safely and flag the transaction for re-execution.
+type trackedState struct {
+	*state.StateDB
+	speculative bool
+
+	reads      map[stateKey]struct{}           // State read by the transaction
+	writes     map[stateKey]struct{}           // Nonces and storage slots written
+	balances   map[common.Address]*uint256.Int // Balances before the first modification
+	structural map[common.Address]struct{}     // Accounts created, destructed or deployed to
+	preimages  map[common.Hash][]byte          // Preimages recorded by the transaction
+
+	unsupported bool // Flag whether the transaction can't be merged
+}
+
+func newTrackedState(db *state.StateDB, speculative bool) *trackedState {
+	return &trackedState{
+		StateDB:     db,
+		speculative: speculative,
+		reads:       make(map[stateKey]struct{}),
+		writes:      make(map[stateKey]struct{}),
+		balances:    make(map[common.Address]*uint256.Int),
+		structural:  make(map[common.Address]struct{}),
+		preimages:   make(map[common.Hash][]byte),
+	}
+}
+
+func (t *trackedState) read(addr common.Address, kind stateKeyKind, slot common.Hash) {
+	t.reads[stateKey{addr: addr, kind: kind, slot: slot}] = struct{}{}
+}
+
+// touch is invoked before modifying an account. Speculative writes are only
+// supported on accounts which already exist in the state.
+func (t *trackedState) touch(addr common.Address) {
+	if t.speculative && !t.StateDB.Exist(addr) {
+		t.unsupported = true
+	}
+}
+
+// restructure is invoked on operations changing the structure of an account.
+func (t *trackedState) restructure(addr common.Address) {
+	if t.speculative {
+		t.unsupported = true
+	}
+	t.structural[addr] = struct{}{}
+}
+
+func (t *trackedState) trackBalance(addr common.Address) {
+	t.touch(addr)
+	if _, ok := t.balances[addr]; !ok {
+		t.balances[addr] = new(uint256.Int).Set(t.StateDB.GetBalance(addr))
+	}
+}
+
+func (t *trackedState) CreateAccount(addr common.Address) {
+	t.restructure(addr)
+	t.StateDB.CreateAccount(addr)
+}
+
+func (t *trackedState) CreateContract(addr common.Address) {
+	t.restructure(addr)
+	t.StateDB.CreateContract(addr)
+}
+
+func (t *trackedState) SubBalance(addr common.Address, amount *uint256.Int, reason tracing.BalanceChangeReason) {
+	t.trackBalance(addr)
+	t.StateDB.SubBalance(addr, amount, reason)
+}
+
+func (t *trackedState) AddBalance(addr common.Address, amount *uint256.Int, reason tracing.BalanceChangeReason) {
+	t.trackBalance(addr)
+	t.StateDB.AddBalance(addr, amount, reason)
+}
+
+func (t *trackedState) GetBalance(addr common.Address) *uint256.Int {
+	t.read(addr, balanceKey, common.Hash{})
+	return t.StateDB.GetBalance(addr)
+}
+
+func (t *trackedState) GetNonce(addr common.Address) uint64 {
+	t.read(addr, nonceKey, common.Hash{})
+	return t.StateDB.GetNonce(addr)
+}
+
+func (t *trackedState) SetNonce(addr common.Address, nonce uint64) {
+	t.touch(addr)
+	t.writes[stateKey{addr: addr, kind: nonceKey}] = struct{}{}
+	t.StateDB.SetNonce(addr, nonce)
+}
+
+func (t *trackedState) GetCodeHash(addr common.Address) common.Hash {
+	t.read(addr, codeKey, common.Hash{})
+	return t.StateDB.GetCodeHash(addr)
+}
+
+func (t *trackedState) GetCode(addr common.Address) []byte {
+	t.read(addr, codeKey, common.Hash{})
+	return t.StateDB.GetCode(addr)
+}
+
+func (t *trackedState) GetCodeSize(addr common.Address) int {
+	t.read(addr, codeKey, common.Hash{})
+	return t.StateDB.GetCodeSize(addr)
+}
+
+func (t *trackedState) SetCode(addr common.Address, code []byte) {
+	t.restructure(addr)
+	t.StateDB.SetCode(addr, code)
+}
+
+func (t *trackedState) GetCommittedState(addr common.Address, key common.Hash) common.Hash {
+	t.read(addr, storageKey, key)
+	return t.StateDB.GetCommittedState(addr, key)
+}
+
+func (t *trackedState) GetState(addr common.Address, key common.Hash) common.Hash {
+	t.read(addr, storageKey, key)
+	return t.StateDB.GetState(addr, key)
+}
+
+func (t *trackedState) SetState(addr common.Address, key, value common.Hash) {
+	t.touch(addr)
+	t.writes[stateKey{addr: addr, kind: storageKey, slot: key}] = struct{}{}
+	t.StateDB.SetState(addr, key, value)
+}
+
+func (t *trackedState) GetStorageRoot(addr common.Address) common.Hash {
+	// The storage root depends on every slot of the account, it is only
+	// consulted during contract creation which is not merged anyway.
+	if t.speculative {
+		t.unsupported = true
+	}
+	return t.StateDB.GetStorageRoot(addr)
+}
+
+func (t *trackedState) SelfDestruct(addr common.Address) {
+	t.restructure(addr)
+	t.StateDB.SelfDestruct(addr)
+}
+
+func (t *trackedState) Selfdestruct6780(addr common.Address) {
+	t.restructure(addr)
+	t.StateDB.Selfdestruct6780(addr)
+}
+
+func (t *trackedState) Exist(addr common.Address) bool {
+	t.read(addr, balanceKey, common.Hash{})
+	t.read(addr, nonceKey, common.Hash{})
+	t.read(addr, codeKey, common.Hash{})
+	return t.StateDB.Exist(addr)
+}
+
+func (t *trackedState) Empty(addr common.Address) bool {
+	t.read(addr, balanceKey, common.Hash{})
+	t.read(addr, nonceKey, common.Hash{})
+	t.read(addr, codeKey, common.Hash{})
+	return t.StateDB.Empty(addr)
+}
+
+func (t *trackedState) AddPreimage(hash common.Hash, preimage []byte) {
+	t.preimages[hash] = preimage
+	t.StateDB.AddPreimage(hash, preimage)
+}
+
+// checkEmpty flags the transaction as unsupported if any account it modified
+// ended up empty, since deleting it can't be expressed as a delta.
+func (t *trackedState) checkEmpty() {
+	for addr := range t.balances {
+		if !t.StateDB.Exist(addr) || t.StateDB.Empty(addr) {
+			t.unsupported = true
+			return
+		}
+	}
+	for key := range t.writes {
+		if !t.StateDB.Exist(key.addr) || t.StateDB.Empty(key.addr) {
+			t.unsupported = true
+			return
+		}
+	}
+}
+
+// conflicts reports whether the transaction accessed any state that was modified
+// by a previously committed transaction. Absolute writes are also checked, as
+// merging them would overwrite the earlier changes.
+func (t *trackedState) conflicts(committed map[stateKey]struct{}, structural map[common.Address]struct{}) bool {
+	for _, keys := range []map[stateKey]struct{}{t.reads, t.writes} {
+		for key := range keys {
+			if _, ok := committed[key]; ok {
+				return true
+			}
+			if _, ok := structural[key.addr]; ok {
+				return true
+			}
+		}
+	}
+	for addr := range t.balances {
+		if _, ok := structural[addr]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// merge applies the state changes of the speculatively executed transaction to
+// the given state.
+func (t *trackedState) merge(dst *state.StateDB, txHash common.Hash) {
+	for addr, prev := range t.balances {
+		cur := t.StateDB.GetBalance(addr)
+		switch cur.Cmp(prev) {
+		case 1:
+			dst.AddBalance(addr, new(uint256.Int).Sub(cur, prev), tracing.BalanceChangeUnspecified)
+		case -1:
+			dst.SubBalance(addr, new(uint256.Int).Sub(prev, cur), tracing.BalanceChangeUnspecified)
+		}
+	}
+	for key := range t.writes {
+		switch key.kind {
+		case nonceKey:
+			dst.SetNonce(key.addr, t.StateDB.GetNonce(key.addr))
+		case storageKey:
+			dst.SetState(key.addr, key.slot, t.StateDB.GetState(key.addr, key.slot))
+		}
+	}
+	for hash, preimage := range t.preimages {
+		dst.AddPreimage(hash, preimage)
+	}
+	for _, log := range t.StateDB.GetLogs(txHash, 0, common.Hash{}) {
+		dst.AddLog(&types.Log{Address: log.Address, Topics: log.Topics, Data: log.Data})
+	}
+}
+
+// mergeWitness loads the state read by the speculatively executed transaction
+// into the given state and adds the code and headers it accessed to the witness
+// of the latter, as if the transaction was executed on it.
+func (t *trackedState) mergeWitness(dst *state.StateDB) {
+	for key := range t.reads {
+		if key.kind == storageKey {
+			dst.GetCommittedState(key.addr, key.slot)
+		} else {
+			dst.Exist(key.addr)
+		}
+	}
+	witness, spec := dst.Witness(), t.StateDB.Witness()
+	for code := range spec.Codes {
+		witness.AddCode([]byte(code))
+	}
+	if n := len(spec.Headers); n > 0 {
+		witness.AddBlockHash(spec.Headers[n-1].Number.Uint64())
+	}
+}
+
+// record adds the state modified by the transaction to the committed set. It is
+// invoked on the finalised state, so accounts deleted at the end of the
+// transaction can be detected.
+func (t *trackedState) record(db *state.StateDB, committed map[stateKey]struct{}, structural map[common.Address]struct{}) {
+	for addr := range t.balances {
+		committed[stateKey{addr: addr, kind: balanceKey}] = struct{}{}
+		if !db.Exist(addr) {
+			structural[addr] = struct{}{}
+		}
+	}
+	for key := range t.writes {
+		committed[key] = struct{}{}
+		if !db.Exist(key.addr) {
+			structural[key.addr] = struct{}{}
+		}
+	}
+	for addr := range t.structural {
+		structural[addr] = struct{}{}
+	}
+}
+
+// speculation is a transaction executed optimistically against the pre-block
+// state.
+type speculation struct {
+	msg    *Message
+	state  *trackedState
+	result *ExecutionResult
+	err    error
+}
+
+// execute runs the speculative transaction on the given EVM. Each speculation
+// uses its own gas pool, the block gas limit is enforced when merging.
+func (s *speculation) execute(evm *vm.EVM, gasLimit uint64) {
+	evm.Reset(NewEVMTxContext(s.msg), s.state)
+	s.result, s.err = ApplyMessage(evm, s.msg, new(GasPool).AddGas(gasLimit))
+	if s.err != nil {
+		return
+	}
+	s.state.StateDB.Finalise(true)
+	s.state.checkEmpty()
+}
+
+// parallelizable reports whether the transactions of the block may be executed
+// by the parallel executor.
+func (p *StateProcessor) parallelizable(block *types.Block, statedb *state.StateDB, cfg vm.Config) bool {
+	if !cfg.EnableParallelExecution || cfg.Tracer != nil {
+		return false
+	}
+	// Receipts of pre-Byzantium blocks contain intermediate roots, and verkle
+	// access events are not tracked by the executor.
+	if !p.config.IsByzantium(block.Number()) || p.config.IsVerkle(block.Number(), block.Time()) {
+		return false
+	}
+	return len(block.Transactions()) > 1
+}
+
+// processParallel executes the transactions of the block optimistically in
+// parallel, each on its own copy of the pre-block state. The results are then
+// committed in order: transactions which read state modified by an earlier one,
+// or whose effects can't be merged, are re-executed sequentially. The outcome is
+// identical to sequential execution.
+func (p *StateProcessor) processParallel(block *types.Block, statedb *state.StateDB, cfg vm.Config, gp *GasPool, usedGas *uint64) (types.Receipts, []*types.Log, error) {
+	var (
+		header = block.Header()
+		txs    = block.Transactions()
+		signer = types.MakeSigner(p.config, header.Number, header.Time)
+		specs  = make([]*speculation, len(txs))
+	)
+	for i, tx := range txs {
+		msg, err := TransactionToMessage(tx, signer, header.BaseFee)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+		}
+		db := statedb.Copy()
+		db.SetTxContext(tx.Hash(), i)
+		specs[i] = &speculation{msg: msg, state: newTrackedState(db, true)}
+	}
+	// Execute all transactions speculatively against the pre-block state
+	var (
+		wg      sync.WaitGroup
+		jobs    = make(chan *speculation, len(specs))
+		workers = min(runtime.NumCPU(), len(specs))
+	)
+	for _, spec := range specs {
+		jobs <- spec
+	}
+	close(jobs)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// The block hash cache of the context is not thread safe, so every
+			// worker needs its own.
+			evm := vm.NewEVM(NewEVMBlockContext(header, p.chain, nil), vm.TxContext{}, nil, p.config, cfg)
+			for spec := range jobs {
+				spec.execute(evm, header.GasLimit)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Commit the results in order, re-executing any transaction which conflicts
+	var (
+		receipts   types.Receipts
+		allLogs    []*types.Log
+		committed  = make(map[stateKey]struct{})
+		structural = make(map[common.Address]struct{})
+		vmenv      = vm.NewEVM(NewEVMBlockContext(header, p.chain, nil), vm.TxContext{}, statedb, p.config, cfg)
+	)
+	for i, tx := range txs {
+		var (
+			spec    = specs[i]
+			tracked = spec.state
+			result  = spec.result
+			err     error
+		)
+		statedb.SetTxContext(tx.Hash(), i)
+
+		if spec.err != nil || tracked.unsupported || gp.Gas() < spec.msg.GasLimit || tracked.conflicts(committed, structural) {
+			tracked = newTrackedState(statedb, false)
+			vmenv.Reset(NewEVMTxContext(spec.msg), tracked)
+			result, err = ApplyMessage(vmenv, spec.msg, gp)
+			if err != nil {
+				return nil, nil, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+			}
+			parallelReexecutedMeter.Mark(1)
+		} else {
+			if statedb.Witness() != nil {
+				tracked.mergeWitness(statedb)
+			}
+			tracked.merge(statedb, tx.Hash())
+			if err := gp.SubGas(result.UsedGas); err != nil {
+				return nil, nil, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+			}
+			parallelMergedMeter.Mark(1)
+		}
+		statedb.Finalise(true)
+		tracked.record(statedb, committed, structural)
+
+		*usedGas += result.UsedGas
+		receipt := makeReceipt(vmenv, spec.msg, result, statedb, header.Number, block.Hash(), tx, *usedGas, nil)
+		receipts = append(receipts, receipt)
+		allLogs = append(allLogs, receipt.Logs...)
+	}
+	return receipts, allLogs, nil
+}
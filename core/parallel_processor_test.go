@@ -0,0 +1,407 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that blocks executed by the parallel executor produce exactly the same
+// state and receipts as sequential execution, for a mix of independent and
+// conflicting transactions.
+func TestParallelExecution(t *testing.T) {
+	var (
+		keys     []*ecdsa.PrivateKey
+		accounts []common.Address
+		coinbase = common.HexToAddress("0xc014ba5e")
+		alloc    = types.GenesisAlloc{coinbase: {Balance: big.NewInt(1)}}
+
+		// Contract incrementing a shared counter and emitting a log, every call
+		// conflicts with the previous one
+		counter     = common.HexToAddress("0xc0de01")
+		counterCode = []byte{
+			byte(vm.PUSH1), 0, byte(vm.SLOAD), byte(vm.PUSH1), 1, byte(vm.ADD),
+			byte(vm.PUSH1), 0, byte(vm.SSTORE),
+			byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.LOG0), byte(vm.STOP),
+		}
+		// Contract incrementing a per-caller counter, calls from different
+		// senders are independent
+		ledger     = common.HexToAddress("0xc0de02")
+		ledgerCode = []byte{
+			byte(vm.CALLER), byte(vm.SLOAD), byte(vm.PUSH1), 1, byte(vm.ADD),
+			byte(vm.CALLER), byte(vm.SSTORE), byte(vm.STOP),
+		}
+	)
+	for i := 0; i < 8; i++ {
+		key, _ := crypto.GenerateKey()
+		keys = append(keys, key)
+		accounts = append(accounts, crypto.PubkeyToAddress(key.PublicKey))
+		alloc[accounts[i]] = types.Account{Balance: big.NewInt(params.Ether)}
+	}
+	alloc[counter] = types.Account{Code: counterCode, Balance: common.Big0}
+	alloc[ledger] = types.Account{Code: ledgerCode, Balance: common.Big0}
+
+	var (
+		gspec  = &Genesis{Config: params.TestChainConfig, Alloc: alloc}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 4, func(i int, b *BlockGen) {
+		b.SetCoinbase(coinbase)
+		for j, key := range keys {
+			var to common.Address
+			switch j % 4 {
+			case 0:
+				to = counter
+			case 1:
+				to = ledger
+			case 2:
+				to = accounts[(j+1)%len(accounts)] // transfer to another sender
+			case 3:
+				to = coinbase
+			}
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(accounts[j]), to, big.NewInt(1000), 100000, b.BaseFee(), nil), signer, key)
+			b.AddTx(tx)
+		}
+		// Multiple transactions from the same sender depend on each other
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(accounts[0]), ledger, big.NewInt(0), 100000, b.BaseFee(), nil), signer, keys[0])
+		b.AddTx(tx)
+
+		// Contract creation can't be merged and is always re-executed
+		tx, _ = types.SignTx(types.NewContractCreation(b.TxNonce(accounts[1]), big.NewInt(0), 100000, b.BaseFee(), []byte{byte(vm.STOP)}), signer, keys[1])
+		b.AddTx(tx)
+	})
+	// Import the chain with both executors, the block validator ensures that
+	// the state and receipt roots match the sequentially generated chain.
+	var chains []*BlockChain
+	for _, parallel := range []bool{false, true} {
+		chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfigWithScheme(rawdb.HashScheme), gspec, nil, ethash.NewFaker(), vm.Config{EnableParallelExecution: parallel}, nil, nil)
+		if err != nil {
+			t.Fatalf("failed to create chain: %v", err)
+		}
+		defer chain.Stop()
+
+		if n, err := chain.InsertChain(blocks); err != nil {
+			t.Fatalf("parallel %v: failed to insert block %d: %v", parallel, n, err)
+		}
+		chains = append(chains, chain)
+	}
+	for _, block := range blocks {
+		var (
+			want = chains[0].GetReceiptsByHash(block.Hash())
+			have = chains[1].GetReceiptsByHash(block.Hash())
+		)
+		if len(have) != len(want) {
+			t.Fatalf("block %d: receipt count mismatch: have %d, want %d", block.NumberU64(), len(have), len(want))
+		}
+		for i := range want {
+			if have[i].Status != want[i].Status || have[i].GasUsed != want[i].GasUsed || len(have[i].Logs) != len(want[i].Logs) {
+				t.Errorf("block %d: receipt %d mismatch", block.NumberU64(), i)
+			}
+			for j := range want[i].Logs {
+				if have[i].Logs[j].Index != want[i].Logs[j].Index {
+					t.Errorf("block %d: receipt %d log %d index mismatch: have %d, want %d", block.NumberU64(), i, j, have[i].Logs[j].Index, want[i].Logs[j].Index)
+				}
+			}
+		}
+	}
+}
+
+// Tests that the parallel and the sequential executor produce the same state
+// root, receipts and logs when processing randomly generated blocks full of
+// conflicting transactions.
+func TestParallelExecutionDifferential(t *testing.T) {
+	for seed := int64(1); seed <= 4; seed++ {
+		testParallelExecutionDifferential(t, seed)
+	}
+}
+
+func testParallelExecutionDifferential(t *testing.T, seed int64) {
+	var (
+		rnd      = rand.New(rand.NewSource(seed))
+		keys     []*ecdsa.PrivateKey
+		accounts []common.Address
+		coinbase = common.HexToAddress("0xc014ba5e")
+		sink     = common.HexToAddress("0x5141c")
+		alloc    = types.GenesisAlloc{coinbase: {Balance: big.NewInt(1)}, sink: {Balance: big.NewInt(1)}}
+
+		// Contract incrementing a shared counter and emitting a log
+		counter     = common.HexToAddress("0xc0de01")
+		counterCode = []byte{
+			byte(vm.PUSH1), 0, byte(vm.SLOAD), byte(vm.PUSH1), 1, byte(vm.ADD),
+			byte(vm.PUSH1), 0, byte(vm.SSTORE),
+			byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.LOG0), byte(vm.STOP),
+		}
+		// Contract incrementing a per-caller counter
+		ledger     = common.HexToAddress("0xc0de02")
+		ledgerCode = []byte{
+			byte(vm.CALLER), byte(vm.SLOAD), byte(vm.PUSH1), 1, byte(vm.ADD),
+			byte(vm.CALLER), byte(vm.SSTORE), byte(vm.STOP),
+		}
+		// Contract calling the shared counter, conflicting through another account
+		proxy     = common.HexToAddress("0xc0de03")
+		proxyCode = []byte{
+			byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+			byte(vm.PUSH3), 0xc0, 0xde, 0x01, byte(vm.GAS), byte(vm.CALL),
+			byte(vm.PUSH1), 0, byte(vm.MSTORE), byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.LOG0), byte(vm.STOP),
+		}
+		// Contract writing a slot and reverting
+		reverter     = common.HexToAddress("0xc0de04")
+		reverterCode = []byte{
+			byte(vm.PUSH1), 1, byte(vm.PUSH1), 0, byte(vm.SSTORE),
+			byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT),
+		}
+		// Contract forwarding the call value to the sink
+		forwarder     = common.HexToAddress("0xc0de05")
+		forwarderCode = []byte{
+			byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.CALLVALUE),
+			byte(vm.PUSH3), 0x05, 0x14, 0x1c, byte(vm.GAS), byte(vm.CALL), byte(vm.POP), byte(vm.STOP),
+		}
+		// Contract destructing itself in favour of the caller
+		destructor     = common.HexToAddress("0xc0de06")
+		destructorCode = []byte{byte(vm.CALLER), byte(vm.SELFDESTRUCT)}
+
+		// Init code storing a slot and deploying a single STOP
+		initCode = []byte{
+			byte(vm.PUSH1), 1, byte(vm.PUSH1), 0, byte(vm.SSTORE),
+			byte(vm.PUSH1), 1, byte(vm.PUSH1), 0, byte(vm.RETURN),
+		}
+	)
+	for i := 0; i < 10; i++ {
+		key, _ := crypto.GenerateKey()
+		keys = append(keys, key)
+		accounts = append(accounts, crypto.PubkeyToAddress(key.PublicKey))
+		alloc[accounts[i]] = types.Account{Balance: big.NewInt(params.Ether)}
+	}
+	alloc[counter] = types.Account{Code: counterCode, Balance: common.Big0}
+	alloc[ledger] = types.Account{Code: ledgerCode, Balance: common.Big0}
+	alloc[proxy] = types.Account{Code: proxyCode, Balance: common.Big0}
+	alloc[reverter] = types.Account{Code: reverterCode, Balance: common.Big0}
+	alloc[forwarder] = types.Account{Code: forwarderCode, Balance: common.Big0}
+	alloc[destructor] = types.Account{Code: destructorCode, Balance: big.NewInt(params.GWei)}
+
+	var (
+		gspec  = &Genesis{Config: params.TestChainConfig, Alloc: alloc, GasLimit: 30_000_000}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 6, func(i int, b *BlockGen) {
+		b.SetCoinbase(coinbase)
+		for j := 0; j < 40; j++ {
+			var (
+				sender = rnd.Intn(len(keys))
+				nonce  = b.TxNonce(accounts[sender])
+				value  = big.NewInt(rnd.Int63n(1000))
+				gas    = uint64(200000)
+				tx     *types.Transaction
+			)
+			switch rnd.Intn(10) {
+			case 0:
+				tx = types.NewTransaction(nonce, counter, value, gas, b.BaseFee(), nil)
+			case 1:
+				tx = types.NewTransaction(nonce, ledger, value, gas, b.BaseFee(), nil)
+			case 2:
+				tx = types.NewTransaction(nonce, proxy, value, gas, b.BaseFee(), nil)
+			case 3:
+				tx = types.NewTransaction(nonce, reverter, value, gas, b.BaseFee(), nil)
+			case 4:
+				tx = types.NewTransaction(nonce, forwarder, value, gas, b.BaseFee(), nil)
+			case 5:
+				tx = types.NewTransaction(nonce, destructor, value, gas, b.BaseFee(), nil)
+			case 6:
+				tx = types.NewTransaction(nonce, accounts[rnd.Intn(len(accounts))], value, gas, b.BaseFee(), nil)
+			case 7:
+				// Transfer to a fresh account
+				tx = types.NewTransaction(nonce, common.BigToAddress(big.NewInt(rnd.Int63())), value, gas, b.BaseFee(), nil)
+			case 8:
+				tx = types.NewContractCreation(nonce, value, gas, b.BaseFee(), initCode)
+			case 9:
+				// Runs out of gas in the counter
+				tx = types.NewTransaction(nonce, counter, value, 21100, b.BaseFee(), nil)
+			}
+			tx, _ = types.SignTx(tx, signer, keys[sender])
+			b.AddTx(tx)
+		}
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfigWithScheme(rawdb.HashScheme), gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("seed %d: failed to insert block %d: %v", seed, n, err)
+	}
+	processor := NewStateProcessor(gspec.Config, chain.HeaderChain())
+	for _, block := range blocks {
+		var (
+			parent = chain.GetHeaderByHash(block.ParentHash())
+			roots  []common.Hash
+			recs   []string
+			logs   []string
+		)
+		for _, parallel := range []bool{false, true} {
+			statedb, err := state.New(parent.Root, chain.stateCache, nil)
+			if err != nil {
+				t.Fatalf("seed %d: failed to open parent state: %v", seed, err)
+			}
+			cfg := vm.Config{EnableParallelExecution: parallel}
+			if parallel && !processor.parallelizable(block, statedb, cfg) {
+				t.Fatalf("seed %d: block %d not executed in parallel", seed, block.NumberU64())
+			}
+			res, err := processor.Process(block, statedb, cfg)
+			if err != nil {
+				t.Fatalf("seed %d: parallel %v: failed to process block %d: %v", seed, parallel, block.NumberU64(), err)
+			}
+			if res.GasUsed != block.GasUsed() {
+				t.Fatalf("seed %d: parallel %v: block %d gas mismatch: have %d, want %d", seed, parallel, block.NumberU64(), res.GasUsed, block.GasUsed())
+			}
+			recJSON, _ := json.Marshal(res.Receipts)
+			logJSON, _ := json.Marshal(res.Logs)
+
+			roots = append(roots, statedb.IntermediateRoot(true))
+			recs = append(recs, string(recJSON))
+			logs = append(logs, string(logJSON))
+		}
+		if roots[0] != block.Root() {
+			t.Fatalf("seed %d: block %d sequential root mismatch: have %x, want %x", seed, block.NumberU64(), roots[0], block.Root())
+		}
+		if roots[1] != roots[0] {
+			t.Fatalf("seed %d: block %d root mismatch: have %x, want %x", seed, block.NumberU64(), roots[1], roots[0])
+		}
+		if recs[1] != recs[0] {
+			t.Fatalf("seed %d: block %d receipt mismatch:\nhave %s\nwant %s", seed, block.NumberU64(), recs[1], recs[0])
+		}
+		if logs[1] != logs[0] {
+			t.Fatalf("seed %d: block %d log mismatch:\nhave %s\nwant %s", seed, block.NumberU64(), logs[1], logs[0])
+		}
+	}
+	// Import the blocks in parallel with witness collection, which are verified
+	// by stateless execution
+	for _, snapshots := range []bool{false, true} {
+		cacheConfig := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+		if !snapshots {
+			cacheConfig.SnapshotLimit = 0
+		}
+		cfg := vm.Config{EnableParallelExecution: true, EnableWitnessCollection: true}
+		witnessChain, err := NewBlockChain(rawdb.NewMemoryDatabase(), cacheConfig, gspec, nil, ethash.NewFaker(), cfg, nil, nil)
+		if err != nil {
+			t.Fatalf("failed to create chain: %v", err)
+		}
+		if n, err := witnessChain.InsertChain(blocks); err != nil {
+			t.Fatalf("seed %d: snapshots %v: failed to insert block %d with witness: %v", seed, snapshots, n, err)
+		}
+		witnessChain.Stop()
+	}
+}
+
+// Tests that the witness collected by the parallel executor contains the state
+// and code read by the merged transactions only.
+func TestParallelExecutionWitness(t *testing.T) {
+	var (
+		keys     []*ecdsa.PrivateKey
+		accounts []common.Address
+		readers  []common.Address
+		alloc    = make(types.GenesisAlloc)
+
+		// Reads a storage slot
+		readerCode = []byte{byte(vm.PUSH1), 0x00, byte(vm.SLOAD), byte(vm.POP), byte(vm.STOP)}
+	)
+	for i := 0; i < 4; i++ {
+		key, _ := crypto.GenerateKey()
+		keys = append(keys, key)
+		accounts = append(accounts, crypto.PubkeyToAddress(key.PublicKey))
+		alloc[accounts[i]] = types.Account{Balance: big.NewInt(params.Ether)}
+
+		reader := common.BigToAddress(big.NewInt(int64(0xaa00 + i)))
+		readers = append(readers, reader)
+		alloc[reader] = types.Account{
+			Code:    append(readerCode, byte(i)), // make the codes distinct
+			Storage: map[common.Hash]common.Hash{{}: common.BigToHash(big.NewInt(int64(i + 1)))},
+		}
+	}
+	var (
+		gspec  = &Genesis{Config: params.TestChainConfig, Alloc: alloc}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 6, func(i int, b *BlockGen) {
+		for j, key := range keys {
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(accounts[j]), readers[(i+j)%len(readers)], big.NewInt(0), 100000, b.BaseFee(), nil), signer, key)
+			b.AddTx(tx)
+		}
+	})
+	for _, snapshots := range []bool{false, true} {
+		cacheConfig := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+		if !snapshots {
+			cacheConfig.SnapshotLimit = 0
+		}
+		cfg := vm.Config{EnableParallelExecution: true, EnableWitnessCollection: true}
+		chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), cacheConfig, gspec, nil, ethash.NewFaker(), cfg, nil, nil)
+		if err != nil {
+			t.Fatalf("failed to create chain: %v", err)
+		}
+		defer chain.Stop()
+
+		// The witness is verified by stateless execution during the import
+		if n, err := chain.InsertChain(blocks); err != nil {
+			t.Fatalf("snapshots %v: failed to insert block %d: %v", snapshots, n, err)
+		}
+	}
+}
+
+// Tests the conflict detection of the tracked state.
+func TestTrackedStateConflicts(t *testing.T) {
+	var (
+		addr      = common.HexToAddress("0x01")
+		other     = common.HexToAddress("0x02")
+		slot      = common.HexToHash("0x01")
+		committed = map[stateKey]struct{}{
+			{addr: addr, kind: storageKey, slot: slot}: {},
+			{addr: addr, kind: balanceKey}:             {},
+		}
+		structural = map[common.Address]struct{}{other: {}}
+	)
+	tracked := newTrackedState(nil, true)
+	tracked.balances[addr] = nil
+	if tracked.conflicts(committed, structural) {
+		t.Fatalf("balance delta reported as conflict")
+	}
+	tracked.read(addr, storageKey, common.HexToHash("0x02"))
+	if tracked.conflicts(committed, structural) {
+		t.Fatalf("unrelated storage read reported as conflict")
+	}
+	tracked.read(addr, storageKey, slot)
+	if !tracked.conflicts(committed, structural) {
+		t.Fatalf("storage read after write not detected")
+	}
+	tracked = newTrackedState(nil, true)
+	tracked.balances[other] = nil
+	if !tracked.conflicts(committed, structural) {
+		t.Fatalf("write to restructured account not detected")
+	}
+}
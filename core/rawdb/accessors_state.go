@@ -264,3 +264,27 @@ func WriteStateHistory(db ethdb.AncientWriter, id uint64, meta []byte, accountIn
 		return nil
 	})
 }
+
+// ReadOnlinePruningProgress retrieves the database key up to which stale state
+// has been swept by the interrupted online pruning cycle, or nil if no cycle was
+// in progress.
+func ReadOnlinePruningProgress(db ethdb.KeyValueReader) []byte {
+	data, _ := db.Get(onlinePruningProgressKey)
+	return data
+}
+
+// WriteOnlinePruningProgress stores the database key up to which stale state
+// has been swept by the running online pruning cycle.
+func WriteOnlinePruningProgress(db ethdb.KeyValueWriter, key []byte) {
+	if err := db.Put(onlinePruningProgressKey, key); err != nil {
+		log.Crit("Failed to store online pruning progress", "err", err)
+	}
+}
+
+// DeleteOnlinePruningProgress deletes the online pruning marker, flagging that
+// the last cycle was completed.
+func DeleteOnlinePruningProgress(db ethdb.KeyValueWriter) {
+	if err := db.Delete(onlinePruningProgressKey); err != nil {
+		log.Crit("Failed to remove online pruning progress", "err", err)
+	}
+}
@@ -50,6 +50,14 @@ var chainFreezerNoSnappy = map[string]bool{
 	ChainFreezerDifficultyTable: true,
 }
 
+// chainFreezerExpirable configures which ancient-tables may be pruned below the
+// freezer tail when history expiry is enabled. Headers, hashes and difficulties
+// are always retained.
+var chainFreezerExpirable = map[string]bool{
+	ChainFreezerBodiesTable:  true,
+	ChainFreezerReceiptTable: true,
+}
+
 const (
 	// stateHistoryTableSize defines the maximum size of freezer data files.
 	stateHistoryTableSize = 2 * 1000 * 1000 * 1000
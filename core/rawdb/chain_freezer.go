@@ -64,7 +64,7 @@ func newChainFreezer(datadir string, namespace string, readonly bool) (*chainFre
 	if datadir == "" {
 		freezer = NewMemoryFreezer(readonly, chainFreezerNoSnappy)
 	} else {
-		freezer, err = NewFreezer(datadir, namespace, readonly, freezerTableSize, chainFreezerNoSnappy)
+		freezer, err = newFreezer(datadir, namespace, readonly, freezerTableSize, chainFreezerNoSnappy, chainFreezerExpirable)
 	}
 	if err != nil {
 		return nil, err
@@ -342,3 +342,51 @@ func (f *chainFreezer) freezeRange(nfdb *nofreezedb, number, limit uint64) (hash
 	})
 	return hashes, err
 }
+
+// expireHistory discards the ancient block bodies and receipts below the given
+// block number, keeping headers, hashes and difficulties. The previous expiry
+// tail is returned.
+func (f *chainFreezer) expireHistory(tail uint64) (uint64, error) {
+	freezer, ok := f.AncientStore.(*Freezer)
+	if !ok {
+		return 0, errNotSupported
+	}
+	return freezer.TruncateExpirableTail(tail)
+}
+
+// historyTail returns the number of the first block whose body and receipts
+// are still retained in the ancient store.
+func (f *chainFreezer) historyTail() (uint64, error) {
+	if freezer, ok := f.AncientStore.(*Freezer); ok {
+		return freezer.ExpiredTail(), nil
+	}
+	return f.AncientStore.Tail()
+}
+
+// historyExpirer is implemented by databases backed by a chain freezer capable
+// of expiring block bodies and receipts.
+type historyExpirer interface {
+	expireHistory(tail uint64) (uint64, error)
+	historyTail() (uint64, error)
+}
+
+// ExpireAncientHistory discards the frozen block bodies and receipts below the
+// given block number from the database, returning the previous expiry tail.
+// The tail is capped at the number of frozen blocks.
+func ExpireAncientHistory(db ethdb.Database, tail uint64) (uint64, error) {
+	expirer, ok := db.(historyExpirer)
+	if !ok {
+		return 0, errNotSupported
+	}
+	return expirer.expireHistory(tail)
+}
+
+// ReadAncientHistoryTail returns the number of the first frozen block whose
+// body and receipts are retained in the database.
+func ReadAncientHistoryTail(db ethdb.Database) (uint64, error) {
+	expirer, ok := db.(historyExpirer)
+	if !ok {
+		return db.Tail()
+	}
+	return expirer.historyTail()
+}
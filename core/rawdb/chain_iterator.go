@@ -88,19 +88,30 @@ type blockTxHashes struct {
 	hashes []common.Hash
 }
 
+// HistoryReader retrieves the body of a canonical block expired from the
+// database, or nil if it's not available.
+type HistoryReader func(number uint64) *types.Body
+
 // iterateTransactions iterates over all transactions in the (canon) block
 // number(s) given, and yields the hashes on a channel. If there is a signal
 // received from interrupt channel, the iteration will be aborted and result
 // channel will be closed.
-func iterateTransactions(db ethdb.Database, from uint64, to uint64, reverse bool, interrupt chan struct{}) chan *blockTxHashes {
+//
+// The bodies of the blocks below the history tail were expired from the database,
+// they are retrieved from the history reader instead. Expired blocks are yielded
+// without any transactions if their bodies are not available.
+func iterateTransactions(db ethdb.Database, history HistoryReader, from uint64, to uint64, reverse bool, interrupt chan struct{}) chan *blockTxHashes {
 	// One thread sequentially reads data from db
 	type numberRlp struct {
-		number uint64
-		rlp    rlp.RawValue
+		number  uint64
+		rlp     rlp.RawValue
+		expired bool
+		body    *types.Body // Body of an expired block, if available
 	}
 	if to == from {
 		return nil
 	}
+	historyTail, _ := ReadAncientHistoryTail(db)
 	threads := to - from
 	if cpus := runtime.NumCPU(); threads > uint64(cpus) {
 		threads = uint64(cpus)
@@ -117,10 +128,17 @@ func iterateTransactions(db ethdb.Database, from uint64, to uint64, reverse bool
 		}
 		defer close(rlpCh)
 		for n != end {
-			data := ReadCanonicalBodyRLP(db, n)
+			item := &numberRlp{number: n, expired: n < historyTail}
+			if !item.expired {
+				item.rlp = ReadCanonicalBodyRLP(db, n)
+			} else if history != nil {
+				if item.body = history(n); item.body == nil {
+					log.Warn("Missing expired block body", "block", n)
+				}
+			}
 			// Feed the block to the aggregator, or abort on interrupt
 			select {
-			case rlpCh <- &numberRlp{n, data}:
+			case rlpCh <- item:
 			case <-interrupt:
 				return
 			}
@@ -142,14 +160,19 @@ func iterateTransactions(db ethdb.Database, from uint64, to uint64, reverse bool
 			}
 		}()
 		for data := range rlpCh {
-			var body types.Body
-			if err := rlp.DecodeBytes(data.rlp, &body); err != nil {
-				log.Warn("Failed to decode block body", "block", data.number, "error", err)
-				return
+			body := data.body
+			if !data.expired {
+				body = new(types.Body)
+				if err := rlp.DecodeBytes(data.rlp, body); err != nil {
+					log.Warn("Failed to decode block body", "block", data.number, "error", err)
+					return
+				}
 			}
 			var hashes []common.Hash
-			for _, tx := range body.Transactions {
-				hashes = append(hashes, tx.Hash())
+			if body != nil {
+				for _, tx := range body.Transactions {
+					hashes = append(hashes, tx.Hash())
+				}
 			}
 			result := &blockTxHashes{
 				hashes: hashes,
@@ -178,13 +201,13 @@ func iterateTransactions(db ethdb.Database, from uint64, to uint64, reverse bool
 //
 // There is a passed channel, the whole procedure will be interrupted if any
 // signal received.
-func indexTransactions(db ethdb.Database, from uint64, to uint64, interrupt chan struct{}, hook func(uint64) bool, report bool) {
+func indexTransactions(db ethdb.Database, history HistoryReader, from uint64, to uint64, interrupt chan struct{}, hook func(uint64) bool, report bool) {
 	// short circuit for invalid range
 	if from >= to {
 		return
 	}
 	var (
-		hashesCh = iterateTransactions(db, from, to, true, interrupt)
+		hashesCh = iterateTransactions(db, history, from, to, true, interrupt)
 		batch    = db.NewBatch()
 		start    = time.Now()
 		logged   = start.Add(-7 * time.Second)
@@ -260,27 +283,28 @@ func indexTransactions(db ethdb.Database, from uint64, to uint64, interrupt chan
 // procedure is finished. So that we can resume indexing procedure next time quickly.
 //
 // There is a passed channel, the whole procedure will be interrupted if any
-// signal received.
-func IndexTransactions(db ethdb.Database, from uint64, to uint64, interrupt chan struct{}, report bool) {
-	indexTransactions(db, from, to, interrupt, nil, report)
+// signal received. The transactions of the blocks expired from the database are
+// retrieved from the history reader, if any.
+func IndexTransactions(db ethdb.Database, history HistoryReader, from uint64, to uint64, interrupt chan struct{}, report bool) {
+	indexTransactions(db, history, from, to, interrupt, nil, report)
 }
 
 // indexTransactionsForTesting is the internal debug version with an additional hook.
 func indexTransactionsForTesting(db ethdb.Database, from uint64, to uint64, interrupt chan struct{}, hook func(uint64) bool) {
-	indexTransactions(db, from, to, interrupt, hook, false)
+	indexTransactions(db, nil, from, to, interrupt, hook, false)
 }
 
 // unindexTransactions removes txlookup indices of the specified block range.
 //
 // There is a passed channel, the whole procedure will be interrupted if any
 // signal received.
-func unindexTransactions(db ethdb.Database, from uint64, to uint64, interrupt chan struct{}, hook func(uint64) bool, report bool) {
+func unindexTransactions(db ethdb.Database, history HistoryReader, from uint64, to uint64, interrupt chan struct{}, hook func(uint64) bool, report bool) {
 	// short circuit for invalid range
 	if from >= to {
 		return
 	}
 	var (
-		hashesCh = iterateTransactions(db, from, to, false, interrupt)
+		hashesCh = iterateTransactions(db, history, from, to, false, interrupt)
 		batch    = db.NewBatch()
 		start    = time.Now()
 		logged   = start.Add(-7 * time.Second)
@@ -352,12 +376,13 @@ func unindexTransactions(db ethdb.Database, from uint64, to uint64, interrupt ch
 // The from is included while to is excluded.
 //
 // There is a passed channel, the whole procedure will be interrupted if any
-// signal received.
-func UnindexTransactions(db ethdb.Database, from uint64, to uint64, interrupt chan struct{}, report bool) {
-	unindexTransactions(db, from, to, interrupt, nil, report)
+// signal received. The transactions of the blocks expired from the database are
+// retrieved from the history reader, if any.
+func UnindexTransactions(db ethdb.Database, history HistoryReader, from uint64, to uint64, interrupt chan struct{}, report bool) {
+	unindexTransactions(db, history, from, to, interrupt, nil, report)
 }
 
 // unindexTransactionsForTesting is the internal debug version with an additional hook.
 func unindexTransactionsForTesting(db ethdb.Database, from uint64, to uint64, interrupt chan struct{}, hook func(uint64) bool) {
-	unindexTransactions(db, from, to, interrupt, hook, false)
+	unindexTransactions(db, nil, from, to, interrupt, hook, false)
 }
@@ -72,6 +72,7 @@ type Freezer struct {
 
 	readonly     bool
 	tables       map[string]*freezerTable // Data tables for storing everything
+	expirable    map[string]bool          // Tables whose tail may be truncated independently
 	instanceLock *flock.Flock             // File-system lock to prevent double opens
 	closeOnce    sync.Once
 }
@@ -82,6 +83,12 @@ type Freezer struct {
 // The 'tables' argument defines the data tables. If the value of a map
 // entry is true, snappy compression is disabled for the table.
 func NewFreezer(datadir string, namespace string, readonly bool, maxTableSize uint32, tables map[string]bool) (*Freezer, error) {
+	return newFreezer(datadir, namespace, readonly, maxTableSize, tables, nil)
+}
+
+// newFreezer creates a freezer instance, allowing the tail of the tables marked
+// in 'expirable' to be truncated beyond the common tail of the freezer.
+func newFreezer(datadir string, namespace string, readonly bool, maxTableSize uint32, tables map[string]bool, expirable map[string]bool) (*Freezer, error) {
 	// Create the initial freezer object
 	var (
 		readMeter  = metrics.NewRegisteredMeter(namespace+"ancient/read", nil)
@@ -115,6 +122,7 @@ func NewFreezer(datadir string, namespace string, readonly bool, maxTableSize ui
 	freezer := &Freezer{
 		readonly:     readonly,
 		tables:       make(map[string]*freezerTable),
+		expirable:    expirable,
 		instanceLock: lock,
 	}
 
@@ -315,6 +323,58 @@ func (f *Freezer) TruncateTail(tail uint64) (uint64, error) {
 	return old, nil
 }
 
+// TruncateExpirableTail discards the data below the provided threshold number
+// from the expirable tables only, leaving the remaining tables untouched. The
+// threshold is capped at the number of frozen items. The previous tail of the
+// expirable tables is returned.
+func (f *Freezer) TruncateExpirableTail(tail uint64) (uint64, error) {
+	if f.readonly {
+		return 0, errReadOnly
+	}
+	f.writeLock.Lock()
+	defer f.writeLock.Unlock()
+
+	if len(f.expirable) == 0 {
+		return 0, errNotSupported
+	}
+	if frozen := f.frozen.Load(); tail > frozen {
+		tail = frozen
+	}
+	old := f.expiredTail()
+	if old >= tail {
+		return old, nil
+	}
+	for kind := range f.expirable {
+		if err := f.tables[kind].truncateTail(tail); err != nil {
+			return 0, err
+		}
+	}
+	return old, nil
+}
+
+// ExpiredTail returns the number of the first item retained in the expirable
+// tables. It equals the freezer tail if nothing was expired.
+func (f *Freezer) ExpiredTail() uint64 {
+	f.writeLock.RLock()
+	defer f.writeLock.RUnlock()
+
+	return f.expiredTail()
+}
+
+// expiredTail is the non-locking version of ExpiredTail.
+func (f *Freezer) expiredTail() uint64 {
+	tail := uint64(math.MaxUint64)
+	for kind := range f.expirable {
+		if hidden := f.tables[kind].itemHidden.Load(); hidden < tail {
+			tail = hidden
+		}
+	}
+	if tail == math.MaxUint64 {
+		return f.tail.Load()
+	}
+	return tail
+}
+
 // Sync flushes all data tables to disk.
 func (f *Freezer) Sync() error {
 	var errs []error
@@ -340,18 +400,28 @@ func (f *Freezer) validate() error {
 		tail uint64
 		name string
 	)
-	// Hack to get boundary of any table
+	// Hack to get boundary of any non-expirable table
 	for kind, table := range f.tables {
+		if f.expirable[kind] {
+			continue
+		}
 		head = table.items.Load()
 		tail = table.itemHidden.Load()
 		name = kind
 		break
 	}
-	// Now check every table against those boundaries.
+	// Now check every table against those boundaries. Expirable tables may
+	// have their tail beyond the common one.
 	for kind, table := range f.tables {
 		if head != table.items.Load() {
 			return fmt.Errorf("freezer tables %s and %s have differing head: %d != %d", kind, name, table.items.Load(), head)
 		}
+		if f.expirable[kind] {
+			if table.itemHidden.Load() < tail {
+				return fmt.Errorf("freezer table %s has tail below %s: %d < %d", kind, name, table.itemHidden.Load(), tail)
+			}
+			continue
+		}
 		if tail != table.itemHidden.Load() {
 			return fmt.Errorf("freezer tables %s and %s have differing tail: %d != %d", kind, name, table.itemHidden.Load(), tail)
 		}
@@ -361,17 +431,21 @@ func (f *Freezer) validate() error {
 	return nil
 }
 
-// repair truncates all data tables to the same length.
+// repair truncates all data tables to the same length. The tail of expirable
+// tables is left alone if it's beyond the common tail.
 func (f *Freezer) repair() error {
 	var (
 		head = uint64(math.MaxUint64)
 		tail = uint64(0)
 	)
-	for _, table := range f.tables {
+	for kind, table := range f.tables {
 		items := table.items.Load()
 		if head > items {
 			head = items
 		}
+		if f.expirable[kind] {
+			continue
+		}
 		hidden := table.itemHidden.Load()
 		if hidden > tail {
 			tail = hidden
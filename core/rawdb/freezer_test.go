@@ -284,6 +284,80 @@ func TestFreezerReadonlyValidate(t *testing.T) {
 	}
 }
 
+func TestFreezerExpirableTail(t *testing.T) {
+	t.Parallel()
+
+	var (
+		tables    = map[string]bool{"headers": true, "bodies": true}
+		expirable = map[string]bool{"bodies": true}
+		dir       = t.TempDir()
+		item      = make([]byte, 1024)
+	)
+	f, err := newFreezer(dir, "", false, 2049, tables, expirable)
+	if err != nil {
+		t.Fatal("can't open freezer", err)
+	}
+	_, err = f.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		for i := uint64(0); i < 10; i++ {
+			if err := op.AppendRaw("headers", i, item); err != nil {
+				return err
+			}
+			if err := op.AppendRaw("bodies", i, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Expire the bodies below 6, the headers must remain accessible.
+	old, err := f.TruncateExpirableTail(6)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), old)
+	require.Equal(t, uint64(6), f.ExpiredTail())
+
+	check := func(f *Freezer) {
+		if tail, _ := f.Tail(); tail != 0 {
+			t.Fatalf("wrong freezer tail: have %d, want 0", tail)
+		}
+		if _, err := f.Ancient("headers", 0); err != nil {
+			t.Fatalf("header retrieval failed: %v", err)
+		}
+		if _, err := f.Ancient("bodies", 5); err == nil {
+			t.Fatal("expired body retrievable")
+		}
+		if _, err := f.Ancient("bodies", 6); err != nil {
+			t.Fatalf("body retrieval failed: %v", err)
+		}
+	}
+	check(f)
+
+	// Expiring beyond the frozen items is capped.
+	_, err = f.TruncateExpirableTail(20)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), f.ExpiredTail())
+	require.NoError(t, f.Close())
+
+	// Reopen the freezer, the differing tails must survive repair and validation.
+	f, err = newFreezer(dir, "", false, 2049, tables, expirable)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), f.ExpiredTail())
+	if _, err := f.Ancient("headers", 9); err != nil {
+		t.Fatalf("header retrieval failed after reopen: %v", err)
+	}
+	require.NoError(t, f.Close())
+
+	f, err = newFreezer(dir, "", true, 2049, tables, expirable)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	// Without the expirable configuration, the tables are repaired to a common tail.
+	_, err = NewFreezer(dir, "", true, 2049, tables)
+	if err == nil {
+		t.Fatal("readonly freezer should fail with differing table tails")
+	}
+}
+
 func TestFreezerConcurrentReadonly(t *testing.T) {
 	t.Parallel()
 
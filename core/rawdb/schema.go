@@ -96,6 +96,9 @@ var (
 	// snapSyncStatusFlagKey flags that status of snap sync.
 	snapSyncStatusFlagKey = []byte("SnapSyncStatus")
 
+	// onlinePruningProgressKey tracks the online state pruning marker across restarts.
+	onlinePruningProgressKey = []byte("OnlinePruningProgress")
+
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`, used for indexes).
 	headerPrefix       = []byte("h") // headerPrefix + num (uint64 big endian) + hash -> header
 	headerTDSuffix     = []byte("t") // headerPrefix + num (uint64 big endian) + hash + headerTDSuffix -> td
@@ -134,9 +137,10 @@ var (
 
 	CliqueSnapshotPrefix = []byte("clique-")
 
-	BestUpdateKey         = []byte("update-")    // bigEndian64(syncPeriod) -> RLP(types.LightClientUpdate)  (nextCommittee only referenced by root hash)
-	FixedCommitteeRootKey = []byte("fixedRoot-") // bigEndian64(syncPeriod) -> committee root hash
-	SyncCommitteeKey      = []byte("committee-") // bigEndian64(syncPeriod) -> serialized committee
+	BestUpdateKey         = []byte("update-")         // bigEndian64(syncPeriod) -> RLP(types.LightClientUpdate)  (nextCommittee only referenced by root hash)
+	FixedCommitteeRootKey = []byte("fixedRoot-")      // bigEndian64(syncPeriod) -> committee root hash
+	SyncCommitteeKey      = []byte("committee-")      // bigEndian64(syncPeriod) -> serialized committee
+	FinalizedBeaconKey    = []byte("beaconFinalized") // RLP(types.Header) of the latest validated finalized beacon header
 
 	preimageCounter    = metrics.NewRegisteredCounter("db/preimage/total", nil)
 	preimageHitCounter = metrics.NewRegisteredCounter("db/preimage/hits", nil)
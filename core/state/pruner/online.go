@@ -0,0 +1,402 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pruner
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/triedb"
+)
+
+const (
+	// onlineBloomSize is the default megabytes of memory allocated to the bloom
+	// filter of the online pruner.
+	onlineBloomSize = 1024
+
+	// onlinePruningInterval is the default time to wait between two pruning cycles.
+	onlinePruningInterval = 24 * time.Hour
+
+	// onlinePruningRetry is the time to wait before retrying a failed cycle.
+	onlinePruningRetry = time.Minute
+
+	// onlineSweepBatch is the number of stale entries deleted at once.
+	onlineSweepBatch = 1000
+)
+
+var (
+	// errPruningInterrupted is returned if the pruning is aborted by shutting down.
+	errPruningInterrupted = errors.New("pruning interrupted")
+
+	// ErrChainSyncing is returned by the retain callback of the online pruner
+	// if the chain is still syncing and its state can't be pruned yet.
+	ErrChainSyncing = errors.New("chain is syncing")
+)
+
+// OnlineConfig includes the configurations for online pruning.
+type OnlineConfig struct {
+	BloomSize uint64        // Megabytes of memory allocated to the bloom filter
+	RateLimit int           // Maximum number of entries deleted per second (0 = unlimited)
+	Interval  time.Duration // Time to wait between two pruning cycles
+}
+
+// OnlinePruner garbage collects the stale state of a hash-scheme database in the
+// background, while the node keeps processing blocks. Every cycle works as:
+//
+//   - start protecting all state entries written to the database
+//   - pin the recent states of the chain in the trie database and mark their
+//     entries, in memory or on disk, along with the genesis state in a bloom
+//     filter. The retain callback persists the oldest of them, so that the disk
+//     keeps holding a complete state once the older ones are swept
+//   - iterate the database in rate limited batches, deleting all trie nodes and
+//     codes which are neither marked nor protected
+//
+// All state written after the marking started is derived from the marked states,
+// so the recent states and all the ones after them stay complete, whenever their
+// nodes held in memory get flushed. The states older than the marked ones are
+// deleted, just like in offline pruning.
+//
+// The sweep progress is persisted, so an interrupted cycle resumes where it left
+// off after a restart, marking the new recent states first.
+type OnlinePruner struct {
+	config OnlineConfig
+	db     ethdb.Database                        // Raw database to prune
+	triedb *triedb.Database                      // Trie database holding the recent states
+	retain func() ([]common.Hash, func(), error) // Callback to pin the recent states
+
+	bloom *stateBloom  // Filter of the live state entries, nil if no cycle is running
+	lock  sync.RWMutex // Lock serialising protected writes and deletions
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewOnlinePruner creates an online pruner for the given database. The pruner
+// has to be started once the chain is ready.
+func NewOnlinePruner(db ethdb.Database, config OnlineConfig) *OnlinePruner {
+	if config.BloomSize == 0 {
+		config.BloomSize = onlineBloomSize
+	}
+	if config.BloomSize < 256 {
+		log.Warn("Sanitizing bloomfilter size", "provided(MB)", config.BloomSize, "updated(MB)", 256)
+		config.BloomSize = 256
+	}
+	if config.Interval == 0 {
+		config.Interval = onlinePruningInterval
+	}
+	return &OnlinePruner{
+		config: config,
+		db:     db,
+		quit:   make(chan struct{}),
+	}
+}
+
+// Database returns a wrapper of the pruned database which protects all the state
+// entries written to it from being pruned by a running cycle. All state writes
+// have to go through it.
+func (p *OnlinePruner) Database() ethdb.Database {
+	return &protectedDB{Database: p.db, pruner: p}
+}
+
+// Start launches the background pruning of the states in the given trie database.
+// The retain callback is invoked at the beginning of each cycle to pin the states
+// to retain, returning their roots with the head state first along with a function
+// releasing them once marked. It returns ErrChainSyncing to postpone the cycle
+// while the chain is syncing.
+func (p *OnlinePruner) Start(triedb *triedb.Database, retain func() ([]common.Hash, func(), error)) {
+	p.triedb, p.retain = triedb, retain
+
+	p.wg.Add(1)
+	go p.loop()
+}
+
+// Prune runs a single pruning cycle of the states in the given trie database in
+// the foreground. It must not be used along with the background pruning.
+func (p *OnlinePruner) Prune(triedb *triedb.Database, retain func() ([]common.Hash, func(), error)) error {
+	p.triedb, p.retain = triedb, retain
+	return p.cycle()
+}
+
+// Stop terminates the background pruning. The progress of the running cycle is
+// persisted and resumed at the next start.
+func (p *OnlinePruner) Stop() {
+	close(p.quit)
+	p.wg.Wait()
+}
+
+// loop runs the pruning cycles until the pruner is stopped.
+func (p *OnlinePruner) loop() {
+	defer p.wg.Done()
+
+	for {
+		wait := p.config.Interval
+		if err := p.cycle(); err != nil {
+			switch {
+			case errors.Is(err, errPruningInterrupted):
+				return
+			case errors.Is(err, ErrChainSyncing):
+				log.Debug("Online state pruning postponed until synced")
+			default:
+				log.Warn("Online state pruning failed", "err", err)
+			}
+			wait = onlinePruningRetry
+		}
+		select {
+		case <-time.After(wait):
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// protect marks the given key as live if a cycle is running.
+func (p *OnlinePruner) protect(key []byte) {
+	if !isStateKey(key) {
+		return
+	}
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if p.bloom != nil {
+		p.bloom.Put(key, nil)
+	}
+}
+
+// cycle runs a full pruning cycle.
+func (p *OnlinePruner) cycle() error {
+	bloom, err := newStateBloomWithSize(p.config.BloomSize)
+	if err != nil {
+		return err
+	}
+	// Start protecting the new state entries before picking the state to keep,
+	// everything written from now on is derived from it.
+	p.lock.Lock()
+	p.bloom = bloom
+	p.lock.Unlock()
+
+	defer func() {
+		p.lock.Lock()
+		p.bloom = nil
+		p.lock.Unlock()
+	}()
+	start := time.Now()
+	if err := p.mark(bloom); err != nil {
+		return err
+	}
+
+	count, size, err := p.sweep(bloom)
+	if err != nil {
+		return err
+	}
+	rawdb.DeleteOnlinePruningProgress(p.db)
+	log.Info("Online state pruning finished", "nodes", count, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+
+	if count >= rangeCompactionThreshold {
+		return compactDatabase(p.db)
+	}
+	return nil
+}
+
+// mark pins the recent states and marks their entries, along with the ones of
+// the genesis state, in the bloom filter. The states are released once marked,
+// so that the trie database can garbage collect them again.
+func (p *OnlinePruner) mark(bloom *stateBloom) error {
+	roots, release, err := p.retain()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if len(roots) == 0 {
+		return errors.New("no state to retain")
+	}
+	// Mark the head state in full, and only the differences to it for the older
+	// states, as they mostly share their entries.
+	start := time.Now()
+	log.Info("Marking live state for online pruning", "root", roots[0], "states", len(roots))
+	if err := extractState(p.triedb, roots[0], common.Hash{}, bloom, p.quit); err != nil {
+		return err
+	}
+	for _, root := range roots[1:] {
+		if err := extractState(p.triedb, root, roots[0], bloom, p.quit); err != nil {
+			return err
+		}
+	}
+	if err := extractGenesis(p.db, bloom); err != nil {
+		return err
+	}
+	log.Info("Marked live state for online pruning", "root", roots[0], "states", len(roots), "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// sweep iterates the database from the persisted progress marker, deleting all
+// the state entries not contained in the bloom filter.
+func (p *OnlinePruner) sweep(bloom *stateBloom) (int, common.StorageSize, error) {
+	var (
+		count  int
+		size   common.StorageSize
+		logged = time.Now()
+		keys   [][]byte
+		marker = rawdb.ReadOnlinePruningProgress(p.db)
+		iter   = p.db.NewIterator(nil, marker)
+	)
+	if marker != nil {
+		log.Info("Resuming online state pruning", "marker", common.Bytes2Hex(marker))
+	}
+	for iter.Next() {
+		key := iter.Key()
+		if !isStateKey(key) || bloom.Contain(stateKeyHash(key)) {
+			continue
+		}
+		keys = append(keys, common.CopyBytes(key))
+		size += common.StorageSize(len(key) + len(iter.Value()))
+
+		if len(keys) < onlineSweepBatch {
+			continue
+		}
+		// Recreate the iterator after every batch commit in order to allow
+		// the underlying compactor to delete the entries.
+		iter.Release()
+
+		next := keys[len(keys)-1]
+		deleted, err := p.delete(bloom, keys)
+		if err != nil {
+			return count, size, err
+		}
+		count += deleted
+		keys = keys[:0]
+
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Pruning stale state", "nodes", count, "size", size, "marker", common.Bytes2Hex(next))
+			logged = time.Now()
+		}
+		iter = p.db.NewIterator(nil, next)
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return count, size, err
+	}
+	deleted, err := p.delete(bloom, keys)
+	return count + deleted, size, err
+}
+
+// delete removes the given stale entries from the database and persists the
+// sweep progress, throttling to the configured rate limit.
+func (p *OnlinePruner) delete(bloom *stateBloom, keys [][]byte) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	start := time.Now()
+
+	// Entries might have been rewritten since they were checked, so recheck
+	// them while holding the lock to avoid racing with the protected writes.
+	p.lock.Lock()
+	var (
+		count int
+		batch = p.db.NewBatch()
+	)
+	for _, key := range keys {
+		if bloom.Contain(stateKeyHash(key)) {
+			continue
+		}
+		batch.Delete(key)
+		count++
+	}
+	rawdb.WriteOnlinePruningProgress(batch, keys[len(keys)-1])
+	err := batch.Write()
+	p.lock.Unlock()
+
+	if err != nil {
+		return 0, err
+	}
+	if p.config.RateLimit > 0 {
+		wait := time.Duration(count)*time.Second/time.Duration(p.config.RateLimit) - time.Since(start)
+		if wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-p.quit:
+				return count, errPruningInterrupted
+			}
+		}
+	}
+	select {
+	case <-p.quit:
+		return count, errPruningInterrupted
+	default:
+	}
+	return count, nil
+}
+
+// isStateKey reports whether the key belongs to a hash-scheme trie node, or
+// a contract code.
+func isStateKey(key []byte) bool {
+	if len(key) == common.HashLength {
+		return true
+	}
+	isCode, _ := rawdb.IsCodeKey(key)
+	return isCode
+}
+
+// stateKeyHash returns the hash of the state entry with the given key.
+func stateKeyHash(key []byte) []byte {
+	if isCode, hash := rawdb.IsCodeKey(key); isCode {
+		return hash
+	}
+	return key
+}
+
+// protectedDB is a database wrapper which protects all the state entries written
+// during a pruning cycle from deletion.
+type protectedDB struct {
+	ethdb.Database
+	pruner *OnlinePruner
+}
+
+// Put inserts the given value into the key-value data store.
+func (db *protectedDB) Put(key []byte, value []byte) error {
+	db.pruner.protect(key)
+	return db.Database.Put(key, value)
+}
+
+// NewBatch creates a write-only database that buffers changes to its host db
+// until a final write is called.
+func (db *protectedDB) NewBatch() ethdb.Batch {
+	return &protectedBatch{Batch: db.Database.NewBatch(), pruner: db.pruner}
+}
+
+// NewBatchWithSize creates a write-only database batch with pre-allocated buffer.
+func (db *protectedDB) NewBatchWithSize(size int) ethdb.Batch {
+	return &protectedBatch{Batch: db.Database.NewBatchWithSize(size), pruner: db.pruner}
+}
+
+// protectedBatch is a batch wrapper which protects all the state entries written
+// during a pruning cycle from deletion.
+type protectedBatch struct {
+	ethdb.Batch
+	pruner *OnlinePruner
+}
+
+// Put inserts the given value into the batch.
+func (b *protectedBatch) Put(key []byte, value []byte) error {
+	b.pruner.protect(key)
+	return b.Batch.Put(key, value)
+}
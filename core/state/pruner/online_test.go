@@ -0,0 +1,233 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pruner
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/holiman/uint256"
+)
+
+// newOnlineTestState creates a pruner over a fresh database with an empty
+// genesis and returns a state database writing through it.
+func newOnlineTestState(t *testing.T) (ethdb.Database, *OnlinePruner, state.Database) {
+	db := rawdb.NewMemoryDatabase()
+	genesis := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(0), Root: types.EmptyRootHash})
+	rawdb.WriteBlock(db, genesis)
+	rawdb.WriteCanonicalHash(db, genesis.Hash(), 0)
+
+	pruner := NewOnlinePruner(db, OnlineConfig{BloomSize: 256})
+	sdb := state.NewDatabase(pruner.Database())
+	pruner.triedb = sdb.TrieDB()
+	return db, pruner, sdb
+}
+
+// retainRoots returns a retain callback for the online pruner which pins the
+// given roots.
+func retainRoots(roots ...common.Hash) func() ([]common.Hash, func(), error) {
+	return func() ([]common.Hash, func(), error) { return roots, func() {}, nil }
+}
+
+// commitTestState applies a set of seeded modifications on top of the state
+// with the given root and flushes the result to disk.
+func commitTestState(t *testing.T, sdb state.Database, root common.Hash, seed byte, block uint64) common.Hash {
+	statedb, err := state.New(root, sdb, nil)
+	if err != nil {
+		t.Fatalf("failed to open state: %v", err)
+	}
+	for i := byte(0); i < 16; i++ {
+		addr := common.Address{i}
+		statedb.AddBalance(addr, uint256.NewInt(uint64(seed)+1), tracing.BalanceChangeUnspecified)
+		statedb.SetState(addr, common.Hash{i}, common.Hash{seed, i})
+		statedb.SetCode(addr, []byte{seed, i})
+	}
+	root, err = statedb.Commit(block, true)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := sdb.TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to flush state: %v", err)
+	}
+	return root
+}
+
+// checkState ensures that the entire state with the given root is present.
+func checkState(t *testing.T, db ethdb.Database, root common.Hash) {
+	bloom, _ := newStateBloomWithSize(256)
+	if err := extractState(triedb.NewDatabase(db, triedb.HashDefaults), root, common.Hash{}, bloom, nil); err != nil {
+		t.Fatalf("state %x is incomplete: %v", root, err)
+	}
+}
+
+func TestOnlinePruning(t *testing.T) {
+	db, pruner, sdb := newOnlineTestState(t)
+
+	var (
+		stale = commitTestState(t, sdb, types.EmptyRootHash, 1, 1)
+		live  = commitTestState(t, sdb, stale, 2, 2)
+		fresh common.Hash
+	)
+	// Create a new state while the cycle is running, it must be protected
+	pruner.retain = func() ([]common.Hash, func(), error) {
+		fresh = commitTestState(t, sdb, live, 3, 3)
+		return []common.Hash{live}, func() {}, nil
+	}
+	if err := pruner.cycle(); err != nil {
+		t.Fatalf("pruning failed: %v", err)
+	}
+	if rawdb.HasLegacyTrieNode(db, stale) {
+		t.Fatalf("stale state root was not pruned")
+	}
+	if code := rawdb.ReadCode(db, crypto.Keccak256Hash([]byte{1, 0})); len(code) != 0 {
+		t.Fatalf("stale code was not pruned")
+	}
+	checkState(t, db, live)
+	checkState(t, db, fresh)
+
+	if marker := rawdb.ReadOnlinePruningProgress(db); marker != nil {
+		t.Fatalf("progress marker left after finished cycle: %x", marker)
+	}
+}
+
+// Tests that the older states returned along with the head state are retained
+// entirely, even though only their differences to the head state are marked.
+func TestOnlinePruningRecentStates(t *testing.T) {
+	db, pruner, sdb := newOnlineTestState(t)
+
+	var (
+		stale  = commitTestState(t, sdb, types.EmptyRootHash, 1, 1)
+		recent = commitTestState(t, sdb, stale, 2, 2)
+		side   = commitTestState(t, sdb, stale, 3, 2)
+		head   = commitTestState(t, sdb, recent, 4, 3)
+	)
+	pruner.retain = retainRoots(head, recent, side)
+
+	if err := pruner.cycle(); err != nil {
+		t.Fatalf("pruning failed: %v", err)
+	}
+	if rawdb.HasLegacyTrieNode(db, stale) {
+		t.Fatalf("stale state root was not pruned")
+	}
+	checkState(t, db, head)
+	checkState(t, db, recent)
+	checkState(t, db, side)
+}
+
+// Tests that a recent state held only in memory is retained without flushing it,
+// including the entries on disk it shares with older states.
+func TestOnlinePruningMemoryState(t *testing.T) {
+	db, pruner, sdb := newOnlineTestState(t)
+
+	base := commitTestState(t, sdb, types.EmptyRootHash, 1, 1)
+
+	// Modify a single account on top of the flushed state, keeping the rest of
+	// the state shared with it on disk.
+	statedb, err := state.New(base, sdb, nil)
+	if err != nil {
+		t.Fatalf("failed to open state: %v", err)
+	}
+	statedb.AddBalance(common.Address{0}, uint256.NewInt(1), tracing.BalanceChangeUnspecified)
+	head, err := statedb.Commit(2, true)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	var released bool
+	pruner.retain = func() ([]common.Hash, func(), error) {
+		return []common.Hash{head}, func() { released = true }, nil
+	}
+	if err := pruner.cycle(); err != nil {
+		t.Fatalf("pruning failed: %v", err)
+	}
+	if !released {
+		t.Fatalf("retained states not released")
+	}
+	if rawdb.HasLegacyTrieNode(db, head) {
+		t.Fatalf("in-memory state flushed by the pruner")
+	}
+	if rawdb.HasLegacyTrieNode(db, base) {
+		t.Fatalf("stale state root was not pruned")
+	}
+	if err := sdb.TrieDB().Commit(head, false); err != nil {
+		t.Fatalf("failed to flush state: %v", err)
+	}
+	checkState(t, db, head)
+}
+
+// Tests that a cycle is aborted without deleting anything if the chain is not
+// ready to be pruned.
+func TestOnlinePruningSyncing(t *testing.T) {
+	db, pruner, sdb := newOnlineTestState(t)
+
+	stale := commitTestState(t, sdb, types.EmptyRootHash, 1, 1)
+	pruner.retain = func() ([]common.Hash, func(), error) { return nil, nil, ErrChainSyncing }
+
+	if err := pruner.cycle(); !errors.Is(err, ErrChainSyncing) {
+		t.Fatalf("pruning error mismatch: have %v, want %v", err, ErrChainSyncing)
+	}
+	checkState(t, db, stale)
+}
+
+func TestOnlinePruningResume(t *testing.T) {
+	db, pruner, sdb := newOnlineTestState(t)
+
+	var (
+		stale = commitTestState(t, sdb, types.EmptyRootHash, 1, 1)
+		live  = commitTestState(t, sdb, stale, 2, 2)
+	)
+	pruner.retain = retainRoots(live)
+
+	// Pretend that an interrupted cycle already swept all the entries before
+	// the stale root, only the ones after it should be deleted.
+	var before [][]byte
+	it := db.NewIterator(nil, nil)
+	for it.Next() {
+		if len(it.Key()) == common.HashLength {
+			before = append(before, common.CopyBytes(it.Key()))
+		}
+	}
+	it.Release()
+
+	rawdb.WriteOnlinePruningProgress(db, stale.Bytes())
+	if err := pruner.cycle(); err != nil {
+		t.Fatalf("pruning failed: %v", err)
+	}
+	var deleted int
+	for _, key := range before {
+		if ok, _ := db.Has(key); ok {
+			continue
+		}
+		if bytes.Compare(key, stale.Bytes()) < 0 {
+			t.Fatalf("entry before the progress marker was pruned: %x", key)
+		}
+		deleted++
+	}
+	if deleted == 0 {
+		t.Fatalf("no entries pruned after the progress marker")
+	}
+	checkState(t, db, live)
+}
@@ -211,22 +211,9 @@ func prune(snaptree *snapshot.Tree, root common.Hash, maindb ethdb.Database, sta
 	// Start compactions, will remove the deleted data from the disk immediately.
 	// Note for small pruning, the compaction is skipped.
 	if count >= rangeCompactionThreshold {
-		cstart := time.Now()
-		for b := 0x00; b <= 0xf0; b += 0x10 {
-			var (
-				start = []byte{byte(b)}
-				end   = []byte{byte(b + 0x10)}
-			)
-			if b == 0xf0 {
-				end = nil
-			}
-			log.Info("Compacting database", "range", fmt.Sprintf("%#x-%#x", start, end), "elapsed", common.PrettyDuration(time.Since(cstart)))
-			if err := maindb.Compact(start, end); err != nil {
-				log.Error("Database compaction failed", "error", err)
-				return err
-			}
+		if err := compactDatabase(maindb); err != nil {
+			return err
 		}
-		log.Info("Database compaction finished", "elapsed", common.PrettyDuration(time.Since(cstart)))
 	}
 	log.Info("State pruning successful", "pruned", size, "elapsed", common.PrettyDuration(time.Since(start)))
 	return nil
@@ -399,6 +386,28 @@ func RecoverPruning(datadir string, db ethdb.Database) error {
 	return prune(snaptree, stateBloomRoot, db, stateBloom, stateBloomPath, middleRoots, time.Now())
 }
 
+// compactDatabase compacts the entire key-value store in 16 ranges, removing
+// the deleted data from the disk.
+func compactDatabase(maindb ethdb.Database) error {
+	cstart := time.Now()
+	for b := 0x00; b <= 0xf0; b += 0x10 {
+		var (
+			start = []byte{byte(b)}
+			end   = []byte{byte(b + 0x10)}
+		)
+		if b == 0xf0 {
+			end = nil
+		}
+		log.Info("Compacting database", "range", fmt.Sprintf("%#x-%#x", start, end), "elapsed", common.PrettyDuration(time.Since(cstart)))
+		if err := maindb.Compact(start, end); err != nil {
+			log.Error("Database compaction failed", "error", err)
+			return err
+		}
+	}
+	log.Info("Database compaction finished", "elapsed", common.PrettyDuration(time.Since(cstart)))
+	return nil
+}
+
 // extractGenesis loads the genesis state and commits all the state entries
 // into the given bloomfilter.
 func extractGenesis(db ethdb.Database, stateBloom *stateBloom) error {
@@ -410,7 +419,16 @@ func extractGenesis(db ethdb.Database, stateBloom *stateBloom) error {
 	if genesis == nil {
 		return errors.New("missing genesis block")
 	}
-	t, err := trie.NewStateTrie(trie.StateTrieID(genesis.Root()), triedb.NewDatabase(db, triedb.HashDefaults))
+	return extractState(triedb.NewDatabase(db, triedb.HashDefaults), genesis.Root(), common.Hash{}, stateBloom, nil)
+}
+
+// extractState iterates the state with the given root in the trie database and
+// commits all the state entries into the given bloomfilter. If a base root is
+// given, only the entries not shared with the base state are committed, the base
+// state has to be committed separately. The iteration can be aborted by closing
+// the optional quit channel.
+func extractState(tdb *triedb.Database, root common.Hash, base common.Hash, stateBloom *stateBloom, quit chan struct{}) error {
+	t, err := trie.NewStateTrie(trie.StateTrieID(root), tdb)
 	if err != nil {
 		return err
 	}
@@ -418,7 +436,23 @@ func extractGenesis(db ethdb.Database, stateBloom *stateBloom) error {
 	if err != nil {
 		return err
 	}
+	var baseTrie *trie.StateTrie
+	if base != (common.Hash{}) {
+		if baseTrie, err = trie.NewStateTrie(trie.StateTrieID(base), tdb); err != nil {
+			return err
+		}
+		baseIter, err := baseTrie.NodeIterator(nil)
+		if err != nil {
+			return err
+		}
+		accIter, _ = trie.NewDifferenceIterator(baseIter, accIter)
+	}
 	for accIter.Next(true) {
+		select {
+		case <-quit:
+			return errPruningInterrupted
+		default:
+		}
 		hash := accIter.Hash()
 
 		// Embedded nodes don't have hash.
@@ -432,9 +466,23 @@ func extractGenesis(db ethdb.Database, stateBloom *stateBloom) error {
 			if err := rlp.DecodeBytes(accIter.LeafBlob(), &acc); err != nil {
 				return err
 			}
-			if acc.Root != types.EmptyRootHash {
-				id := trie.StorageTrieID(genesis.Root(), common.BytesToHash(accIter.LeafKey()), acc.Root)
-				storageTrie, err := trie.NewStateTrie(id, triedb.NewDatabase(db, triedb.HashDefaults))
+			// Skip the storage shared with the base state entirely
+			var (
+				addrHash = common.BytesToHash(accIter.LeafKey())
+				baseRoot = types.EmptyRootHash
+			)
+			if baseTrie != nil {
+				baseAcc, err := baseTrie.GetAccountByHash(addrHash)
+				if err != nil {
+					return err
+				}
+				if baseAcc != nil {
+					baseRoot = baseAcc.Root
+				}
+			}
+			if acc.Root != types.EmptyRootHash && acc.Root != baseRoot {
+				id := trie.StorageTrieID(root, addrHash, acc.Root)
+				storageTrie, err := trie.NewStateTrie(id, tdb)
 				if err != nil {
 					return err
 				}
@@ -442,6 +490,17 @@ func extractGenesis(db ethdb.Database, stateBloom *stateBloom) error {
 				if err != nil {
 					return err
 				}
+				if baseRoot != types.EmptyRootHash {
+					baseStorage, err := trie.NewStateTrie(trie.StorageTrieID(base, addrHash, baseRoot), tdb)
+					if err != nil {
+						return err
+					}
+					baseIter, err := baseStorage.NodeIterator(nil)
+					if err != nil {
+						return err
+					}
+					storageIter, _ = trie.NewDifferenceIterator(baseIter, storageIter)
+				}
 				for storageIter.Next(true) {
 					hash := storageIter.Hash()
 					if hash != (common.Hash{}) {
@@ -51,10 +51,10 @@ func NewStateProcessor(config *params.ChainConfig, chain *HeaderChain) *StatePro
 // the transaction messages using the statedb and applying any rewards to both
 // the processor (coinbase) and any included uncles.
 //
-// Process returns the receipts and logs accumulated during the process and
-// returns the amount of gas that was used in the process. If any of the
-// transactions failed to execute due to insufficient gas it will return an error.
-func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg vm.Config) (types.Receipts, []*types.Log, uint64, error) {
+// Process returns the receipts, logs and requests accumulated during the process
+// and the amount of gas that was used in the process. If any of the transactions
+// failed to execute due to insufficient gas it will return an error.
+func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg vm.Config) (*ProcessResult, error) {
 	var (
 		receipts    types.Receipts
 		usedGas     = new(uint64)
@@ -78,30 +78,53 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 	if beaconRoot := block.BeaconRoot(); beaconRoot != nil {
 		ProcessBeaconBlockRoot(*beaconRoot, vmenv, statedb)
 	}
+	if p.config.IsHistoryStorage(block.Number(), block.Time()) {
+		ProcessParentBlockHash(block.ParentHash(), vmenv, statedb)
+	}
 	// Iterate over and process the individual transactions
-	for i, tx := range block.Transactions() {
-		msg, err := TransactionToMessage(tx, signer, header.BaseFee)
-		if err != nil {
-			return nil, nil, 0, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+	if p.parallelizable(block, statedb, cfg) {
+		var err error
+		if receipts, allLogs, err = p.processParallel(block, statedb, cfg, gp, usedGas); err != nil {
+			return nil, err
 		}
-		statedb.SetTxContext(tx.Hash(), i)
+	} else {
+		for i, tx := range block.Transactions() {
+			msg, err := TransactionToMessage(tx, signer, header.BaseFee)
+			if err != nil {
+				return nil, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+			}
+			statedb.SetTxContext(tx.Hash(), i)
 
-		receipt, err := ApplyTransactionWithEVM(msg, p.config, gp, statedb, blockNumber, blockHash, tx, usedGas, vmenv)
-		if err != nil {
-			return nil, nil, 0, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+			receipt, err := ApplyTransactionWithEVM(msg, p.config, gp, statedb, blockNumber, blockHash, tx, usedGas, vmenv)
+			if err != nil {
+				return nil, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+			}
+			receipts = append(receipts, receipt)
+			allLogs = append(allLogs, receipt.Logs...)
+		}
+	}
+	// Read requests if Prague is enabled.
+	var requests types.Requests
+	if p.config.IsPrague(block.Number(), block.Time()) {
+		var err error
+		if requests, err = ProcessRequests(allLogs, vmenv, statedb); err != nil {
+			return nil, err
 		}
-		receipts = append(receipts, receipt)
-		allLogs = append(allLogs, receipt.Logs...)
 	}
 	// Fail if Shanghai not enabled and len(withdrawals) is non-zero.
 	withdrawals := block.Withdrawals()
 	if len(withdrawals) > 0 && !p.config.IsShanghai(block.Number(), block.Time()) {
-		return nil, nil, 0, errors.New("withdrawals before shanghai")
+		return nil, errors.New("withdrawals before shanghai")
 	}
 	// Finalize the block, applying any consensus engine specific extras (e.g. block rewards)
 	p.chain.engine.Finalize(p.chain, header, statedb, block.Body())
 
-	return receipts, allLogs, *usedGas, nil
+	return &ProcessResult{
+		Receipts: receipts,
+		Requests: requests,
+		Logs:     allLogs,
+		GasUsed:  *usedGas,
+	}, nil
 }
 
 // ApplyTransactionWithEVM attempts to apply a transaction to the given state database
@@ -135,9 +158,13 @@ func ApplyTransactionWithEVM(msg *Message, config *params.ChainConfig, gp *GasPo
 	}
 	*usedGas += result.UsedGas
 
-	// Create a new receipt for the transaction, storing the intermediate root and gas used
-	// by the tx.
-	receipt = &types.Receipt{Type: tx.Type(), PostState: root, CumulativeGasUsed: *usedGas}
+	return makeReceipt(evm, msg, result, statedb, blockNumber, blockHash, tx, *usedGas, root), nil
+}
+
+// makeReceipt creates a new receipt for the transaction, storing the intermediate
+// root and the cumulative gas used by the block so far.
+func makeReceipt(evm *vm.EVM, msg *Message, result *ExecutionResult, statedb *state.StateDB, blockNumber *big.Int, blockHash common.Hash, tx *types.Transaction, usedGas uint64, root []byte) *types.Receipt {
+	receipt := &types.Receipt{Type: tx.Type(), PostState: root, CumulativeGasUsed: usedGas}
 	if result.Failed() {
 		receipt.Status = types.ReceiptStatusFailed
 	} else {
@@ -153,7 +180,7 @@ func ApplyTransactionWithEVM(msg *Message, config *params.ChainConfig, gp *GasPo
 
 	// If the transaction created a contract, store the creation address in the receipt.
 	if msg.To == nil {
-		receipt.ContractAddress = crypto.CreateAddress(evm.TxContext.Origin, tx.Nonce())
+		receipt.ContractAddress = crypto.CreateAddress(msg.From, tx.Nonce())
 	}
 
 	// Set the receipt logs and create the bloom filter.
@@ -162,7 +189,7 @@ func ApplyTransactionWithEVM(msg *Message, config *params.ChainConfig, gp *GasPo
 	receipt.BlockHash = blockHash
 	receipt.BlockNumber = blockNumber
 	receipt.TransactionIndex = uint(statedb.TxIndex())
-	return receipt, err
+	return receipt
 }
 
 // ApplyTransaction attempts to apply a transaction to the given state database
@@ -207,3 +234,131 @@ func ProcessBeaconBlockRoot(beaconRoot common.Hash, vmenv *vm.EVM, statedb *stat
 	_, _, _ = vmenv.Call(vm.AccountRef(msg.From), *msg.To, msg.Data, 30_000_000, common.U2560)
 	statedb.Finalise(true)
 }
+
+// ProcessParentBlockHash stores the parent block hash in the history storage
+// contract as per EIP-2935.
+func ProcessParentBlockHash(prevHash common.Hash, vmenv *vm.EVM, statedb *state.StateDB) {
+	if vmenv.Config.Tracer != nil && vmenv.Config.Tracer.OnSystemCallStart != nil {
+		vmenv.Config.Tracer.OnSystemCallStart()
+	}
+	if vmenv.Config.Tracer != nil && vmenv.Config.Tracer.OnSystemCallEnd != nil {
+		defer vmenv.Config.Tracer.OnSystemCallEnd()
+	}
+
+	msg := &Message{
+		From:      params.SystemAddress,
+		GasLimit:  30_000_000,
+		GasPrice:  common.Big0,
+		GasFeeCap: common.Big0,
+		GasTipCap: common.Big0,
+		To:        &params.HistoryStorageAddress,
+		Data:      prevHash.Bytes(),
+	}
+	vmenv.Reset(NewEVMTxContext(msg), statedb)
+	statedb.AddAddressToAccessList(params.HistoryStorageAddress)
+	_, _, _ = vmenv.Call(vm.AccountRef(msg.From), *msg.To, msg.Data, 30_000_000, common.U2560)
+	statedb.Finalise(true)
+}
+
+// depositTopic is the topic of the DepositEvent emitted by the deposit contract,
+// keccak256("DepositEvent(bytes,bytes,bytes,bytes,bytes)").
+var depositTopic = common.HexToHash("0x649bbc62d0e31342afea4e5cd82d4049e7e1ee912fc0889aa790803be39038c5")
+
+// ProcessRequests collects the EIP-7685 requests of a block: the EIP-6110
+// deposits contained in the logs, followed by the EIP-7002 withdrawal and the
+// EIP-7251 consolidation requests dequeued from their system contracts.
+func ProcessRequests(logs []*types.Log, vmenv *vm.EVM, statedb *state.StateDB) (types.Requests, error) {
+	requests, err := ParseDepositLogs(logs, vmenv.ChainConfig())
+	if err != nil {
+		return nil, err
+	}
+	withdrawals, err := ProcessWithdrawalQueue(vmenv, statedb)
+	if err != nil {
+		return nil, err
+	}
+	requests = append(requests, withdrawals...)
+
+	consolidations, err := ProcessConsolidationQueue(vmenv, statedb)
+	if err != nil {
+		return nil, err
+	}
+	return append(requests, consolidations...), nil
+}
+
+// ParseDepositLogs extracts the EIP-6110 deposit requests from the logs emitted
+// by the deposit contract configured in the chain config.
+func ParseDepositLogs(logs []*types.Log, config *params.ChainConfig) (types.Requests, error) {
+	deposits := make(types.Requests, 0)
+	for _, log := range logs {
+		if log.Address != config.DepositContractAddress || len(log.Topics) == 0 || log.Topics[0] != depositTopic {
+			continue
+		}
+		d, err := types.UnpackIntoDeposit(log.Data)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse deposit data: %v", err)
+		}
+		deposits = append(deposits, types.NewRequest(d))
+	}
+	return deposits, nil
+}
+
+// ProcessWithdrawalQueue applies the EIP-7002 system call to the withdrawal
+// request contract, dequeuing the withdrawal requests of the block.
+func ProcessWithdrawalQueue(vmenv *vm.EVM, statedb *state.StateDB) (types.Requests, error) {
+	ret, err := processRequestsSystemCall(vmenv, statedb, params.WithdrawalQueueAddress)
+	if err != nil {
+		return nil, fmt.Errorf("withdrawal queue system call failed: %v", err)
+	}
+	withdrawals, err := types.UnpackWithdrawalRequests(ret)
+	if err != nil {
+		return nil, err
+	}
+	requests := make(types.Requests, len(withdrawals))
+	for i, w := range withdrawals {
+		requests[i] = types.NewRequest(w)
+	}
+	return requests, nil
+}
+
+// ProcessConsolidationQueue applies the EIP-7251 system call to the
+// consolidation request contract, dequeuing the consolidation requests of the
+// block.
+func ProcessConsolidationQueue(vmenv *vm.EVM, statedb *state.StateDB) (types.Requests, error) {
+	ret, err := processRequestsSystemCall(vmenv, statedb, params.ConsolidationQueueAddress)
+	if err != nil {
+		return nil, fmt.Errorf("consolidation queue system call failed: %v", err)
+	}
+	consolidations, err := types.UnpackConsolidationRequests(ret)
+	if err != nil {
+		return nil, err
+	}
+	requests := make(types.Requests, len(consolidations))
+	for i, c := range consolidations {
+		requests[i] = types.NewRequest(c)
+	}
+	return requests, nil
+}
+
+// processRequestsSystemCall invokes a request queue system contract without
+// any input and returns the queued requests it outputs.
+func processRequestsSystemCall(vmenv *vm.EVM, statedb *state.StateDB, addr common.Address) ([]byte, error) {
+	if vmenv.Config.Tracer != nil && vmenv.Config.Tracer.OnSystemCallStart != nil {
+		vmenv.Config.Tracer.OnSystemCallStart()
+	}
+	if vmenv.Config.Tracer != nil && vmenv.Config.Tracer.OnSystemCallEnd != nil {
+		defer vmenv.Config.Tracer.OnSystemCallEnd()
+	}
+	msg := &Message{
+		From:      params.SystemAddress,
+		GasLimit:  30_000_000,
+		GasPrice:  common.Big0,
+		GasFeeCap: common.Big0,
+		GasTipCap: common.Big0,
+		To:        &addr,
+	}
+	vmenv.Reset(NewEVMTxContext(msg), statedb)
+	statedb.AddAddressToAccessList(addr)
+	ret, _, err := vmenv.Call(vm.AccountRef(msg.From), *msg.To, msg.Data, 30_000_000, common.U2560)
+	statedb.Finalise(true)
+	return ret, err
+}
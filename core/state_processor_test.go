@@ -18,6 +18,9 @@ package core
 
 import (
 	"crypto/ecdsa"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"math/big"
 	"testing"
 
@@ -29,6 +32,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
 	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -528,3 +532,154 @@ func TestProcessVerkle(t *testing.T) {
 		}
 	}
 }
+
+func TestProcessParentBlockHash(t *testing.T) {
+	var (
+		chainConfig = params.MergedTestChainConfig
+		hashA       = common.Hash{0x01}
+		hashB       = common.Hash{0x02}
+		header      = &types.Header{ParentHash: hashA, Number: big.NewInt(2), Difficulty: big.NewInt(0)}
+		parent      = &types.Header{ParentHash: hashB, Number: big.NewInt(1), Difficulty: big.NewInt(0)}
+		coinbase    = common.Address{}
+	)
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetNonce(params.HistoryStorageAddress, 1)
+	statedb.SetCode(params.HistoryStorageAddress, params.HistoryStorageCode)
+	statedb.IntermediateRoot(true)
+
+	vmContext := NewEVMBlockContext(header, nil, &coinbase)
+	evm := vm.NewEVM(vmContext, vm.TxContext{}, statedb, chainConfig, vm.Config{})
+	ProcessParentBlockHash(header.ParentHash, evm, statedb)
+
+	vmContext = NewEVMBlockContext(parent, nil, &coinbase)
+	evm = vm.NewEVM(vmContext, vm.TxContext{}, statedb, chainConfig, vm.Config{})
+	ProcessParentBlockHash(parent.ParentHash, evm, statedb)
+
+	// make sure that the state is correct
+	if have := getParentBlockHash(statedb, 1); have != hashA {
+		t.Errorf("want parent hash %v, have %v", hashA, have)
+	}
+	if have := getParentBlockHash(statedb, 0); have != hashB {
+		t.Errorf("want parent hash %v, have %v", hashB, have)
+	}
+	// and that the contract getter serves them
+	for number, want := range map[uint64]common.Hash{0: hashB, 1: hashA, 2: {}} {
+		vmContext = NewEVMBlockContext(&types.Header{Number: big.NewInt(3), Difficulty: big.NewInt(0)}, nil, &coinbase)
+		evm = vm.NewEVM(vmContext, vm.TxContext{}, statedb, chainConfig, vm.Config{})
+		ret, _, err := evm.Call(vm.AccountRef(common.Address{0xaa}), params.HistoryStorageAddress, common.BigToHash(new(big.Int).SetUint64(number)).Bytes(), 100_000, common.U2560)
+		if err != nil {
+			t.Fatalf("getter for block %d failed: %v", number, err)
+		}
+		if have := common.BytesToHash(ret); have != want {
+			t.Errorf("getter for block %d: want %v, have %v", number, want, have)
+		}
+	}
+}
+
+func getParentBlockHash(statedb *state.StateDB, number uint64) common.Hash {
+	ringIndex := number % params.HistoryServeWindow
+	var key common.Hash
+	binary.BigEndian.PutUint64(key[24:], ringIndex)
+	return statedb.GetState(params.HistoryStorageAddress, key)
+}
+
+// Tests that the parent hashes are only stored from the EIP-2935 switch time,
+// independently of the Prague fork.
+func TestHistoryStorageActivation(t *testing.T) {
+	for _, activate := range []bool{false, true} {
+		config := *params.MergedTestChainConfig
+		config.PragueTime = u64(0)
+		if activate {
+			config.HistoryStorageTime = u64(0)
+		}
+		gspec := &Genesis{
+			Config: &config,
+			Alloc: types.GenesisAlloc{
+				params.HistoryStorageAddress: {Nonce: 1, Code: params.HistoryStorageCode, Balance: common.Big0},
+			},
+		}
+		db, blocks, _ := GenerateChainWithGenesis(gspec, beacon.NewFaker(), 2, nil)
+
+		statedb, err := state.New(blocks[1].Root(), state.NewDatabase(db), nil)
+		if err != nil {
+			t.Fatalf("failed to open state: %v", err)
+		}
+		want := common.Hash{}
+		if activate {
+			want = blocks[0].Hash()
+		}
+		if have := getParentBlockHash(statedb, 1); have != want {
+			t.Errorf("activated %v: parent hash mismatch: have %v, want %v", activate, have, want)
+		}
+	}
+}
+
+// storePrecompile is a stateful precompile counting its invocations in its own
+// storage.
+type storePrecompile struct{}
+
+func (p *storePrecompile) RequiredGas(input []byte) uint64 { return 100 }
+func (p *storePrecompile) Run(input []byte) ([]byte, error) {
+	return nil, errors.New("stateless invocation")
+}
+
+func (p *storePrecompile) RunStateful(env *vm.PrecompileEnvironment, input []byte) ([]byte, error) {
+	count := new(big.Int).SetBytes(env.GetState(common.Hash{}).Bytes())
+	return nil, env.SetState(common.Hash{}, common.BigToHash(count.Add(count, common.Big1)))
+}
+
+func init() {
+	vm.RegisterPrecompile("test-store", func(config json.RawMessage) (vm.PrecompiledContract, error) {
+		return new(storePrecompile), nil
+	})
+}
+
+// Tests that the storage written by a stateful precompile to its own account
+// outlives the transaction, even though the account has no code.
+func TestStatefulPrecompileStorage(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		addr    = common.HexToAddress("0x0000000000000000000000000000000000002001")
+		config  = *params.TestChainConfig
+		gspec   = &Genesis{Config: &config, Alloc: types.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}}}
+		signer  = types.LatestSigner(&config)
+		engine  = ethash.NewFaker()
+		storage = func(statedb *state.StateDB) *big.Int {
+			return statedb.GetState(addr, common.Hash{}).Big()
+		}
+	)
+	config.Transitions = []params.Transition{
+		{Block: big.NewInt(0), Precompiles: []params.PrecompileConfig{{Name: "test-store", Address: addr}}},
+	}
+	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, 2, func(i int, b *BlockGen) {
+		tx, _ := types.SignNewTx(key, signer, &types.LegacyTx{
+			Nonce:    uint64(i),
+			To:       &addr,
+			Gas:      50000,
+			GasPrice: b.header.BaseFee,
+		})
+		b.AddTx(tx)
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	for i, block := range blocks {
+		statedb, err := chain.StateAt(block.Root())
+		if err != nil {
+			t.Fatalf("block %d: failed to open state: %v", i, err)
+		}
+		if have, want := storage(statedb), big.NewInt(int64(i+1)); have.Cmp(want) != 0 {
+			t.Errorf("block %d: precompile storage mismatch: have %v, want %v", i, have, want)
+		}
+		if have := statedb.GetNonce(addr); have != 1 {
+			t.Errorf("block %d: precompile nonce mismatch: have %d, want 1", i, have)
+		}
+	}
+}
@@ -58,16 +58,42 @@ func ExecuteStateless(config *params.ChainConfig, witness *stateless.Witness) (c
 	validator := NewBlockValidator(config, nil) // No chain, we only validate the state, not the block
 
 	// Run the stateless blocks processing and self-validate certain fields
-	receipts, _, usedGas, err := processor.Process(witness.Block, db, vm.Config{})
+	res, err := processor.Process(witness.Block, db, vm.Config{})
 	if err != nil {
 		return common.Hash{}, common.Hash{}, err
 	}
-	if err = validator.ValidateState(witness.Block, db, receipts, usedGas, true); err != nil {
+	if err = validator.ValidateState(witness.Block, db, res, true); err != nil {
 		return common.Hash{}, common.Hash{}, err
 	}
 	// Almost everything validated, but receipt and state root needs to be returned
-	receiptRoot := types.DeriveSha(receipts, trie.NewStackTrie(nil))
+	receiptRoot := types.DeriveSha(res.Receipts, trie.NewStackTrie(nil))
 	stateRoot := db.IntermediateRoot(config.IsEIP158(witness.Block.Number()))
 
 	return receiptRoot, stateRoot, nil
 }
+
+// ExecutionWitness re-executes the given block on top of its parent state and
+// returns the stateless witness collected along the way. The provided state must
+// be the post-state of the parent block and is consumed by the call.
+func (bc *BlockChain) ExecutionWitness(block *types.Block, statedb *state.StateDB) (*stateless.Witness, error) {
+	witness, err := stateless.NewWitness(bc, block)
+	if err != nil {
+		return nil, err
+	}
+	statedb.StartPrefetcher("witness", witness)
+	defer statedb.StopPrefetcher()
+
+	// Execute the block without any tracers and validate the resulting state,
+	// which also pulls all the touched trie nodes into the witness
+	vmConfig := bc.vmConfig
+	vmConfig.Tracer = nil
+
+	res, err := bc.processor.Process(block, statedb, vmConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := bc.validator.ValidateState(block, statedb, res, false); err != nil {
+		return nil, err
+	}
+	return witness, nil
+}
@@ -18,6 +18,7 @@ package stateless
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -28,8 +29,6 @@ import (
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
-//go:generate go run github.com/fjl/gencodec -type extWitness -field-override extWitnessMarshalling -out gen_encoding_json.go
-
 // toExtWitness converts our internal witness representation to the consensus one.
 func (w *Witness) toExtWitness() *extWitness {
 	ext := &extWitness{
@@ -67,7 +66,32 @@ func (w *Witness) fromExtWitness(ext *extWitness) error {
 
 // MarshalJSON marshals a witness as JSON.
 func (w *Witness) MarshalJSON() ([]byte, error) {
-	return w.toExtWitness().MarshalJSON()
+	ext := w.toExtWitness()
+
+	enc := &jsonWitness{
+		Headers: ext.Headers,
+		Codes:   make([]hexutil.Bytes, len(ext.Codes)),
+		State:   make([]hexutil.Bytes, len(ext.State)),
+	}
+	if ext.Block != nil {
+		body := ext.Block.Body()
+		enc.Block = &jsonBlock{
+			Header: ext.Block.Header(),
+			Body: &jsonBody{
+				Transactions: body.Transactions,
+				Uncles:       body.Uncles,
+				Withdrawals:  body.Withdrawals,
+				Requests:     body.Requests,
+			},
+		}
+	}
+	for i, code := range ext.Codes {
+		enc.Codes[i] = code
+	}
+	for i, node := range ext.State {
+		enc.State[i] = node
+	}
+	return json.Marshal(enc)
 }
 
 // EncodeRLP serializes a witness as RLP.
@@ -77,11 +101,41 @@ func (w *Witness) EncodeRLP(wr io.Writer) error {
 
 // UnmarshalJSON unmarshals from JSON.
 func (w *Witness) UnmarshalJSON(input []byte) error {
-	var ext extWitness
-	if err := ext.UnmarshalJSON(input); err != nil {
+	var dec jsonWitness
+	if err := json.Unmarshal(input, &dec); err != nil {
 		return err
 	}
-	return w.fromExtWitness(&ext)
+	if dec.Block == nil {
+		return errors.New("missing required field 'block' for witness")
+	}
+	if dec.Block.Header == nil {
+		return errors.New("missing required field 'block.header' for witness")
+	}
+	if dec.Block.Body == nil {
+		return errors.New("missing required field 'block.body' for witness")
+	}
+	if dec.Headers == nil {
+		return errors.New("missing required field 'headers' for witness")
+	}
+	body := types.Body{
+		Transactions: dec.Block.Body.Transactions,
+		Uncles:       dec.Block.Body.Uncles,
+		Withdrawals:  dec.Block.Body.Withdrawals,
+		Requests:     dec.Block.Body.Requests,
+	}
+	ext := &extWitness{
+		Block:   types.NewBlockWithHeader(dec.Block.Header).WithBody(body),
+		Headers: dec.Headers,
+		Codes:   make([][]byte, len(dec.Codes)),
+		State:   make([][]byte, len(dec.State)),
+	}
+	for i, code := range dec.Codes {
+		ext.Codes[i] = code
+	}
+	for i, node := range dec.State {
+		ext.State[i] = node
+	}
+	return w.fromExtWitness(ext)
 }
 
 // DecodeRLP decodes a witness from RLP.
@@ -100,6 +154,9 @@ func (w *Witness) sanitize() error {
 	// Verify that the "parent" header (i.e. index 0) is available, and is the
 	// true parent of the block-to-be executed, since we use that to link the
 	// current block to the pre-state.
+	if w.Block == nil {
+		return errors.New("witness block missing")
+	}
 	if len(w.Headers) == 0 {
 		return errors.New("parent header (for pre-root hash) missing")
 	}
@@ -116,14 +173,31 @@ func (w *Witness) sanitize() error {
 
 // extWitness is a witness RLP encoding for transferring across clients.
 type extWitness struct {
-	Block   *types.Block    `json:"block"       gencodec:"required"`
-	Headers []*types.Header `json:"headers"       gencodec:"required"`
-	Codes   [][]byte        `json:"codes"`
-	State   [][]byte        `json:"state"`
+	Block   *types.Block
+	Headers []*types.Header
+	Codes   [][]byte
+	State   [][]byte
+}
+
+// jsonWitness is the JSON encoding of a witness. Blocks have no JSON encoding
+// of their own, so the block is split into its header and body.
+type jsonWitness struct {
+	Block   *jsonBlock      `json:"block"`
+	Headers []*types.Header `json:"headers"`
+	Codes   []hexutil.Bytes `json:"codes"`
+	State   []hexutil.Bytes `json:"state"`
+}
+
+// jsonBlock is the JSON encoding of the witness block.
+type jsonBlock struct {
+	Header *types.Header `json:"header"`
+	Body   *jsonBody     `json:"body"`
 }
 
-// extWitnessMarshalling defines the hex marshalling types for a witness.
-type extWitnessMarshalling struct {
-	Codes []hexutil.Bytes
-	State []hexutil.Bytes
+// jsonBody is the JSON encoding of the witness block's body.
+type jsonBody struct {
+	Transactions []*types.Transaction `json:"transactions"`
+	Uncles       []*types.Header      `json:"uncles"`
+	Withdrawals  []*types.Withdrawal  `json:"withdrawals"`
+	Requests     []*types.Request     `json:"requests"`
 }
@@ -0,0 +1,112 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package stateless
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// makeTestWitness creates a witness for a block with a transaction and a
+// withdrawal in it.
+func makeTestWitness(t *testing.T) *Witness {
+	key, _ := crypto.GenerateKey()
+	signer := types.LatestSigner(params.TestChainConfig)
+
+	tx, err := types.SignNewTx(key, signer, &types.DynamicFeeTx{
+		ChainID:   params.TestChainConfig.ChainID,
+		Nonce:     1,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(10),
+		Gas:       21000,
+		To:        &common.Address{0xaa},
+		Value:     big.NewInt(100),
+	})
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	parent := &types.Header{Number: big.NewInt(9), Root: common.Hash{0x01}, Difficulty: common.Big0, BaseFee: big.NewInt(7)}
+	header := &types.Header{ParentHash: parent.Hash(), Number: big.NewInt(10), Difficulty: common.Big0, BaseFee: big.NewInt(7)}
+	body := &types.Body{
+		Transactions: []*types.Transaction{tx},
+		Withdrawals:  []*types.Withdrawal{{Index: 1, Validator: 2, Address: common.Address{0xbb}, Amount: 3}},
+	}
+	return &Witness{
+		Block:   types.NewBlock(header, body, nil, trie.NewStackTrie(nil)),
+		Headers: []*types.Header{parent},
+		Codes:   map[string]struct{}{"\x60\x00": {}},
+		State:   map[string]struct{}{"\xc2\x01\x02": {}, "\xc2\x03\x04": {}},
+	}
+}
+
+// Tests that a witness survives a JSON round trip.
+func TestWitnessJSON(t *testing.T) {
+	witness := makeTestWitness(t)
+
+	enc, err := json.Marshal(witness)
+	if err != nil {
+		t.Fatalf("failed to marshal witness: %v", err)
+	}
+	dec := new(Witness)
+	if err := json.Unmarshal(enc, dec); err != nil {
+		t.Fatalf("failed to unmarshal witness: %v", err)
+	}
+	if dec.Block.Hash() != witness.Block.Hash() {
+		t.Errorf("block hash mismatch: have %x, want %x", dec.Block.Hash(), witness.Block.Hash())
+	}
+	// The RLP encoding covers the full block body and the sorted codes and state
+	want, _ := rlp.EncodeToBytes(witness)
+	have, _ := rlp.EncodeToBytes(dec)
+	if !bytes.Equal(have, want) {
+		t.Errorf("witness mismatch after JSON round trip:\nhave %x\nwant %x", have, want)
+	}
+}
+
+// Tests that JSON witnesses lacking mandatory fields are rejected.
+func TestWitnessJSONMissingFields(t *testing.T) {
+	var ext map[string]json.RawMessage
+	enc, _ := json.Marshal(makeTestWitness(t))
+	if err := json.Unmarshal(enc, &ext); err != nil {
+		t.Fatalf("failed to unmarshal witness fields: %v", err)
+	}
+	for _, field := range []string{"block", "headers"} {
+		stripped := make(map[string]json.RawMessage)
+		for name, value := range ext {
+			if name != field {
+				stripped[name] = value
+			}
+		}
+		enc, _ := json.Marshal(stripped)
+		if err := json.Unmarshal(enc, new(Witness)); err == nil {
+			t.Errorf("witness without %s accepted", field)
+		}
+	}
+	for _, input := range []string{`{"block":{},"headers":[]}`, `{"block":null,"headers":[]}`} {
+		if err := json.Unmarshal([]byte(input), new(Witness)); err == nil {
+			t.Errorf("witness %s accepted", input)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/stateless"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Tests that the witness collected by re-executing a historical block is enough
+// to statelessly verify it.
+func TestExecutionWitness(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		// Contract adding the value of slot 0 to the block number and storing
+		// the result in slot NUMBER
+		contract = common.HexToAddress("0xc0de")
+		code     = []byte{
+			byte(vm.PUSH1), 0, byte(vm.SLOAD), byte(vm.NUMBER), byte(vm.ADD),
+			byte(vm.NUMBER), byte(vm.SSTORE), byte(vm.STOP),
+		}
+		gspec = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				address:  {Balance: big.NewInt(params.Ether)},
+				contract: {Code: code, Balance: common.Big0, Storage: map[common.Hash]common.Hash{{}: {0x01}}},
+			},
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 8, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(address), contract, big.NewInt(1), 100000, b.BaseFee(), nil), signer, key)
+		b.AddTx(tx)
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfigWithScheme(rawdb.HashScheme), gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	block := blocks[5]
+	statedb, err := chain.StateAt(blocks[4].Root())
+	if err != nil {
+		t.Fatalf("failed to retrieve parent state: %v", err)
+	}
+	witness, err := chain.ExecutionWitness(block, statedb)
+	if err != nil {
+		t.Fatalf("failed to collect witness: %v", err)
+	}
+	if len(witness.Codes) == 0 || len(witness.State) == 0 {
+		t.Fatalf("incomplete witness: %d codes, %d trie nodes", len(witness.Codes), len(witness.State))
+	}
+	// Round trip the witness through RLP to mimic an offline verifier
+	enc, err := rlp.EncodeToBytes(witness)
+	if err != nil {
+		t.Fatalf("failed to encode witness: %v", err)
+	}
+	decoded := new(stateless.Witness)
+	if err := rlp.DecodeBytes(enc, decoded); err != nil {
+		t.Fatalf("failed to decode witness: %v", err)
+	}
+	receiptRoot, stateRoot, err := ExecuteStateless(gspec.Config, decoded)
+	if err != nil {
+		t.Fatalf("stateless execution failed: %v", err)
+	}
+	if receiptRoot != block.ReceiptHash() {
+		t.Errorf("receipt root mismatch: have %x, want %x", receiptRoot, block.ReceiptHash())
+	}
+	if stateRoot != block.Root() {
+		t.Errorf("state root mismatch: have %x, want %x", stateRoot, block.Root())
+	}
+}
@@ -244,6 +244,10 @@ const (
 	// account within the same tx (captured at end of tx).
 	// Note it doesn't account for a self-destruct which appoints itself as recipient.
 	BalanceDecreaseSelfdestructBurn BalanceChangeReason = 14
+
+	// BalanceIncreaseRewardQBFTBlock is a QBFT block reward, credited to the
+	// beneficiaries of the block according to the beneficiary mode of the chain.
+	BalanceIncreaseRewardQBFTBlock BalanceChangeReason = 15
 )
 
 // GasChangeReason is used to indicate the reason for a gas change, useful
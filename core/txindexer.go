@@ -46,6 +46,7 @@ type txIndexer struct {
 	//       and all others shouldn't.
 	limit    uint64
 	db       ethdb.Database
+	history  rawdb.HistoryReader // Reader of the bodies expired from the database
 	progress chan chan TxIndexProgress
 	term     chan chan struct{}
 	closed   chan struct{}
@@ -56,6 +57,7 @@ func newTxIndexer(limit uint64, chain *BlockChain) *txIndexer {
 	indexer := &txIndexer{
 		limit:    limit,
 		db:       chain.db,
+		history:  chain.readHistoryBody,
 		progress: make(chan chan TxIndexProgress),
 		term:     make(chan chan struct{}),
 		closed:   make(chan struct{}),
@@ -91,7 +93,7 @@ func (indexer *txIndexer) run(tail *uint64, head uint64, stop chan struct{}, don
 		if indexer.limit != 0 && head >= indexer.limit {
 			from = head - indexer.limit + 1
 		}
-		rawdb.IndexTransactions(indexer.db, from, head+1, stop, true)
+		rawdb.IndexTransactions(indexer.db, indexer.history, from, head+1, stop, true)
 		return
 	}
 	// The tail flag is existent (which means indexes in [tail, head] should be
@@ -105,7 +107,7 @@ func (indexer *txIndexer) run(tail *uint64, head uint64, stop chan struct{}, don
 			if end > head+1 {
 				end = head + 1
 			}
-			rawdb.IndexTransactions(indexer.db, 0, end, stop, true)
+			rawdb.IndexTransactions(indexer.db, indexer.history, 0, end, stop, true)
 		}
 		return
 	}
@@ -113,10 +115,10 @@ func (indexer *txIndexer) run(tail *uint64, head uint64, stop chan struct{}, don
 	// limit and the latest chain head.
 	if head-indexer.limit+1 < *tail {
 		// Reindex a part of missing indices and rewind index tail to HEAD-limit
-		rawdb.IndexTransactions(indexer.db, head-indexer.limit+1, *tail, stop, true)
+		rawdb.IndexTransactions(indexer.db, indexer.history, head-indexer.limit+1, *tail, stop, true)
 	} else {
 		// Unindex a part of stale indices and forward index tail to HEAD-limit
-		rawdb.UnindexTransactions(indexer.db, *tail, head-indexer.limit+1, stop, false)
+		rawdb.UnindexTransactions(indexer.db, indexer.history, *tail, head-indexer.limit+1, stop, false)
 	}
 }
 
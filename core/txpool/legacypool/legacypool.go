@@ -439,8 +439,10 @@ func (pool *LegacyPool) SetGasTip(tip *big.Int) {
 		old    = pool.gasTip.Load()
 	)
 	pool.gasTip.Store(newTip)
-	// If the min miner fee increased, remove transactions below the new threshold
-	if newTip.Cmp(old) > 0 {
+	// If the min miner fee increased, remove transactions below the new threshold,
+	// unless gas is free and zero priced transactions are welcome
+	next := new(big.Int).Add(pool.currentHead.Load().Number, common.Big1)
+	if newTip.Cmp(old) > 0 && pool.chainconfig.IsGasPriceEnabled(next) {
 		// pool.priced is sorted by GasFeeCap, so we have to iterate through pool.all instead
 		drop := pool.all.RemotesBelowTip(tip)
 		for _, tx := range drop {
@@ -542,6 +544,10 @@ func (pool *LegacyPool) Pending(filter txpool.PendingFilter) map[common.Address]
 	if filter.BaseFee != nil {
 		baseFeeBig = filter.BaseFee.ToBig()
 	}
+	// Don't enforce any tip if gas is free in the block being built
+	if next := new(big.Int).Add(pool.currentHead.Load().Number, common.Big1); !pool.chainconfig.IsGasPriceEnabled(next) {
+		minTipBig = nil
+	}
 	pending := make(map[common.Address][]*txpool.LazyTransaction, len(pool.pending))
 	for addr, list := range pool.pending {
 		txs := list.Flatten()
@@ -356,6 +356,63 @@ func TestInvalidTransactions(t *testing.T) {
 	}
 }
 
+// Tests that zero priced transactions are accepted and handed to the miner while
+// gas is free, and that the transaction size limit of the chain is enforced.
+func TestGaslessTransactions(t *testing.T) {
+	t.Parallel()
+
+	disabled := false
+	config := *params.TestChainConfig
+	config.Transitions = []params.Transition{
+		{Block: big.NewInt(0), GasPriceEnabled: &disabled, TransactionSizeLimit: 64},
+	}
+	pool, key := setupPoolWithConfig(&config)
+	defer pool.Close()
+
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, from, big.NewInt(1000))
+
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(0), key)); err != nil {
+		t.Fatalf("zero priced transaction rejected: %v", err)
+	}
+	pending := pool.Pending(txpool.PendingFilter{MinTip: uint256.NewInt(1), BaseFee: uint256.NewInt(1)})
+	if len(pending[from]) != 1 {
+		t.Fatalf("zero priced transaction not pending: %v", pending)
+	}
+	// Transactions above the configured size limit are rejected even though
+	// the pool could handle them
+	if err := pool.addRemote(pricedDataTransaction(1, 2000000, big.NewInt(0), key, 65*1024)); !errors.Is(err, txpool.ErrOversizedData) {
+		t.Errorf("oversized transaction error mismatch: have %v, want %v", err, txpool.ErrOversizedData)
+	}
+	if err := pool.addRemote(pricedDataTransaction(1, 2000000, big.NewInt(0), key, 60*1024)); err != nil {
+		t.Errorf("transaction within size limit rejected: %v", err)
+	}
+}
+
+// Tests that the gas price transition is evaluated at the pending block, so zero
+// priced transactions are accepted right before gas becomes free.
+func TestGaslessTransition(t *testing.T) {
+	t.Parallel()
+
+	disabled := false
+	config := *params.TestChainConfig
+	config.Transitions = []params.Transition{
+		{Block: big.NewInt(1), GasPriceEnabled: &disabled},
+	}
+	pool, key := setupPoolWithConfig(&config)
+	defer pool.Close()
+
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, from, big.NewInt(1000))
+
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(0), key)); err != nil {
+		t.Fatalf("zero priced transaction rejected: %v", err)
+	}
+	if pending := pool.Pending(txpool.PendingFilter{MinTip: uint256.NewInt(1)}); len(pending[from]) != 1 {
+		t.Fatalf("zero priced transaction not pending: %v", pending)
+	}
+}
+
 func TestQueue(t *testing.T) {
 	t.Parallel()
 
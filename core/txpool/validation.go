@@ -58,10 +58,19 @@ func ValidateTransaction(tx *types.Transaction, head *types.Header, signer types
 	if opts.Accept&(1<<tx.Type()) == 0 {
 		return fmt.Errorf("%w: tx type %v not supported by this pool", core.ErrTxTypeNotSupported, tx.Type())
 	}
+	// The gas price and size limit transitions are evaluated at the block the
+	// transaction can be included in next
+	next := new(big.Int).Add(head.Number, common.Big1)
+
 	// Before performing any expensive validations, sanity check that the tx is
-	// smaller than the maximum limit the pool can meaningfully handle
-	if tx.Size() > opts.MaxSize {
-		return fmt.Errorf("%w: transaction size %v, limit %v", ErrOversizedData, tx.Size(), opts.MaxSize)
+	// smaller than the maximum limit the pool can meaningfully handle, or the
+	// one configured for the chain
+	maxSize := opts.MaxSize
+	if limit := opts.Config.GetTransactionSizeLimit(next); limit != 0 && tx.Type() != types.BlobTxType {
+		maxSize = limit
+	}
+	if tx.Size() > maxSize {
+		return fmt.Errorf("%w: transaction size %v, limit %v", ErrOversizedData, tx.Size(), maxSize)
 	}
 	// Ensure only transactions that have been enabled are accepted
 	if !opts.Config.IsBerlin(head.Number) && tx.Type() != types.LegacyTxType {
@@ -74,8 +83,8 @@ func ValidateTransaction(tx *types.Transaction, head *types.Header, signer types
 		return fmt.Errorf("%w: type %d rejected, pool not yet in Cancun", core.ErrTxTypeNotSupported, tx.Type())
 	}
 	// Check whether the init code size has been exceeded
-	if opts.Config.IsShanghai(head.Number, head.Time) && tx.To() == nil && len(tx.Data()) > params.MaxInitCodeSize {
-		return fmt.Errorf("%w: code size %v, limit %v", core.ErrMaxInitCodeSizeExceeded, len(tx.Data()), params.MaxInitCodeSize)
+	if maxInitCodeSize := opts.Config.GetMaxInitCodeSize(next); opts.Config.IsShanghai(head.Number, head.Time) && tx.To() == nil && len(tx.Data()) > maxInitCodeSize {
+		return fmt.Errorf("%w: code size %v, limit %v", core.ErrMaxInitCodeSizeExceeded, len(tx.Data()), maxInitCodeSize)
 	}
 	// Transactions can't be negative. This may never happen using RLP decoded
 	// transactions but may occur for transactions created using the RPC.
@@ -110,8 +119,9 @@ func ValidateTransaction(tx *types.Transaction, head *types.Header, signer types
 	if tx.Gas() < intrGas {
 		return fmt.Errorf("%w: gas %v, minimum needed %v", core.ErrIntrinsicGas, tx.Gas(), intrGas)
 	}
-	// Ensure the gasprice is high enough to cover the requirement of the calling pool
-	if tx.GasTipCapIntCmp(opts.MinTip) < 0 {
+	// Ensure the gasprice is high enough to cover the requirement of the calling
+	// pool, unless gas is free on the chain
+	if opts.Config.IsGasPriceEnabled(next) && tx.GasTipCapIntCmp(opts.MinTip) < 0 {
 		return fmt.Errorf("%w: gas tip cap %v, minimum needed %v", ErrUnderpriced, tx.GasTipCap(), opts.MinTip)
 	}
 	if tx.Type() == types.BlobTxType {
@@ -94,6 +94,9 @@ type Header struct {
 
 	// ParentBeaconRoot was added by EIP-4788 and is ignored in legacy headers.
 	ParentBeaconRoot *common.Hash `json:"parentBeaconBlockRoot" rlp:"optional"`
+
+	// RequestsHash was added by EIP-7685 and is ignored in legacy headers.
+	RequestsHash *common.Hash `json:"requestsRoot" rlp:"optional"`
 }
 
 // field type overrides for gencodec
@@ -112,7 +115,16 @@ type headerMarshaling struct {
 
 // Hash returns the block hash of the header, which is simply the keccak256 hash of its
 // RLP encoding.
+//
+// QBFT headers are hashed without their committed seals and round number, so
+// the block hash is known before the validators seal it and is the same on all
+// nodes, regardless of which quorum of seals they collected.
 func (h *Header) Hash() common.Hash {
+	if h != nil && h.MixDigest == IstanbulDigest {
+		if qbftHeader := QBFTFilteredHeader(h); qbftHeader != nil {
+			return rlpHash(qbftHeader)
+		}
+	}
 	return rlpHash(h)
 }
 
@@ -161,8 +173,11 @@ func (h *Header) QBFTHashWithRoundNumber(round uint32) common.Hash {
 // ##END
 
 // EmptyBody returns true if there is no additional 'body' to complete the header
-// that is: no transactions, no uncles and no withdrawals.
+// that is: no transactions, no uncles, no withdrawals and no requests.
 func (h *Header) EmptyBody() bool {
+	if h.RequestsHash != nil && *h.RequestsHash != EmptyRequestsHash {
+		return false
+	}
 	if h.WithdrawalsHash != nil {
 		return h.TxHash == EmptyTxsHash && *h.WithdrawalsHash == EmptyWithdrawalsHash
 	}
@@ -180,6 +195,7 @@ type Body struct {
 	Transactions []*Transaction
 	Uncles       []*Header
 	Withdrawals  []*Withdrawal `rlp:"optional"`
+	Requests     []*Request    `rlp:"optional"`
 }
 
 // Block represents an Ethereum block.
@@ -204,6 +220,7 @@ type Block struct {
 	uncles       []*Header
 	transactions Transactions
 	withdrawals  Withdrawals
+	requests     Requests
 
 	// caches
 	hash atomic.Pointer[common.Hash]
@@ -221,6 +238,7 @@ type extblock struct {
 	Txs         []*Transaction
 	Uncles      []*Header
 	Withdrawals []*Withdrawal `rlp:"optional"`
+	Requests    []*Request    `rlp:"optional"`
 }
 
 // NewBlock creates a new block. The input data is copied, changes to header and to the
@@ -237,6 +255,7 @@ func NewBlock(header *Header, body *Body, receipts []*Receipt, hasher TrieHasher
 		txs         = body.Transactions
 		uncles      = body.Uncles
 		withdrawals = body.Withdrawals
+		requests    = body.Requests
 	)
 
 	if len(txs) == 0 {
@@ -275,6 +294,17 @@ func NewBlock(header *Header, body *Body, receipts []*Receipt, hasher TrieHasher
 		b.withdrawals = slices.Clone(withdrawals)
 	}
 
+	if requests == nil {
+		b.header.RequestsHash = nil
+	} else if len(requests) == 0 {
+		b.header.RequestsHash = &EmptyRequestsHash
+		b.requests = Requests{}
+	} else {
+		hash := DeriveSha(Requests(requests), hasher)
+		b.header.RequestsHash = &hash
+		b.requests = slices.Clone(requests)
+	}
+
 	return b
 }
 
@@ -310,6 +340,10 @@ func CopyHeader(h *Header) *Header {
 		cpy.ParentBeaconRoot = new(common.Hash)
 		*cpy.ParentBeaconRoot = *h.ParentBeaconRoot
 	}
+	if h.RequestsHash != nil {
+		cpy.RequestsHash = new(common.Hash)
+		*cpy.RequestsHash = *h.RequestsHash
+	}
 	return &cpy
 }
 
@@ -320,7 +354,7 @@ func (b *Block) DecodeRLP(s *rlp.Stream) error {
 	if err := s.Decode(&eb); err != nil {
 		return err
 	}
-	b.header, b.uncles, b.transactions, b.withdrawals = eb.Header, eb.Uncles, eb.Txs, eb.Withdrawals
+	b.header, b.uncles, b.transactions, b.withdrawals, b.requests = eb.Header, eb.Uncles, eb.Txs, eb.Withdrawals, eb.Requests
 	b.size.Store(rlp.ListSize(size))
 	return nil
 }
@@ -332,13 +366,14 @@ func (b *Block) EncodeRLP(w io.Writer) error {
 		Txs:         b.transactions,
 		Uncles:      b.uncles,
 		Withdrawals: b.withdrawals,
+		Requests:    b.requests,
 	})
 }
 
 // Body returns the non-header content of the block.
 // Note the returned data is not an independent copy.
 func (b *Block) Body() *Body {
-	return &Body{b.transactions, b.uncles, b.withdrawals}
+	return &Body{b.transactions, b.uncles, b.withdrawals, b.requests}
 }
 
 // Accessors for body data. These do not return a copy because the content
@@ -347,6 +382,7 @@ func (b *Block) Body() *Body {
 func (b *Block) Uncles() []*Header          { return b.uncles }
 func (b *Block) Transactions() Transactions { return b.transactions }
 func (b *Block) Withdrawals() Withdrawals   { return b.withdrawals }
+func (b *Block) Requests() Requests         { return b.requests }
 
 func (b *Block) Transaction(hash common.Hash) *Transaction {
 	for _, transaction := range b.transactions {
@@ -467,6 +503,7 @@ func (b *Block) WithBody(body Body) *Block {
 		transactions: slices.Clone(body.Transactions),
 		uncles:       make([]*Header, len(body.Uncles)),
 		withdrawals:  slices.Clone(body.Withdrawals),
+		requests:     slices.Clone(body.Requests),
 	}
 	for i := range body.Uncles {
 		block.uncles[i] = CopyHeader(body.Uncles[i])
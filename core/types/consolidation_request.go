@@ -0,0 +1,82 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+//go:generate go run github.com/fjl/gencodec -type ConsolidationRequest -field-override consolidationRequestMarshaling -out gen_consolidation_request_json.go
+
+// ConsolidationRequest represents an EIP-7251 consolidation request from the
+// execution layer.
+type ConsolidationRequest struct {
+	Source          common.Address `json:"sourceAddress"` // address that initiated the request
+	SourcePublicKey [48]byte       `json:"sourcePubkey"`  // public key of the validator to consolidate from
+	TargetPublicKey [48]byte       `json:"targetPubkey"`  // public key of the validator to consolidate into
+}
+
+// field type overrides for gencodec
+type consolidationRequestMarshaling struct {
+	SourcePublicKey hexutil.Bytes
+	TargetPublicKey hexutil.Bytes
+}
+
+// ConsolidationRequests implements DerivableList for consolidation requests.
+type ConsolidationRequests []*ConsolidationRequest
+
+// Len returns the length of s.
+func (s ConsolidationRequests) Len() int { return len(s) }
+
+// EncodeIndex encodes the i'th consolidation request to w.
+func (s ConsolidationRequests) EncodeIndex(i int, w *bytes.Buffer) {
+	rlp.Encode(w, s[i])
+}
+
+// consolidationRequestSize is the size of a consolidation request as returned
+// by the EIP-7251 system contract.
+const consolidationRequestSize = 20 + 48 + 48
+
+// UnpackConsolidationRequests splits the output of the EIP-7251 system
+// contract into the individual consolidation requests.
+func UnpackConsolidationRequests(data []byte) (ConsolidationRequests, error) {
+	if len(data)%consolidationRequestSize != 0 {
+		return nil, fmt.Errorf("consolidation requests wrong length: %d not a multiple of %d", len(data), consolidationRequestSize)
+	}
+	reqs := make(ConsolidationRequests, 0, len(data)/consolidationRequestSize)
+	for ; len(data) > 0; data = data[consolidationRequestSize:] {
+		r := new(ConsolidationRequest)
+		copy(r.Source[:], data[0:20])
+		copy(r.SourcePublicKey[:], data[20:68])
+		copy(r.TargetPublicKey[:], data[68:116])
+		reqs = append(reqs, r)
+	}
+	return reqs, nil
+}
+
+func (c *ConsolidationRequest) requestType() byte            { return ConsolidationRequestType }
+func (c *ConsolidationRequest) encode(b *bytes.Buffer) error { return rlp.Encode(b, c) }
+func (c *ConsolidationRequest) decode(input []byte) error    { return rlp.DecodeBytes(input, c) }
+func (c *ConsolidationRequest) copy() RequestData {
+	cpy := *c
+	return &cpy
+}
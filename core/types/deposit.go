@@ -0,0 +1,88 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+//go:generate go run github.com/fjl/gencodec -type Deposit -field-override depositMarshaling -out gen_deposit_json.go
+
+// Deposit contains EIP-6110 deposit data.
+type Deposit struct {
+	PublicKey             [48]byte    `json:"pubkey"`                // public key of validator
+	WithdrawalCredentials common.Hash `json:"withdrawalCredentials"` // beneficiary of the validator funds
+	Amount                uint64      `json:"amount"`                // deposit size in Gwei
+	Signature             [96]byte    `json:"signature"`             // signature over deposit msg
+	Index                 uint64      `json:"index"`                 // deposit count value
+}
+
+// field type overrides for gencodec
+type depositMarshaling struct {
+	PublicKey hexutil.Bytes
+	Amount    hexutil.Uint64
+	Signature hexutil.Bytes
+	Index     hexutil.Uint64
+}
+
+// Deposits implements DerivableList for deposit requests.
+type Deposits []*Deposit
+
+// Len returns the length of s.
+func (s Deposits) Len() int { return len(s) }
+
+// EncodeIndex encodes the i'th deposit to w.
+func (s Deposits) EncodeIndex(i int, w *bytes.Buffer) {
+	rlp.Encode(w, s[i])
+}
+
+// depositLogSize is the size of the ABI encoded DepositEvent log data.
+const depositLogSize = 576
+
+// UnpackIntoDeposit unpacks a serialized DepositEvent, emitted by the deposit
+// contract, into a Deposit.
+func UnpackIntoDeposit(data []byte) (*Deposit, error) {
+	if len(data) != depositLogSize {
+		return nil, fmt.Errorf("deposit wrong length: want %d, have %d", depositLogSize, len(data))
+	}
+	var d Deposit
+	// The ABI encodes the five dynamic byte arrays as head offsets followed by
+	// length-prefixed, 32-byte aligned data. As the sizes are fixed, the data
+	// is read from the known positions directly:
+	//   pubkey: 192..240, credentials: 288..320, amount: 352..360,
+	//   signature: 416..512, index: 544..552
+	copy(d.PublicKey[:], data[192:240])
+	copy(d.WithdrawalCredentials[:], data[288:320])
+	d.Amount = binary.LittleEndian.Uint64(data[352:360])
+	copy(d.Signature[:], data[416:512])
+	d.Index = binary.LittleEndian.Uint64(data[544:552])
+	return &d, nil
+}
+
+func (d *Deposit) requestType() byte            { return DepositRequestType }
+func (d *Deposit) encode(b *bytes.Buffer) error { return rlp.Encode(b, d) }
+func (d *Deposit) decode(input []byte) error    { return rlp.DecodeBytes(input, d) }
+func (d *Deposit) copy() RequestData {
+	cpy := *d
+	return &cpy
+}
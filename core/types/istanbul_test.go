@@ -18,10 +18,12 @@ package types
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rlp"
 )
 
 func TestHeaderHash(t *testing.T) {
@@ -78,3 +80,83 @@ func TestExtractToQBFTExtra(t *testing.T) {
 		}
 	}
 }
+
+// Tests the QBFT extra-data encoding against golden vectors spelling out the
+// layout [vanity, [validators], vote, round, [seals]], with the vote as
+// [recipient, type] or an empty list. The "upstream" vector is the one of
+// TestExtractToQBFTExtra inherited from GoQuorum, the others are built from the
+// layout.
+func TestQBFTExtraGolden(t *testing.T) {
+	var (
+		vanity     = common.FromHex("0x0000000000000000000000000000000000000000000000000000000000000001")
+		validators = []common.Address{
+			common.HexToAddress("0x44add0ec310f115a0e603b2d7db9f067778eaf8a"),
+			common.HexToAddress("0x294fc7e8f22b3bcdcf955dd7ff3ba2ed833f8212"),
+		}
+		upstream = []common.Address{
+			common.HexToAddress("0x44add0ec310f115a0e603b2d7db9f067778eaf8a"),
+			common.HexToAddress("0x294fc7e8f22b3bcdcf955dd7ff3ba2ed833f8212"),
+			common.HexToAddress("0x6beaaed781d2d2ab6350f5c4566a2c6eaac407a6"),
+			common.HexToAddress("0x8be76812f765c24641ec63dc2852b378aba2b440"),
+		}
+		recipient = common.HexToAddress("0x6beaaed781d2d2ab6350f5c4566a2c6eaac407a6")
+		seals     = [][]byte{
+			common.FromHex("0x" + strings.Repeat("11", IstanbulExtraSeal)),
+			common.FromHex("0x" + strings.Repeat("22", IstanbulExtraSeal)),
+		}
+	)
+	tests := []struct {
+		name   string
+		extra  *QBFTExtra
+		layout []interface{}
+		want   string
+	}{
+		{
+			name:   "upstream",
+			extra:  &QBFTExtra{VanityData: []byte{}, Validators: upstream, CommittedSeal: [][]byte{}},
+			layout: []interface{}{[]byte{}, upstream, []interface{}{}, uint32(0), [][]byte{}},
+			want:   "0xf85a80f8549444add0ec310f115a0e603b2d7db9f067778eaf8a94294fc7e8f22b3bcdcf955dd7ff3ba2ed833f8212946beaaed781d2d2ab6350f5c4566a2c6eaac407a6948be76812f765c24641ec63dc2852b378aba2b440c080c0",
+		},
+		{
+			name:   "genesis",
+			extra:  &QBFTExtra{VanityData: vanity, Validators: validators, CommittedSeal: [][]byte{}},
+			layout: []interface{}{vanity, validators, []interface{}{}, uint32(0), [][]byte{}},
+			want:   "0xf84fa00000000000000000000000000000000000000000000000000000000000000001ea9444add0ec310f115a0e603b2d7db9f067778eaf8a94294fc7e8f22b3bcdcf955dd7ff3ba2ed833f8212c080c0",
+		},
+		{
+			name: "sealed",
+			extra: &QBFTExtra{
+				VanityData:    vanity,
+				Validators:    validators,
+				Vote:          &ValidatorVote{RecipientAddress: recipient, VoteType: QBFTAuthVote},
+				Round:         3,
+				CommittedSeal: seals,
+			},
+			layout: []interface{}{vanity, validators, []interface{}{recipient, QBFTAuthVote}, uint32(3), seals},
+			want:   "0xf8eda00000000000000000000000000000000000000000000000000000000000000001ea9444add0ec310f115a0e603b2d7db9f067778eaf8a94294fc7e8f22b3bcdcf955dd7ff3ba2ed833f8212d7946beaaed781d2d2ab6350f5c4566a2c6eaac407a681ff03f886b8411111111111111111111111111111111111111111111111111111111111111111111111111111111111111111111111111111111111111111111111111111111111b8412222222222222222222222222222222222222222222222222222222222222222222222222222222222222222222222222222222222222222222222222222222222",
+		},
+	}
+	for _, tt := range tests {
+		enc, err := rlp.EncodeToBytes(tt.extra)
+		if err != nil {
+			t.Fatalf("%s: failed to encode: %v", tt.name, err)
+		}
+		if have := hexutil.Encode(enc); have != tt.want {
+			t.Errorf("%s: encoding mismatch:\nhave %s\nwant %s", tt.name, have, tt.want)
+		}
+		layout, err := rlp.EncodeToBytes(tt.layout)
+		if err != nil {
+			t.Fatalf("%s: failed to encode layout: %v", tt.name, err)
+		}
+		if have := hexutil.Encode(layout); have != tt.want {
+			t.Errorf("%s: layout mismatch:\nhave %s\nwant %s", tt.name, have, tt.want)
+		}
+		extra, err := ExtractQBFTExtra(&Header{Extra: hexutil.MustDecode(tt.want)})
+		if err != nil {
+			t.Fatalf("%s: failed to decode: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(extra, tt.extra) {
+			t.Errorf("%s: decoding mismatch: have %+v, want %+v", tt.name, extra, tt.extra)
+		}
+	}
+}
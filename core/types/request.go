@@ -0,0 +1,201 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var (
+	ErrRequestTypeNotSupported = errors.New("request type not supported")
+	errShortTypedRequest       = errors.New("typed request too short")
+)
+
+// Request types.
+const (
+	DepositRequestType       = 0x00
+	WithdrawalRequestType    = 0x01
+	ConsolidationRequestType = 0x02
+)
+
+// Request is an EIP-7685 request object. It represents execution layer
+// initiated requests, which are passed to the consensus layer for processing.
+type Request struct {
+	inner RequestData // request data
+}
+
+// RequestData is the underlying data of a request.
+type RequestData interface {
+	requestType() byte
+	encode(*bytes.Buffer) error
+	decode([]byte) error
+	copy() RequestData // creates a deep copy and initializes all fields
+}
+
+// NewRequest creates a new request.
+func NewRequest(inner RequestData) *Request {
+	return &Request{inner: inner.copy()}
+}
+
+// Type returns the EIP-7685 type of the request.
+func (r *Request) Type() byte {
+	return r.inner.requestType()
+}
+
+// Inner returns the inner request data.
+func (r *Request) Inner() RequestData {
+	return r.inner
+}
+
+// EncodeRLP implements rlp.Encoder. Requests are encoded as RLP strings
+// containing their typed encoding.
+func (r *Request) EncodeRLP(w io.Writer) error {
+	buf := encodeBufferPool.Get().(*bytes.Buffer)
+	defer encodeBufferPool.Put(buf)
+	buf.Reset()
+
+	if err := r.encodeTyped(buf); err != nil {
+		return err
+	}
+	return rlp.Encode(w, buf.Bytes())
+}
+
+// encodeTyped writes the canonical encoding of a typed request to w.
+func (r *Request) encodeTyped(w *bytes.Buffer) error {
+	w.WriteByte(r.Type())
+	return r.inner.encode(w)
+}
+
+// DecodeRLP implements rlp.Decoder.
+func (r *Request) DecodeRLP(s *rlp.Stream) error {
+	b, err := s.Bytes()
+	if err != nil {
+		return err
+	}
+	return r.UnmarshalBinary(b)
+}
+
+// MarshalBinary returns the canonical encoding of the request.
+func (r *Request) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	err := r.encodeTyped(&buf)
+	return buf.Bytes(), err
+}
+
+// UnmarshalBinary decodes the canonical encoding of requests.
+func (r *Request) UnmarshalBinary(b []byte) error {
+	inner, err := r.decode(b)
+	if err != nil {
+		return err
+	}
+	r.inner = inner
+	return nil
+}
+
+// decode decodes a request from the canonical format.
+func (r *Request) decode(b []byte) (RequestData, error) {
+	if len(b) <= 1 {
+		return nil, errShortTypedRequest
+	}
+	var inner RequestData
+	switch b[0] {
+	case DepositRequestType:
+		inner = new(Deposit)
+	case WithdrawalRequestType:
+		inner = new(WithdrawalRequest)
+	case ConsolidationRequestType:
+		inner = new(ConsolidationRequest)
+	default:
+		return nil, ErrRequestTypeNotSupported
+	}
+	err := inner.decode(b[1:])
+	return inner, err
+}
+
+// MarshalJSON marshals the request as the hex encoding of its canonical form.
+func (r *Request) MarshalJSON() ([]byte, error) {
+	enc, err := r.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(hexutil.Bytes(enc))
+}
+
+// UnmarshalJSON unmarshals the hex encoding of a request's canonical form.
+func (r *Request) UnmarshalJSON(input []byte) error {
+	var enc hexutil.Bytes
+	if err := enc.UnmarshalJSON(input); err != nil {
+		return err
+	}
+	if err := r.UnmarshalBinary(enc); err != nil {
+		return fmt.Errorf("invalid request: %v", err)
+	}
+	return nil
+}
+
+// Requests implements DerivableList for requests.
+type Requests []*Request
+
+// Len returns the length of s.
+func (s Requests) Len() int { return len(s) }
+
+// EncodeIndex encodes the i'th request to w. Note that this does not check for
+// errors because we assume that *Request will only ever contain valid requests
+// that were either constructed by decoding or via public API in this package.
+func (s Requests) EncodeIndex(i int, w *bytes.Buffer) {
+	s[i].encodeTyped(w)
+}
+
+// Deposits returns the deposit requests contained in s.
+func (s Requests) Deposits() Deposits {
+	deposits := make(Deposits, 0)
+	for _, r := range s {
+		if d, ok := r.inner.(*Deposit); ok {
+			deposits = append(deposits, d)
+		}
+	}
+	return deposits
+}
+
+// WithdrawalRequests returns the withdrawal requests contained in s.
+func (s Requests) WithdrawalRequests() WithdrawalRequests {
+	withdrawals := make(WithdrawalRequests, 0)
+	for _, r := range s {
+		if w, ok := r.inner.(*WithdrawalRequest); ok {
+			withdrawals = append(withdrawals, w)
+		}
+	}
+	return withdrawals
+}
+
+// ConsolidationRequests returns the consolidation requests contained in s.
+func (s Requests) ConsolidationRequests() ConsolidationRequests {
+	consolidations := make(ConsolidationRequests, 0)
+	for _, r := range s {
+		if c, ok := r.inner.(*ConsolidationRequest); ok {
+			consolidations = append(consolidations, c)
+		}
+	}
+	return consolidations
+}
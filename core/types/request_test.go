@@ -0,0 +1,151 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/internal/blocktest"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// packDeposit ABI encodes a deposit the same way the deposit contract does
+// when emitting a DepositEvent.
+func packDeposit(d *Deposit) []byte {
+	var (
+		data    = make([]byte, depositLogSize)
+		amount  = make([]byte, 8)
+		index   = make([]byte, 8)
+		offsets = []int{160, 256, 320, 384, 512}
+		fields  = [][]byte{d.PublicKey[:], d.WithdrawalCredentials[:], amount, d.Signature[:], index}
+	)
+	binary.LittleEndian.PutUint64(amount, d.Amount)
+	binary.LittleEndian.PutUint64(index, d.Index)
+	for i, offset := range offsets {
+		binary.BigEndian.PutUint64(data[i*32+24:], uint64(offset))
+		binary.BigEndian.PutUint64(data[offset+24:], uint64(len(fields[i])))
+		copy(data[offset+32:], fields[i])
+	}
+	return data
+}
+
+func TestUnpackIntoDeposit(t *testing.T) {
+	want := &Deposit{
+		WithdrawalCredentials: common.HexToHash("0x0100000000000000000000001234567890abcdef1234567890abcdef12345678"),
+		Amount:                32_000_000_000,
+		Index:                 7,
+	}
+	copy(want.PublicKey[:], bytes.Repeat([]byte{0x11}, 48))
+	copy(want.Signature[:], bytes.Repeat([]byte{0x22}, 96))
+
+	have, err := UnpackIntoDeposit(packDeposit(want))
+	if err != nil {
+		t.Fatalf("failed to unpack deposit: %v", err)
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Fatalf("deposit mismatch: have %+v, want %+v", have, want)
+	}
+	if _, err := UnpackIntoDeposit(make([]byte, depositLogSize-1)); err == nil {
+		t.Fatal("expected error for short deposit data")
+	}
+}
+
+func TestUnpackWithdrawalRequests(t *testing.T) {
+	data := make([]byte, 2*withdrawalRequestSize)
+	data[0] = 0xaa
+	binary.BigEndian.PutUint64(data[68:], 1000)
+	data[withdrawalRequestSize+20] = 0xbb
+
+	reqs, err := UnpackWithdrawalRequests(data)
+	if err != nil {
+		t.Fatalf("failed to unpack requests: %v", err)
+	}
+	if len(reqs) != 2 {
+		t.Fatalf("wrong number of requests: have %d, want 2", len(reqs))
+	}
+	if reqs[0].Source[0] != 0xaa || reqs[0].Amount != 1000 || reqs[1].PublicKey[0] != 0xbb {
+		t.Fatalf("unexpected requests: %+v, %+v", reqs[0], reqs[1])
+	}
+	if _, err := UnpackWithdrawalRequests(data[1:]); err == nil {
+		t.Fatal("expected error for misaligned request data")
+	}
+}
+
+func TestRequestEncoding(t *testing.T) {
+	reqs := Requests{
+		NewRequest(&Deposit{Amount: 1, Index: 2}),
+		NewRequest(&WithdrawalRequest{Source: common.Address{0x01}, Amount: 3}),
+		NewRequest(&ConsolidationRequest{Source: common.Address{0x02}}),
+	}
+	// RLP roundtrip through the block body.
+	var (
+		blobGas    uint64
+		beaconRoot common.Hash
+		header     = &Header{
+			Difficulty:       big.NewInt(0),
+			Number:           big.NewInt(1),
+			BaseFee:          big.NewInt(1),
+			BlobGasUsed:      &blobGas,
+			ExcessBlobGas:    &blobGas,
+			ParentBeaconRoot: &beaconRoot,
+		}
+	)
+	block := NewBlock(header, &Body{Withdrawals: []*Withdrawal{}, Requests: reqs}, nil, blocktest.NewHasher())
+	if want := DeriveSha(reqs, blocktest.NewHasher()); *block.Header().RequestsHash != want {
+		t.Fatalf("requests hash mismatch: have %x, want %x", *block.Header().RequestsHash, want)
+	}
+	enc, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		t.Fatalf("failed to encode block: %v", err)
+	}
+	var dec Block
+	if err := rlp.DecodeBytes(enc, &dec); err != nil {
+		t.Fatalf("failed to decode block: %v", err)
+	}
+	if dec.Hash() != block.Hash() {
+		t.Fatalf("block hash mismatch: have %x, want %x", dec.Hash(), block.Hash())
+	}
+	if !reflect.DeepEqual(dec.Requests(), block.Requests()) {
+		t.Fatalf("requests mismatch after decoding")
+	}
+	if len(dec.Requests().Deposits()) != 1 || len(dec.Requests().WithdrawalRequests()) != 1 || len(dec.Requests().ConsolidationRequests()) != 1 {
+		t.Fatalf("wrong request split: %v", dec.Requests())
+	}
+	// JSON roundtrip.
+	blob, err := json.Marshal(reqs)
+	if err != nil {
+		t.Fatalf("failed to marshal requests: %v", err)
+	}
+	var decoded Requests
+	if err := json.Unmarshal(blob, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal requests: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, reqs) {
+		t.Fatalf("requests mismatch after JSON roundtrip")
+	}
+	// Empty requests are committed to with the empty hash.
+	block = NewBlock(header, &Body{Requests: []*Request{}}, nil, blocktest.NewHasher())
+	if *block.Header().RequestsHash != EmptyRequestsHash {
+		t.Fatalf("wrong empty requests hash: %x", *block.Header().RequestsHash)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+//go:generate go run github.com/fjl/gencodec -type WithdrawalRequest -field-override withdrawalRequestMarshaling -out gen_withdrawal_request_json.go
+
+// WithdrawalRequest represents an EIP-7002 withdrawal request from the
+// execution layer.
+type WithdrawalRequest struct {
+	Source    common.Address `json:"sourceAddress"`   // address that initiated the request
+	PublicKey [48]byte       `json:"validatorPubkey"` // public key of the exiting validator
+	Amount    uint64         `json:"amount"`          // amount to withdraw in Gwei, zero for a full exit
+}
+
+// field type overrides for gencodec
+type withdrawalRequestMarshaling struct {
+	PublicKey hexutil.Bytes
+	Amount    hexutil.Uint64
+}
+
+// WithdrawalRequests implements DerivableList for withdrawal requests.
+type WithdrawalRequests []*WithdrawalRequest
+
+// Len returns the length of s.
+func (s WithdrawalRequests) Len() int { return len(s) }
+
+// EncodeIndex encodes the i'th withdrawal request to w.
+func (s WithdrawalRequests) EncodeIndex(i int, w *bytes.Buffer) {
+	rlp.Encode(w, s[i])
+}
+
+// withdrawalRequestSize is the size of a withdrawal request as returned by
+// the EIP-7002 system contract.
+const withdrawalRequestSize = 20 + 48 + 8
+
+// UnpackWithdrawalRequests splits the output of the EIP-7002 system contract
+// into the individual withdrawal requests.
+func UnpackWithdrawalRequests(data []byte) (WithdrawalRequests, error) {
+	if len(data)%withdrawalRequestSize != 0 {
+		return nil, fmt.Errorf("withdrawal requests wrong length: %d not a multiple of %d", len(data), withdrawalRequestSize)
+	}
+	reqs := make(WithdrawalRequests, 0, len(data)/withdrawalRequestSize)
+	for ; len(data) > 0; data = data[withdrawalRequestSize:] {
+		r := new(WithdrawalRequest)
+		copy(r.Source[:], data[0:20])
+		copy(r.PublicKey[:], data[20:68])
+		r.Amount = binary.BigEndian.Uint64(data[68:76])
+		reqs = append(reqs, r)
+	}
+	return reqs, nil
+}
+
+func (w *WithdrawalRequest) requestType() byte            { return WithdrawalRequestType }
+func (w *WithdrawalRequest) encode(b *bytes.Buffer) error { return rlp.Encode(b, w) }
+func (w *WithdrawalRequest) decode(input []byte) error    { return rlp.DecodeBytes(input, w) }
+func (w *WithdrawalRequest) copy() RequestData {
+	cpy := *w
+	return &cpy
+}
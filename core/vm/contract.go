@@ -57,6 +57,12 @@ type Contract struct {
 	CodeAddr *common.Address
 	Input    []byte
 
+	// Container is the parsed EOF container of the code, nil for legacy code.
+	// During execution Code holds the currently executed code section.
+	Container   *Container
+	codeSection uint64           // Currently executed EOF code section
+	returnStack []*returnContext // EOF return stack, pushed by CALLF
+
 	// is the execution frame represented by this object a contract deployment
 	IsDeployment bool
 
@@ -64,6 +70,12 @@ type Contract struct {
 	value *uint256.Int
 }
 
+// returnContext is the location to resume EOF execution at after a RETF.
+type returnContext struct {
+	section uint64
+	pc      uint64
+}
+
 // NewContract returns a new contract environment for the execution of EVM.
 func NewContract(caller ContractRef, object ContractRef, value *uint256.Int, gas uint64) *Contract {
 	c := &Contract{CallerAddress: caller.Address(), caller: caller, self: object}
@@ -207,4 +219,5 @@ func (c *Contract) SetCodeOptionalHash(addr *common.Address, codeAndHash *codeAn
 	c.Code = codeAndHash.code
 	c.CodeHash = codeAndHash.hash
 	c.CodeAddr = addr
+	c.Container = codeAndHash.container
 }
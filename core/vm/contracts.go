@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"slices"
 
 	"github.com/consensys/gnark-crypto/ecc"
 	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
@@ -169,8 +170,22 @@ func init() {
 	}
 }
 
-// ActivePrecompiles returns the precompiles enabled with the current configuration.
+// ActivePrecompiles returns the precompiles enabled with the current configuration,
+// including the custom ones activated by the chain config.
 func ActivePrecompiles(rules params.Rules) []common.Address {
+	if len(rules.Precompiles) > 0 {
+		addresses := slices.Clone(activeNativePrecompiles(rules))
+		for _, precompile := range rules.Precompiles {
+			addresses = append(addresses, precompile.Address)
+		}
+		return addresses
+	}
+	return activeNativePrecompiles(rules)
+}
+
+// activeNativePrecompiles returns the native precompiled contracts enabled
+// with the current configuration.
+func activeNativePrecompiles(rules params.Rules) []common.Address {
 	switch {
 	case rules.IsPrague:
 		return PrecompiledAddressesPrague
@@ -193,16 +208,7 @@ func ActivePrecompiles(rules params.Rules) []common.Address {
 // - the _remaining_ gas,
 // - any error that occurred
 func RunPrecompiledContract(p PrecompiledContract, input []byte, suppliedGas uint64, logger *tracing.Hooks) (ret []byte, remainingGas uint64, err error) {
-	gasCost := p.RequiredGas(input)
-	if suppliedGas < gasCost {
-		return nil, 0, ErrOutOfGas
-	}
-	if logger != nil && logger.OnGasChange != nil {
-		logger.OnGasChange(suppliedGas, suppliedGas-gasCost, tracing.GasChangeCallPrecompiledContract)
-	}
-	suppliedGas -= gasCost
-	output, err := p.Run(input)
-	return output, suppliedGas, err
+	return runPrecompiledContract(p, nil, input, suppliedGas, logger)
 }
 
 // ecrecover implemented as a native contract.
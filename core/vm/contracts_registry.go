@@ -0,0 +1,119 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// PrecompileFactory creates a custom precompiled contract from the configuration
+// given for it in the chain config.
+type PrecompileFactory func(config json.RawMessage) (PrecompiledContract, error)
+
+var (
+	registryLock sync.RWMutex
+	registry     = make(map[string]PrecompileFactory)
+
+	// instances caches the contracts created by the factories, keyed by the
+	// precompile name, address and configuration.
+	instances sync.Map
+)
+
+// RegisterPrecompile makes a custom precompiled contract implementation available
+// under the given name, to be activated by the chain config. It's meant to be
+// called from the init function of the package implementing the contract and
+// panics if the name is registered twice.
+func RegisterPrecompile(name string, factory PrecompileFactory) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if factory == nil {
+		panic("vm: RegisterPrecompile factory is nil")
+	}
+	if _, dup := registry[name]; dup {
+		panic("vm: RegisterPrecompile called twice for " + name)
+	}
+	registry[name] = factory
+}
+
+// ValidatePrecompiles checks that all custom precompiles configured in the chain
+// config are registered, can be created and don't shadow a native precompile.
+func ValidatePrecompiles(config *params.ChainConfig) error {
+	for _, transition := range config.Transitions {
+		for _, precompile := range transition.Precompiles {
+			if precompile.Disabled {
+				continue
+			}
+			if _, ok := PrecompiledContractsVerkle[precompile.Address]; ok {
+				return fmt.Errorf("precompile %q at %v shadows a native precompile", precompile.Name, precompile.Address)
+			}
+			if _, ok := PrecompiledContractsPrague[precompile.Address]; ok {
+				return fmt.Errorf("precompile %q at %v shadows a native precompile", precompile.Name, precompile.Address)
+			}
+			if _, err := newCustomPrecompile(precompile); err != nil {
+				return fmt.Errorf("precompile %q at %v: %w", precompile.Name, precompile.Address, err)
+			}
+		}
+	}
+	return nil
+}
+
+// newCustomPrecompile returns the contract instance for the given precompile
+// configuration, creating it on first use.
+func newCustomPrecompile(config params.PrecompileConfig) (PrecompiledContract, error) {
+	key := config.Name + "/" + config.Address.Hex() + "/" + string(config.Config)
+	if p, ok := instances.Load(key); ok {
+		return p.(PrecompiledContract), nil
+	}
+	registryLock.RLock()
+	factory, ok := registry[config.Name]
+	registryLock.RUnlock()
+	if !ok {
+		return nil, errors.New("precompile not registered")
+	}
+	p, err := factory(config.Config)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := instances.LoadOrStore(key, p)
+	return actual.(PrecompiledContract), nil
+}
+
+// customPrecompiles creates the custom precompiled contracts active under the
+// given rules, or nil if there are none.
+func customPrecompiles(rules params.Rules) map[common.Address]PrecompiledContract {
+	if len(rules.Precompiles) == 0 {
+		return nil
+	}
+	precompiles := make(map[common.Address]PrecompiledContract, len(rules.Precompiles))
+	for _, config := range rules.Precompiles {
+		p, err := newCustomPrecompile(config)
+		if err != nil {
+			log.Error("Failed to create custom precompile", "name", config.Name, "address", config.Address, "err", err)
+			continue
+		}
+		precompiles[config.Address] = p
+	}
+	return precompiles
+}
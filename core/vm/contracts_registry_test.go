@@ -0,0 +1,164 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// prefixPrecompile is a test precompile prepending a configured prefix to its
+// input.
+type prefixPrecompile struct {
+	prefix []byte
+}
+
+func (p *prefixPrecompile) RequiredGas(input []byte) uint64 { return 100 }
+func (p *prefixPrecompile) Run(input []byte) ([]byte, error) {
+	return append(common.CopyBytes(p.prefix), input...), nil
+}
+
+func init() {
+	RegisterPrecompile("test-prefix", func(config json.RawMessage) (PrecompiledContract, error) {
+		var cfg struct {
+			Prefix string `json:"prefix"`
+		}
+		if err := json.Unmarshal(config, &cfg); err != nil {
+			return nil, err
+		}
+		if cfg.Prefix == "" {
+			return nil, errors.New("missing prefix")
+		}
+		return &prefixPrecompile{prefix: []byte(cfg.Prefix)}, nil
+	})
+}
+
+func TestCustomPrecompiles(t *testing.T) {
+	var (
+		addr   = common.HexToAddress("0x0000000000000000000000000000000000001000")
+		caller = common.HexToAddress("0x000000000000000000000000000000000000cafe")
+		config = *params.TestChainConfig
+	)
+	config.Transitions = []params.Transition{
+		{Block: big.NewInt(5), Precompiles: []params.PrecompileConfig{
+			{Name: "test-prefix", Address: addr, Config: json.RawMessage(`{"prefix":"hello "}`)},
+		}},
+		{Block: big.NewInt(10), Precompiles: []params.PrecompileConfig{
+			{Name: "test-prefix", Address: addr, Disabled: true},
+		}},
+	}
+	if err := ValidatePrecompiles(&config); err != nil {
+		t.Fatalf("failed to validate precompiles: %v", err)
+	}
+	for _, tt := range []struct {
+		number uint64
+		active bool
+	}{
+		{4, false},
+		{5, true},
+		{9, true},
+		{10, false},
+	} {
+		statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+
+		var entered []common.Address
+		vmctx := BlockContext{
+			CanTransfer: func(StateDB, common.Address, *uint256.Int) bool { return true },
+			Transfer:    func(StateDB, common.Address, common.Address, *uint256.Int) {},
+			BlockNumber: new(big.Int).SetUint64(tt.number),
+		}
+		hooks := &tracing.Hooks{
+			OnEnter: func(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+				entered = append(entered, to)
+			},
+		}
+		evm := NewEVM(vmctx, TxContext{}, statedb, &config, Config{Tracer: hooks})
+
+		rules := config.Rules(vmctx.BlockNumber, false, 0)
+		if have := slices.Contains(ActivePrecompiles(rules), addr); have != tt.active {
+			t.Errorf("block %d: precompile listed as active %v, want %v", tt.number, have, tt.active)
+		}
+		ret, leftover, err := evm.Call(AccountRef(caller), addr, []byte("world"), 1000, new(uint256.Int))
+		if err != nil {
+			t.Fatalf("block %d: call failed: %v", tt.number, err)
+		}
+		if tt.active {
+			if !bytes.Equal(ret, []byte("hello world")) {
+				t.Errorf("block %d: output mismatch: have %q, want %q", tt.number, ret, "hello world")
+			}
+			if leftover != 900 {
+				t.Errorf("block %d: leftover gas mismatch: have %d, want %d", tt.number, leftover, 900)
+			}
+		} else if len(ret) != 0 {
+			t.Errorf("block %d: unexpected output from inactive precompile: %q", tt.number, ret)
+		}
+		if len(entered) != 1 || entered[0] != addr {
+			t.Errorf("block %d: tracer not entered correctly: %v", tt.number, entered)
+		}
+	}
+}
+
+func TestCustomPrecompileTimestamp(t *testing.T) {
+	var (
+		addr   = common.HexToAddress("0x0000000000000000000000000000000000001000")
+		time   = uint64(100)
+		config = *params.TestChainConfig
+	)
+	config.Transitions = []params.Transition{
+		{Block: big.NewInt(0), Precompiles: []params.PrecompileConfig{
+			{Name: "test-prefix", Address: addr, Timestamp: &time, Config: json.RawMessage(`{"prefix":"a"}`)},
+		}},
+	}
+	if rules := config.Rules(big.NewInt(1), false, time-1); slices.Contains(ActivePrecompiles(rules), addr) {
+		t.Errorf("precompile active before its timestamp")
+	}
+	if rules := config.Rules(big.NewInt(1), false, time); !slices.Contains(ActivePrecompiles(rules), addr) {
+		t.Errorf("precompile inactive at its timestamp")
+	}
+}
+
+func TestValidatePrecompiles(t *testing.T) {
+	tests := []struct {
+		precompile params.PrecompileConfig
+		fail       bool
+	}{
+		{params.PrecompileConfig{Name: "test-prefix", Address: common.HexToAddress("0x1000"), Config: json.RawMessage(`{"prefix":"a"}`)}, false},
+		{params.PrecompileConfig{Name: "unknown", Address: common.HexToAddress("0x1000")}, true},
+		{params.PrecompileConfig{Name: "test-prefix", Address: common.HexToAddress("0x1000"), Config: json.RawMessage(`{}`)}, true},
+		{params.PrecompileConfig{Name: "test-prefix", Address: common.BytesToAddress([]byte{0x1}), Config: json.RawMessage(`{"prefix":"a"}`)}, true},
+		{params.PrecompileConfig{Name: "unknown", Address: common.HexToAddress("0x1000"), Disabled: true}, false},
+	}
+	for i, tt := range tests {
+		config := *params.TestChainConfig
+		config.Transitions = []params.Transition{{Block: big.NewInt(0), Precompiles: []params.PrecompileConfig{tt.precompile}}}
+		if err := ValidatePrecompiles(&config); (err != nil) != tt.fail {
+			t.Errorf("test %d: error mismatch: have %v, want failure %v", i, err, tt.fail)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// StatefulPrecompiledContract is a native Go contract which, on top of its input,
+// has access to the state and the context of the call. The state modifications
+// go through the journal of the StateDB, so they are reverted along with the
+// call frame on failure and are reported to the tracer as any other.
+type StatefulPrecompiledContract interface {
+	PrecompiledContract
+
+	// RunStateful runs the precompiled contract in the given environment. It is
+	// invoked instead of Run when the contract is called from the EVM.
+	RunStateful(env *PrecompileEnvironment, input []byte) ([]byte, error)
+}
+
+// PrecompileEnvironment is the context a stateful precompiled contract is
+// executed in.
+type PrecompileEnvironment struct {
+	StateDB  StateDB        // State the contract may read and, unless read-only, modify
+	Caller   common.Address // Address of the caller of the contract
+	Address  common.Address // Address of the account the call is executed in the context of
+	Value    *uint256.Int   // Value transferred along the call
+	ReadOnly bool           // Whether state modifications are disallowed (static call)
+
+	evm *EVM
+}
+
+// BlockContext returns the context of the block the contract is executed in.
+func (env *PrecompileEnvironment) BlockContext() BlockContext {
+	return env.evm.Context
+}
+
+// Rules returns the chain rules the contract is executed under.
+func (env *PrecompileEnvironment) Rules() params.Rules {
+	return env.evm.chainRules
+}
+
+// SetState sets a storage slot of the account the call is executed in the
+// context of, failing if the call is read-only.
+//
+// A precompile has no code, so its account is empty until something is stored
+// in it and would be deleted along with the storage at the end of the transaction
+// (EIP-161). Like the system contracts of EIP-4788 and EIP-2935, the account is
+// given a nonce of 1 before it's first written to.
+func (env *PrecompileEnvironment) SetState(key, value common.Hash) error {
+	if env.ReadOnly {
+		return ErrWriteProtection
+	}
+	if env.StateDB.Empty(env.Address) {
+		env.StateDB.SetNonce(env.Address, 1)
+	}
+	env.StateDB.SetState(env.Address, key, value)
+	return nil
+}
+
+// GetState retrieves a storage slot of the account the call is executed in the
+// context of.
+func (env *PrecompileEnvironment) GetState(key common.Hash) common.Hash {
+	return env.StateDB.GetState(env.Address, key)
+}
+
+// AddLog emits a log from the account the call is executed in the context of,
+// failing if the call is read-only.
+func (env *PrecompileEnvironment) AddLog(topics []common.Hash, data []byte) error {
+	if env.ReadOnly {
+		return ErrWriteProtection
+	}
+	env.StateDB.AddLog(&types.Log{
+		Address: env.Address,
+		Topics:  topics,
+		Data:    data,
+		// This is a non-consensus field, but assigned here because
+		// core/state doesn't know the current block number.
+		BlockNumber: env.evm.Context.BlockNumber.Uint64(),
+	})
+	return nil
+}
+
+// runPrecompiledContract runs a precompiled contract, passing the environment to
+// it if it is stateful.
+func runPrecompiledContract(p PrecompiledContract, env *PrecompileEnvironment, input []byte, suppliedGas uint64, logger *tracing.Hooks) (ret []byte, remainingGas uint64, err error) {
+	gasCost := p.RequiredGas(input)
+	if suppliedGas < gasCost {
+		return nil, 0, ErrOutOfGas
+	}
+	if logger != nil && logger.OnGasChange != nil {
+		logger.OnGasChange(suppliedGas, suppliedGas-gasCost, tracing.GasChangeCallPrecompiledContract)
+	}
+	suppliedGas -= gasCost
+
+	var output []byte
+	if stateful, ok := p.(StatefulPrecompiledContract); ok && env != nil {
+		output, err = stateful.RunStateful(env, input)
+	} else {
+		output, err = p.Run(input)
+	}
+	return output, suppliedGas, err
+}
@@ -0,0 +1,144 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+var (
+	counterSlot  = common.Hash{}
+	counterTopic = common.Hash{0xc0}
+	errCounter   = errors.New("counter failure")
+)
+
+// counterPrecompile is a test stateful precompile counting its invocations.
+// An input of 0xff makes it fail after modifying the state.
+type counterPrecompile struct{}
+
+func (c *counterPrecompile) RequiredGas(input []byte) uint64 { return 100 }
+func (c *counterPrecompile) Run(input []byte) ([]byte, error) {
+	return nil, errors.New("stateless invocation")
+}
+
+func (c *counterPrecompile) RunStateful(env *PrecompileEnvironment, input []byte) ([]byte, error) {
+	count := new(big.Int).SetBytes(env.GetState(counterSlot).Bytes())
+	count.Add(count, common.Big1)
+	if err := env.SetState(counterSlot, common.BigToHash(count)); err != nil {
+		return nil, err
+	}
+	if err := env.AddLog([]common.Hash{counterTopic}, env.Caller.Bytes()); err != nil {
+		return nil, err
+	}
+	if len(input) > 0 && input[0] == 0xff {
+		return nil, errCounter
+	}
+	return common.BigToHash(count).Bytes(), nil
+}
+
+func init() {
+	RegisterPrecompile("test-counter", func(config json.RawMessage) (PrecompiledContract, error) {
+		return new(counterPrecompile), nil
+	})
+}
+
+func TestStatefulPrecompile(t *testing.T) {
+	var (
+		addr   = common.HexToAddress("0x0000000000000000000000000000000000002000")
+		caller = common.HexToAddress("0x000000000000000000000000000000000000cafe")
+		config = *params.TestChainConfig
+	)
+	config.Transitions = []params.Transition{
+		{Block: big.NewInt(0), Precompiles: []params.PrecompileConfig{{Name: "test-counter", Address: addr}}},
+	}
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+
+	var (
+		storageChanges int
+		logs           []*types.Log
+	)
+	hooks := &tracing.Hooks{
+		OnStorageChange: func(a common.Address, slot common.Hash, prev, new common.Hash) {
+			if a == addr && slot == counterSlot {
+				storageChanges++
+			}
+		},
+		OnLog: func(log *types.Log) {
+			logs = append(logs, log)
+		},
+	}
+	statedb.SetLogger(hooks)
+
+	vmctx := BlockContext{
+		CanTransfer: func(StateDB, common.Address, *uint256.Int) bool { return true },
+		Transfer:    func(StateDB, common.Address, common.Address, *uint256.Int) {},
+		BlockNumber: big.NewInt(1),
+	}
+	evm := NewEVM(vmctx, TxContext{}, statedb, &config, Config{Tracer: hooks})
+
+	// A successful call modifies the state and emits a log
+	ret, _, err := evm.Call(AccountRef(caller), addr, nil, 1000, new(uint256.Int))
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if have := common.BytesToHash(ret); have != common.BigToHash(common.Big1) {
+		t.Fatalf("unexpected output: %x", ret)
+	}
+	if len(logs) != 1 || logs[0].Address != addr || common.BytesToAddress(logs[0].Data) != caller {
+		t.Fatalf("unexpected logs: %v", logs)
+	}
+	if storageChanges != 1 {
+		t.Fatalf("storage change not reported: %d", storageChanges)
+	}
+	// A failing call has its modifications reverted
+	if _, _, err := evm.Call(AccountRef(caller), addr, []byte{0xff}, 1000, new(uint256.Int)); !errors.Is(err, errCounter) {
+		t.Fatalf("unexpected error: have %v, want %v", err, errCounter)
+	}
+	if have := statedb.GetState(addr, counterSlot); have != common.BigToHash(common.Big1) {
+		t.Fatalf("state not reverted: %v", have)
+	}
+	if have := len(statedb.Logs()); have != 1 {
+		t.Fatalf("logs not reverted: have %d logs", have)
+	}
+	// A static call is not allowed to modify the state
+	if _, _, err := evm.StaticCall(AccountRef(caller), addr, nil, 1000); !errors.Is(err, ErrWriteProtection) {
+		t.Fatalf("unexpected error: have %v, want %v", err, ErrWriteProtection)
+	}
+	if have := statedb.GetState(addr, counterSlot); have != common.BigToHash(common.Big1) {
+		t.Fatalf("state modified by static call: %v", have)
+	}
+	// The storage must survive the end of the transaction, when the empty
+	// touched accounts are deleted
+	statedb.Finalise(true)
+	if have := statedb.GetState(addr, counterSlot); have != common.BigToHash(common.Big1) {
+		t.Fatalf("state deleted at the end of the transaction: %v", have)
+	}
+	if have := statedb.GetNonce(addr); have != 1 {
+		t.Fatalf("precompile account nonce mismatch: have %d, want 1", have)
+	}
+}
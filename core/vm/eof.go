@@ -0,0 +1,499 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	kindTypes     = 1
+	kindCode      = 2
+	kindContainer = 3
+	kindData      = 4
+
+	eofFormatByte = 0xef
+	eof1Version   = 1
+
+	maxInputItems        = 127
+	maxOutputItems       = 127
+	maxStackHeight       = 1023
+	maxCodeSections      = 1024
+	maxContainerSections = 256
+	nonReturningFunction = 0x80
+
+	// returnStackLimit is the maximum depth of CALLF calls within a frame.
+	returnStackLimit = 1024
+)
+
+var eofMagic = []byte{eofFormatByte, 0x00}
+
+// hasEOFMagic returns true if code starts with the EOF magic prefix 0xEF00.
+func hasEOFMagic(code []byte) bool {
+	return len(eofMagic) <= len(code) && bytes.Equal(eofMagic, code[0:len(eofMagic)])
+}
+
+// isEOFVersion1 returns true if the code's version byte equals eof1Version. It
+// does not verify the EOF magic is valid.
+func isEOFVersion1(code []byte) bool {
+	return 2 < len(code) && code[2] == byte(eof1Version)
+}
+
+// Container is an EOF container object.
+type Container struct {
+	types             []*functionMetadata
+	codeSections      [][]byte
+	subContainers     []*Container
+	subContainerCodes [][]byte
+	data              []byte
+	dataSize          int // might be more than len(data) for truncated subcontainers
+}
+
+// functionMetadata is an EOF function signature.
+type functionMetadata struct {
+	inputs         uint8
+	outputs        uint8
+	maxStackHeight uint16
+}
+
+// stackDelta returns the #outputs - #inputs
+func (meta *functionMetadata) stackDelta() int {
+	return int(meta.outputs) - int(meta.inputs)
+}
+
+// checkInputs checks the current minimum stack (stackMin) against the required inputs
+// of the metadata, and returns an error if the stack is too shallow.
+func (meta *functionMetadata) checkInputs(stackMin int) error {
+	if int(meta.inputs) > stackMin {
+		return &ErrStackUnderflow{stackLen: stackMin, required: int(meta.inputs)}
+	}
+	return nil
+}
+
+// checkStackMax checks the if current maximum stack combined with the
+// function max stack will result in a stack overflow, and if so returns an error.
+func (meta *functionMetadata) checkStackMax(stackMax int) error {
+	newMaxStack := stackMax + int(meta.maxStackHeight) - int(meta.inputs)
+	if newMaxStack > int(params.StackLimit) {
+		return &ErrStackOverflow{stackLen: newMaxStack, limit: int(params.StackLimit)}
+	}
+	return nil
+}
+
+// CodeSections returns the code sections of the container.
+func (c *Container) CodeSections() [][]byte {
+	return c.codeSections
+}
+
+// SubContainers returns the subcontainers embedded in the container.
+func (c *Container) SubContainers() []*Container {
+	return c.subContainers
+}
+
+// MarshalBinary encodes an EOF container into binary format.
+func (c *Container) MarshalBinary() []byte {
+	// Build EOF prefix.
+	b := make([]byte, 2)
+	copy(b, eofMagic)
+	b = append(b, eof1Version)
+
+	// Write section headers.
+	b = append(b, kindTypes)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(c.types)*4))
+	b = append(b, kindCode)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(c.codeSections)))
+	for _, codeSection := range c.codeSections {
+		b = binary.BigEndian.AppendUint16(b, uint16(len(codeSection)))
+	}
+	var encodedContainer [][]byte
+	if len(c.subContainers) != 0 {
+		b = append(b, kindContainer)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(c.subContainers)))
+		for _, section := range c.subContainers {
+			encoded := section.MarshalBinary()
+			b = binary.BigEndian.AppendUint16(b, uint16(len(encoded)))
+			encodedContainer = append(encodedContainer, encoded)
+		}
+	}
+	b = append(b, kindData)
+	b = binary.BigEndian.AppendUint16(b, uint16(c.dataSize))
+	b = append(b, 0) // terminator
+
+	// Write section contents.
+	for _, ty := range c.types {
+		b = append(b, []byte{ty.inputs, ty.outputs, byte(ty.maxStackHeight >> 8), byte(ty.maxStackHeight & 0x00ff)}...)
+	}
+	for _, code := range c.codeSections {
+		b = append(b, code...)
+	}
+	for _, section := range encodedContainer {
+		b = append(b, section...)
+	}
+	b = append(b, c.data...)
+
+	return b
+}
+
+// UnmarshalBinary decodes an EOF container. The container must span the whole
+// input, including a complete data section.
+func (c *Container) UnmarshalBinary(b []byte) error {
+	n, err := c.unmarshal(b, false)
+	if err != nil {
+		return err
+	}
+	if n != len(b) {
+		return fmt.Errorf("%w: have %d, want %d", errInvalidContainerSize, len(b), n)
+	}
+	return nil
+}
+
+// unmarshalInitcode decodes an EOF initcontainer from the beginning of a
+// creation transaction's data. The remaining bytes are returned as calldata
+// for the initcode (EIP-7698).
+func (c *Container) unmarshalInitcode(b []byte) ([]byte, error) {
+	n, err := c.unmarshal(b, false)
+	if err != nil {
+		return nil, err
+	}
+	return b[n:], nil
+}
+
+// unmarshal decodes an EOF container from the beginning of b and returns the
+// number of bytes consumed. If allowTruncated is set, the data section may be
+// shorter than declared in the header, which is permitted for subcontainers.
+func (c *Container) unmarshal(b []byte, allowTruncated bool) (int, error) {
+	if !hasEOFMagic(b) {
+		return 0, fmt.Errorf("%w: want %x", errInvalidMagic, eofMagic)
+	}
+	if len(b) < 14 {
+		return 0, errInvalidContainerSize
+	}
+	if !isEOFVersion1(b) {
+		return 0, fmt.Errorf("%w: have %d, want %d", errInvalidVersion, b[2], eof1Version)
+	}
+
+	var (
+		kind, typesSize, dataSize int
+		codeSizes                 []int
+		containerSizes            []int
+		err                       error
+	)
+
+	// Parse type section header.
+	kind, typesSize, err = parseSection(b, 3)
+	if err != nil {
+		return 0, err
+	}
+	if kind != kindTypes {
+		return 0, fmt.Errorf("%w: found section kind %x instead", errMissingTypeHeader, kind)
+	}
+	if typesSize < 4 || typesSize%4 != 0 {
+		return 0, fmt.Errorf("%w: type section size must be divisible by 4, have %d", errInvalidTypeSize, typesSize)
+	}
+	if typesSize/4 > maxCodeSections {
+		return 0, fmt.Errorf("%w: type section must not exceed %d entries, have %d", errInvalidTypeSize, maxCodeSections, typesSize/4)
+	}
+
+	// Parse code section header.
+	kind, codeSizes, err = parseSectionList(b, 6)
+	if err != nil {
+		return 0, err
+	}
+	if kind != kindCode {
+		return 0, fmt.Errorf("%w: found section kind %x instead", errMissingCodeHeader, kind)
+	}
+	if len(codeSizes) != typesSize/4 {
+		return 0, fmt.Errorf("%w: mismatch of code sections found and type signatures, types %d, code %d", errInvalidCodeSize, typesSize/4, len(codeSizes))
+	}
+
+	// Parse the optional container section header.
+	offset := 6 + 3 + 2*len(codeSizes)
+	if offset < len(b) && b[offset] == kindContainer {
+		_, containerSizes, err = parseSectionList(b, offset)
+		if err != nil {
+			return 0, err
+		}
+		if len(containerSizes) > maxContainerSections {
+			return 0, fmt.Errorf("%w: number of container sections exceeds %d: have %d", errInvalidContainerSectionSize, maxContainerSections, len(containerSizes))
+		}
+		offset += 3 + 2*len(containerSizes)
+	}
+
+	// Parse data section header.
+	kind, dataSize, err = parseSection(b, offset)
+	if err != nil {
+		return 0, err
+	}
+	if kind != kindData {
+		return 0, fmt.Errorf("%w: found section %x instead", errMissingDataHeader, kind)
+	}
+	c.dataSize = dataSize
+	offset += 3
+
+	// Check for terminator.
+	if offset >= len(b) {
+		return 0, fmt.Errorf("%w: invalid offset terminator", errInvalidContainerSize)
+	}
+	if b[offset] != 0 {
+		return 0, fmt.Errorf("%w: have %x", errMissingTerminator, b[offset])
+	}
+	offset++
+
+	// Verify the body is large enough to contain all but the data section.
+	expectedSize := offset + typesSize + sum(codeSizes) + sum(containerSizes)
+	if len(b) < expectedSize {
+		return 0, fmt.Errorf("%w: have %d, want %d", errInvalidContainerSize, len(b), expectedSize)
+	}
+
+	// Parse types section.
+	idx := offset
+	var types = make([]*functionMetadata, 0, typesSize/4)
+	for i := 0; i < typesSize/4; i++ {
+		sig := &functionMetadata{
+			inputs:         b[idx+i*4],
+			outputs:        b[idx+i*4+1],
+			maxStackHeight: binary.BigEndian.Uint16(b[idx+i*4+2:]),
+		}
+		if sig.inputs > maxInputItems {
+			return 0, fmt.Errorf("%w for section %d: have %d", errTooManyInputs, i, sig.inputs)
+		}
+		if sig.outputs > maxOutputItems && sig.outputs != nonReturningFunction {
+			return 0, fmt.Errorf("%w for section %d: have %d", errTooManyOutputs, i, sig.outputs)
+		}
+		if sig.maxStackHeight > maxStackHeight {
+			return 0, fmt.Errorf("%w for section %d: have %d", errTooLargeMaxStackHeight, i, sig.maxStackHeight)
+		}
+		types = append(types, sig)
+	}
+	if types[0].inputs != 0 || types[0].outputs != nonReturningFunction {
+		return 0, fmt.Errorf("%w: have %d, %d", errInvalidSection0Type, types[0].inputs, types[0].outputs)
+	}
+	c.types = types
+	idx += typesSize
+
+	// Parse code sections.
+	var codeSections = make([][]byte, 0, len(codeSizes))
+	for _, size := range codeSizes {
+		codeSections = append(codeSections, b[idx:idx+size])
+		idx += size
+	}
+	c.codeSections = codeSections
+
+	// Parse the optional container sections.
+	if len(containerSizes) != 0 {
+		subContainerCodes := make([][]byte, 0, len(containerSizes))
+		subContainers := make([]*Container, 0, len(containerSizes))
+		for i, size := range containerSizes {
+			code := b[idx : idx+size]
+			sub := new(Container)
+			n, err := sub.unmarshal(code, true)
+			if err != nil {
+				return 0, fmt.Errorf("subcontainer %d: %w", i, err)
+			}
+			if n != len(code) {
+				return 0, fmt.Errorf("%w: subcontainer %d has trailing bytes", errInvalidContainerSize, i)
+			}
+			subContainers = append(subContainers, sub)
+			subContainerCodes = append(subContainerCodes, code)
+			idx += size
+		}
+		c.subContainers = subContainers
+		c.subContainerCodes = subContainerCodes
+	}
+
+	// Parse data section. Only subcontainers deployed via RETURNCONTRACT may
+	// have a data section shorter than declared.
+	end := idx + dataSize
+	if len(b) < end {
+		if !allowTruncated {
+			return 0, fmt.Errorf("%w: have %d, want %d", errTruncatedTopLevelContainer, len(b), end)
+		}
+		end = len(b)
+	}
+	c.data = b[idx:end]
+
+	return end, nil
+}
+
+// ValidateCode validates each code section of the container against the EOF
+// v1 rule set, recursing into the subcontainers.
+func (c *Container) ValidateCode(jt *JumpTable, isInitCode bool) error {
+	refBy := refByReturnContract
+	if isInitCode {
+		refBy = refByEOFCreate
+	}
+	return c.validateSubContainer(jt, refBy)
+}
+
+func (c *Container) validateSubContainer(jt *JumpTable, refBy int) error {
+	var (
+		visited    = make(map[int]struct{})
+		subRefs    = make(map[int]int)
+		toVisit    = []int{0}
+		isInitCode = refBy == refByEOFCreate
+	)
+	for len(toVisit) > 0 {
+		index := toVisit[0]
+		toVisit = toVisit[1:]
+		if _, ok := visited[index]; ok {
+			continue
+		}
+		res, err := validateCode(c.codeSections[index], index, c, jt, isInitCode)
+		if err != nil {
+			return err
+		}
+		visited[index] = struct{}{}
+		for section := range res.visitedCode {
+			if _, ok := visited[section]; !ok {
+				toVisit = append(toVisit, section)
+			}
+		}
+		for idx, kind := range res.visitedSubContainers {
+			if prev, ok := subRefs[idx]; ok && prev != kind {
+				return fmt.Errorf("%w: subcontainer %d", errIncompatibleContainerKind, idx)
+			}
+			subRefs[idx] = kind
+		}
+	}
+	if len(visited) != len(c.codeSections) {
+		return fmt.Errorf("%w: reached %d of %d code sections", errUnreachableCode, len(visited), len(c.codeSections))
+	}
+	if len(subRefs) != len(c.subContainers) {
+		return fmt.Errorf("%w: referenced %d of %d subcontainers", errOrphanedSubcontainer, len(subRefs), len(c.subContainers))
+	}
+	for idx, sub := range c.subContainers {
+		kind := subRefs[idx]
+		if kind == refByEOFCreate && sub.dataSize != len(sub.data) {
+			return fmt.Errorf("%w: subcontainer %d", errEOFCreateWithTruncatedSection, idx)
+		}
+		if err := sub.validateSubContainer(jt, kind); err != nil {
+			return fmt.Errorf("subcontainer %d: %w", idx, err)
+		}
+	}
+	return nil
+}
+
+// parseSection decodes a (kind, size) pair from an EOF header.
+func parseSection(b []byte, idx int) (kind, size int, err error) {
+	if idx+3 > len(b) {
+		return 0, 0, errInvalidContainerSize
+	}
+	kind = int(b[idx])
+	size = int(binary.BigEndian.Uint16(b[idx+1 : idx+3]))
+	return kind, size, nil
+}
+
+// parseSectionList decodes a (kind, len, []codeSize) section list from an EOF
+// header.
+func parseSectionList(b []byte, idx int) (kind int, list []int, err error) {
+	if idx >= len(b) {
+		return 0, nil, errInvalidContainerSize
+	}
+	kind = int(b[idx])
+	list, err = parseList(b, idx+1)
+	if err != nil {
+		return 0, nil, err
+	}
+	return kind, list, nil
+}
+
+// parseList decodes a list of uint16.
+func parseList(b []byte, idx int) ([]int, error) {
+	if len(b) < idx+2 {
+		return nil, errInvalidContainerSize
+	}
+	count := binary.BigEndian.Uint16(b[idx:])
+	if count == 0 {
+		return nil, fmt.Errorf("%w: section list must not be empty", errInvalidSectionCount)
+	}
+	if len(b) < idx+2+int(count)*2 {
+		return nil, errInvalidContainerSize
+	}
+	list := make([]int, count)
+	for i := 0; i < int(count); i++ {
+		size := int(binary.BigEndian.Uint16(b[idx+2+2*i:]))
+		if size == 0 {
+			return nil, fmt.Errorf("%w: section %d is empty", errInvalidSectionSize, i)
+		}
+		list[i] = size
+	}
+	return list, nil
+}
+
+// parseUint16 parses a 16 bit unsigned integer.
+func parseUint16(b []byte) (int, error) {
+	if len(b) < 2 {
+		return 0, errTruncatedImmediate
+	}
+	return int(binary.BigEndian.Uint16(b)), nil
+}
+
+// parseInt16 parses a 16 bit signed integer.
+func parseInt16(b []byte) int {
+	return int(int16(b[1]) | int16(b[0])<<8)
+}
+
+// sum computes the sum of a slice.
+func sum(list []int) (s int) {
+	for _, n := range list {
+		s += n
+	}
+	return
+}
+
+// String returns a human readable representation of the container, listing
+// its header, function types, code sections and subcontainers.
+func (c *Container) String() string {
+	var output = []string{
+		"Header",
+		fmt.Sprintf("  - EOFMagic: %02x", eofMagic),
+		fmt.Sprintf("  - EOFVersion: %02x", eof1Version),
+		fmt.Sprintf("  - KindType: %02x", kindTypes),
+		fmt.Sprintf("  - TypesSize: %04x", len(c.types)*4),
+		fmt.Sprintf("  - KindCode: %02x", kindCode),
+		fmt.Sprintf("  - KindData: %02x", kindData),
+		fmt.Sprintf("  - DataSize: %04x", c.dataSize),
+		fmt.Sprintf("  - Number of code sections: %d", len(c.codeSections)),
+	}
+	for i, code := range c.codeSections {
+		output = append(output, fmt.Sprintf("    - Code section %d length: %04x", i, len(code)))
+	}
+	output = append(output, fmt.Sprintf("  - Number of subcontainers: %d", len(c.subContainers)))
+	if len(c.subContainers) > 0 {
+		for i, section := range c.subContainerCodes {
+			output = append(output, fmt.Sprintf("    - subcontainer %d length: %04x", i, len(section)))
+		}
+	}
+	output = append(output, "Body")
+	for i, typ := range c.types {
+		output = append(output, fmt.Sprintf("  - Type %v: %x", i,
+			[]byte{typ.inputs, typ.outputs, byte(typ.maxStackHeight >> 8), byte(typ.maxStackHeight & 0x00ff)}))
+	}
+	for i, code := range c.codeSections {
+		output = append(output, fmt.Sprintf("  - Code section %d: %#x", i, code))
+	}
+	for i, section := range c.subContainerCodes {
+		output = append(output, fmt.Sprintf("  - Subcontainer %d: %x", i, section))
+	}
+	output = append(output, fmt.Sprintf("  - Data: %#x", c.data))
+	return strings.Join(output, "\n")
+}
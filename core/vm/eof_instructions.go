@@ -0,0 +1,694 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+var (
+	errAddressHighBytes = errors.New("address has non-zero high bytes")
+	errInvalidAuxData   = errors.New("invalid aux data size")
+)
+
+// eofCodeHash is the code hash reported to legacy code introspecting an
+// EOF contract, keccak256(0xEF00).
+var eofCodeHash = crypto.Keccak256Hash(eofMagic)
+
+// enable3540 applies EIP-3540 changes to the legacy instruction set, hiding the
+// code of EOF contracts from EXTCODESIZE, EXTCODECOPY and EXTCODEHASH.
+func enable3540(jt *JumpTable) {
+	jt[EXTCODESIZE].execute = opExtCodeSizeEOF
+	jt[EXTCODECOPY].execute = opExtCodeCopyEOF
+	jt[EXTCODEHASH].execute = opExtCodeHashEOF
+}
+
+// enableEOF applies the EOF v1 changes (EIP-3540, 3670, 4200, 4750, 5450,
+// 6206, 7069, 7480, 7620, 7698) to an instruction set, turning it into the
+// one used by EOF code.
+func enableEOF(jt *JumpTable) {
+	// Deprecate instructions which are not part of EOF.
+	undefined := &operation{
+		execute:     opUndefined,
+		constantGas: 0,
+		minStack:    minStack(0, 0),
+		maxStack:    maxStack(0, 0),
+		undefined:   true,
+	}
+	for _, op := range []OpCode{
+		CALL, CALLCODE, DELEGATECALL, STATICCALL, SELFDESTRUCT, JUMP, JUMPI, PC,
+		CREATE, CREATE2, CODESIZE, CODECOPY, EXTCODESIZE, EXTCODECOPY, EXTCODEHASH, GAS,
+	} {
+		jt[op] = undefined
+	}
+	// INVALID is a designated instruction in EOF, terminating the section.
+	jt[INVALID] = &operation{
+		execute:     opUndefined,
+		constantGas: 0,
+		minStack:    minStack(0, 0),
+		maxStack:    maxStack(0, 0),
+	}
+	// New opcodes
+	jt[RJUMP] = &operation{
+		execute:     opRjump,
+		constantGas: GasQuickStep,
+		minStack:    minStack(0, 0),
+		maxStack:    maxStack(0, 0),
+	}
+	jt[RJUMPI] = &operation{
+		execute:     opRjumpi,
+		constantGas: GasFastishStep,
+		minStack:    minStack(1, 0),
+		maxStack:    maxStack(1, 0),
+	}
+	jt[RJUMPV] = &operation{
+		execute:     opRjumpv,
+		constantGas: GasFastishStep,
+		minStack:    minStack(1, 0),
+		maxStack:    maxStack(1, 0),
+	}
+	jt[CALLF] = &operation{
+		execute:     opCallf,
+		constantGas: GasFastStep,
+		minStack:    minStack(0, 0),
+		maxStack:    maxStack(0, 0),
+	}
+	jt[RETF] = &operation{
+		execute:     opRetf,
+		constantGas: GasFastestStep,
+		minStack:    minStack(0, 0),
+		maxStack:    maxStack(0, 0),
+	}
+	jt[JUMPF] = &operation{
+		execute:     opJumpf,
+		constantGas: GasFastStep,
+		minStack:    minStack(0, 0),
+		maxStack:    maxStack(0, 0),
+	}
+	jt[EOFCREATE] = &operation{
+		execute:     opEOFCreate,
+		constantGas: params.CreateGas,
+		dynamicGas:  gasEOFCreate,
+		minStack:    minStack(4, 1),
+		maxStack:    maxStack(4, 1),
+		memorySize:  memoryEOFCreate,
+	}
+	jt[RETURNCONTRACT] = &operation{
+		execute:     opReturnContract,
+		constantGas: 0,
+		dynamicGas:  pureMemoryGascost,
+		minStack:    minStack(2, 0),
+		maxStack:    maxStack(2, 0),
+		memorySize:  memoryReturnContract,
+	}
+	jt[DATALOAD] = &operation{
+		execute:     opDataLoad,
+		constantGas: GasFastishStep,
+		minStack:    minStack(1, 1),
+		maxStack:    maxStack(1, 1),
+	}
+	jt[DATALOADN] = &operation{
+		execute:     opDataLoadN,
+		constantGas: GasFastestStep,
+		minStack:    minStack(0, 1),
+		maxStack:    maxStack(0, 1),
+	}
+	jt[DATASIZE] = &operation{
+		execute:     opDataSize,
+		constantGas: GasQuickStep,
+		minStack:    minStack(0, 1),
+		maxStack:    maxStack(0, 1),
+	}
+	jt[DATACOPY] = &operation{
+		execute:     opDataCopy,
+		constantGas: GasFastestStep,
+		dynamicGas:  memoryCopierGas(2),
+		minStack:    minStack(3, 0),
+		maxStack:    maxStack(3, 0),
+		memorySize:  memoryDataCopy,
+	}
+	jt[DUPN] = &operation{
+		execute:     opDupN,
+		constantGas: GasFastestStep,
+		minStack:    minStack(0, 1),
+		maxStack:    maxStack(0, 1),
+	}
+	jt[SWAPN] = &operation{
+		execute:     opSwapN,
+		constantGas: GasFastestStep,
+		minStack:    minStack(0, 0),
+		maxStack:    maxStack(0, 0),
+	}
+	jt[EXCHANGE] = &operation{
+		execute:     opExchange,
+		constantGas: GasFastestStep,
+		minStack:    minStack(0, 0),
+		maxStack:    maxStack(0, 0),
+	}
+	jt[RETURNDATALOAD] = &operation{
+		execute:     opReturnDataLoad,
+		constantGas: GasFastestStep,
+		minStack:    minStack(1, 1),
+		maxStack:    maxStack(1, 1),
+	}
+	jt[EXTCALL] = &operation{
+		execute:     opExtCall,
+		constantGas: params.WarmStorageReadCostEIP2929,
+		dynamicGas:  gasExtCall,
+		minStack:    minStack(4, 1),
+		maxStack:    maxStack(4, 1),
+		memorySize:  memoryExtCall,
+	}
+	jt[EXTDELEGATECALL] = &operation{
+		execute:     opExtDelegateCall,
+		constantGas: params.WarmStorageReadCostEIP2929,
+		dynamicGas:  gasExtDelegateCall,
+		minStack:    minStack(3, 1),
+		maxStack:    maxStack(3, 1),
+		memorySize:  memoryExtCall,
+	}
+	jt[EXTSTATICCALL] = &operation{
+		execute:     opExtStaticCall,
+		constantGas: params.WarmStorageReadCostEIP2929,
+		dynamicGas:  gasExtStaticCall,
+		minStack:    minStack(3, 1),
+		maxStack:    maxStack(3, 1),
+		memorySize:  memoryExtCall,
+	}
+}
+
+// opRjump implements the RJUMP opcode.
+func opRjump(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		code   = scope.Contract.Code
+		offset = parseInt16(code[*pc+1:])
+	)
+	// move pc past op and operand (+3), add relative offset, subtract 1 to
+	// account for interpreter loop.
+	*pc = uint64(int64(*pc+3) + int64(offset) - 1)
+	return nil, nil
+}
+
+// opRjumpi implements the RJUMPI opcode
+func opRjumpi(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	condition := scope.Stack.pop()
+	if condition.BitLen() == 0 {
+		// Not branching, just skip over immediate argument.
+		*pc += 2
+		return nil, nil
+	}
+	return opRjump(pc, interpreter, scope)
+}
+
+// opRjumpv implements the RJUMPV opcode
+func opRjumpv(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		code  = scope.Contract.Code
+		count = uint64(code[*pc+1]) + 1
+		index = scope.Stack.pop()
+	)
+	idx, overflow := index.Uint64WithOverflow()
+	if overflow || idx >= count {
+		// Index out-of-bounds, don't branch, just skip over immediate
+		// argument.
+		*pc += 1 + count*2
+		return nil, nil
+	}
+	offset := parseInt16(code[*pc+2+2*idx:])
+	// move pc past op and count byte (2), move past count number of 16bit
+	// offsets (count*2), add relative offset, subtract 1 to account for
+	// interpreter loop.
+	*pc = uint64(int64(*pc+2+count*2) + int64(offset) - 1)
+	return nil, nil
+}
+
+// opCallf implements the CALLF opcode
+func opCallf(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		code = scope.Contract.Code
+		idx  = binary.BigEndian.Uint16(code[*pc+1:])
+		typ  = scope.Contract.Container.types[idx]
+	)
+	if scope.Stack.len()+int(typ.maxStackHeight)-int(typ.inputs) > int(params.StackLimit) {
+		return nil, &ErrStackOverflow{stackLen: scope.Stack.len() + int(typ.maxStackHeight) - int(typ.inputs), limit: int(params.StackLimit)}
+	}
+	if len(scope.Contract.returnStack) >= returnStackLimit {
+		return nil, ErrReturnStackExceeded
+	}
+	scope.Contract.returnStack = append(scope.Contract.returnStack, &returnContext{
+		section: scope.Contract.codeSection,
+		pc:      *pc + 3,
+	})
+	scope.Contract.codeSection = uint64(idx)
+	scope.Contract.Code = scope.Contract.Container.codeSections[idx]
+	*pc = ^uint64(0) // wraps to 0 once the interpreter increments it
+	return nil, nil
+}
+
+// opRetf implements the RETF opcode
+func opRetf(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	retCtx := scope.Contract.returnStack[len(scope.Contract.returnStack)-1]
+	scope.Contract.returnStack = scope.Contract.returnStack[:len(scope.Contract.returnStack)-1]
+	scope.Contract.codeSection = retCtx.section
+	scope.Contract.Code = scope.Contract.Container.codeSections[retCtx.section]
+	*pc = retCtx.pc - 1
+	return nil, nil
+}
+
+// opJumpf implements the JUMPF opcode
+func opJumpf(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		code = scope.Contract.Code
+		idx  = binary.BigEndian.Uint16(code[*pc+1:])
+		typ  = scope.Contract.Container.types[idx]
+	)
+	if scope.Stack.len()+int(typ.maxStackHeight)-int(typ.inputs) > int(params.StackLimit) {
+		return nil, &ErrStackOverflow{stackLen: scope.Stack.len() + int(typ.maxStackHeight) - int(typ.inputs), limit: int(params.StackLimit)}
+	}
+	scope.Contract.codeSection = uint64(idx)
+	scope.Contract.Code = scope.Contract.Container.codeSections[idx]
+	*pc = ^uint64(0) // wraps to 0 once the interpreter increments it
+	return nil, nil
+}
+
+// opEOFCreate implements the EOFCREATE opcode
+func opEOFCreate(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	if interpreter.readOnly {
+		return nil, ErrWriteProtection
+	}
+	var (
+		code         = scope.Contract.Code
+		idx          = code[*pc+1]
+		value        = scope.Stack.pop()
+		salt         = scope.Stack.pop()
+		offset, size = scope.Stack.pop(), scope.Stack.pop()
+		input        = scope.Memory.GetCopy(int64(offset.Uint64()), int64(size.Uint64()))
+		initcode     = scope.Contract.Container.subContainerCodes[idx]
+	)
+	// Charge the hashing of the initcontainer, used to derive the address.
+	hashingCharge := toWordSize(uint64(len(initcode))) * params.Keccak256WordGas
+	if !scope.Contract.UseGas(hashingCharge, interpreter.evm.Config.Tracer, tracing.GasChangeIgnored) {
+		return nil, ErrOutOfGas
+	}
+	// Apply EIP150
+	gas := scope.Contract.Gas
+	gas -= gas / 64
+	scope.Contract.UseGas(gas, interpreter.evm.Config.Tracer, tracing.GasChangeCallContractCreation2)
+
+	res, addr, returnGas, suberr := interpreter.evm.EOFCreate(scope.Contract, input, scope.Contract.Container.subContainers[idx], initcode, gas, &value, &salt)
+	if suberr != nil {
+		size.Clear()
+	} else {
+		size.SetBytes(addr.Bytes())
+	}
+	scope.Stack.push(&size)
+	scope.Contract.RefundGas(returnGas, interpreter.evm.Config.Tracer, tracing.GasChangeCallLeftOverRefunded)
+	*pc += 1 // move past immediate
+
+	if suberr == ErrExecutionReverted {
+		interpreter.returnData = res // set REVERT data to return data buffer
+		return nil, nil
+	}
+	interpreter.returnData = nil // clear dirty return data buffer
+	return nil, nil
+}
+
+// opReturnContract implements the RETURNCONTRACT opcode
+func opReturnContract(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		code         = scope.Contract.Code
+		idx          = code[*pc+1]
+		offset, size = scope.Stack.pop(), scope.Stack.pop()
+		sub          = scope.Contract.Container.subContainers[idx]
+		aux          = scope.Memory.GetPtr(int64(offset.Uint64()), int64(size.Uint64()))
+	)
+	// Append the aux data to the data section of the deployed container.
+	deploy := *sub
+	deploy.data = make([]byte, 0, len(sub.data)+len(aux))
+	deploy.data = append(deploy.data, sub.data...)
+	deploy.data = append(deploy.data, aux...)
+
+	if len(deploy.data) < sub.dataSize || len(deploy.data) > math.MaxUint16 {
+		return nil, errInvalidAuxData
+	}
+	deploy.dataSize = len(deploy.data)
+	return deploy.MarshalBinary(), errStopToken
+}
+
+// opDataLoad implements the DATALOAD opcode
+func opDataLoad(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		index = scope.Stack.peek()
+		data  = scope.Contract.Container.data
+	)
+	offset, overflow := index.Uint64WithOverflow()
+	if overflow {
+		offset = math.MaxUint64
+	}
+	index.SetBytes32(getData(data, offset, 32))
+	return nil, nil
+}
+
+// opDataLoadN implements the DATALOADN opcode
+func opDataLoadN(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		code   = scope.Contract.Code
+		offset = uint64(binary.BigEndian.Uint16(code[*pc+1:]))
+	)
+	val := new(uint256.Int).SetBytes32(getData(scope.Contract.Container.data, offset, 32))
+	scope.Stack.push(val)
+	*pc += 2 // move past 2 byte immediate
+	return nil, nil
+}
+
+// opDataSize implements the DATASIZE opcode
+func opDataSize(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	length := len(scope.Contract.Container.data)
+	item := uint256.NewInt(uint64(length))
+	scope.Stack.push(item)
+	return nil, nil
+}
+
+// opDataCopy implements the DATACOPY opcode
+func opDataCopy(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		memOffset = scope.Stack.pop()
+		offset    = scope.Stack.pop()
+		size      = scope.Stack.pop()
+	)
+	offset64, overflow := offset.Uint64WithOverflow()
+	if overflow {
+		offset64 = math.MaxUint64
+	}
+	// These values are checked for overflow during memory expansion.
+	memOffset64 := memOffset.Uint64()
+	size64 := size.Uint64()
+	scope.Memory.Set(memOffset64, size64, getData(scope.Contract.Container.data, offset64, size64))
+	return nil, nil
+}
+
+// opDupN implements the DUPN opcode
+func opDupN(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		code  = scope.Contract.Code
+		index = int(code[*pc+1]) + 1
+	)
+	scope.Stack.dup(index)
+	*pc += 1 // move past immediate
+	return nil, nil
+}
+
+// opSwapN implements the SWAPN opcode
+func opSwapN(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		code  = scope.Contract.Code
+		index = int(code[*pc+1]) + 2
+	)
+	scope.Stack.swap(index)
+	*pc += 1 // move past immediate
+	return nil, nil
+}
+
+// opExchange implements the EXCHANGE opcode
+func opExchange(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		code  = scope.Contract.Code
+		index = int(code[*pc+1])
+		n     = (index >> 4) + 1
+		m     = (index & 0x0F) + 1
+	)
+	a, b := scope.Stack.Back(n), scope.Stack.Back(n+m)
+	*a, *b = *b, *a
+	*pc += 1 // move past immediate
+	return nil, nil
+}
+
+// opReturnDataLoad implements the RETURNDATALOAD opcode
+func opReturnDataLoad(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		index = scope.Stack.peek()
+	)
+	offset, overflow := index.Uint64WithOverflow()
+	if overflow {
+		offset = math.MaxUint64
+	}
+	index.SetBytes32(getData(interpreter.returnData, offset, 32))
+	return nil, nil
+}
+
+// extCallResult converts the outcome of an EXT*CALL into the status code
+// pushed onto the stack: 0 on success, 1 on revert or light failure and 2 on
+// any other failure.
+func extCallResult(err error) uint64 {
+	switch err {
+	case nil:
+		return 0
+	case ErrExecutionReverted, ErrDepth, ErrInsufficientBalance:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// opExtCall implements the EXTCALL opcode
+func opExtCall(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	stack := scope.Stack
+	// Use all available gas
+	gas := interpreter.evm.callGasTemp
+	// Pop other call parameters.
+	addr, inOffset, inSize, value := stack.pop(), stack.pop(), stack.pop(), stack.pop()
+	toAddr := common.Address(addr.Bytes20())
+	// Get the arguments from the memory.
+	args := scope.Memory.GetPtr(int64(inOffset.Uint64()), int64(inSize.Uint64()))
+
+	if interpreter.readOnly && !value.IsZero() {
+		return nil, ErrWriteProtection
+	}
+	if gas < params.MinCalleeGas {
+		// Light failure, the callee would not have enough gas to do anything.
+		scope.Contract.RefundGas(gas, interpreter.evm.Config.Tracer, tracing.GasChangeCallLeftOverRefunded)
+		interpreter.returnData = nil
+		stack.push(uint256.NewInt(1))
+		return nil, nil
+	}
+	ret, returnGas, err := interpreter.evm.Call(scope.Contract, toAddr, args, gas, &value)
+	stack.push(uint256.NewInt(extCallResult(err)))
+
+	scope.Contract.RefundGas(returnGas, interpreter.evm.Config.Tracer, tracing.GasChangeCallLeftOverRefunded)
+
+	interpreter.returnData = ret
+	return nil, nil
+}
+
+// opExtDelegateCall implements the EXTDELEGATECALL opcode
+func opExtDelegateCall(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	stack := scope.Stack
+	// Use all available gas
+	gas := interpreter.evm.callGasTemp
+	// Pop other call parameters.
+	addr, inOffset, inSize := stack.pop(), stack.pop(), stack.pop()
+	toAddr := common.Address(addr.Bytes20())
+	// Get arguments from the memory.
+	args := scope.Memory.GetPtr(int64(inOffset.Uint64()), int64(inSize.Uint64()))
+
+	// Delegating to legacy code is a light failure, as is not having enough
+	// gas for the callee.
+	if gas < params.MinCalleeGas || !hasEOFMagic(interpreter.evm.StateDB.GetCode(toAddr)) {
+		scope.Contract.RefundGas(gas, interpreter.evm.Config.Tracer, tracing.GasChangeCallLeftOverRefunded)
+		interpreter.returnData = nil
+		stack.push(uint256.NewInt(1))
+		return nil, nil
+	}
+	ret, returnGas, err := interpreter.evm.DelegateCall(scope.Contract, toAddr, args, gas)
+	stack.push(uint256.NewInt(extCallResult(err)))
+
+	scope.Contract.RefundGas(returnGas, interpreter.evm.Config.Tracer, tracing.GasChangeCallLeftOverRefunded)
+
+	interpreter.returnData = ret
+	return nil, nil
+}
+
+// opExtStaticCall implements the EXTSTATICCALL opcode
+func opExtStaticCall(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	stack := scope.Stack
+	// Use all available gas
+	gas := interpreter.evm.callGasTemp
+	// Pop other call parameters.
+	addr, inOffset, inSize := stack.pop(), stack.pop(), stack.pop()
+	toAddr := common.Address(addr.Bytes20())
+	// Get arguments from the memory.
+	args := scope.Memory.GetPtr(int64(inOffset.Uint64()), int64(inSize.Uint64()))
+
+	if gas < params.MinCalleeGas {
+		// Light failure, the callee would not have enough gas to do anything.
+		scope.Contract.RefundGas(gas, interpreter.evm.Config.Tracer, tracing.GasChangeCallLeftOverRefunded)
+		interpreter.returnData = nil
+		stack.push(uint256.NewInt(1))
+		return nil, nil
+	}
+	ret, returnGas, err := interpreter.evm.StaticCall(scope.Contract, toAddr, args, gas)
+	stack.push(uint256.NewInt(extCallResult(err)))
+
+	scope.Contract.RefundGas(returnGas, interpreter.evm.Config.Tracer, tracing.GasChangeCallLeftOverRefunded)
+
+	interpreter.returnData = ret
+	return nil, nil
+}
+
+// opExtCodeSizeEOF implements EXTCODESIZE for legacy code after EIP-3540,
+// reporting a size of 2 for EOF contracts.
+func opExtCodeSizeEOF(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	slot := scope.Stack.peek()
+	address := common.Address(slot.Bytes20())
+	code := interpreter.evm.StateDB.GetCode(address)
+	if witness := interpreter.evm.StateDB.Witness(); witness != nil {
+		witness.AddCode(code)
+	}
+	if hasEOFMagic(code) {
+		slot.SetUint64(uint64(len(eofMagic)))
+	} else {
+		slot.SetUint64(uint64(len(code)))
+	}
+	return nil, nil
+}
+
+// opExtCodeCopyEOF implements EXTCODECOPY for legacy code after EIP-3540,
+// copying the EOF magic in place of the code of EOF contracts.
+func opExtCodeCopyEOF(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		stack      = scope.Stack
+		a          = stack.pop()
+		memOffset  = stack.pop()
+		codeOffset = stack.pop()
+		length     = stack.pop()
+	)
+	uint64CodeOffset, overflow := codeOffset.Uint64WithOverflow()
+	if overflow {
+		uint64CodeOffset = math.MaxUint64
+	}
+	addr := common.Address(a.Bytes20())
+	code := interpreter.evm.StateDB.GetCode(addr)
+	if witness := interpreter.evm.StateDB.Witness(); witness != nil {
+		witness.AddCode(code)
+	}
+	if hasEOFMagic(code) {
+		code = eofMagic
+	}
+	codeCopy := getData(code, uint64CodeOffset, length.Uint64())
+	scope.Memory.Set(memOffset.Uint64(), length.Uint64(), codeCopy)
+
+	return nil, nil
+}
+
+// opExtCodeHashEOF implements EXTCODEHASH for legacy code after EIP-3540,
+// reporting keccak256(0xEF00) as the hash of EOF contracts.
+func opExtCodeHashEOF(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	slot := scope.Stack.peek()
+	address := common.Address(slot.Bytes20())
+	if interpreter.evm.StateDB.Empty(address) {
+		slot.Clear()
+	} else if hasEOFMagic(interpreter.evm.StateDB.GetCode(address)) {
+		slot.SetBytes(eofCodeHash.Bytes())
+	} else {
+		slot.SetBytes(interpreter.evm.StateDB.GetCodeHash(address).Bytes())
+	}
+	return nil, nil
+}
+
+func memoryEOFCreate(stack *Stack) (uint64, bool) {
+	return calcMemSize64(stack.Back(2), stack.Back(3))
+}
+
+func memoryReturnContract(stack *Stack) (uint64, bool) {
+	return calcMemSize64(stack.Back(0), stack.Back(1))
+}
+
+func memoryDataCopy(stack *Stack) (uint64, bool) {
+	return calcMemSize64(stack.Back(0), stack.Back(2))
+}
+
+func memoryExtCall(stack *Stack) (uint64, bool) {
+	return calcMemSize64(stack.Back(1), stack.Back(2))
+}
+
+func gasEOFCreate(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	return memoryGasCost(mem, memorySize)
+}
+
+// makeExtCallGas creates the gas function of an EXT*CALL variant. Besides the
+// memory expansion, the cold account access and the value transfer charges,
+// it computes the gas passed to the callee, retaining at least MinRetainedGas
+// or 1/64th of the available gas in the caller (EIP-7069).
+func makeExtCallGas(hasValue bool) gasFunc {
+	return func(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+		target := stack.Back(0)
+		if target.BitLen() > 160 {
+			return 0, errAddressHighBytes
+		}
+		address := common.Address(target.Bytes20())
+
+		gas, err := memoryGasCost(mem, memorySize)
+		if err != nil {
+			return 0, err
+		}
+		var overflow bool
+		if !evm.StateDB.AddressInAccessList(address) {
+			evm.StateDB.AddAddressToAccessList(address)
+			// The warm access cost is already charged as the constant cost.
+			if gas, overflow = math.SafeAdd(gas, params.ColdAccountAccessCostEIP2929-params.WarmStorageReadCostEIP2929); overflow {
+				return 0, ErrGasUintOverflow
+			}
+		}
+		if hasValue && !stack.Back(3).IsZero() {
+			if gas, overflow = math.SafeAdd(gas, params.CallValueTransferGas); overflow {
+				return 0, ErrGasUintOverflow
+			}
+			if evm.StateDB.Empty(address) {
+				if gas, overflow = math.SafeAdd(gas, params.CallNewAccountGas); overflow {
+					return 0, ErrGasUintOverflow
+				}
+			}
+		}
+		if contract.Gas < gas {
+			return 0, ErrOutOfGas
+		}
+		available := contract.Gas - gas
+		if retained := max(available/64, params.MinRetainedGas); available > retained {
+			evm.callGasTemp = available - retained
+		} else {
+			evm.callGasTemp = 0
+		}
+		if gas, overflow = math.SafeAdd(gas, evm.callGasTemp); overflow {
+			return 0, ErrGasUintOverflow
+		}
+		return gas, nil
+	}
+}
+
+var (
+	gasExtCall         = makeExtCallGas(true)
+	gasExtDelegateCall = makeExtCallGas(false)
+	gasExtStaticCall   = makeExtCallGas(false)
+)
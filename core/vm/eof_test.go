@@ -0,0 +1,546 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// eofRuntime returns a runtime container calling a helper function, and
+// returning its result along with the first word of the data section.
+func eofRuntime(data []byte, dataSize int) *Container {
+	return &Container{
+		types: []*functionMetadata{
+			{inputs: 0, outputs: nonReturningFunction, maxStackHeight: 2},
+			{inputs: 0, outputs: 1, maxStackHeight: 1},
+		},
+		codeSections: [][]byte{
+			{
+				byte(CALLF), 0x00, 0x01,
+				byte(PUSH0),
+				byte(MSTORE),
+				byte(DATALOADN), 0x00, 0x00,
+				byte(PUSH1), 0x20,
+				byte(MSTORE),
+				byte(PUSH1), 0x40,
+				byte(PUSH0),
+				byte(RETURN),
+			},
+			{byte(PUSH1), 0x2a, byte(RETF)},
+		},
+		data:     data,
+		dataSize: dataSize,
+	}
+}
+
+// eofInitcode returns an initcontainer deploying the given runtime container,
+// appending the calldata as aux data.
+func eofInitcode(runtime *Container) *Container {
+	return &Container{
+		types: []*functionMetadata{{inputs: 0, outputs: nonReturningFunction, maxStackHeight: 3}},
+		codeSections: [][]byte{{
+			byte(CALLDATASIZE),
+			byte(PUSH0),
+			byte(PUSH0),
+			byte(CALLDATACOPY),
+			byte(CALLDATASIZE),
+			byte(PUSH0),
+			byte(RETURNCONTRACT), 0x00,
+		}},
+		subContainers:     []*Container{runtime},
+		subContainerCodes: [][]byte{runtime.MarshalBinary()},
+	}
+}
+
+func TestEOFMarshaling(t *testing.T) {
+	for i, test := range []struct {
+		want Container
+		err  error
+	}{
+		{
+			want: Container{
+				types:        []*functionMetadata{{inputs: 0, outputs: 0x80, maxStackHeight: 1}},
+				codeSections: [][]byte{common.Hex2Bytes("604200")},
+				data:         []byte{0x01, 0x02, 0x03},
+				dataSize:     3,
+			},
+		},
+		{
+			want: *eofRuntime(bytes.Repeat([]byte{0xaa}, 32), 32),
+		},
+		{
+			want: *eofInitcode(eofRuntime(nil, 32)),
+		},
+	} {
+		var (
+			b   = test.want.MarshalBinary()
+			got Container
+		)
+		if err := got.UnmarshalBinary(b); err != nil && err != test.err {
+			t.Fatalf("test %d: got error \"%v\", want \"%v\"", i, err, test.err)
+		}
+		if have, want := got.MarshalBinary(), b; !bytes.Equal(have, want) {
+			t.Fatalf("test %d: encoding mismatch, have %x, want %x", i, have, want)
+		}
+		if !reflect.DeepEqual(got.types, test.want.types) || !reflect.DeepEqual(got.codeSections, test.want.codeSections) {
+			t.Fatalf("test %d: decoded container mismatch\nhave %v\nwant %v", i, got, test.want)
+		}
+	}
+}
+
+func TestEOFParseErrors(t *testing.T) {
+	valid := (&Container{
+		types:        []*functionMetadata{{inputs: 0, outputs: 0x80, maxStackHeight: 0}},
+		codeSections: [][]byte{{byte(STOP)}},
+		data:         []byte{0x01},
+		dataSize:     1,
+	}).MarshalBinary()
+
+	for i, test := range []struct {
+		code []byte
+		want error
+	}{
+		{code: append([]byte{0xef, 0x01}, valid[2:]...), want: errInvalidMagic},
+		{code: append([]byte{0xef, 0x00, 0x02}, valid[3:]...), want: errInvalidVersion},
+		{code: valid[:len(valid)-1], want: errTruncatedTopLevelContainer},
+		{code: append(common.CopyBytes(valid), 0x00), want: errInvalidContainerSize},
+		{code: common.Hex2Bytes("ef000101000402000100010400000000800000fe"), want: nil},
+		{code: common.Hex2Bytes("ef000101000402000100010400000000000000fe"), want: errInvalidSection0Type},
+		{code: common.Hex2Bytes("ef000101000302000100010400000000800000fe"), want: errInvalidTypeSize},
+		{code: common.Hex2Bytes("ef000101000402000000010400000000800000fe"), want: errInvalidSectionCount},
+		{code: common.Hex2Bytes("ef000101000402000100010500000000800000fe"), want: errMissingDataHeader},
+		{code: common.Hex2Bytes("ef000101000402000100010400000100800000fe"), want: errMissingTerminator},
+		{code: common.Hex2Bytes("ef000101000402000100010400000000800400fe"), want: errTooLargeMaxStackHeight},
+	} {
+		var c Container
+		if err := c.UnmarshalBinary(test.code); !errors.Is(err, test.want) {
+			t.Errorf("test %d: have error %v, want %v", i, err, test.want)
+		}
+	}
+}
+
+func TestEOFValidation(t *testing.T) {
+	nonReturning := func(maxStack uint16) *functionMetadata {
+		return &functionMetadata{inputs: 0, outputs: nonReturningFunction, maxStackHeight: maxStack}
+	}
+	for i, test := range []struct {
+		container  *Container
+		isInitCode bool
+		want       error
+	}{
+		{
+			container: &Container{types: []*functionMetadata{nonReturning(0)}, codeSections: [][]byte{{byte(STOP)}}},
+		},
+		{
+			container: &Container{types: []*functionMetadata{nonReturning(1)}, codeSections: [][]byte{{byte(PUSH1), 0x01, byte(POP), byte(STOP)}}},
+		},
+		{
+			// RJUMPI over a STOP
+			container: &Container{types: []*functionMetadata{nonReturning(1)}, codeSections: [][]byte{{byte(PUSH0), byte(RJUMPI), 0x00, 0x01, byte(STOP), byte(STOP)}}},
+		},
+		{
+			// RJUMPV with a single entry
+			container: &Container{types: []*functionMetadata{nonReturning(1)}, codeSections: [][]byte{{byte(PUSH0), byte(RJUMPV), 0x00, 0x00, 0x00, byte(STOP)}}},
+		},
+		{
+			// Loop with a stable stack height
+			container: &Container{types: []*functionMetadata{nonReturning(1)}, codeSections: [][]byte{{byte(PUSH0), byte(POP), byte(RJUMP), 0xff, 0xfb}}},
+		},
+		{
+			container: eofRuntime(make([]byte, 32), 32),
+		},
+		{
+			container:  eofInitcode(eofRuntime(nil, 32)),
+			isInitCode: true,
+		},
+		{
+			container: &Container{types: []*functionMetadata{nonReturning(0)}, codeSections: [][]byte{{0x0c}}},
+			want:      errUndefinedInstruction,
+		},
+		{
+			container: &Container{types: []*functionMetadata{nonReturning(0)}, codeSections: [][]byte{{byte(JUMP)}}},
+			want:      errUndefinedInstruction,
+		},
+		{
+			container: &Container{types: []*functionMetadata{nonReturning(0)}, codeSections: [][]byte{{byte(PUSH2), 0x00}}},
+			want:      errTruncatedImmediate,
+		},
+		{
+			container: &Container{types: []*functionMetadata{nonReturning(1)}, codeSections: [][]byte{{byte(PUSH0)}}},
+			want:      errInvalidCodeTermination,
+		},
+		{
+			// RJUMP into its own immediate
+			container: &Container{types: []*functionMetadata{nonReturning(0)}, codeSections: [][]byte{{byte(RJUMP), 0xff, 0xfe}}},
+			want:      errInvalidJumpDest,
+		},
+		{
+			container: &Container{types: []*functionMetadata{nonReturning(0)}, codeSections: [][]byte{{byte(RJUMP), 0x00, 0x10, byte(STOP)}}},
+			want:      errInvalidJumpDest,
+		},
+		{
+			// Loop growing the stack
+			container: &Container{types: []*functionMetadata{nonReturning(1)}, codeSections: [][]byte{{byte(PUSH0), byte(RJUMP), 0xff, 0xfc}}},
+			want:      errInvalidBackwardJump,
+		},
+		{
+			container: &Container{types: []*functionMetadata{nonReturning(2)}, codeSections: [][]byte{{byte(PUSH0), byte(POP), byte(STOP)}}},
+			want:      errInvalidMaxStackHeight,
+		},
+		{
+			container: &Container{types: []*functionMetadata{nonReturning(0)}, codeSections: [][]byte{{byte(STOP), byte(STOP)}}},
+			want:      errUnreachableCode,
+		},
+		{
+			container: &Container{
+				types:        []*functionMetadata{nonReturning(0), nonReturning(0)},
+				codeSections: [][]byte{{byte(STOP)}, {byte(STOP)}},
+			},
+			want: errUnreachableCode,
+		},
+		{
+			container: &Container{
+				types:        []*functionMetadata{nonReturning(0), nonReturning(0)},
+				codeSections: [][]byte{{byte(CALLF), 0x00, 0x01, byte(STOP)}, {byte(STOP)}},
+			},
+			want: errInvalidCallArgument,
+		},
+		{
+			container: &Container{types: []*functionMetadata{nonReturning(0)}, codeSections: [][]byte{{byte(RETF)}}},
+			want:      errInvalidNonReturningFlag,
+		},
+		{
+			container: &Container{types: []*functionMetadata{nonReturning(1)}, codeSections: [][]byte{{byte(DATALOADN), 0x00, 0x00, byte(STOP)}}},
+			want:      errInvalidDataloadNArgument,
+		},
+		{
+			container:  &Container{types: []*functionMetadata{nonReturning(0)}, codeSections: [][]byte{{byte(STOP)}}},
+			isInitCode: true,
+			want:       errStopInInitCode,
+		},
+		{
+			container: eofInitcode(eofRuntime(nil, 32)),
+			want:      errIncompatibleContainerKind,
+		},
+		{
+			container: &Container{
+				types:             []*functionMetadata{nonReturning(0)},
+				codeSections:      [][]byte{{byte(STOP)}},
+				subContainers:     []*Container{eofRuntime(nil, 32)},
+				subContainerCodes: [][]byte{eofRuntime(nil, 32).MarshalBinary()},
+			},
+			want: errOrphanedSubcontainer,
+		},
+	} {
+		err := test.container.ValidateCode(&eofInstructionSet, test.isInitCode)
+		if !errors.Is(err, test.want) {
+			t.Errorf("test %d: have error %v, want %v", i, err, test.want)
+		}
+	}
+	// Stack underflows are reported with the dedicated error type.
+	c := &Container{types: []*functionMetadata{nonReturning(0)}, codeSections: [][]byte{{byte(POP), byte(STOP)}}}
+	var underflow *ErrStackUnderflow
+	if err := c.ValidateCode(&eofInstructionSet, false); !errors.As(err, &underflow) {
+		t.Errorf("have error %v, want stack underflow", err)
+	}
+}
+
+// newEOFTestEVM creates an EVM with the osaka rules active.
+func newEOFTestEVM(t *testing.T) (*EVM, *state.StateDB) {
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := *params.MergedTestChainConfig
+	config.PragueTime = new(uint64)
+	config.OsakaTime = new(uint64)
+
+	vmctx := BlockContext{
+		CanTransfer: func(StateDB, common.Address, *uint256.Int) bool { return true },
+		Transfer:    func(StateDB, common.Address, common.Address, *uint256.Int) {},
+		BlockNumber: big.NewInt(1),
+		Random:      &common.Hash{},
+	}
+	return NewEVM(vmctx, TxContext{}, statedb, &config, Config{}), statedb
+}
+
+func TestEOFExecution(t *testing.T) {
+	var (
+		evm, statedb = newEOFTestEVM(t)
+		address      = common.HexToAddress("0xe0f")
+		legacy       = common.HexToAddress("0x1e9")
+		data         = bytes.Repeat([]byte{0xaa}, 32)
+	)
+	statedb.SetCode(address, eofRuntime(data, 32).MarshalBinary())
+
+	// Legacy code returning the EXTCODESIZE of the EOF contract.
+	statedb.SetCode(legacy, append(append([]byte{byte(PUSH20)}, address.Bytes()...),
+		byte(EXTCODESIZE), byte(PUSH0), byte(MSTORE), byte(PUSH1), 0x20, byte(PUSH0), byte(RETURN)))
+
+	ret, _, err := evm.Call(AccountRef(common.Address{}), address, nil, 100000, new(uint256.Int))
+	if err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	if want := append(common.LeftPadBytes([]byte{0x2a}, 32), data...); !bytes.Equal(ret, want) {
+		t.Fatalf("unexpected return data: have %x, want %x", ret, want)
+	}
+	ret, _, err = evm.Call(AccountRef(common.Address{}), legacy, nil, 100000, new(uint256.Int))
+	if err != nil {
+		t.Fatalf("legacy execution failed: %v", err)
+	}
+	if have := new(uint256.Int).SetBytes(ret); have.Uint64() != 2 {
+		t.Fatalf("unexpected EXTCODESIZE of EOF contract: have %d, want 2", have.Uint64())
+	}
+}
+
+func TestEOFCreation(t *testing.T) {
+	var (
+		evm, statedb = newEOFTestEVM(t)
+		sender       = common.HexToAddress("0x5e4de4")
+		aux          = bytes.Repeat([]byte{0xbb}, 32)
+		runtime      = eofRuntime(nil, 32)
+		initcode     = eofInitcode(runtime).MarshalBinary()
+	)
+	// The calldata following the initcontainer is appended to the data
+	// section of the deployed container.
+	_, addr, _, err := evm.Create(AccountRef(sender), append(common.CopyBytes(initcode), aux...), 1000000, new(uint256.Int))
+	if err != nil {
+		t.Fatalf("creation failed: %v", err)
+	}
+	if have, want := statedb.GetCode(addr), eofRuntime(aux, 32).MarshalBinary(); !bytes.Equal(have, want) {
+		t.Fatalf("unexpected deployed code: have %x, want %x", have, want)
+	}
+	ret, _, err := evm.Call(AccountRef(sender), addr, nil, 100000, new(uint256.Int))
+	if err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	if !bytes.Equal(ret[32:], aux) {
+		t.Fatalf("unexpected data section: have %x, want %x", ret[32:], aux)
+	}
+	// Invalid initcode consumes the nonce and all gas.
+	nonce := statedb.GetNonce(sender)
+	_, _, gas, err := evm.Create(AccountRef(sender), initcode[:len(initcode)-1], 1000000, new(uint256.Int))
+	if !errors.Is(err, ErrInvalidEOFInitcode) {
+		t.Fatalf("have error %v, want %v", err, ErrInvalidEOFInitcode)
+	}
+	if gas != 0 {
+		t.Fatalf("invalid initcode left %d gas", gas)
+	}
+	if have := statedb.GetNonce(sender); have != nonce+1 {
+		t.Fatalf("unexpected nonce: have %d, want %d", have, nonce+1)
+	}
+}
+
+// eofReturnTop is the code returning the top stack item as a 32 byte word.
+var eofReturnTop = []byte{byte(PUSH0), byte(MSTORE), byte(PUSH1), 0x20, byte(PUSH0), byte(RETURN)}
+
+// runEOFContainer validates the given runtime container, deploys it and calls it.
+func runEOFContainer(t *testing.T, c *Container) ([]byte, error) {
+	t.Helper()
+
+	if err := c.ValidateCode(&eofInstructionSet, false); err != nil {
+		t.Fatalf("invalid container: %v", err)
+	}
+	var (
+		evm, statedb = newEOFTestEVM(t)
+		address      = common.HexToAddress("0xe0f")
+	)
+	statedb.SetCode(address, c.MarshalBinary())
+	ret, _, err := evm.Call(AccountRef(common.Address{}), address, nil, 1000000, new(uint256.Int))
+	return ret, err
+}
+
+func TestEOFRelativeJumps(t *testing.T) {
+	var (
+		// Returns 0xaa if the condition is zero, 0xbb otherwise
+		rjumpi = func(cond byte) []byte {
+			return append([]byte{
+				byte(PUSH1), cond,
+				byte(RJUMPI), 0x00, 0x05,
+				byte(PUSH1), 0xaa,
+				byte(RJUMP), 0x00, 0x02,
+				byte(PUSH1), 0xbb,
+			}, eofReturnTop...)
+		}
+		// Returns 0xbb for index 0, 0xcc for index 1 and 0xaa otherwise
+		rjumpv = func(index byte) []byte {
+			return append([]byte{
+				byte(PUSH1), index,
+				byte(RJUMPV), 0x01, 0x00, 0x05, 0x00, 0x0a,
+				byte(PUSH1), 0xaa,
+				byte(RJUMP), 0x00, 0x07,
+				byte(PUSH1), 0xbb,
+				byte(RJUMP), 0x00, 0x02,
+				byte(PUSH1), 0xcc,
+			}, eofReturnTop...)
+		}
+		// Adds 2 to an accumulator in a backward jumping loop of the given
+		// number of iterations
+		loop = func(n byte) []byte {
+			return append([]byte{
+				byte(PUSH0),
+				byte(PUSH1), n,
+				byte(SWAP1), byte(PUSH1), 0x02, byte(ADD), byte(SWAP1),
+				byte(PUSH1), 0x01, byte(SWAP1), byte(SUB),
+				byte(DUP1), byte(RJUMPI), 0xff, 0xf3,
+				byte(POP),
+			}, eofReturnTop...)
+		}
+	)
+	for i, test := range []struct {
+		code     []byte
+		maxStack uint16
+		want     byte
+	}{
+		{code: rjumpi(0), maxStack: 2, want: 0xaa},
+		{code: rjumpi(1), maxStack: 2, want: 0xbb},
+		{code: rjumpi(0xff), maxStack: 2, want: 0xbb},
+		{code: rjumpv(0), maxStack: 2, want: 0xbb},
+		{code: rjumpv(1), maxStack: 2, want: 0xcc},
+		{code: rjumpv(2), maxStack: 2, want: 0xaa},
+		{code: rjumpv(0xff), maxStack: 2, want: 0xaa},
+		{code: loop(1), maxStack: 3, want: 2},
+		{code: loop(4), maxStack: 3, want: 8},
+	} {
+		c := &Container{
+			types:        []*functionMetadata{{inputs: 0, outputs: nonReturningFunction, maxStackHeight: test.maxStack}},
+			codeSections: [][]byte{test.code},
+		}
+		ret, err := runEOFContainer(t, c)
+		if err != nil {
+			t.Fatalf("test %d: execution failed: %v", i, err)
+		}
+		if want := common.LeftPadBytes([]byte{test.want}, 32); !bytes.Equal(ret, want) {
+			t.Errorf("test %d: unexpected return data: have %x, want %x", i, ret, want)
+		}
+	}
+}
+
+func TestEOFFunctions(t *testing.T) {
+	// Section 0 computes (2+3) * (7+8) through two returning functions, and
+	// jumps to a non-returning function returning the result.
+	c := &Container{
+		types: []*functionMetadata{
+			{inputs: 0, outputs: nonReturningFunction, maxStackHeight: 3},
+			{inputs: 2, outputs: 1, maxStackHeight: 2},
+			{inputs: 0, outputs: 2, maxStackHeight: 2},
+			{inputs: 1, outputs: nonReturningFunction, maxStackHeight: 2},
+		},
+		codeSections: [][]byte{
+			{
+				byte(PUSH1), 0x02, byte(PUSH1), 0x03,
+				byte(CALLF), 0x00, 0x01,
+				byte(CALLF), 0x00, 0x02,
+				byte(ADD), byte(MUL),
+				byte(JUMPF), 0x00, 0x03,
+			},
+			{byte(ADD), byte(RETF)},
+			{byte(PUSH1), 0x07, byte(PUSH1), 0x08, byte(RETF)},
+			eofReturnTop,
+		},
+	}
+	ret, err := runEOFContainer(t, c)
+	if err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	if want := common.LeftPadBytes([]byte{75}, 32); !bytes.Equal(ret, want) {
+		t.Fatalf("unexpected return data: have %x, want %x", ret, want)
+	}
+	// Unbounded recursion exhausts the return stack.
+	c = &Container{
+		types: []*functionMetadata{
+			{inputs: 0, outputs: nonReturningFunction, maxStackHeight: 0},
+			{inputs: 0, outputs: 0, maxStackHeight: 0},
+		},
+		codeSections: [][]byte{
+			{byte(CALLF), 0x00, 0x01, byte(STOP)},
+			{byte(CALLF), 0x00, 0x01, byte(RETF)},
+		},
+	}
+	if _, err := runEOFContainer(t, c); !errors.Is(err, ErrReturnStackExceeded) {
+		t.Fatalf("have error %v, want %v", err, ErrReturnStackExceeded)
+	}
+}
+
+func TestEOFCreateOpcode(t *testing.T) {
+	var (
+		evm, statedb = newEOFTestEVM(t)
+		runtime      = eofRuntime(nil, 32)
+		initcode     = eofInitcode(runtime)
+		factory      = func(addr common.Address, auxSize byte) *Container {
+			// Deploys the initcontainer with a salt of 1, passing 0xcc
+			// left-padded to the given size as aux data, and returns the
+			// address of the new contract.
+			c := &Container{
+				types: []*functionMetadata{{inputs: 0, outputs: nonReturningFunction, maxStackHeight: 4}},
+				codeSections: [][]byte{append([]byte{
+					byte(PUSH1), 0xcc, byte(PUSH0), byte(MSTORE),
+					byte(PUSH1), auxSize, byte(PUSH0), byte(PUSH1), 0x01, byte(PUSH0),
+					byte(EOFCREATE), 0x00,
+				}, eofReturnTop...)},
+				subContainers:     []*Container{initcode},
+				subContainerCodes: [][]byte{initcode.MarshalBinary()},
+			}
+			if err := c.ValidateCode(&eofInstructionSet, false); err != nil {
+				t.Fatalf("invalid factory: %v", err)
+			}
+			statedb.SetCode(addr, c.MarshalBinary())
+			return c
+		}
+		deployer = common.HexToAddress("0xfac1")
+		failing  = common.HexToAddress("0xfac2")
+	)
+	factory(deployer, 0x20)
+	factory(failing, 0x00)
+
+	ret, _, err := evm.Call(AccountRef(common.Address{}), deployer, nil, 1000000, new(uint256.Int))
+	if err != nil {
+		t.Fatalf("factory execution failed: %v", err)
+	}
+	want := crypto.CreateAddress2(deployer, common.BigToHash(big.NewInt(1)), crypto.Keccak256(initcode.MarshalBinary()))
+	if have := common.BytesToAddress(ret); have != want {
+		t.Fatalf("unexpected created address: have %x, want %x", have, want)
+	}
+	aux := common.LeftPadBytes([]byte{0xcc}, 32)
+	if have, want := statedb.GetCode(want), eofRuntime(aux, 32).MarshalBinary(); !bytes.Equal(have, want) {
+		t.Fatalf("unexpected deployed code: have %x, want %x", have, want)
+	}
+	if have := statedb.GetNonce(want); have != 1 {
+		t.Fatalf("unexpected nonce of the created contract: have %d, want 1", have)
+	}
+	// RETURNCONTRACT fails if the aux data doesn't fill the declared data
+	// section, and EOFCREATE pushes zero.
+	ret, _, err = evm.Call(AccountRef(common.Address{}), failing, nil, 1000000, new(uint256.Int))
+	if err != nil {
+		t.Fatalf("factory execution failed: %v", err)
+	}
+	if !bytes.Equal(ret, make([]byte, 32)) {
+		t.Fatalf("unexpected return data of failed creation: have %x, want zero", ret)
+	}
+}
@@ -0,0 +1,362 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Below are all possible errors that can occur during parsing and validation
+// of an EOF container.
+var (
+	errInvalidMagic                  = errors.New("invalid magic")
+	errUndefinedInstruction          = errors.New("undefined instruction")
+	errTruncatedImmediate            = errors.New("truncated immediate")
+	errInvalidSectionArgument        = errors.New("invalid section argument")
+	errInvalidCallArgument           = errors.New("callf into non-returning section")
+	errInvalidDataloadNArgument      = errors.New("invalid dataloadN argument")
+	errInvalidJumpDest               = errors.New("invalid jump destination")
+	errInvalidBackwardJump           = errors.New("invalid backward jump")
+	errInvalidOutputs                = errors.New("invalid number of outputs")
+	errInvalidMaxStackHeight         = errors.New("invalid max stack height")
+	errInvalidCodeTermination        = errors.New("invalid code termination")
+	errEOFCreateWithTruncatedSection = errors.New("eofcreate with truncated section")
+	errOrphanedSubcontainer          = errors.New("subcontainer not referenced at all")
+	errIncompatibleContainerKind     = errors.New("incompatible container kind")
+	errStopInInitCode                = errors.New("initcode contains a RETURN or STOP opcode")
+	errTruncatedTopLevelContainer    = errors.New("truncated top level container")
+	errUnreachableCode               = errors.New("unreachable code")
+	errInvalidNonReturningFlag       = errors.New("invalid non-returning flag, bad RETF")
+	errInvalidVersion                = errors.New("invalid version")
+	errMissingTypeHeader             = errors.New("missing type header")
+	errInvalidTypeSize               = errors.New("invalid type section size")
+	errMissingCodeHeader             = errors.New("missing code header")
+	errInvalidCodeSize               = errors.New("invalid code size")
+	errInvalidContainerSectionSize   = errors.New("invalid container section size")
+	errInvalidSectionCount           = errors.New("invalid section count")
+	errInvalidSectionSize            = errors.New("invalid section size")
+	errMissingDataHeader             = errors.New("missing data header")
+	errMissingTerminator             = errors.New("missing header terminator")
+	errTooManyInputs                 = errors.New("invalid type content, too many inputs")
+	errTooManyOutputs                = errors.New("invalid type content, too many outputs")
+	errInvalidSection0Type           = errors.New("invalid section 0 type, input and output should be zero and non-returning (0x80)")
+	errTooLargeMaxStackHeight        = errors.New("invalid type content, max stack height exceeds limit")
+	errInvalidContainerSize          = errors.New("invalid container size")
+	errInvalidContainerArgument      = errors.New("invalid container argument")
+)
+
+const (
+	refByReturnContract = iota + 1
+	refByEOFCreate
+)
+
+// validationResult holds the code sections and subcontainers referenced from
+// a single code section, the latter along with the kind of the referencing
+// instruction.
+type validationResult struct {
+	visitedCode          map[int]struct{}
+	visitedSubContainers map[int]int
+}
+
+// immediates lists the fixed number of immediate bytes of each EOF instruction.
+// The size of the RJUMPV jump table is encoded in its first immediate and is
+// not accounted for here.
+var immediates [256]uint8
+
+func init() {
+	for op := PUSH1; op <= PUSH32; op++ {
+		immediates[op] = uint8(op - PUSH1 + 1)
+	}
+	immediates[DATALOADN] = 2
+	immediates[RJUMP] = 2
+	immediates[RJUMPI] = 2
+	immediates[RJUMPV] = 1
+	immediates[CALLF] = 2
+	immediates[JUMPF] = 2
+	immediates[DUPN] = 1
+	immediates[SWAPN] = 1
+	immediates[EXCHANGE] = 1
+	immediates[EOFCREATE] = 1
+	immediates[RETURNCONTRACT] = 1
+}
+
+// Immediates returns the number of immediate bytes of an instruction in EOF
+// code. The RJUMPV jump table, whose size depends on the first immediate byte,
+// is not included.
+func Immediates(op OpCode) int {
+	return int(immediates[op])
+}
+
+// terminals lists the instructions which end the execution of a code section.
+var terminals = [256]bool{
+	STOP:           true,
+	RETF:           true,
+	JUMPF:          true,
+	RETURNCONTRACT: true,
+	RETURN:         true,
+	REVERT:         true,
+	INVALID:        true,
+}
+
+// validateCode validates the code parameter against the EOF v1 validity
+// requirements.
+func validateCode(code []byte, section int, container *Container, jt *JumpTable, isInitCode bool) (*validationResult, error) {
+	var (
+		i                    int
+		op                   OpCode
+		analysis             = make(bitvec, len(code)/8+1+4)
+		visitedCode          = make(map[int]struct{})
+		visitedSubcontainers = make(map[int]int)
+		hasReturning         bool
+		jumpTargets          []int
+	)
+	// This loop visits every single instruction and verifies:
+	// * if the instruction is valid for the given jump table.
+	// * if the instruction has an immediate value, it is not truncated.
+	// * if performing a relative jump, all jump destinations are valid.
+	// * if changing code sections, the new code section index is valid and
+	//   will not cause a stack overflow.
+	for i < len(code) {
+		op = OpCode(code[i])
+		if jt[op].undefined {
+			return nil, fmt.Errorf("%w: op %s, pos %d", errUndefinedInstruction, op, i)
+		}
+		size := int(immediates[op])
+		if size != 0 && len(code) <= i+size {
+			return nil, fmt.Errorf("%w: op %s, pos %d", errTruncatedImmediate, op, i)
+		}
+		switch op {
+		case RJUMP, RJUMPI:
+			jumpTargets = append(jumpTargets, i+3+parseInt16(code[i+1:]))
+		case RJUMPV:
+			maxSize := int(code[i+1])
+			length := maxSize + 1
+			if len(code) <= i+1+length*2 {
+				return nil, fmt.Errorf("%w: jump table truncated, op %s, pos %d", errTruncatedImmediate, op, i)
+			}
+			offset := i + 2
+			for j := 0; j < length; j++ {
+				jumpTargets = append(jumpTargets, offset+length*2+parseInt16(code[offset+j*2:]))
+			}
+			size = length*2 + 1
+		case CALLF:
+			arg, _ := parseUint16(code[i+1:])
+			if arg >= len(container.types) {
+				return nil, fmt.Errorf("%w: arg %d, last %d, pos %d", errInvalidSectionArgument, arg, len(container.types), i)
+			}
+			if container.types[arg].outputs == nonReturningFunction {
+				return nil, fmt.Errorf("%w: section %v", errInvalidCallArgument, arg)
+			}
+			visitedCode[arg] = struct{}{}
+		case JUMPF:
+			arg, _ := parseUint16(code[i+1:])
+			if arg >= len(container.types) {
+				return nil, fmt.Errorf("%w: arg %d, last %d, pos %d", errInvalidSectionArgument, arg, len(container.types), i)
+			}
+			if container.types[arg].outputs != nonReturningFunction {
+				if container.types[section].outputs < container.types[arg].outputs {
+					return nil, fmt.Errorf("%w: section %v", errInvalidOutputs, arg)
+				}
+				hasReturning = true
+			}
+			visitedCode[arg] = struct{}{}
+		case RETF:
+			if container.types[section].outputs == nonReturningFunction {
+				return nil, fmt.Errorf("%w: section %v", errInvalidNonReturningFlag, section)
+			}
+			hasReturning = true
+		case DATALOADN:
+			arg, _ := parseUint16(code[i+1:])
+			if arg+32 > container.dataSize {
+				return nil, fmt.Errorf("%w: arg %d, data size %d, pos %d", errInvalidDataloadNArgument, arg, container.dataSize, i)
+			}
+		case RETURNCONTRACT:
+			if !isInitCode {
+				return nil, errIncompatibleContainerKind
+			}
+			arg := int(code[i+1])
+			if arg >= len(container.subContainers) {
+				return nil, fmt.Errorf("%w: arg %d, last %d, pos %d", errInvalidContainerArgument, arg, len(container.subContainers), i)
+			}
+			if prev, ok := visitedSubcontainers[arg]; ok && prev != refByReturnContract {
+				return nil, fmt.Errorf("%w: subcontainer %d", errIncompatibleContainerKind, arg)
+			}
+			visitedSubcontainers[arg] = refByReturnContract
+		case EOFCREATE:
+			arg := int(code[i+1])
+			if arg >= len(container.subContainers) {
+				return nil, fmt.Errorf("%w: arg %d, last %d, pos %d", errInvalidContainerArgument, arg, len(container.subContainers), i)
+			}
+			if prev, ok := visitedSubcontainers[arg]; ok && prev != refByEOFCreate {
+				return nil, fmt.Errorf("%w: subcontainer %d", errIncompatibleContainerKind, arg)
+			}
+			visitedSubcontainers[arg] = refByEOFCreate
+		case STOP, RETURN:
+			if isInitCode {
+				return nil, errStopInInitCode
+			}
+		}
+		for j := 1; j <= size; j++ {
+			analysis.set1(uint64(i + j))
+		}
+		i += size + 1
+	}
+	// Code sections may not "fall through" and require proper termination.
+	// Therefore, the last instruction must be considered terminal or RJUMP.
+	if !terminals[op] && op != RJUMP {
+		return nil, fmt.Errorf("%w: end with %s, pos %d", errInvalidCodeTermination, op, i)
+	}
+	// Relative jumps must land on an instruction within the code section.
+	for _, target := range jumpTargets {
+		if target < 0 || target >= len(code) || !analysis.codeSegment(uint64(target)) {
+			return nil, fmt.Errorf("%w: target %d", errInvalidJumpDest, target)
+		}
+	}
+	// A section declared as returning must contain a RETF or a JUMPF into a
+	// returning section.
+	if container.types[section].outputs != nonReturningFunction && !hasReturning {
+		return nil, fmt.Errorf("%w: section %v", errInvalidNonReturningFlag, section)
+	}
+	if err := validateControlFlow(code, section, container.types, jt); err != nil {
+		return nil, err
+	}
+	return &validationResult{
+		visitedCode:          visitedCode,
+		visitedSubContainers: visitedSubcontainers,
+	}, nil
+}
+
+// stackBounds holds the minimum and maximum stack height reachable at an
+// instruction, across all the paths leading to it.
+type stackBounds struct {
+	min, max int
+}
+
+// validateControlFlow iterates over all possible paths of execution (EIP-5450)
+// in a single linear pass. It verifies that every instruction is reachable,
+// the stack never underflows, backward jumps arrive with a stable stack height
+// and the computed maximum stack height matches the declared one.
+func validateControlFlow(code []byte, section int, metadata []*functionMetadata, jt *JumpTable) error {
+	var (
+		maxStackHeight = int(metadata[section].inputs)
+		bounds         = make([]*stackBounds, len(code))
+	)
+	bounds[0] = &stackBounds{min: maxStackHeight, max: maxStackHeight}
+
+	for pos := 0; pos < len(code); {
+		op := OpCode(code[pos])
+		cur := bounds[pos]
+		if cur == nil {
+			return fmt.Errorf("%w: pos %d", errUnreachableCode, pos)
+		}
+
+		// Compute the stack requirement and effect of the instruction.
+		var required, delta int
+		switch op {
+		case CALLF:
+			arg, _ := parseUint16(code[pos+1:])
+			if err := metadata[arg].checkStackMax(cur.max); err != nil {
+				return fmt.Errorf("callf: %w, pos %d", err, pos)
+			}
+			required, delta = int(metadata[arg].inputs), metadata[arg].stackDelta()
+		case RETF:
+			want := int(metadata[section].outputs)
+			if cur.min != cur.max || cur.max != want {
+				return fmt.Errorf("%w: have %d-%d, want %d, pos %d", errInvalidOutputs, cur.min, cur.max, want, pos)
+			}
+		case JUMPF:
+			arg, _ := parseUint16(code[pos+1:])
+			if err := metadata[arg].checkStackMax(cur.max); err != nil {
+				return fmt.Errorf("jumpf: %w, pos %d", err, pos)
+			}
+			if metadata[arg].outputs == nonReturningFunction {
+				if err := metadata[arg].checkInputs(cur.min); err != nil {
+					return fmt.Errorf("jumpf: %w, pos %d", err, pos)
+				}
+			} else {
+				want := int(metadata[section].outputs) + int(metadata[arg].inputs) - int(metadata[arg].outputs)
+				if cur.min != cur.max || cur.max != want {
+					return fmt.Errorf("%w: have %d-%d, want %d, pos %d", errInvalidOutputs, cur.min, cur.max, want, pos)
+				}
+			}
+		case DUPN:
+			required, delta = int(code[pos+1])+1, 1
+		case SWAPN:
+			required = int(code[pos+1]) + 2
+		case EXCHANGE:
+			n, m := int(code[pos+1]>>4)+1, int(code[pos+1]&0x0f)+1
+			required = n + m + 1
+		default:
+			required = jt[op].minStack
+			delta = int(params.StackLimit) - jt[op].maxStack
+		}
+		if cur.min < required {
+			return fmt.Errorf("%w: op %s, pos %d", &ErrStackUnderflow{stackLen: cur.min, required: required}, op, pos)
+		}
+		next := stackBounds{min: cur.min + delta, max: cur.max + delta}
+		maxStackHeight = max(maxStackHeight, next.max)
+
+		// Propagate the stack bounds to the successors.
+		size := int(immediates[op]) + 1
+		var successors []int
+		switch op {
+		case RJUMP:
+			successors = []int{pos + size + parseInt16(code[pos+1:])}
+		case RJUMPI:
+			successors = []int{pos + size, pos + size + parseInt16(code[pos+1:])}
+		case RJUMPV:
+			length := int(code[pos+1]) + 1
+			size = 2 + length*2
+			successors = append(successors, pos+size)
+			for j := 0; j < length; j++ {
+				successors = append(successors, pos+size+parseInt16(code[pos+2+j*2:]))
+			}
+		default:
+			if !terminals[op] {
+				successors = []int{pos + size}
+			}
+		}
+		for _, succ := range successors {
+			if succ >= len(code) {
+				return fmt.Errorf("%w: pos %d", errInvalidCodeTermination, pos)
+			}
+			if succ <= pos {
+				// Backward jumps must arrive with an unchanged stack height.
+				if b := bounds[succ]; b == nil || b.min != next.min || b.max != next.max {
+					return fmt.Errorf("%w: pos %d", errInvalidBackwardJump, pos)
+				}
+				continue
+			}
+			if b := bounds[succ]; b == nil {
+				bounds[succ] = &stackBounds{min: next.min, max: next.max}
+			} else {
+				b.min, b.max = min(b.min, next.min), max(b.max, next.max)
+			}
+		}
+		pos += size
+	}
+	if maxStackHeight >= int(params.StackLimit) {
+		return &ErrStackOverflow{stackLen: maxStackHeight, limit: int(params.StackLimit)}
+	}
+	if maxStackHeight != int(metadata[section].maxStackHeight) {
+		return fmt.Errorf("%w in code section %d: have %d, want %d", errInvalidMaxStackHeight, section, maxStackHeight, metadata[section].maxStackHeight)
+	}
+	return nil
+}
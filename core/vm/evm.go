@@ -18,6 +18,7 @@ package vm
 
 import (
 	"errors"
+	"fmt"
 	"math/big"
 	"sync/atomic"
 
@@ -41,6 +42,9 @@ type (
 )
 
 func (evm *EVM) precompile(addr common.Address) (PrecompiledContract, bool) {
+	if p, ok := evm.customPrecompiles[addr]; ok {
+		return p, true
+	}
 	var precompiles map[common.Address]PrecompiledContract
 	switch {
 	case evm.chainRules.IsVerkle:
@@ -62,6 +66,22 @@ func (evm *EVM) precompile(addr common.Address) (PrecompiledContract, bool) {
 	return p, ok
 }
 
+// precompileEnvironment creates the environment a stateful precompiled contract
+// is executed in, or nil if the contract is stateless.
+func (evm *EVM) precompileEnvironment(p PrecompiledContract, caller, addr common.Address, value *uint256.Int, readOnly bool) *PrecompileEnvironment {
+	if _, ok := p.(StatefulPrecompiledContract); !ok {
+		return nil
+	}
+	return &PrecompileEnvironment{
+		StateDB:  evm.StateDB,
+		Caller:   caller,
+		Address:  addr,
+		Value:    value,
+		ReadOnly: readOnly,
+		evm:      evm,
+	}
+}
+
 // BlockContext provides the EVM with auxiliary information. Once provided
 // it shouldn't be modified.
 type BlockContext struct {
@@ -117,6 +137,9 @@ type EVM struct {
 	chainConfig *params.ChainConfig
 	// chain rules contains the chain rules for the current epoch
 	chainRules params.Rules
+	// customPrecompiles contains the custom precompiled contracts activated
+	// by the chain config for the current epoch
+	customPrecompiles map[common.Address]PrecompiledContract
 	// virtual machine configuration options used to initialise the
 	// evm.
 	Config Config
@@ -153,6 +176,7 @@ func NewEVM(blockCtx BlockContext, txCtx TxContext, statedb StateDB, chainConfig
 		chainConfig: chainConfig,
 		chainRules:  chainConfig.Rules(blockCtx.BlockNumber, blockCtx.Random != nil, blockCtx.Time),
 	}
+	evm.customPrecompiles = customPrecompiles(evm.chainRules)
 	evm.interpreter = NewEVMInterpreter(evm)
 	return evm
 }
@@ -226,7 +250,8 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 	evm.Context.Transfer(evm.StateDB, caller.Address(), addr, value)
 
 	if isPrecompile {
-		ret, gas, err = RunPrecompiledContract(p, input, gas, evm.Config.Tracer)
+		env := evm.precompileEnvironment(p, caller.Address(), addr, value, evm.interpreter.readOnly)
+		ret, gas, err = runPrecompiledContract(p, env, input, gas, evm.Config.Tracer)
 	} else {
 		// Initialise a new contract and set the code that is to be used by the EVM.
 		// The contract is a scoped environment for this execution context only.
@@ -295,7 +320,8 @@ func (evm *EVM) CallCode(caller ContractRef, addr common.Address, input []byte,
 
 	// It is allowed to call precompiles, even via delegatecall
 	if p, isPrecompile := evm.precompile(addr); isPrecompile {
-		ret, gas, err = RunPrecompiledContract(p, input, gas, evm.Config.Tracer)
+		env := evm.precompileEnvironment(p, caller.Address(), caller.Address(), value, evm.interpreter.readOnly)
+		ret, gas, err = runPrecompiledContract(p, env, input, gas, evm.Config.Tracer)
 	} else {
 		addrCopy := addr
 		// Initialise a new contract and set the code that is to be used by the EVM.
@@ -346,7 +372,9 @@ func (evm *EVM) DelegateCall(caller ContractRef, addr common.Address, input []by
 
 	// It is allowed to call precompiles, even via delegatecall
 	if p, isPrecompile := evm.precompile(addr); isPrecompile {
-		ret, gas, err = RunPrecompiledContract(p, input, gas, evm.Config.Tracer)
+		parent := caller.(*Contract)
+		env := evm.precompileEnvironment(p, parent.CallerAddress, caller.Address(), parent.value, evm.interpreter.readOnly)
+		ret, gas, err = runPrecompiledContract(p, env, input, gas, evm.Config.Tracer)
 	} else {
 		addrCopy := addr
 		// Initialise a new contract and make initialise the delegate values
@@ -400,7 +428,8 @@ func (evm *EVM) StaticCall(caller ContractRef, addr common.Address, input []byte
 	evm.StateDB.AddBalance(addr, new(uint256.Int), tracing.BalanceChangeTouchAccount)
 
 	if p, isPrecompile := evm.precompile(addr); isPrecompile {
-		ret, gas, err = RunPrecompiledContract(p, input, gas, evm.Config.Tracer)
+		env := evm.precompileEnvironment(p, caller.Address(), addr, new(uint256.Int), true)
+		ret, gas, err = runPrecompiledContract(p, env, input, gas, evm.Config.Tracer)
 	} else {
 		// At this point, we use a copy of address. If we don't, the go compiler will
 		// leak the 'contract' to the outer scope, and make allocation for 'contract'
@@ -433,8 +462,9 @@ func (evm *EVM) StaticCall(caller ContractRef, addr common.Address, input []byte
 }
 
 type codeAndHash struct {
-	code []byte
-	hash common.Hash
+	code      []byte
+	hash      common.Hash
+	container *Container // parsed EOF initcontainer, nil for legacy initcode
 }
 
 func (c *codeAndHash) Hash() common.Hash {
@@ -445,7 +475,7 @@ func (c *codeAndHash) Hash() common.Hash {
 }
 
 // create creates a new contract using code as deployment code.
-func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64, value *uint256.Int, address common.Address, typ OpCode) (ret []byte, createAddress common.Address, leftOverGas uint64, err error) {
+func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, input []byte, gas uint64, value *uint256.Int, address common.Address, typ OpCode) (ret []byte, createAddress common.Address, leftOverGas uint64, err error) {
 	if evm.Config.Tracer != nil {
 		evm.captureBegin(evm.depth, typ, caller.Address(), address, codeAndHash.code, gas, value.ToBig())
 		defer func(startGas uint64) {
@@ -518,16 +548,17 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 	}
 
 	if err == nil {
-		ret, err = evm.interpreter.Run(contract, nil, false)
+		ret, err = evm.interpreter.Run(contract, input, false)
 	}
 
 	// Check whether the max code size has been exceeded, assign err if the case.
-	if err == nil && evm.chainRules.IsEIP158 && len(ret) > params.MaxCodeSize {
+	if err == nil && evm.chainRules.IsEIP158 && len(ret) > evm.chainConfig.GetMaxCodeSize(evm.Context.BlockNumber) {
 		err = ErrMaxCodeSizeExceeded
 	}
 
-	// Reject code starting with 0xEF if EIP-3541 is enabled.
-	if err == nil && len(ret) >= 1 && ret[0] == 0xEF && evm.chainRules.IsLondon {
+	// Reject code starting with 0xEF if EIP-3541 is enabled. EOF initcode
+	// returns a validated EOF container instead.
+	if err == nil && len(ret) >= 1 && ret[0] == 0xEF && evm.chainRules.IsLondon && codeAndHash.container == nil {
 		err = ErrInvalidCode
 	}
 
@@ -573,7 +604,29 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 // Create creates a new contract using code as deployment code.
 func (evm *EVM) Create(caller ContractRef, code []byte, gas uint64, value *uint256.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
 	contractAddr = crypto.CreateAddress(caller.Address(), evm.StateDB.GetNonce(caller.Address()))
-	return evm.create(caller, &codeAndHash{code: code}, gas, value, contractAddr, CREATE)
+
+	// Creation transactions may deploy EOF contracts, in which case the data
+	// is an initcontainer followed by the calldata of the initcode (EIP-7698).
+	if evm.interpreter.eofTable != nil && evm.depth == 0 && hasEOFMagic(code) {
+		container := new(Container)
+		input, err := container.unmarshalInitcode(code)
+		if err == nil {
+			err = container.ValidateCode(evm.interpreter.eofTable, true)
+		}
+		if err != nil {
+			// Invalid initcode fails the transaction, still consuming the
+			// nonce and all the gas.
+			nonce := evm.StateDB.GetNonce(caller.Address())
+			if nonce+1 < nonce {
+				return nil, common.Address{}, gas, ErrNonceUintOverflow
+			}
+			evm.StateDB.SetNonce(caller.Address(), nonce+1)
+			return nil, contractAddr, 0, fmt.Errorf("%w: %v", ErrInvalidEOFInitcode, err)
+		}
+		initcode := code[:len(code)-len(input)]
+		return evm.create(caller, &codeAndHash{code: initcode, container: container}, input, gas, value, contractAddr, CREATE)
+	}
+	return evm.create(caller, &codeAndHash{code: code}, nil, gas, value, contractAddr, CREATE)
 }
 
 // Create2 creates a new contract using code as deployment code.
@@ -583,7 +636,17 @@ func (evm *EVM) Create(caller ContractRef, code []byte, gas uint64, value *uint2
 func (evm *EVM) Create2(caller ContractRef, code []byte, gas uint64, endowment *uint256.Int, salt *uint256.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
 	codeAndHash := &codeAndHash{code: code}
 	contractAddr = crypto.CreateAddress2(caller.Address(), salt.Bytes32(), codeAndHash.Hash().Bytes())
-	return evm.create(caller, codeAndHash, gas, endowment, contractAddr, CREATE2)
+	return evm.create(caller, codeAndHash, nil, gas, endowment, contractAddr, CREATE2)
+}
+
+// EOFCreate creates a new contract from an EOF initcontainer, executing it
+// with the given input as calldata.
+//
+// The address is derived as in Create2, from keccak256(0xff ++ msg.sender ++ salt ++ keccak256(initcontainer))[12:].
+func (evm *EVM) EOFCreate(caller ContractRef, input []byte, container *Container, initcode []byte, gas uint64, endowment *uint256.Int, salt *uint256.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
+	codeAndHash := &codeAndHash{code: initcode, container: container}
+	contractAddr = crypto.CreateAddress2(caller.Address(), salt.Bytes32(), codeAndHash.Hash().Bytes())
+	return evm.create(caller, codeAndHash, input, gas, endowment, contractAddr, EOFCREATE)
 }
 
 // ChainConfig returns the environment's chain configuration
@@ -34,6 +34,7 @@ type Config struct {
 	EnablePreimageRecording bool  // Enables recording of SHA3/keccak preimages
 	ExtraEips               []int // Additional EIPS that are to be enabled
 	EnableWitnessCollection bool  // true if witness collection is enabled
+	EnableParallelExecution bool  // true if transactions may be executed optimistically in parallel
 }
 
 // ScopeContext contains the things that are per-call, such as stack and memory,
@@ -85,8 +86,9 @@ func (ctx *ScopeContext) CallInput() []byte {
 
 // EVMInterpreter represents an EVM interpreter
 type EVMInterpreter struct {
-	evm   *EVM
-	table *JumpTable
+	evm      *EVM
+	table    *JumpTable
+	eofTable *JumpTable // Instruction set of EOF code, nil before osaka
 
 	hasher    crypto.KeccakState // Keccak256 hasher instance shared across opcodes
 	hasherBuf common.Hash        // Keccak256 hasher result array shared across opcodes
@@ -103,6 +105,8 @@ func NewEVMInterpreter(evm *EVM) *EVMInterpreter {
 	case evm.chainRules.IsVerkle:
 		// TODO replace with proper instruction set when fork is specified
 		table = &verkleInstructionSet
+	case evm.chainRules.IsOsaka:
+		table = &osakaInstructionSet
 	case evm.chainRules.IsCancun:
 		table = &cancunInstructionSet
 	case evm.chainRules.IsShanghai:
@@ -142,7 +146,12 @@ func NewEVMInterpreter(evm *EVM) *EVMInterpreter {
 		}
 	}
 	evm.Config.ExtraEips = extraEips
-	return &EVMInterpreter{evm: evm, table: table}
+
+	var eofTable *JumpTable
+	if evm.chainRules.IsOsaka && !evm.chainRules.IsVerkle {
+		eofTable = &eofInstructionSet
+	}
+	return &EVMInterpreter{evm: evm, table: table, eofTable: eofTable}
 }
 
 // Run loops and evaluates the contract's code with the given input data and returns
@@ -171,6 +180,21 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 	if len(contract.Code) == 0 {
 		return nil, nil
 	}
+	// EOF code is executed section by section with its own instruction set.
+	// Deployed EOF code was validated on creation, so it only has to be parsed.
+	table := in.table
+	if in.eofTable != nil {
+		if contract.Container == nil && !contract.IsDeployment && hasEOFMagic(contract.Code) {
+			contract.Container = new(Container)
+			if err := contract.Container.UnmarshalBinary(contract.Code); err != nil {
+				return nil, err
+			}
+		}
+		if contract.Container != nil {
+			contract.Code = contract.Container.codeSections[0]
+			table = in.eofTable
+		}
+	}
 
 	var (
 		op          OpCode        // current opcode
@@ -234,7 +258,7 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 		// Get the operation from the jump table and validate the stack to ensure there are
 		// enough stack items available to perform the operation.
 		op = contract.GetOp(pc)
-		operation := in.table[op]
+		operation := table[op]
 		cost = operation.constantGas // For tracing
 		// Validate stack
 		if sLen := stack.len(); sLen < operation.minStack {
@@ -42,6 +42,9 @@ type operation struct {
 
 	// memorySize returns the memory size required for the operation
 	memorySize memorySizeFunc
+
+	// undefined denotes if the instruction is not officially defined in the jump table
+	undefined bool
 }
 
 var (
@@ -57,6 +60,8 @@ var (
 	mergeInstructionSet            = newMergeInstructionSet()
 	shanghaiInstructionSet         = newShanghaiInstructionSet()
 	cancunInstructionSet           = newCancunInstructionSet()
+	osakaInstructionSet            = newOsakaInstructionSet()
+	eofInstructionSet              = newEOFInstructionSet()
 	verkleInstructionSet           = newVerkleInstructionSet()
 )
 
@@ -87,6 +92,21 @@ func newVerkleInstructionSet() JumpTable {
 	return validate(instructionSet)
 }
 
+// newEOFInstructionSet returns the instruction set of EOF v1 code, which is
+// the osaka instruction set without the legacy-only instructions, extended
+// with the EOF ones.
+func newEOFInstructionSet() JumpTable {
+	instructionSet := newOsakaInstructionSet()
+	enableEOF(&instructionSet)
+	return validate(instructionSet)
+}
+
+func newOsakaInstructionSet() JumpTable {
+	instructionSet := newCancunInstructionSet()
+	enable3540(&instructionSet) // EIP-3540 (Legacy code introspection of EOF contracts)
+	return validate(instructionSet)
+}
+
 func newCancunInstructionSet() JumpTable {
 	instructionSet := newShanghaiInstructionSet()
 	enable4844(&instructionSet) // EIP-4844 (BLOBHASH opcode)
@@ -1064,7 +1084,7 @@ func newFrontierInstructionSet() JumpTable {
 	// Fill all unassigned slots with opUndefined.
 	for i, entry := range tbl {
 		if entry == nil {
-			tbl[i] = &operation{execute: opUndefined, maxStack: maxStack(0, 0)}
+			tbl[i] = &operation{execute: opUndefined, maxStack: maxStack(0, 0), undefined: true}
 		}
 	}
 
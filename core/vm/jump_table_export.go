@@ -28,6 +28,8 @@ func LookupInstructionSet(rules params.Rules) (JumpTable, error) {
 	switch {
 	case rules.IsVerkle:
 		return newCancunInstructionSet(), errors.New("verkle-fork not defined yet")
+	case rules.IsOsaka:
+		return newOsakaInstructionSet(), nil
 	case rules.IsPrague:
 		return newCancunInstructionSet(), errors.New("prague-fork not defined yet")
 	case rules.IsCancun:
@@ -56,6 +58,12 @@ func LookupInstructionSet(rules params.Rules) (JumpTable, error) {
 	return newFrontierInstructionSet(), nil
 }
 
+// NewEOFInstructionSetForTesting returns the instruction set used for
+// validating and executing EOF code.
+func NewEOFInstructionSetForTesting() JumpTable {
+	return newEOFInstructionSet()
+}
+
 // Stack returns the minimum and maximum stack requirements.
 func (op *operation) Stack() (int, int) {
 	return op.minStack, op.maxStack
@@ -26,6 +26,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/stateless"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
@@ -443,3 +444,44 @@ func (api *DebugAPI) GetTrieFlushInterval() (string, error) {
 	}
 	return api.eth.blockchain.GetTrieFlushInterval().String(), nil
 }
+
+// witnessReexec is the number of blocks the state regeneration is allowed to
+// re-execute to produce the parent state of a block for witness collection.
+const witnessReexec = uint64(128)
+
+// ExecutionWitness re-executes the given block and returns the stateless witness
+// required to verify it: the block with its state and receipt roots zeroed out,
+// the ancestor headers accessed, plus the bytecodes and trie nodes touched.
+func (api *DebugAPI) ExecutionWitness(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*stateless.Witness, error) {
+	block, err := api.eth.APIBackend.BlockByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errors.New("block not found")
+	}
+	if block.NumberU64() == 0 {
+		return nil, errors.New("genesis is not executable")
+	}
+	bc := api.eth.blockchain
+	parent := bc.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent %#x not found", block.ParentHash())
+	}
+	statedb, release, err := api.eth.stateAtBlock(ctx, parent, witnessReexec, nil, true, false)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return bc.ExecutionWitness(block, statedb)
+}
+
+// ExecutionWitnessRLP is the RLP encoded variant of ExecutionWitness.
+func (api *DebugAPI) ExecutionWitnessRLP(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	witness, err := api.ExecutionWitness(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(witness)
+}
@@ -19,6 +19,7 @@ package eth
 import (
 	"math/big"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 )
 
@@ -32,6 +33,24 @@ func NewMinerAPI(e *Ethereum) *MinerAPI {
 	return &MinerAPI{e}
 }
 
+// Start starts sealing blocks as QBFT validator. If sealing is already running,
+// this method does nothing.
+func (api *MinerAPI) Start() error {
+	return api.e.StartMining()
+}
+
+// Stop terminates sealing blocks.
+func (api *MinerAPI) Stop() {
+	api.e.StopMining()
+}
+
+// SetEtherbase sets the validator account used for sealing. It takes effect
+// the next time sealing is started.
+func (api *MinerAPI) SetEtherbase(etherbase common.Address) bool {
+	api.e.SetEtherbase(etherbase)
+	return true
+}
+
 // SetExtra sets the extra data string that is included when this miner mines a block.
 func (api *MinerAPI) SetExtra(extra string) (bool, error) {
 	if err := api.e.Miner().SetExtra([]byte(extra)); err != nil {
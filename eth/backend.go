@@ -19,6 +19,7 @@ package eth
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"runtime"
@@ -28,6 +29,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus"
+	istanbulBackend "github.com/ethereum/go-ethereum/consensus/istanbul/backend"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -37,6 +39,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
@@ -185,6 +188,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		vmConfig = vm.Config{
 			EnablePreimageRecording: config.EnablePreimageRecording,
 			EnableWitnessCollection: config.EnableWitnessCollection,
+			EnableParallelExecution: config.EnableParallelExecution,
 		}
 		cacheConfig = &core.CacheConfig{
 			TrieCleanLimit:      config.TrieCleanCache,
@@ -196,6 +200,10 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 			Preimages:           config.Preimages,
 			StateHistory:        config.StateHistory,
 			StateScheme:         scheme,
+			HistoryExpiry:       config.HistoryExpiry,
+			HistoryDir:          config.HistoryDir,
+			OnlinePruning:       config.OnlinePruning,
+			OnlinePruningRate:   config.OnlinePruningRate,
 		}
 	)
 	if config.VMTrace != "" {
@@ -247,6 +255,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		NodeID:         eth.p2pServer.Self().ID(),
 		Database:       chainDb,
 		Chain:          eth.blockchain,
+		Engine:         eth.engine,
 		TxPool:         eth.txPool,
 		Network:        networkID,
 		Sync:           config.SyncMode,
@@ -347,6 +356,86 @@ func (s *Ethereum) ResetWithGenesisBlock(gb *types.Block) {
 
 func (s *Ethereum) Miner() *miner.Miner { return s.miner }
 
+// StartMining starts the QBFT engine and the sealing loop, signing as validator
+// with the etherbase account, which needs to be unlocked in the keystore.
+func (s *Ethereum) StartMining() error {
+	engine, ok := s.engine.(*istanbulBackend.Backend)
+	if !ok {
+		return errors.New("block sealing is only supported by QBFT, blocks are produced by the consensus client")
+	}
+	if s.miner.Mining() {
+		return nil
+	}
+	s.lock.RLock()
+	etherbase := s.config.Miner.Etherbase
+	s.lock.RUnlock()
+
+	if etherbase == (common.Address{}) {
+		return errors.New("etherbase must be explicitly specified")
+	}
+	// Sign with the wallet holding the etherbase, either an unlocked keystore
+	// account or an external signer like clef keeping the key off the host.
+	account := accounts.Account{Address: etherbase}
+	wallet, err := s.accountManager.Find(account)
+	if wallet == nil || err != nil {
+		return fmt.Errorf("etherbase missing: %v", err)
+	}
+	if err := engine.Authorize(etherbase, func(mimeType string, data []byte) ([]byte, error) {
+		return wallet.SignData(account, mimeType, data)
+	}); err != nil {
+		return err
+	}
+	// Sign the validator announcement upfront, to fail early on locked accounts
+	// or signers rejecting the requests. Peers identify validators by their node
+	// key, like GoQuorum and Besu do, so the announcement is only sent if the
+	// validator signs with another key.
+	nodeKey := crypto.PubkeyToAddress(*s.p2pServer.Self().Pubkey())
+	if err := s.handler.announceValidator(nodeKey); err != nil {
+		return fmt.Errorf("etherbase locked or signing rejected: %v", err)
+	}
+	hasBadBlock := func(db ethdb.Reader, hash common.Hash) bool {
+		return rawdb.ReadBadBlock(db, hash) != nil
+	}
+	currentBlock := func() *types.Block {
+		return s.blockchain.GetBlockByHash(s.blockchain.CurrentBlock().Hash())
+	}
+	if err := engine.Start(s.blockchain, currentBlock, hasBadBlock); err != nil {
+		return err
+	}
+	s.miner.SetEtherbase(etherbase)
+	s.miner.Start()
+	log.Info("Started block sealing", "validator", etherbase)
+	return nil
+}
+
+// StopMining terminates the sealing loop and the QBFT engine.
+func (s *Ethereum) StopMining() {
+	if !s.miner.Mining() {
+		return
+	}
+	s.miner.Stop()
+	if engine, ok := s.engine.(consensus.Istanbul); ok {
+		engine.Stop()
+	}
+}
+
+// Etherbase retrieves the address of the validator account used for sealing.
+func (s *Ethereum) Etherbase() common.Address {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.config.Miner.Etherbase
+}
+
+// SetEtherbase sets the address of the validator account used for sealing. It
+// takes effect the next time sealing is started.
+func (s *Ethereum) SetEtherbase(etherbase common.Address) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.config.Miner.Etherbase = etherbase
+}
+
 func (s *Ethereum) AccountManager() *accounts.Manager  { return s.accountManager }
 func (s *Ethereum) BlockChain() *core.BlockChain       { return s.blockchain }
 func (s *Ethereum) TxPool() *txpool.TxPool             { return s.txPool }
@@ -367,6 +456,9 @@ func (s *Ethereum) Protocols() []p2p.Protocol {
 	if s.config.SnapshotCache > 0 {
 		protos = append(protos, snap.MakeProtocols((*snapHandler)(s.handler), s.snapDialCandidates)...)
 	}
+	if s.handler.istanbul != nil {
+		protos = append(protos, s.handler.makeIstanbulProtocol())
+	}
 	return protos
 }
 
@@ -403,6 +495,7 @@ func (s *Ethereum) Stop() error {
 	s.handler.Stop()
 
 	// Then stop everything else.
+	s.StopMining()
 	s.bloomIndexer.Close()
 	close(s.closeBloomHandler)
 	s.txPool.Close()
@@ -202,7 +202,11 @@ func (c *SimulatedBeacon) sealBlock(withdrawals []*types.Withdrawal, timestamp u
 		}
 	}
 	// Mark the payload as canon
-	if _, err = c.engineAPI.NewPayloadV3(*payload, blobHashes, &common.Hash{}); err != nil {
+	newPayload := c.engineAPI.NewPayloadV3
+	if c.eth.BlockChain().Config().IsPrague(new(big.Int).SetUint64(payload.Number), payload.Timestamp) {
+		newPayload = c.engineAPI.NewPayloadV4
+	}
+	if _, err = newPayload(*payload, blobHashes, &common.Hash{}); err != nil {
 		return err
 	}
 	c.setCurrentState(payload.BlockHash, finalizedHash)
@@ -41,6 +41,7 @@ import (
 var (
 	MaxBlockFetch   = 128 // Number of blocks to be fetched per retrieval request
 	MaxHeaderFetch  = 192 // Number of block headers to be fetched per retrieval request
+	MaxSkeletonSize = 128 // Number of header fetches needed for a skeleton assembly
 	MaxReceiptFetch = 256 // Number of transaction receipts to allow fetching per request
 
 	maxQueuedHeaders           = 32 * 1024                        // [eth/62] Maximum number of headers to queue for import (DOS protection)
@@ -48,7 +49,8 @@ var (
 	maxResultsProcess          = 2048                             // Number of content download results to import at once into the chain
 	fullMaxForkAncestry uint64 = params.FullImmutabilityThreshold // Maximum chain reorganisation (locally redeclared so tests can reduce it)
 
-	reorgProtHeaderDelay = 2 // Number of headers to delay delivering to cover mini reorgs
+	reorgProtThreshold   = 48 // Threshold number of recent blocks to disable mini reorg protection
+	reorgProtHeaderDelay = 2  // Number of headers to delay delivering to cover mini reorgs
 
 	fsHeaderSafetyNet = 2048            // Number of headers to discard in case a chain violation is detected
 	fsHeaderContCheck = 3 * time.Second // Time interval to check for header continuations during state download
@@ -56,8 +58,14 @@ var (
 )
 
 var (
-	errBusy    = errors.New("busy")
-	errBadPeer = errors.New("action from bad peer ignored")
+	errBusy             = errors.New("busy")
+	errUnknownPeer      = errors.New("peer is unknown or unhealthy")
+	errBadPeer          = errors.New("action from bad peer ignored")
+	errStallingPeer     = errors.New("peer is stalling")
+	errEmptyHeaderSet   = errors.New("empty header set by peer")
+	errPeersUnavailable = errors.New("no peers available or all tried for download")
+	errInvalidAncestor  = errors.New("retrieved ancestor is invalid")
+	errNoAncestorFound  = errors.New("no common ancestor found")
 
 	errTimeout                 = errors.New("timeout")
 	errInvalidChain            = errors.New("retrieved hash chain is invalid")
@@ -301,7 +309,7 @@ func (d *Downloader) UnregisterPeer(id string) error {
 // synchronise will select the peer and use it for synchronising. If an empty string is given
 // it will use the best peer possible and synchronize if its TD is higher than our own. If any of the
 // checks fail an error will be returned. This method is synchronous
-func (d *Downloader) synchronise(mode SyncMode, beaconPing chan struct{}) error {
+func (d *Downloader) synchronise(id string, hash common.Hash, td *big.Int, mode SyncMode, beaconMode bool, beaconPing chan struct{}) error {
 	// The beacon header syncer is async. It will start this synchronization and
 	// will continue doing other tasks. However, if synchronization needs to be
 	// cancelled, the syncer needs to know if we reached the startup point (and
@@ -370,19 +378,28 @@ func (d *Downloader) synchronise(mode SyncMode, beaconPing chan struct{}) error
 	// Atomically set the requested sync mode
 	d.mode.Store(uint32(mode))
 
+	// Retrieve the origin peer and initiate the downloading process
+	var p *peerConnection
+	if !beaconMode { // Beacon mode doesn't need a peer to sync from
+		p = d.peers.Peer(id)
+		if p == nil {
+			return errUnknownPeer
+		}
+	}
 	if beaconPing != nil {
 		close(beaconPing)
 	}
-	return d.syncToHead()
+	return d.syncWithPeer(p, hash, td, beaconMode)
 }
 
 func (d *Downloader) getMode() SyncMode {
 	return SyncMode(d.mode.Load())
 }
 
-// syncToHead starts a block synchronization based on the hash chain from
-// the specified head hash.
-func (d *Downloader) syncToHead() (err error) {
+// syncWithPeer starts a block synchronization based on the hash chain from
+// the specified peer and head hash. In beacon mode, the peer is nil and the
+// head is taken from the skeleton chain instead.
+func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.Int, beaconMode bool) (err error) {
 	d.mux.Post(StartEvent{})
 	defer func() {
 		// reset on error
@@ -395,18 +412,31 @@ func (d *Downloader) syncToHead() (err error) {
 	}()
 	mode := d.getMode()
 
-	log.Debug("Backfilling with the network", "mode", mode)
+	if !beaconMode {
+		log.Debug("Synchronising with the network", "peer", p.id, "eth", p.version, "head", hash, "td", td, "mode", mode)
+	} else {
+		log.Debug("Backfilling with the network", "mode", mode)
+	}
 	defer func(start time.Time) {
 		log.Debug("Synchronisation terminated", "elapsed", common.PrettyDuration(time.Since(start)))
 	}(time.Now())
 
 	// Look up the sync boundaries: the common ancestor and the target block
 	var latest, pivot, final *types.Header
-	latest, _, final, err = d.skeleton.Bounds()
-	if err != nil {
-		return err
+	if !beaconMode {
+		// In legacy mode, use the master peer to retrieve the headers from
+		latest, pivot, err = d.fetchHead(p)
+		if err != nil {
+			return err
+		}
+	} else {
+		// In beacon mode, use the skeleton chain to retrieve the headers from
+		latest, _, final, err = d.skeleton.Bounds()
+		if err != nil {
+			return err
+		}
 	}
-	if latest.Number.Uint64() > uint64(fsMinFullBlocks) {
+	if beaconMode && latest.Number.Uint64() > uint64(fsMinFullBlocks) {
 		number := latest.Number.Uint64() - uint64(fsMinFullBlocks)
 
 		// Retrieve the pivot header from the skeleton chain segment but
@@ -439,10 +469,19 @@ func (d *Downloader) syncToHead() (err error) {
 	}
 	height := latest.Number.Uint64()
 
-	// In beacon mode, use the skeleton chain for the ancestor lookup
-	origin, err := d.findBeaconAncestor()
-	if err != nil {
-		return err
+	var origin uint64
+	if !beaconMode {
+		// In legacy mode, reach out to the network and find the ancestor
+		origin, err = d.findAncestor(p, latest)
+		if err != nil {
+			return err
+		}
+	} else {
+		// In beacon mode, use the skeleton chain for the ancestor lookup
+		origin, err = d.findBeaconAncestor()
+		if err != nil {
+			return err
+		}
 	}
 	d.syncStatsLock.Lock()
 	if d.syncStatsChainHeight <= origin || d.syncStatsChainOrigin > origin {
@@ -481,13 +520,8 @@ func (d *Downloader) syncToHead() (err error) {
 		// finalized block, in which case the skeleton.Bounds will return nil and
 		// we'll revert to head - 90K. That's fine, we're finishing sync anyway.
 		//
-		// For non-merged networks, if there is a checkpoint available, then calculate
-		// the ancientLimit through that. Otherwise calculate the ancient limit through
-		// the advertised height of the remote peer. This most is mostly a fallback for
-		// legacy networks, but should eventually be dropped. TODO(karalabe).
-		//
-		// Beacon sync, use the latest finalized block as the ancient limit
-		// or a reasonable height if no finalized block is yet announced.
+		// For non-beacon networks, calculate the ancient limit through the
+		// advertised height of the remote peer.
 		if final != nil {
 			d.ancientLimit = final.Number.Uint64()
 		} else if height > fullMaxForkAncestry+1 {
@@ -516,12 +550,19 @@ func (d *Downloader) syncToHead() (err error) {
 	// Initiate the sync using a concurrent header and content retrieval algorithm
 	d.queue.Prepare(origin+1, mode)
 
-	// In beacon mode, headers are served by the skeleton syncer
+	var headerFetcher func() error
+	if !beaconMode {
+		// In legacy mode, headers are retrieved from the network
+		headerFetcher = func() error { return d.fetchHeaders(p, origin+1, latest.Number.Uint64()) }
+	} else {
+		// In beacon mode, headers are served by the skeleton syncer
+		headerFetcher = func() error { return d.fetchBeaconHeaders(origin + 1) }
+	}
 	fetchers := []func() error{
-		func() error { return d.fetchHeaders(origin + 1) },  // Headers are always retrieved
-		func() error { return d.fetchBodies(origin + 1) },   // Bodies are retrieved during normal and snap sync
-		func() error { return d.fetchReceipts(origin + 1) }, // Receipts are retrieved during snap sync
-		func() error { return d.processHeaders(origin + 1) },
+		headerFetcher, // Headers are always retrieved
+		func() error { return d.fetchBodies(origin+1, beaconMode) },   // Bodies are retrieved during normal and snap sync
+		func() error { return d.fetchReceipts(origin+1, beaconMode) }, // Receipts are retrieved during snap sync
+		func() error { return d.processHeaders(origin+1, td, beaconMode) },
 	}
 	if mode == SnapSync {
 		d.pivotLock.Lock()
@@ -612,9 +653,9 @@ func (d *Downloader) Terminate() {
 // fetchBodies iteratively downloads the scheduled block bodies, taking any
 // available peers, reserving a chunk of blocks for each, waiting for delivery
 // and also periodically checking for timeouts.
-func (d *Downloader) fetchBodies(from uint64) error {
+func (d *Downloader) fetchBodies(from uint64, beaconMode bool) error {
 	log.Debug("Downloading block bodies", "origin", from)
-	err := d.concurrentFetch((*bodyQueue)(d))
+	err := d.concurrentFetch((*bodyQueue)(d), beaconMode)
 
 	log.Debug("Block body download terminated", "err", err)
 	return err
@@ -623,9 +664,9 @@ func (d *Downloader) fetchBodies(from uint64) error {
 // fetchReceipts iteratively downloads the scheduled block receipts, taking any
 // available peers, reserving a chunk of receipts for each, waiting for delivery
 // and also periodically checking for timeouts.
-func (d *Downloader) fetchReceipts(from uint64) error {
+func (d *Downloader) fetchReceipts(from uint64, beaconMode bool) error {
 	log.Debug("Downloading receipts", "origin", from)
-	err := d.concurrentFetch((*receiptQueue)(d))
+	err := d.concurrentFetch((*receiptQueue)(d), beaconMode)
 
 	log.Debug("Receipt download terminated", "err", err)
 	return err
@@ -634,16 +675,36 @@ func (d *Downloader) fetchReceipts(from uint64) error {
 // processHeaders takes batches of retrieved headers from an input channel and
 // keeps processing and scheduling them into the header chain and downloader's
 // queue until the stream ends or a failure occurs.
-func (d *Downloader) processHeaders(origin uint64) error {
+func (d *Downloader) processHeaders(origin uint64, td *big.Int, beaconMode bool) error {
+	// Keep a count of uncertain headers to roll back
 	var (
-		mode  = d.getMode()
-		timer = time.NewTimer(time.Second)
+		rollback    uint64 // Zero means no rollback (fine as you can't unroll the genesis)
+		rollbackErr error
+		mode        = d.getMode()
+		timer       = time.NewTimer(time.Second)
 	)
 	defer timer.Stop()
 
+	defer func() {
+		if rollback > 0 {
+			lastHeader, lastSnapBlock, lastBlock := d.blockchain.CurrentHeader().Number, d.blockchain.CurrentSnapBlock().Number, d.blockchain.CurrentBlock().Number
+			if err := d.blockchain.SetHead(rollback - 1); err != nil { // -1 to target the parent of the first uncertain block
+				// We're already unwinding the stack, only print the error to make it more visible
+				log.Error("Failed to roll back chain segment", "head", rollback-1, "err", err)
+			}
+			log.Warn("Rolled back chain segment",
+				"header", fmt.Sprintf("%d->%d", lastHeader, d.blockchain.CurrentHeader().Number),
+				"snap", fmt.Sprintf("%d->%d", lastSnapBlock, d.blockchain.CurrentSnapBlock().Number),
+				"block", fmt.Sprintf("%d->%d", lastBlock, d.blockchain.CurrentBlock().Number), "reason", rollbackErr)
+		}
+	}()
+	// Wait for batches of headers to process
+	gotHeaders := false
+
 	for {
 		select {
 		case <-d.cancelCh:
+			rollbackErr = errCanceled
 			return errCanceled
 
 		case task := <-d.headerProcCh:
@@ -656,15 +717,53 @@ func (d *Downloader) processHeaders(origin uint64) error {
 					case <-d.cancelCh:
 					}
 				}
+				// If we're in legacy sync mode, we need to check total difficulty
+				// violations from malicious peers. That is not needed in beacon
+				// mode and we can skip to terminating sync.
+				if !beaconMode {
+					// If no headers were retrieved at all, the peer violated its TD promise that it had a
+					// better chain compared to ours. The only exception is if its promised blocks were
+					// already imported by other means (e.g. fetcher):
+					//
+					// R <remote peer>, L <local node>: Both at block 10
+					// R: Mine block 11, and propagate it to L
+					// L: Queue block 11 for import
+					// L: Notice that R's head and TD increased compared to ours, start sync
+					// L: Import of block 11 finishes
+					// L: Sync begins, and finds common ancestor at 11
+					// L: Request new headers up from 11 (R's TD was higher, it must have something)
+					// R: Nothing to give
+					head := d.blockchain.CurrentBlock()
+					if !gotHeaders && td.Cmp(d.blockchain.GetTd(head.Hash(), head.Number.Uint64())) > 0 {
+						return errStallingPeer
+					}
+					// If snap syncing, ensure promised headers are indeed delivered. This is
+					// needed to detect scenarios where an attacker feeds a bad pivot and then bails out
+					// of delivering the post-pivot blocks that would flag the invalid content.
+					//
+					// This check cannot be executed "as is" for full imports, since blocks may still be
+					// queued for processing when the header download completes. However, as long as the
+					// peer gave us something useful, we're already happy/progressed (above check).
+					if mode == SnapSync {
+						head := d.blockchain.CurrentHeader()
+						if td.Cmp(d.blockchain.GetTd(head.Hash(), head.Number.Uint64())) > 0 {
+							return errStallingPeer
+						}
+					}
+				}
+				// Disable any rollback and return
+				rollback = 0
 				return nil
 			}
 			// Otherwise split the chunk of headers into batches and process them
 			headers, hashes := task.headers, task.hashes
 
+			gotHeaders = true
 			for len(headers) > 0 {
 				// Terminate if something failed in between processing chunks
 				select {
 				case <-d.cancelCh:
+					rollbackErr = errCanceled
 					return errCanceled
 				default:
 				}
@@ -683,9 +782,25 @@ func (d *Downloader) processHeaders(origin uint64) error {
 					// that any transition is rejected at this point.
 					if len(chunkHeaders) > 0 {
 						if n, err := d.blockchain.InsertHeaderChain(chunkHeaders); err != nil {
+							rollbackErr = err
+
+							// If some headers were inserted, track them as uncertain
+							if !beaconMode && n > 0 && rollback == 0 {
+								rollback = chunkHeaders[0].Number.Uint64()
+							}
 							log.Warn("Invalid header encountered", "number", chunkHeaders[n].Number, "hash", chunkHashes[n], "parent", chunkHeaders[n].ParentHash, "err", err)
 							return fmt.Errorf("%w: %v", errInvalidChain, err)
 						}
+						// All verifications passed, track all headers within the allowed
+						// limits. Headers served by the beacon chain are trusted.
+						if !beaconMode {
+							head := chunkHeaders[len(chunkHeaders)-1].Number.Uint64()
+							if head-rollback > uint64(fsHeaderSafetyNet) {
+								rollback = head - uint64(fsHeaderSafetyNet)
+							} else {
+								rollback = 1
+							}
+						}
 					}
 				}
 				// If we've reached the allowed number of pending headers, stall a bit
@@ -693,6 +808,7 @@ func (d *Downloader) processHeaders(origin uint64) error {
 					timer.Reset(time.Second)
 					select {
 					case <-d.cancelCh:
+						rollbackErr = errCanceled
 						return errCanceled
 					case <-timer.C:
 					}
@@ -700,6 +816,7 @@ func (d *Downloader) processHeaders(origin uint64) error {
 				// Otherwise insert the headers for content retrieval
 				inserts := d.queue.Schedule(chunkHeaders, chunkHashes, origin)
 				if len(inserts) != len(chunkHeaders) {
+					rollbackErr = fmt.Errorf("stale headers: len inserts %v len(chunk) %v", len(inserts), len(chunkHeaders))
 					return fmt.Errorf("%w: stale headers", errBadPeer)
 				}
 
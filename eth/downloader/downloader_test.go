@@ -230,6 +230,7 @@ func (dlp *downloadTesterPeer) RequestBodies(hashes []common.Hash, sink chan *et
 		txsHashes        = make([]common.Hash, len(bodies))
 		uncleHashes      = make([]common.Hash, len(bodies))
 		withdrawalHashes = make([]common.Hash, len(bodies))
+		requestsHashes   = make([]common.Hash, len(bodies))
 	)
 	hasher := trie.NewStackTrie(nil)
 	for i, body := range bodies {
@@ -248,7 +249,7 @@ func (dlp *downloadTesterPeer) RequestBodies(hashes []common.Hash, sink chan *et
 	res := &eth.Response{
 		Req:  req,
 		Res:  (*eth.BlockBodiesResponse)(&bodies),
-		Meta: [][]common.Hash{txsHashes, uncleHashes, withdrawalHashes},
+		Meta: [][]common.Hash{txsHashes, uncleHashes, withdrawalHashes, requestsHashes},
 		Time: 1,
 		Done: make(chan error, 1), // Ignore the returned status
 	}
@@ -685,6 +686,70 @@ func testBeaconSync(t *testing.T, protocol uint, mode SyncMode) {
 	}
 }
 
+// Tests that a chain can be synchronised header-first from the peer announcing
+// it, without a beacon client driving the sync.
+func TestLegacySync68Full(t *testing.T) { testLegacySync(t, eth.ETH68, FullSync) }
+func TestLegacySync68Snap(t *testing.T) { testLegacySync(t, eth.ETH68, SnapSync) }
+
+func testLegacySync(t *testing.T, protocol uint, mode SyncMode) {
+	var cases = []struct {
+		name  string // The name of testing scenario
+		local int    // The length of local chain(canonical chain assumed), 0 means genesis is the head
+	}{
+		{name: "Legacy sync since genesis", local: 0},
+		{name: "Legacy sync with short local chain", local: 1},
+		{name: "Legacy sync with long local chain", local: blockCacheMaxItems - 15 - fsMinFullBlocks/2},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tester := newTester(t)
+			defer tester.terminate()
+
+			chain := testChainBase.shorten(blockCacheMaxItems - 15)
+			tester.newPeer("peer", protocol, chain.blocks[1:])
+
+			// Build the local chain segment if it's required
+			if c.local > 0 {
+				tester.chain.InsertChain(chain.blocks[1 : c.local+1])
+			}
+			head, td := tester.peers["peer"].Head()
+			if err := tester.downloader.LegacySync("peer", head, td, mode); err != nil {
+				t.Fatalf("failed to synchronise chain: %v", err)
+			}
+			assertOwnChain(t, tester, len(chain.blocks))
+		})
+	}
+}
+
+// Tests that a node switches over to a heavier fork announced by another peer,
+// locating the common ancestor with its local chain.
+func TestLegacyForkedSync68Full(t *testing.T) { testLegacyForkedSync(t, eth.ETH68, FullSync) }
+func TestLegacyForkedSync68Snap(t *testing.T) { testLegacyForkedSync(t, eth.ETH68, SnapSync) }
+
+func testLegacyForkedSync(t *testing.T, protocol uint, mode SyncMode) {
+	tester := newTester(t)
+	defer tester.terminate()
+
+	chainA := testChainForkLightA.shorten(len(testChainBase.blocks) + 80)
+	chainB := testChainForkLightB.shorten(len(testChainBase.blocks) + 81)
+	tester.newPeer("fork A", protocol, chainA.blocks[1:])
+	tester.newPeer("fork B", protocol, chainB.blocks[1:])
+
+	// Synchronise with the peer and make sure all blocks were retrieved
+	head, td := tester.peers["fork A"].Head()
+	if err := tester.downloader.LegacySync("fork A", head, td, mode); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	assertOwnChain(t, tester, len(chainA.blocks))
+
+	// Synchronise with the second peer and make sure that fork is pulled too
+	head, td = tester.peers["fork B"].Head()
+	if err := tester.downloader.LegacySync("fork B", head, td, mode); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	assertOwnChain(t, tester, len(chainB.blocks))
+}
+
 // Tests that synchronisation progress (origin block number, current block number
 // and highest block number) is tracked and updated correctly.
 func TestSyncProgress68Full(t *testing.T) { testSyncProgress(t, eth.ETH68, FullSync) }
@@ -76,7 +76,7 @@ type typedQueue interface {
 // concurrentFetch iteratively downloads scheduled block parts, taking available
 // peers, reserving a chunk of fetch requests for each and waiting for delivery
 // or timeouts.
-func (d *Downloader) concurrentFetch(queue typedQueue) error {
+func (d *Downloader) concurrentFetch(queue typedQueue, beaconMode bool) error {
 	// Create a delivery channel to accept responses from all peers
 	responses := make(chan *eth.Response)
 
@@ -154,7 +154,11 @@ func (d *Downloader) concurrentFetch(queue typedQueue) error {
 			}
 			sort.Sort(&peerCapacitySort{idles, caps})
 
-			var throttled bool
+			var (
+				progressed bool
+				throttled  bool
+				queued     = queue.pending()
+			)
 			for _, peer := range idles {
 				// Short circuit if throttling activated or there are no more
 				// queued tasks to be retrieved
@@ -167,7 +171,10 @@ func (d *Downloader) concurrentFetch(queue typedQueue) error {
 				// Reserve a chunk of fetches for a peer. A nil can mean either that
 				// no more headers are available, or that the peer is known not to
 				// have them.
-				request, _, throttle := queue.reserve(peer, queue.capacity(peer, d.peers.rates.TargetRoundTrip()))
+				request, progress, throttle := queue.reserve(peer, queue.capacity(peer, d.peers.rates.TargetRoundTrip()))
+				if progress {
+					progressed = true
+				}
 				if throttle {
 					throttled = true
 					throttleCounter.Inc(1)
@@ -196,6 +203,12 @@ func (d *Downloader) concurrentFetch(queue typedQueue) error {
 					timeout.Reset(ttl)
 				}
 			}
+			// Make sure that we have peers available for fetching. If all peers have been tried
+			// and all failed throw an error. In beacon mode, the skeleton syncer is responsible
+			// for keeping peers around, so only do this in legacy mode.
+			if !progressed && !throttled && len(pending) == 0 && len(idles) == d.peers.Len() && queued > 0 && !beaconMode {
+				return errPeersUnavailable
+			}
 		}
 		// Wait for something to happen
 		select {
@@ -70,6 +70,7 @@ type fetchResult struct {
 	Transactions types.Transactions
 	Receipts     types.Receipts
 	Withdrawals  types.Withdrawals
+	Requests     types.Requests
 }
 
 func newFetchResult(header *types.Header, fastSync bool) *fetchResult {
@@ -78,8 +79,13 @@ func newFetchResult(header *types.Header, fastSync bool) *fetchResult {
 	}
 	if !header.EmptyBody() {
 		item.pending.Store(item.pending.Load() | (1 << bodyType))
-	} else if header.WithdrawalsHash != nil {
-		item.Withdrawals = make(types.Withdrawals, 0)
+	} else {
+		if header.WithdrawalsHash != nil {
+			item.Withdrawals = make(types.Withdrawals, 0)
+		}
+		if header.RequestsHash != nil {
+			item.Requests = make(types.Requests, 0)
+		}
 	}
 	if fastSync && !header.EmptyReceipts() {
 		item.pending.Store(item.pending.Load() | (1 << receiptType))
@@ -93,6 +99,7 @@ func (f *fetchResult) body() types.Body {
 		Transactions: f.Transactions,
 		Uncles:       f.Uncles,
 		Withdrawals:  f.Withdrawals,
+		Requests:     f.Requests,
 	}
 }
 
@@ -783,7 +790,8 @@ func (q *queue) DeliverHeaders(id string, headers []*types.Header, hashes []comm
 // also wakes any threads waiting for data delivery.
 func (q *queue) DeliverBodies(id string, txLists [][]*types.Transaction, txListHashes []common.Hash,
 	uncleLists [][]*types.Header, uncleListHashes []common.Hash,
-	withdrawalLists [][]*types.Withdrawal, withdrawalListHashes []common.Hash) (int, error) {
+	withdrawalLists [][]*types.Withdrawal, withdrawalListHashes []common.Hash,
+	requestsLists [][]*types.Request, requestsListHashes []common.Hash) (int, error) {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
@@ -807,6 +815,19 @@ func (q *queue) DeliverBodies(id string, txLists [][]*types.Transaction, txListH
 				return errInvalidBody
 			}
 		}
+		if header.RequestsHash == nil {
+			// nil hash means that requests should not be present in body
+			if requestsLists[index] != nil {
+				return errInvalidBody
+			}
+		} else { // non-nil hash: body must have requests
+			if requestsLists[index] == nil {
+				return errInvalidBody
+			}
+			if requestsListHashes[index] != *header.RequestsHash {
+				return errInvalidBody
+			}
+		}
 		// Blocks must have a number of blobs corresponding to the header gas usage,
 		// and zero before the Cancun hardfork.
 		var blobs int
@@ -845,6 +866,7 @@ func (q *queue) DeliverBodies(id string, txLists [][]*types.Transaction, txListH
 		result.Transactions = txLists[index]
 		result.Uncles = uncleLists[index]
 		result.Withdrawals = withdrawalLists[index]
+		result.Requests = requestsLists[index]
 		result.SetBodyDone()
 	}
 	return q.deliver(id, q.blockTaskPool, q.blockTaskQueue, q.blockPendPool,
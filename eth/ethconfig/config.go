@@ -26,6 +26,8 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/beacon"
 	"github.com/ethereum/go-ethereum/consensus/clique"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	istanbulBackend "github.com/ethereum/go-ethereum/consensus/istanbul/backend"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/txpool/blobpool"
 	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
@@ -53,6 +55,7 @@ var Defaults = Config{
 	TxLookupLimit:      2350000,
 	TransactionHistory: 2350000,
 	StateHistory:       params.FullImmutabilityThreshold,
+	OnlinePruningRate:  10000,
 	LightPeers:         100,
 	DatabaseCache:      512,
 	TrieCleanCache:     154,
@@ -100,6 +103,18 @@ type Config struct {
 	// consistent with persistent state.
 	StateScheme string `toml:",omitempty"`
 
+	// HistoryExpiry is the block number below which ancient block bodies and
+	// receipts are dropped from the database and served from the era files in
+	// HistoryDir instead. Zero disables history expiry.
+	HistoryExpiry uint64 `toml:",omitempty"`
+	HistoryDir    string `toml:",omitempty"`
+
+	// OnlinePruning enables the background garbage collection of stale state,
+	// deleting at most OnlinePruningRate entries per second. It's only supported
+	// by the hash scheme.
+	OnlinePruning     bool `toml:",omitempty"`
+	OnlinePruningRate int  `toml:",omitempty"`
+
 	// RequiredBlocks is a set of block number -> hash mappings which must be in the
 	// canonical chain of all remote peers. Setting the option makes geth verify the
 	// presence of these blocks for every new peer connection.
@@ -144,6 +159,9 @@ type Config struct {
 	// Enables prefetching trie nodes for read operations too
 	EnableWitnessCollection bool `toml:"-"`
 
+	// Enables optimistic parallel transaction execution
+	EnableParallelExecution bool `toml:"-"`
+
 	// Enables VM tracing
 	VMTrace           string
 	VMTraceJsonConfig string
@@ -169,10 +187,20 @@ type Config struct {
 }
 
 // CreateConsensusEngine creates a consensus engine for the given chain config.
-// Clique is allowed for now to live standalone, but ethash is forbidden and can
-// only exist on already merged networks.
+// Clique is allowed for now to live standalone, QBFT networks run standalone
+// by design, but ethash is forbidden and can only exist on already merged networks.
 func CreateConsensusEngine(config *params.ChainConfig, db ethdb.Database) (consensus.Engine, error) {
-	// Geth v1.14.0 dropped support for non-merged networks in any consensus
+	// QBFT networks finalize blocks themselves, without a beacon client driving
+	// them. The validator key is injected once sealing is started.
+	if config.QBFT != nil {
+		return istanbulBackend.New(istanbul.NewConfig(config), nil, db), nil
+	}
+	// Clique networks which never merged seal blocks themselves too, and are
+	// synced from the peers like QBFT ones.
+	if config.Clique != nil && !config.TerminalTotalDifficultyPassed {
+		return clique.New(config.Clique, db), nil
+	}
+	// Geth v1.14.0 dropped support for non-merged networks in any other consensus
 	// mode. If such a network is requested, reject startup.
 	if !config.TerminalTotalDifficultyPassed {
 		return nil, errors.New("only PoS networks are supported, please transition old ones with Geth v1.13.x")
@@ -28,6 +28,10 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		TransactionHistory      uint64                 `toml:",omitempty"`
 		StateHistory            uint64                 `toml:",omitempty"`
 		StateScheme             string                 `toml:",omitempty"`
+		HistoryExpiry           uint64                 `toml:",omitempty"`
+		HistoryDir              string                 `toml:",omitempty"`
+		OnlinePruning           bool                   `toml:",omitempty"`
+		OnlinePruningRate       int                    `toml:",omitempty"`
 		RequiredBlocks          map[uint64]common.Hash `toml:"-"`
 		LightServ               int                    `toml:",omitempty"`
 		LightIngress            int                    `toml:",omitempty"`
@@ -51,6 +55,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		GPO                     gasprice.Config
 		EnablePreimageRecording bool
 		EnableWitnessCollection bool `toml:"-"`
+		EnableParallelExecution bool `toml:"-"`
 		VMTrace                 string
 		VMTraceJsonConfig       string
 		DocRoot                 string `toml:"-"`
@@ -72,6 +77,10 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.TransactionHistory = c.TransactionHistory
 	enc.StateHistory = c.StateHistory
 	enc.StateScheme = c.StateScheme
+	enc.HistoryExpiry = c.HistoryExpiry
+	enc.HistoryDir = c.HistoryDir
+	enc.OnlinePruning = c.OnlinePruning
+	enc.OnlinePruningRate = c.OnlinePruningRate
 	enc.RequiredBlocks = c.RequiredBlocks
 	enc.LightServ = c.LightServ
 	enc.LightIngress = c.LightIngress
@@ -95,6 +104,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.GPO = c.GPO
 	enc.EnablePreimageRecording = c.EnablePreimageRecording
 	enc.EnableWitnessCollection = c.EnableWitnessCollection
+	enc.EnableParallelExecution = c.EnableParallelExecution
 	enc.VMTrace = c.VMTrace
 	enc.VMTraceJsonConfig = c.VMTraceJsonConfig
 	enc.DocRoot = c.DocRoot
@@ -120,6 +130,10 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		TransactionHistory      *uint64                `toml:",omitempty"`
 		StateHistory            *uint64                `toml:",omitempty"`
 		StateScheme             *string                `toml:",omitempty"`
+		HistoryExpiry           *uint64                `toml:",omitempty"`
+		HistoryDir              *string                `toml:",omitempty"`
+		OnlinePruning           *bool                  `toml:",omitempty"`
+		OnlinePruningRate       *int                   `toml:",omitempty"`
 		RequiredBlocks          map[uint64]common.Hash `toml:"-"`
 		LightServ               *int                   `toml:",omitempty"`
 		LightIngress            *int                   `toml:",omitempty"`
@@ -143,6 +157,7 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		GPO                     *gasprice.Config
 		EnablePreimageRecording *bool
 		EnableWitnessCollection *bool `toml:"-"`
+		EnableParallelExecution *bool `toml:"-"`
 		VMTrace                 *string
 		VMTraceJsonConfig       *string
 		DocRoot                 *string `toml:"-"`
@@ -189,6 +204,18 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.StateScheme != nil {
 		c.StateScheme = *dec.StateScheme
 	}
+	if dec.HistoryExpiry != nil {
+		c.HistoryExpiry = *dec.HistoryExpiry
+	}
+	if dec.HistoryDir != nil {
+		c.HistoryDir = *dec.HistoryDir
+	}
+	if dec.OnlinePruning != nil {
+		c.OnlinePruning = *dec.OnlinePruning
+	}
+	if dec.OnlinePruningRate != nil {
+		c.OnlinePruningRate = *dec.OnlinePruningRate
+	}
 	if dec.RequiredBlocks != nil {
 		c.RequiredBlocks = dec.RequiredBlocks
 	}
@@ -258,6 +285,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.EnableWitnessCollection != nil {
 		c.EnableWitnessCollection = *dec.EnableWitnessCollection
 	}
+	if dec.EnableParallelExecution != nil {
+		c.EnableParallelExecution = *dec.EnableParallelExecution
+	}
 	if dec.VMTrace != nil {
 		c.VMTrace = *dec.VMTrace
 	}
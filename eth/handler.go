@@ -25,6 +25,8 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/forkid"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -53,6 +55,9 @@ const (
 	// All transactions with a higher size will be announced and need to be fetched
 	// by the peer.
 	txMaxBroadcastSize = 4096
+
+	// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
+	chainHeadChanSize = 10
 )
 
 var syncChallengeTimeout = 15 * time.Second // Time allowance for a node to reply to the sync progress challenge
@@ -87,6 +92,7 @@ type handlerConfig struct {
 	NodeID         enode.ID               // P2P node ID used for tx propagation topology
 	Database       ethdb.Database         // Database for direct sync insertions
 	Chain          *core.BlockChain       // Blockchain to serve data from
+	Engine         consensus.Engine       // Consensus engine, handling its own messages if a consensus.Handler
 	TxPool         txPool                 // Transaction pool to propagate from
 	Network        uint64                 // Network identifier to advertise
 	Sync           downloader.SyncMode    // Whether to snap or full sync
@@ -109,6 +115,7 @@ type handler struct {
 	maxPeers int
 
 	downloader *downloader.Downloader
+	chainSync  *chainSyncer // Peer driven sync for engines not following a beacon client
 	txFetcher  *fetcher.TxFetcher
 	peers      *peerSet
 
@@ -118,6 +125,15 @@ type handler struct {
 
 	requiredBlocks map[uint64]common.Hash
 
+	// QBFT consensus protocol and block propagation, nil for beacon-driven networks
+	istanbul         consensus.Handler
+	istanbulPeers    map[string]*istanbulPeer
+	istanbulLock     sync.RWMutex
+	istanbulSelf     atomic.Pointer[istanbulStatusPacket] // Signed status of the local validator, if announced
+	istanbulImportCh chan *istanbulBlock
+	istanbulHeadCh   chan core.ChainHeadEvent
+	istanbulHeadSub  event.Subscription
+
 	// channels for fetcher, syncer, txsyncLoop
 	quitSync chan struct{}
 
@@ -193,6 +209,20 @@ func newHandler(config *handlerConfig) (*handler, error) {
 		return h.txpool.Add(txs, false, false)
 	}
 	h.txFetcher = fetcher.NewTxFetcher(h.txpool.Has, addTxs, fetchTx, h.removePeer)
+
+	// Consensus engines exchanging their own messages sync and propagate the
+	// blocks themselves instead of following a beacon client.
+	if istanbul, ok := config.Engine.(consensus.Handler); ok {
+		h.istanbul = istanbul
+		h.istanbulPeers = make(map[string]*istanbulPeer)
+		h.istanbulImportCh = make(chan *istanbulBlock, istanbulImportChanSize)
+		istanbul.SetBroadcaster(h)
+	}
+	// Engines not following a beacon client sync from the peers with the
+	// highest total difficulty.
+	if _, ok := config.Engine.(*beacon.Beacon); config.Engine != nil && !ok {
+		h.chainSync = newChainSyncer(h)
+	}
 	return h, nil
 }
 
@@ -302,6 +332,9 @@ func (h *handler) runEthPeer(peer *eth.Peer, handler eth.Handler) error {
 			return err
 		}
 	}
+	if h.chainSync != nil {
+		h.chainSync.handlePeerEvent()
+	}
 	// Propagate existing transactions. new transactions appearing
 	// after this will be sent via broadcasts.
 	h.syncTransactions(peer)
@@ -434,10 +467,34 @@ func (h *handler) Start(maxPeers int) {
 	// start peer handler tracker
 	h.wg.Add(1)
 	go h.protoTracker()
+
+	// start the peer driven chain sync if the engine isn't beacon-driven
+	if h.chainSync != nil {
+		h.wg.Add(1)
+		go h.chainSync.loop()
+	}
+
+	// start block propagation and sync of the consensus protocol
+	if h.istanbul != nil {
+		h.istanbulHeadCh = make(chan core.ChainHeadEvent, chainHeadChanSize)
+		h.istanbulHeadSub = h.chain.SubscribeChainHeadEvent(h.istanbulHeadCh)
+
+		h.wg.Add(2)
+		go h.istanbulBroadcastLoop()
+		go h.istanbulImportLoop()
+
+		if auditor, ok := h.istanbul.(validatorAuditor); ok {
+			h.wg.Add(1)
+			go h.istanbulAuditLoop(auditor)
+		}
+	}
 }
 
 func (h *handler) Stop() {
 	h.txsSub.Unsubscribe() // quits txBroadcastLoop
+	if h.istanbulHeadSub != nil {
+		h.istanbulHeadSub.Unsubscribe() // quits istanbulBroadcastLoop
+	}
 	h.txFetcher.Stop()
 	h.downloader.Terminate()
 
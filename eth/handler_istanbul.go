@@ -0,0 +1,421 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	// istanbulStatusMsg announces the validator address of a node validating with
+	// another key than its node key. It is sent on connect and whenever the node
+	// starts validating.
+	istanbulStatusMsg = 0x00
+
+	// istanbulNewBlockMsg propagates a committed block on the consensus protocol.
+	// It shares the eth code, as the consensus engine inspects it too.
+	istanbulNewBlockMsg = eth.NewBlockMsg
+
+	maxKnownIstanbulBlocks = 1024 // Maximum block hashes to keep in the known list per peer
+	istanbulImportChanSize = 64   // Size of the queue of blocks waiting for import
+)
+
+var (
+	errIstanbulBadStatus  = errors.New("invalid validator signature in consensus status")
+	errIstanbulUnexpected = errors.New("unexpected consensus protocol message")
+)
+
+// istanbulSigner is implemented by consensus engines able to prove the ownership
+// of their validator address to the peers.
+type istanbulSigner interface {
+	Address() common.Address
+	Sign(data []byte) ([]byte, error)
+}
+
+// istanbulStatusPacket is the network packet announcing the validator address of
+// a node, signed over its node ID to prevent impersonation.
+type istanbulStatusPacket struct {
+	Validator common.Address
+	Signature []byte
+}
+
+// istanbulBlock is a block waiting for import, along with the peer which sent it.
+type istanbulBlock struct {
+	peer  string
+	block *types.Block
+}
+
+// istanbulPeer is a peer connected on the consensus sub-protocol. It implements
+// consensus.Peer to let the engine send messages to the validators.
+//
+// Like GoQuorum and Besu, peers are identified by the address of their node key,
+// unless they announce another validator address in a status message.
+type istanbulPeer struct {
+	*p2p.Peer
+	rw p2p.MsgReadWriter
+
+	nodeKey     common.Address // Address of the node key
+	validator   common.Address // Validator address announced by the peer, if any
+	lock        sync.RWMutex   // Protects the validator address
+	knownBlocks *lru.Cache[common.Hash, struct{}]
+}
+
+func newIstanbulPeer(p *p2p.Peer, rw p2p.MsgReadWriter) *istanbulPeer {
+	var address common.Address
+	if pubkey := p.Node().Pubkey(); pubkey != nil {
+		address = crypto.PubkeyToAddress(*pubkey)
+	}
+	return &istanbulPeer{
+		Peer:        p,
+		rw:          rw,
+		nodeKey:     address,
+		knownBlocks: lru.NewCache[common.Hash, struct{}](maxKnownIstanbulBlocks),
+	}
+}
+
+// address returns the validator address announced by the peer, or the address of
+// its node key if it announced none.
+func (p *istanbulPeer) address() common.Address {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if p.validator != (common.Address{}) {
+		return p.validator
+	}
+	return p.nodeKey
+}
+
+// Send implements consensus.Peer, sending a message on the consensus protocol.
+func (p *istanbulPeer) Send(msgcode uint64, data interface{}) error {
+	return p2p.Send(p.rw, msgcode, data)
+}
+
+// SendConsensus implements consensus.Peer, sending a message on the consensus
+// protocol.
+func (p *istanbulPeer) SendConsensus(msgcode uint64, data interface{}) error {
+	return p2p.Send(p.rw, msgcode, data)
+}
+
+// SendQBFTConsensus implements consensus.Peer, sending an already encoded QBFT
+// message on the consensus protocol.
+func (p *istanbulPeer) SendQBFTConsensus(msgcode uint64, payload []byte) error {
+	return p.rw.WriteMsg(p2p.Msg{Code: msgcode, Size: uint32(len(payload)), Payload: bytes.NewReader(payload)})
+}
+
+// makeIstanbulProtocol creates the devp2p sub-protocol the consensus messages
+// and the committed blocks are exchanged on.
+func (h *handler) makeIstanbulProtocol() p2p.Protocol {
+	proto := h.istanbul.Protocol()
+	version := proto.Versions[0]
+
+	return p2p.Protocol{
+		Name:    proto.Name,
+		Version: version,
+		Length:  proto.Lengths[version],
+		Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+			return h.runIstanbulPeer(newIstanbulPeer(p, rw))
+		},
+	}
+}
+
+// istanbulStatusData returns the data a validator signs to prove the ownership
+// of its address on the connections of the node with the given ID. It's a two
+// item list like the consensus messages, so remote signers can tell it apart
+// from headers and transactions.
+func istanbulStatusData(id enode.ID) []byte {
+	blob, _ := rlp.EncodeToBytes([]interface{}{uint64(istanbulStatusMsg), id})
+	return blob
+}
+
+// readIstanbulStatus decodes a status packet, checking the announced validator
+// address against the signature over the node ID of the peer.
+func (h *handler) readIstanbulStatus(peer *istanbulPeer, msg p2p.Msg) error {
+	var status istanbulStatusPacket
+	if err := msg.Decode(&status); err != nil {
+		return fmt.Errorf("%w: %v", errIstanbulBadStatus, err)
+	}
+	signer, err := istanbul.GetSignatureAddress(istanbulStatusData(peer.ID()), status.Signature)
+	if err != nil || signer != status.Validator {
+		return errIstanbulBadStatus
+	}
+	peer.lock.Lock()
+	peer.validator = status.Validator
+	peer.lock.Unlock()
+
+	peer.Log().Debug("Consensus peer announced validator", "validator", status.Validator)
+	return nil
+}
+
+// announceValidator signs the status with the local validator, failing early on
+// locked accounts or signers rejecting the requests. If the validator address
+// differs from the given node key, the status is sent to all consensus peers and
+// on every new connection, as they can't identify the validator otherwise.
+func (h *handler) announceValidator(nodeKey common.Address) error {
+	signer, ok := h.istanbul.(istanbulSigner)
+	if !ok || signer.Address() == (common.Address{}) {
+		return errors.New("consensus engine has no validator")
+	}
+	sig, err := signer.Sign(istanbulStatusData(h.nodeID))
+	if err != nil {
+		return err
+	}
+	if signer.Address() == nodeKey {
+		h.istanbulSelf.Store(nil)
+		return nil
+	}
+	status := &istanbulStatusPacket{Validator: signer.Address(), Signature: sig}
+	h.istanbulSelf.Store(status)
+
+	h.istanbulLock.RLock()
+	defer h.istanbulLock.RUnlock()
+
+	for _, peer := range h.istanbulPeers {
+		go peer.Send(istanbulStatusMsg, status)
+	}
+	return nil
+}
+
+// runIstanbulPeer announces the local validator to a consensus peer if needed,
+// then dispatches its messages until the connection is torn down. The eth
+// protocol running alongside takes care of the chain checks.
+func (h *handler) runIstanbulPeer(peer *istanbulPeer) error {
+	if !h.incHandlers() {
+		return p2p.DiscQuitting
+	}
+	defer h.decHandlers()
+
+	peer.Log().Debug("Consensus peer connected", "nodekey", peer.nodeKey)
+
+	h.istanbulLock.Lock()
+	h.istanbulPeers[peer.ID().String()] = peer
+	status := h.istanbulSelf.Load()
+	h.istanbulLock.Unlock()
+
+	if status != nil {
+		go peer.Send(istanbulStatusMsg, status)
+	}
+
+	defer func() {
+		h.istanbulLock.Lock()
+		delete(h.istanbulPeers, peer.ID().String())
+		h.istanbulLock.Unlock()
+	}()
+	for {
+		if err := h.handleIstanbulMsg(peer); err != nil {
+			peer.Log().Debug("Consensus message handling failed", "err", err)
+			return err
+		}
+	}
+}
+
+// handleIstanbulMsg reads the next message from a consensus peer and hands it
+// to the consensus engine, or to the block importer for propagated blocks.
+func (h *handler) handleIstanbulMsg(peer *istanbulPeer) error {
+	msg, err := peer.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	defer msg.Discard()
+
+	handled, err := h.istanbul.HandleMsg(peer.address(), msg)
+	if handled {
+		if err != nil && !errors.Is(err, istanbul.ErrStoppedEngine) {
+			return err
+		}
+		return nil
+	}
+	switch msg.Code {
+	case istanbulStatusMsg:
+		return h.readIstanbulStatus(peer, msg)
+
+	case istanbulNewBlockMsg:
+		var packet eth.NewBlockPacket
+		if err := msg.Decode(&packet); err != nil {
+			return fmt.Errorf("%w: %v", errIstanbulUnexpected, err)
+		}
+		if err := packet.Block.SanityCheck(); err != nil {
+			return err
+		}
+		block := packet.Block
+		peer.knownBlocks.Add(block.Hash(), struct{}{})
+
+		// Track the head of the peer to sync from it if we fall behind
+		if p := h.peers.peer(peer.ID().String()); p != nil && packet.TD != nil {
+			if _, td := p.Head(); packet.TD.Cmp(td) > 0 {
+				p.SetHead(block.Hash(), packet.TD)
+				if h.chainSync != nil {
+					h.chainSync.handlePeerEvent()
+				}
+			}
+		}
+		select {
+		case h.istanbulImportCh <- &istanbulBlock{peer: peer.ID().String(), block: block}:
+		case <-h.quitSync:
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("%w: code %d", errIstanbulUnexpected, msg.Code)
+	}
+}
+
+// Enqueue implements consensus.Broadcaster, scheduling a block committed by the
+// consensus engine for import.
+func (h *handler) Enqueue(id string, block *types.Block) {
+	select {
+	case h.istanbulImportCh <- &istanbulBlock{peer: id, block: block}:
+	case <-h.quitSync:
+	}
+}
+
+// FindPeers implements consensus.Broadcaster, retrieving the connected peers
+// validating with one of the given addresses.
+func (h *handler) FindPeers(targets map[common.Address]bool) map[common.Address]consensus.Peer {
+	h.istanbulLock.RLock()
+	defer h.istanbulLock.RUnlock()
+
+	peers := make(map[common.Address]consensus.Peer)
+	for _, peer := range h.istanbulPeers {
+		if addr := peer.address(); targets[addr] {
+			peers[addr] = peer
+		}
+	}
+	return peers
+}
+
+// istanbulBroadcastLoop notifies the consensus engine about new chain heads and
+// propagates the new head blocks to the consensus peers.
+func (h *handler) istanbulBroadcastLoop() {
+	defer h.wg.Done()
+
+	for {
+		select {
+		case ev := <-h.istanbulHeadCh:
+			if err := h.istanbul.NewChainHead(); err != nil && !errors.Is(err, istanbul.ErrStoppedEngine) {
+				log.Warn("Failed to notify consensus engine of new head", "err", err)
+			}
+			h.broadcastIstanbulBlock(ev.Block)
+
+		case <-h.istanbulHeadSub.Err():
+			return
+		}
+	}
+}
+
+// validatorAuditor is implemented by consensus engines keeping an audit log of
+// the validator changes applied by the canonical chain.
+type validatorAuditor interface {
+	RecordValidatorChanges(chain consensus.ChainHeaderReader, head *types.Header) error
+}
+
+// istanbulAuditLoop records the validator changes of the blocks becoming
+// canonical in the audit log of the consensus engine.
+func (h *handler) istanbulAuditLoop(auditor validatorAuditor) {
+	defer h.wg.Done()
+
+	headCh := make(chan core.ChainHeadEvent, chainHeadChanSize)
+	headSub := h.chain.SubscribeChainHeadEvent(headCh)
+	defer headSub.Unsubscribe()
+
+	record := func(head *types.Header) {
+		if err := auditor.RecordValidatorChanges(h.chain, head); err != nil {
+			log.Warn("Failed to record validator changes", "number", head.Number, "hash", head.Hash(), "err", err)
+		}
+	}
+	record(h.chain.CurrentHeader())
+	for {
+		select {
+		case ev := <-headCh:
+			record(ev.Block.Header())
+		case <-headSub.Err():
+			return
+		case <-h.quitSync:
+			return
+		}
+	}
+}
+
+// broadcastIstanbulBlock sends a block to all consensus peers not yet knowing it.
+func (h *handler) broadcastIstanbulBlock(block *types.Block) {
+	var (
+		hash = block.Hash()
+		td   = h.chain.GetTd(hash, block.NumberU64())
+	)
+	if td == nil {
+		return
+	}
+	h.istanbulLock.RLock()
+	defer h.istanbulLock.RUnlock()
+
+	for _, peer := range h.istanbulPeers {
+		if peer.knownBlocks.Contains(hash) {
+			continue
+		}
+		peer.knownBlocks.Add(hash, struct{}{})
+		go peer.Send(istanbulNewBlockMsg, &eth.NewBlockPacket{Block: block, TD: td})
+	}
+	log.Trace("Propagated block", "hash", hash, "number", block.Number())
+}
+
+// istanbulImportLoop imports the committed and propagated blocks. Blocks not
+// connecting to the local chain are skipped, the chain syncer catches up with
+// the peer announcing them instead.
+func (h *handler) istanbulImportLoop() {
+	defer h.wg.Done()
+
+	for {
+		select {
+		case op := <-h.istanbulImportCh:
+			block := op.block
+			if h.chain.HasBlock(block.Hash(), block.NumberU64()) {
+				continue
+			}
+			// Blocks can't be imported on top of a pending snap sync, the state
+			// of their parents is only available once the sync is done.
+			if h.snapSync.Load() {
+				log.Trace("Discarded propagated block during snap sync", "number", block.Number(), "hash", block.Hash())
+				continue
+			}
+			if !h.chain.HasBlock(block.ParentHash(), block.NumberU64()-1) {
+				log.Debug("Discarded unconnected block", "number", block.Number(), "hash", block.Hash(), "peer", op.peer)
+				continue
+			}
+			if _, err := h.chain.InsertChain(types.Blocks{block}); err != nil {
+				log.Debug("Failed to import propagated block", "number", block.Number(), "hash", block.Hash(), "peer", op.peer, "err", err)
+			}
+
+		case <-h.quitSync:
+			return
+		}
+	}
+}
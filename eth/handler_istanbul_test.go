@@ -0,0 +1,275 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/miner"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// makeQBFTGenesis creates a genesis block of a QBFT network sealed by the given
+// validators.
+func makeQBFTGenesis(validators []common.Address) *core.Genesis {
+	config := *params.AllEthashProtocolChanges
+	config.Ethash = nil
+	config.ShanghaiTime = nil
+	config.CancunTime = nil
+	config.PragueTime = nil
+	config.VerkleTime = nil
+	config.TerminalTotalDifficulty = nil
+	config.TerminalTotalDifficultyPassed = false
+	config.QBFT = &params.QBFTConfig{
+		EpochLength:           30000,
+		BlockPeriodSeconds:    1,
+		RequestTimeoutSeconds: 4,
+	}
+	extra, err := rlp.EncodeToBytes(&types.QBFTExtra{
+		VanityData:    bytes.Repeat([]byte{0x00}, types.IstanbulExtraVanity),
+		Validators:    validators,
+		CommittedSeal: [][]byte{},
+	})
+	if err != nil {
+		panic(err)
+	}
+	return &core.Genesis{
+		Config:     &config,
+		ExtraData:  extra,
+		GasLimit:   30_000_000,
+		Difficulty: big.NewInt(1),
+		Mixhash:    types.IstanbulDigest,
+		Alloc:      types.GenesisAlloc{},
+	}
+}
+
+// startQBFTNode starts a full node of the QBFT network. If a key is given, the
+// node imports it into its keystore as validator account, unlocked unless locked
+// is set. The node key is random if none is given.
+func startQBFTNode(t *testing.T, genesis *core.Genesis, nodeKey, key *ecdsa.PrivateKey, locked bool) (*node.Node, *Ethereum) {
+	t.Helper()
+
+	stack, err := node.New(&node.Config{
+		DataDir: t.TempDir(),
+		P2P: p2p.Config{
+			PrivateKey:  nodeKey,
+			ListenAddr:  "127.0.0.1:0",
+			NoDiscovery: true,
+			MaxPeers:    25,
+		},
+	})
+	if err != nil {
+		t.Fatal("can't create node:", err)
+	}
+	t.Cleanup(func() { stack.Close() })
+
+	mcfg := miner.DefaultConfig
+	if key != nil {
+		ks := keystore.NewKeyStore(t.TempDir(), keystore.LightScryptN, keystore.LightScryptP)
+		stack.AccountManager().AddBackend(ks)
+
+		account, err := ks.ImportECDSA(key, "")
+		if err != nil {
+			t.Fatal("can't import validator key:", err)
+		}
+		if !locked {
+			if err := ks.Unlock(account, ""); err != nil {
+				t.Fatal("can't unlock validator key:", err)
+			}
+		}
+		mcfg.Etherbase = account.Address
+	}
+	ethcfg := &ethconfig.Config{
+		Genesis:        genesis,
+		NetworkId:      1337,
+		SyncMode:       downloader.FullSync,
+		TrieTimeout:    time.Minute,
+		TrieDirtyCache: 16,
+		TrieCleanCache: 16,
+		Miner:          mcfg,
+	}
+	backend, err := New(stack, ethcfg)
+	if err != nil {
+		t.Fatal("can't create eth service:", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatal("can't start node:", err)
+	}
+	return stack, backend
+}
+
+// waitForQBFTBlock waits until all the given nodes imported the same block at
+// the given height.
+func waitForQBFTBlock(t *testing.T, backends []*Ethereum, number uint64) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Minute)
+	for time.Now().Before(deadline) {
+		var (
+			hash common.Hash
+			done = true
+		)
+		for _, backend := range backends {
+			block := backend.BlockChain().GetBlockByNumber(number)
+			if block == nil {
+				done = false
+				break
+			}
+			if hash == (common.Hash{}) {
+				hash = block.Hash()
+			} else if hash != block.Hash() {
+				t.Fatalf("chains diverged at block %d: have %x, want %x", number, block.Hash(), hash)
+			}
+		}
+		if done {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	for i, backend := range backends {
+		t.Logf("node %d: head %d", i, backend.BlockChain().CurrentBlock().Number)
+	}
+	t.Fatalf("timed out waiting for block %d", number)
+}
+
+// Tests that a network of QBFT validators produces blocks and agrees on them,
+// and that a non-validator node joining later catches up with the network. Half
+// of the validators run with another node key, announcing their validator.
+func TestQBFTNetwork(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode")
+	}
+	var (
+		keys       = make([]*ecdsa.PrivateKey, 4)
+		validators = make([]common.Address, len(keys))
+	)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		validators[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+	}
+	genesis := makeQBFTGenesis(validators)
+
+	var (
+		stacks   = make([]*node.Node, len(keys))
+		backends = make([]*Ethereum, len(keys))
+	)
+	for i, key := range keys {
+		nodeKey := key
+		if i%2 == 1 {
+			nodeKey = nil
+		}
+		stacks[i], backends[i] = startQBFTNode(t, genesis, nodeKey, key, false)
+	}
+	for i := range stacks {
+		for j := 0; j < i; j++ {
+			stacks[i].Server().AddPeer(stacks[j].Server().Self())
+		}
+	}
+	for i, backend := range backends {
+		if err := backend.StartMining(); err != nil {
+			t.Fatalf("node %d: failed to start mining: %v", i, err)
+		}
+	}
+	waitForQBFTBlock(t, backends, 3)
+
+	// Check that the blocks are sealed by the validators
+	for number := uint64(1); number <= 3; number++ {
+		header := backends[0].BlockChain().GetHeaderByNumber(number)
+		author, err := backends[0].Engine().Author(header)
+		if err != nil {
+			t.Fatalf("block %d: failed to retrieve author: %v", number, err)
+		}
+		if !slices.Contains(validators, author) {
+			t.Fatalf("block %d: sealed by non-validator %x", number, author)
+		}
+	}
+	// Join a node without a validator key and check that it syncs
+	stack, backend := startQBFTNode(t, genesis, nil, nil, false)
+	if err := backend.StartMining(); err == nil {
+		t.Fatal("non-validator started mining")
+	}
+	stack.Server().AddPeer(stacks[0].Server().Self())
+
+	head := backends[0].BlockChain().CurrentBlock().Number.Uint64()
+	waitForQBFTBlock(t, append(backends, backend), head)
+}
+
+// Tests that a validator can't start sealing if its account can't sign, instead
+// of silently missing the consensus messages of its peers.
+func TestQBFTLockedValidator(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	genesis := makeQBFTGenesis([]common.Address{crypto.PubkeyToAddress(key.PublicKey)})
+
+	_, backend := startQBFTNode(t, genesis, nil, key, true)
+	if err := backend.StartMining(); err == nil {
+		t.Fatal("validator with a locked account started mining")
+	}
+}
+
+// Tests that the validator announced in the status of a consensus peer is bound
+// to the peer only if signed over its node ID.
+func TestIstanbulStatus(t *testing.T) {
+	var (
+		key, _    = crypto.GenerateKey()
+		validator = crypto.PubkeyToAddress(key.PublicKey)
+		h         = &handler{istanbulPeers: make(map[string]*istanbulPeer)}
+		peer      = newIstanbulPeer(p2p.NewPeer(enode.ID{1}, "peer", nil), nil)
+	)
+	h.istanbulPeers[peer.ID().String()] = peer
+
+	status := func(id enode.ID) p2p.Msg {
+		sig, err := crypto.Sign(crypto.Keccak256(istanbulStatusData(id)), key)
+		if err != nil {
+			t.Fatalf("failed to sign status: %v", err)
+		}
+		size, r, err := rlp.EncodeToReader(&istanbulStatusPacket{Validator: validator, Signature: sig})
+		if err != nil {
+			t.Fatalf("failed to encode status: %v", err)
+		}
+		return p2p.Msg{Code: istanbulStatusMsg, Size: uint32(size), Payload: r}
+	}
+	// A status signed for another node must be rejected
+	if err := h.readIstanbulStatus(peer, status(enode.ID{2})); !errors.Is(err, errIstanbulBadStatus) {
+		t.Fatalf("replayed status error mismatch: have %v, want %v", err, errIstanbulBadStatus)
+	}
+	if peers := h.FindPeers(map[common.Address]bool{validator: true}); len(peers) != 0 {
+		t.Fatalf("peer bound to validator of a replayed status")
+	}
+	if err := h.readIstanbulStatus(peer, status(peer.ID())); err != nil {
+		t.Fatalf("failed to read status: %v", err)
+	}
+	if peers := h.FindPeers(map[common.Address]bool{validator: true}); peers[validator] != peer {
+		t.Fatalf("peer not bound to announced validator: have %v", peers)
+	}
+}
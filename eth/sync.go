@@ -17,9 +17,20 @@
 package eth
 
 import (
+	"math/big"
+	"time"
+
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	forceSyncCycle      = 10 * time.Second // Time interval to force syncs, even if few peers are available
+	defaultMinSyncPeers = 5                // Amount of peers desired to start syncing
 )
 
 // syncTransactions starts sending all currently pending transactions to the given peer.
@@ -35,3 +46,172 @@ func (h *handler) syncTransactions(p *eth.Peer) {
 	}
 	p.AsyncSendPooledTransactionHashes(hashes)
 }
+
+// chainSyncer coordinates blockchain sync components for consensus engines not
+// following a beacon client, syncing from the peer with the highest total
+// difficulty whenever it's ahead of the local chain.
+type chainSyncer struct {
+	handler     *handler
+	force       *time.Timer
+	forced      bool // true when force timer fired
+	peerEventCh chan struct{}
+	doneCh      chan error // non-nil when sync is running
+}
+
+// chainSyncOp is a scheduled sync operation.
+type chainSyncOp struct {
+	mode downloader.SyncMode
+	peer *eth.Peer
+	td   *big.Int
+	head common.Hash
+}
+
+// newChainSyncer creates a chainSyncer.
+func newChainSyncer(handler *handler) *chainSyncer {
+	return &chainSyncer{
+		handler:     handler,
+		peerEventCh: make(chan struct{}),
+	}
+}
+
+// handlePeerEvent notifies the syncer about a change in the peer set.
+// This is called for new peers and every time a peer announces a new
+// chain head.
+func (cs *chainSyncer) handlePeerEvent() bool {
+	select {
+	case cs.peerEventCh <- struct{}{}:
+		return true
+	case <-cs.handler.quitSync:
+		return false
+	}
+}
+
+// loop runs in its own goroutine and launches the sync when necessary.
+func (cs *chainSyncer) loop() {
+	defer cs.handler.wg.Done()
+
+	// The force timer lowers the peer count threshold down to one when it fires.
+	// This ensures we'll always start sync even if there aren't enough peers.
+	cs.force = time.NewTimer(forceSyncCycle)
+	defer cs.force.Stop()
+
+	for {
+		if op := cs.nextSyncOp(); op != nil {
+			cs.startSync(op)
+		}
+		select {
+		case <-cs.peerEventCh:
+			// Peer information changed, recheck.
+		case err := <-cs.doneCh:
+			cs.doneCh = nil
+			cs.force.Reset(forceSyncCycle)
+			cs.forced = false
+
+			if err != nil {
+				log.Debug("Chain sync failed", "err", err)
+			}
+		case <-cs.force.C:
+			cs.forced = true
+
+		case <-cs.handler.quitSync:
+			// Disable all insertion on the blockchain. This needs to happen before
+			// terminating the downloader because the downloader waits for blockchain
+			// inserts, and these can take a long time to finish.
+			cs.handler.chain.StopInsert()
+			cs.handler.downloader.Terminate()
+			if cs.doneCh != nil {
+				<-cs.doneCh
+			}
+			return
+		}
+	}
+}
+
+// nextSyncOp determines whether sync is required at this time.
+func (cs *chainSyncer) nextSyncOp() *chainSyncOp {
+	if cs.doneCh != nil {
+		return nil // Sync already running
+	}
+	// Ensure we're at minimum peer count.
+	minPeers := defaultMinSyncPeers
+	if cs.forced {
+		minPeers = 1
+	} else if minPeers > cs.handler.maxPeers {
+		minPeers = cs.handler.maxPeers
+	}
+	if cs.handler.peers.len() < minPeers {
+		return nil
+	}
+	// We have enough peers, pick the one with the highest TD.
+	peer := cs.handler.peers.peerWithHighestTD()
+	if peer == nil {
+		return nil
+	}
+	mode, ourTD := cs.modeAndLocalHead()
+	op := peerToSyncOp(mode, peer)
+	if op.td.Cmp(ourTD) <= 0 {
+		// We seem to be in sync with the best peer. Nothing will ever be
+		// downloaded on a network all nodes of which are in sync, so enable
+		// the synced features right away.
+		if !cs.handler.synced.Load() {
+			cs.handler.enableSyncedFeatures()
+		}
+		return nil
+	}
+	return op
+}
+
+func peerToSyncOp(mode downloader.SyncMode, p *eth.Peer) *chainSyncOp {
+	peerHead, peerTD := p.Head()
+	return &chainSyncOp{mode: mode, peer: p, td: peerTD, head: peerHead}
+}
+
+// modeAndLocalHead determines the sync mode to use and the total difficulty of
+// the local chain head.
+func (cs *chainSyncer) modeAndLocalHead() (downloader.SyncMode, *big.Int) {
+	// If we're in snap sync mode, return that directly
+	if cs.handler.snapSync.Load() {
+		block := cs.handler.chain.CurrentSnapBlock()
+		td := cs.handler.chain.GetTd(block.Hash(), block.Number.Uint64())
+		return downloader.SnapSync, td
+	}
+	// We are probably in full sync, but we might have rewound to before the
+	// snap sync pivot, check if we should re-enable snap sync.
+	head := cs.handler.chain.CurrentBlock()
+	if pivot := rawdb.ReadLastPivotNumber(cs.handler.database); pivot != nil {
+		if head.Number.Uint64() < *pivot {
+			block := cs.handler.chain.CurrentSnapBlock()
+			td := cs.handler.chain.GetTd(block.Hash(), block.Number.Uint64())
+			return downloader.SnapSync, td
+		}
+	}
+	// We are in a full sync, but the associated head state is missing. To complete
+	// the head state, forcefully rerun the snap sync. Note it doesn't mean the
+	// persistent state is corrupted, just mismatch with the head block.
+	if !cs.handler.chain.HasState(head.Root) {
+		block := cs.handler.chain.CurrentSnapBlock()
+		td := cs.handler.chain.GetTd(block.Hash(), block.Number.Uint64())
+		log.Info("Reenabled snap sync as chain is stateless")
+		return downloader.SnapSync, td
+	}
+	// Nope, we're really full syncing
+	td := cs.handler.chain.GetTd(head.Hash(), head.Number.Uint64())
+	return downloader.FullSync, td
+}
+
+// startSync launches doSync in a new goroutine.
+func (cs *chainSyncer) startSync(op *chainSyncOp) {
+	cs.doneCh = make(chan error, 1)
+	go func() { cs.doneCh <- cs.handler.doSync(op) }()
+}
+
+// doSync synchronizes the local blockchain with a remote peer.
+func (h *handler) doSync(op *chainSyncOp) error {
+	// Run the sync cycle, and disable snap sync if we're past the pivot block
+	err := h.downloader.LegacySync(op.peer.ID(), op.head, op.td, op.mode)
+	if err != nil {
+		return err
+	}
+	h.enableSyncedFeatures()
+	return nil
+}
@@ -17,14 +17,23 @@
 package eth
 
 import (
+	"math/big"
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/clique"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
+	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 // Tests that snap sync is disabled after a successful sync cycle.
@@ -94,3 +103,84 @@ func testSnapSyncDisabling(t *testing.T, ethVer uint, snapVer uint) {
 		t.Fatalf("snap sync not disabled after successful synchronisation")
 	}
 }
+
+// Tests that a clique network which never merged runs its engine standalone, and
+// that a new node syncs the chain from its peers without a beacon client.
+func TestCliqueLegacySync(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		signer = crypto.PubkeyToAddress(key.PublicKey)
+		config = *params.AllCliqueProtocolChanges
+	)
+	config.Clique = &params.CliqueConfig{Period: 0, Epoch: 30000}
+	genesis := &core.Genesis{
+		Config:    &config,
+		ExtraData: make([]byte, 32+common.AddressLength+crypto.SignatureLength),
+		GasLimit:  30_000_000,
+		BaseFee:   big.NewInt(params.InitialBaseFee),
+	}
+	copy(genesis.ExtraData[32:], signer[:])
+
+	// Generate a chain sealed by the single signer, in turn at every block
+	engine := clique.New(config.Clique, rawdb.NewMemoryDatabase())
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, engine, 64, nil)
+	for i, block := range blocks {
+		header := block.Header()
+		if i > 0 {
+			header.ParentHash = blocks[i-1].Hash()
+		}
+		header.Extra = make([]byte, 32+crypto.SignatureLength)
+		header.Difficulty = big.NewInt(2)
+
+		sig, _ := crypto.Sign(clique.SealHash(header).Bytes(), key)
+		copy(header.Extra[32:], sig)
+		blocks[i] = block.WithSeal(header)
+	}
+	start := func() (*node.Node, *Ethereum) {
+		stack, err := node.New(&node.Config{
+			DataDir: t.TempDir(),
+			P2P: p2p.Config{
+				ListenAddr:  "127.0.0.1:0",
+				NoDiscovery: true,
+				MaxPeers:    25,
+			},
+		})
+		if err != nil {
+			t.Fatal("can't create node:", err)
+		}
+		t.Cleanup(func() { stack.Close() })
+
+		backend, err := New(stack, &ethconfig.Config{
+			Genesis:        genesis,
+			NetworkId:      1337,
+			SyncMode:       downloader.FullSync,
+			TrieTimeout:    time.Minute,
+			TrieDirtyCache: 16,
+			TrieCleanCache: 16,
+		})
+		if err != nil {
+			t.Fatal("can't create eth service:", err)
+		}
+		if _, ok := backend.Engine().(*clique.Clique); !ok {
+			t.Fatalf("consensus engine mismatch: have %T, want *clique.Clique", backend.Engine())
+		}
+		if err := stack.Start(); err != nil {
+			t.Fatal("can't start node:", err)
+		}
+		return stack, backend
+	}
+	source, sourceBackend := start()
+	if _, err := sourceBackend.BlockChain().InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import chain: %v", err)
+	}
+	sink, sinkBackend := start()
+	sink.Server().AddPeer(source.Server().Self())
+
+	want := blocks[len(blocks)-1]
+	for deadline := time.Now().Add(time.Minute); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		if head := sinkBackend.BlockChain().CurrentBlock(); head.Hash() == want.Hash() {
+			return
+		}
+	}
+	t.Fatalf("chain not synced: have head %d, want %d", sinkBackend.BlockChain().CurrentBlock().Number, want.Number())
+}
@@ -180,7 +180,7 @@ func (s *supply) OnBalanceChange(a common.Address, prevBalance, newBalance *big.
 	// NOTE: don't handle "BalanceIncreaseGenesisBalance" because it is handled in OnGenesisBlock
 	switch reason {
 	case tracing.BalanceIncreaseRewardMineUncle:
-	case tracing.BalanceIncreaseRewardMineBlock:
+	case tracing.BalanceIncreaseRewardMineBlock, tracing.BalanceIncreaseRewardQBFTBlock:
 		s.delta.Issuance.Reward.Add(s.delta.Issuance.Reward, diff)
 	case tracing.BalanceIncreaseWithdrawal:
 		s.delta.Issuance.Withdrawals.Add(s.delta.Issuance.Withdrawals, diff)
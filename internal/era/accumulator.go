@@ -47,6 +47,22 @@ func ComputeAccumulator(hashes []common.Hash, tds []*big.Int) (common.Hash, erro
 	return hh.HashRoot()
 }
 
+// ComputeBlockAccumulator calculates the SSZ hash tree root of the post-merge
+// EraE accumulator, which is a list of the block hashes alone. Unlike the Era1
+// accumulator, it is not anchored anywhere, the beacon chain commits to beacon
+// block roots instead.
+func ComputeBlockAccumulator(hashes []common.Hash) (common.Hash, error) {
+	if len(hashes) > MaxEra1Size {
+		return common.Hash{}, fmt.Errorf("too many records: have %d, max %d", len(hashes), MaxEra1Size)
+	}
+	hh := ssz.NewHasher()
+	for i := range hashes {
+		hh.Append(hashes[i][:])
+	}
+	hh.MerkleizeWithMixin(0, uint64(len(hashes)), uint64(MaxEra1Size))
+	return hh.HashRoot()
+}
+
 // headerRecord is an individual record for a historical header.
 //
 // See https://github.com/ethereum/portal-network-specs/blob/master/history-network.md#the-header-accumulator
@@ -70,14 +70,33 @@ import (
 //
 // Due to the accumulator size limit of 8192, the maximum number of blocks in
 // an Era1 batch is also 8192.
+//
+// Blocks after the merge have no meaningful total difficulty, so they can also
+// be stored in the post-merge variant of the format (EraE), which drops the
+// total difficulty entries and replaces the header accumulator:
+//
+//	erae := Version | block-tuple* | BlockAccumulator | BlockIndex
+//	block-tuple := CompressedHeader | CompressedBody | CompressedReceipts
+//
+//	BlockAccumulator = { type: [0x08, 0x00], data: block-accumulator-root }
+//
+// The block accumulator is a hash accumulator over the block hashes only. It
+// lets an archive be checked against a trusted list of execution block hashes
+// or accumulator roots, but it is not linked to the beacon block roots or the
+// historical summaries of the beacon state, so it can't be verified against the
+// consensus layer. The trusted roots have to come from a node already following
+// the chain:
+//
+//	block-accumulator := hash_tree_root([]Bytes32, 8192)
 type Builder struct {
-	w        *e2store.Writer
-	startNum *uint64
-	startTd  *big.Int
-	indexes  []uint64
-	hashes   []common.Hash
-	tds      []*big.Int
-	written  int
+	w         *e2store.Writer
+	postMerge bool
+	startNum  *uint64
+	startTd   *big.Int
+	indexes   []uint64
+	hashes    []common.Hash
+	tds       []*big.Int
+	written   int
 
 	buf    *bytes.Buffer
 	snappy *snappy.Writer
@@ -93,8 +112,17 @@ func NewBuilder(w io.Writer) *Builder {
 	}
 }
 
+// NewPostMergeBuilder returns a new Builder instance creating post-merge EraE
+// archives.
+func NewPostMergeBuilder(w io.Writer) *Builder {
+	b := NewBuilder(w)
+	b.postMerge = true
+	return b
+}
+
 // Add writes a compressed block entry and compressed receipts entry to the
-// underlying e2store file.
+// underlying e2store file. The total difficulty is ignored by post-merge
+// builders.
 func (b *Builder) Add(block *types.Block, receipts types.Receipts, td *big.Int) error {
 	eh, err := rlp.EncodeToBytes(block.Header())
 	if err != nil {
@@ -108,13 +136,52 @@ func (b *Builder) Add(block *types.Block, receipts types.Receipts, td *big.Int)
 	if err != nil {
 		return err
 	}
+	if b.postMerge {
+		if block.Difficulty().Sign() != 0 {
+			return fmt.Errorf("block %d is not a post-merge block", block.NumberU64())
+		}
+		return b.AddPostMergeRLP(eh, eb, er, block.NumberU64(), block.Hash())
+	}
 	return b.AddRLP(eh, eb, er, block.NumberU64(), block.Hash(), td, block.Difficulty())
 }
 
 // AddRLP writes a compressed block entry and compressed receipts entry to the
 // underlying e2store file.
 func (b *Builder) AddRLP(header, body, receipts []byte, number uint64, hash common.Hash, td, difficulty *big.Int) error {
-	// Write Era1 version entry before first block.
+	if b.postMerge {
+		return errors.New("total difficulty not supported in post-merge era")
+	}
+	if b.startNum == nil {
+		b.startTd = new(big.Int).Sub(td, difficulty)
+	}
+	if err := b.addBlock(header, body, receipts, number, hash); err != nil {
+		return err
+	}
+	b.tds = append(b.tds, td)
+
+	// Also write total difficulty, but don't snappy encode.
+	btd := bigToBytes32(td)
+	n, err := b.w.Write(TypeTotalDifficulty, btd[:])
+	b.written += n
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AddPostMergeRLP writes a compressed block entry and compressed receipts entry
+// to the underlying post-merge e2store file.
+func (b *Builder) AddPostMergeRLP(header, body, receipts []byte, number uint64, hash common.Hash) error {
+	if !b.postMerge {
+		return errors.New("total difficulty required in era1")
+	}
+	return b.addBlock(header, body, receipts, number, hash)
+}
+
+// addBlock writes the block tuple entries shared by both archive formats.
+func (b *Builder) addBlock(header, body, receipts []byte, number uint64, hash common.Hash) error {
+	// Write version entry before first block.
 	if b.startNum == nil {
 		n, err := b.w.Write(TypeVersion, nil)
 		if err != nil {
@@ -122,7 +189,6 @@ func (b *Builder) AddRLP(header, body, receipts []byte, number uint64, hash comm
 		}
 		startNum := number
 		b.startNum = &startNum
-		b.startTd = new(big.Int).Sub(td, difficulty)
 		b.written += n
 	}
 	if len(b.indexes) >= MaxEra1Size {
@@ -131,7 +197,6 @@ func (b *Builder) AddRLP(header, body, receipts []byte, number uint64, hash comm
 
 	b.indexes = append(b.indexes, uint64(b.written))
 	b.hashes = append(b.hashes, hash)
-	b.tds = append(b.tds, td)
 
 	// Write block data.
 	if err := b.snappyWrite(TypeCompressedHeader, header); err != nil {
@@ -140,19 +205,7 @@ func (b *Builder) AddRLP(header, body, receipts []byte, number uint64, hash comm
 	if err := b.snappyWrite(TypeCompressedBody, body); err != nil {
 		return err
 	}
-	if err := b.snappyWrite(TypeCompressedReceipts, receipts); err != nil {
-		return err
-	}
-
-	// Also write total difficulty, but don't snappy encode.
-	btd := bigToBytes32(td)
-	n, err := b.w.Write(TypeTotalDifficulty, btd[:])
-	b.written += n
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return b.snappyWrite(TypeCompressedReceipts, receipts)
 }
 
 // Finalize computes the accumulator and block index values, then writes the
@@ -162,11 +215,21 @@ func (b *Builder) Finalize() (common.Hash, error) {
 		return common.Hash{}, errors.New("finalize called on empty builder")
 	}
 	// Compute accumulator root and write entry.
-	root, err := ComputeAccumulator(b.hashes, b.tds)
+	var (
+		root    common.Hash
+		err     error
+		accType = TypeAccumulator
+	)
+	if b.postMerge {
+		root, err = ComputeBlockAccumulator(b.hashes)
+		accType = TypeBlockAccumulator
+	} else {
+		root, err = ComputeAccumulator(b.hashes, b.tds)
+	}
 	if err != nil {
 		return common.Hash{}, fmt.Errorf("error calculating accumulator root: %w", err)
 	}
-	n, err := b.w.Write(TypeAccumulator, root[:])
+	n, err := b.w.Write(accType, root[:])
 	b.written += n
 	if err != nil {
 		return common.Hash{}, fmt.Errorf("error writing accumulator: %w", err)
@@ -32,6 +32,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/internal/era/e2store"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
 	"github.com/golang/snappy"
 )
 
@@ -42,6 +43,7 @@ var (
 	TypeCompressedReceipts uint16 = 0x05
 	TypeTotalDifficulty    uint16 = 0x06
 	TypeAccumulator        uint16 = 0x07
+	TypeBlockAccumulator   uint16 = 0x08
 	TypeBlockIndex         uint16 = 0x3266
 
 	MaxEra1Size = 8192
@@ -53,8 +55,15 @@ func Filename(network string, epoch int, root common.Hash) string {
 	return fmt.Sprintf("%s-%05d-%s.era1", network, epoch, root.Hex()[2:10])
 }
 
-// ReadDir reads all the era1 files in a directory for a given network.
-// Format: <network>-<epoch>-<hexroot>.era1
+// PostMergeFilename returns a recognizable EraE-formatted file name for the
+// specified epoch and network.
+func PostMergeFilename(network string, epoch int, root common.Hash) string {
+	return fmt.Sprintf("%s-%05d-%s.erae", network, epoch, root.Hex()[2:10])
+}
+
+// ReadDir reads all the era1 and post-merge erae files in a directory for a
+// given network.
+// Format: <network>-<epoch>-<hexroot>.era1 or <network>-<epoch>-<hexroot>.erae
 func ReadDir(dir, network string) ([]string, error) {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
@@ -65,7 +74,7 @@ func ReadDir(dir, network string) ([]string, error) {
 		eras []string
 	)
 	for _, entry := range entries {
-		if path.Ext(entry.Name()) != ".era1" {
+		if ext := path.Ext(entry.Name()); ext != ".era1" && ext != ".erae" {
 			continue
 		}
 		parts := strings.Split(entry.Name(), "-")
@@ -90,7 +99,7 @@ type ReadAtSeekCloser interface {
 	io.Closer
 }
 
-// Era reads and Era1 file.
+// Era reads an Era1 or post-merge EraE file.
 type Era struct {
 	f   ReadAtSeekCloser // backing era1 file
 	s   *e2store.Reader  // e2store reader over f
@@ -154,9 +163,49 @@ func (e *Era) GetBlockByNumber(num uint64) (*types.Block, error) {
 	return types.NewBlockWithHeader(&header).WithBody(body), nil
 }
 
-// Accumulator reads the accumulator entry in the Era1 file.
+// GetReceiptsByNumber returns the receipts of the block with the given number.
+// Only the consensus fields of the receipts are populated.
+func (e *Era) GetReceiptsByNumber(num uint64) (types.Receipts, error) {
+	if e.m.start > num || e.m.start+e.m.count <= num {
+		return nil, errors.New("out-of-bounds")
+	}
+	off, err := e.readOffset(num)
+	if err != nil {
+		return nil, err
+	}
+	// Skip over the header and body entries.
+	for _, typ := range []uint16{TypeCompressedHeader, TypeCompressedBody} {
+		_, n, err := e.s.ReaderAt(typ, off)
+		if err != nil {
+			return nil, err
+		}
+		off += int64(n)
+	}
+	r, _, err := newSnappyReader(e.s, TypeCompressedReceipts, off)
+	if err != nil {
+		return nil, err
+	}
+	var receipts types.Receipts
+	if err := rlp.Decode(r, &receipts); err != nil {
+		return nil, err
+	}
+	return receipts, nil
+}
+
+// PostMerge reports whether the file is a post-merge EraE archive, which has no
+// total difficulty entries and a block accumulator.
+func (e *Era) PostMerge() bool {
+	return e.m.postMerge
+}
+
+// Accumulator reads the accumulator entry in the Era1 file. For post-merge
+// archives, the block accumulator is returned.
 func (e *Era) Accumulator() (common.Hash, error) {
-	entry, err := e.s.Find(TypeAccumulator)
+	typ := TypeAccumulator
+	if e.m.postMerge {
+		typ = TypeBlockAccumulator
+	}
+	entry, err := e.s.Find(typ)
 	if err != nil {
 		return common.Hash{}, err
 	}
@@ -166,6 +215,9 @@ func (e *Era) Accumulator() (common.Hash, error) {
 // InitialTD returns initial total difficulty before the difficulty of the
 // first block of the Era1 is applied.
 func (e *Era) InitialTD() (*big.Int, error) {
+	if e.m.postMerge {
+		return nil, errors.New("total difficulty not available in post-merge era")
+	}
 	var (
 		r      io.Reader
 		header types.Header
@@ -208,6 +260,88 @@ func (e *Era) InitialTD() (*big.Int, error) {
 	return td.Sub(td, header.Difficulty), nil
 }
 
+// Verify checks that the data of the Era matches its accumulator, and returns
+// the hashes of the blocks it contains.
+func (e *Era) Verify() ([]common.Hash, error) {
+	var (
+		err    error
+		want   common.Hash
+		td     *big.Int
+		tds    = make([]*big.Int, 0)
+		hashes = make([]common.Hash, 0)
+	)
+	if want, err = e.Accumulator(); err != nil {
+		return nil, fmt.Errorf("error reading accumulator: %w", err)
+	}
+	if !e.PostMerge() {
+		if td, err = e.InitialTD(); err != nil {
+			return nil, fmt.Errorf("error reading total difficulty: %w", err)
+		}
+	}
+	it, err := NewIterator(e)
+	if err != nil {
+		return nil, fmt.Errorf("error making era iterator: %w", err)
+	}
+	// To fully verify an era the following attributes must be checked:
+	//   1) the block index is constructed correctly
+	//   2) the tx root matches the value in the block
+	//   3) the receipts root matches the value in the block
+	//   4) the starting total difficulty value is correct
+	//   5) the accumulator is correct by recomputing it locally, which verifies
+	//      the blocks are all correct (via hash)
+	//
+	// The attributes 1), 2), and 3) are checked for each block. 4) and 5) require
+	// accumulation across the entire set and are verified at the end. Post-merge
+	// archives have no total difficulty, their accumulator covers the block
+	// hashes only.
+	for it.Next() {
+		// 1) next() walks the block index, so we're able to implicitly verify it.
+		if it.Error() != nil {
+			return nil, fmt.Errorf("error reading block %d: %w", it.Number(), it.Error())
+		}
+		block, receipts, err := it.BlockAndReceipts()
+		if err != nil {
+			return nil, fmt.Errorf("error reading block %d: %w", it.Number(), err)
+		}
+		// 2) recompute tx root and verify against header.
+		tr := types.DeriveSha(block.Transactions(), trie.NewStackTrie(nil))
+		if tr != block.TxHash() {
+			return nil, fmt.Errorf("tx root in block %d mismatch: want %s, got %s", block.NumberU64(), block.TxHash(), tr)
+		}
+		// 3) recompute receipt root and check value against block.
+		rr := types.DeriveSha(receipts, trie.NewStackTrie(nil))
+		if rr != block.ReceiptHash() {
+			return nil, fmt.Errorf("receipt root in block %d mismatch: want %s, got %s", block.NumberU64(), block.ReceiptHash(), rr)
+		}
+		hashes = append(hashes, block.Hash())
+		if e.PostMerge() {
+			if block.Difficulty().Sign() != 0 {
+				return nil, fmt.Errorf("pre-merge block %d in post-merge era", block.NumberU64())
+			}
+			continue
+		}
+		td.Add(td, block.Difficulty())
+		tds = append(tds, new(big.Int).Set(td))
+	}
+	if it.Error() != nil {
+		return nil, fmt.Errorf("error reading era: %w", it.Error())
+	}
+	// 4+5) Verify accumulator and total difficulty.
+	var got common.Hash
+	if e.PostMerge() {
+		got, err = ComputeBlockAccumulator(hashes)
+	} else {
+		got, err = ComputeAccumulator(hashes, tds)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error computing accumulator: %w", err)
+	}
+	if got != want {
+		return nil, fmt.Errorf("expected accumulator root does not match calculated: got %s, want %s", got, want)
+	}
+	return hashes, nil
+}
+
 // Start returns the listed start block.
 func (e *Era) Start() uint64 {
 	return e.m.start
@@ -250,9 +384,10 @@ func newSnappyReader(e *e2store.Reader, expectedType uint16, off int64) (io.Read
 
 // metadata wraps the metadata in the block index.
 type metadata struct {
-	start  uint64
-	count  uint64
-	length int64
+	start     uint64
+	count     uint64
+	length    int64
+	postMerge bool
 }
 
 // readMetadata reads the metadata stored in an Era1 file's block index.
@@ -273,5 +408,30 @@ func readMetadata(f ReadAtSeekCloser) (m metadata, err error) {
 		return
 	}
 	m.start = binary.LittleEndian.Uint64(b[8:])
+	if m.count == 0 {
+		return
+	}
+	// Determine the archive format by looking at the entry following the first
+	// block tuple, which is a total difficulty in Era1 files only.
+	var (
+		r                      = e2store.NewReader(f)
+		blockIndexRecordOffset = m.length - 24 - int64(m.count)*8
+	)
+	if _, err = f.ReadAt(b[:8], blockIndexRecordOffset+16); err != nil {
+		return
+	}
+	off := blockIndexRecordOffset + int64(binary.LittleEndian.Uint64(b[:8]))
+	for i := 0; i < 3; i++ {
+		var length int64
+		if length, err = r.LengthAt(off); err != nil {
+			return
+		}
+		off += length
+	}
+	var typ uint16
+	if typ, _, err = r.ReadMetadataAt(off); err != nil {
+		return
+	}
+	m.postMerge = typ != TypeTotalDifficulty
 	return
 }
@@ -124,6 +124,81 @@ func TestEra1Builder(t *testing.T) {
 	}
 }
 
+func TestEraEBuilder(t *testing.T) {
+	f, err := os.CreateTemp("", "erae-test")
+	if err != nil {
+		t.Fatalf("error creating temp file: %v", err)
+	}
+	defer f.Close()
+
+	var (
+		builder = NewPostMergeBuilder(f)
+		hashes  []common.Hash
+	)
+	if err := builder.AddRLP(nil, nil, nil, 0, common.Hash{}, big.NewInt(0), big.NewInt(0)); err == nil {
+		t.Fatalf("expected error adding total difficulty to post-merge era")
+	}
+	for i := 0; i < 128; i++ {
+		hashes = append(hashes, common.Hash{byte(i)})
+		err := builder.AddPostMergeRLP([]byte{'h', byte(i)}, []byte{'b', byte(i)}, []byte{'r', byte(i)}, uint64(1000+i), hashes[i])
+		if err != nil {
+			t.Fatalf("error adding entry: %v", err)
+		}
+	}
+	root, err := builder.Finalize()
+	if err != nil {
+		t.Fatalf("error finalizing erae: %v", err)
+	}
+	want, _ := ComputeBlockAccumulator(hashes)
+	if root != want {
+		t.Fatalf("wrong accumulator: have %x, want %x", root, want)
+	}
+
+	// Verify EraE contents.
+	e, err := Open(f.Name())
+	if err != nil {
+		t.Fatalf("failed to open era: %v", err)
+	}
+	defer e.Close()
+	if !e.PostMerge() {
+		t.Fatalf("post-merge era not detected")
+	}
+	if e.Start() != 1000 || e.Count() != 128 {
+		t.Fatalf("wrong metadata: start %d, count %d", e.Start(), e.Count())
+	}
+	if acc, err := e.Accumulator(); err != nil || acc != want {
+		t.Fatalf("wrong stored accumulator: have %x, want %x (err %v)", acc, want, err)
+	}
+	if _, err := e.InitialTD(); err == nil {
+		t.Fatalf("expected error reading total difficulty of post-merge era")
+	}
+	it, err := NewRawIterator(e)
+	if err != nil {
+		t.Fatalf("failed to make iterator: %s", err)
+	}
+	for i := 0; i < 128; i++ {
+		if !it.Next() {
+			t.Fatalf("expected more entries")
+		}
+		if it.Error() != nil {
+			t.Fatalf("unexpected error %v", it.Error())
+		}
+		if it.TotalDifficulty != nil {
+			t.Fatalf("unexpected total difficulty in post-merge era")
+		}
+		receipts, err := io.ReadAll(it.Receipts)
+		if err != nil {
+			t.Fatalf("error reading receipts: %v", err)
+		}
+		if !bytes.Equal(receipts, []byte{'r', byte(i)}) {
+			t.Fatalf("mismatched receipts: want %s, got %s", []byte{'r', byte(i)}, receipts)
+		}
+	}
+	if it.Next() {
+		t.Fatalf("expected end of entries")
+	}
+}
+
 func TestEraFilename(t *testing.T) {
 	for i, tt := range []struct {
 		network  string
@@ -138,5 +213,8 @@ func TestEraFilename(t *testing.T) {
 		if tt.expected != got {
 			t.Errorf("test %d: invalid filename: want %s, got %s", i, tt.expected, got)
 		}
+		if got, want := PostMergeFilename(tt.network, tt.epoch, tt.root), tt.expected[:len(tt.expected)-1]+"e"; got != want {
+			t.Errorf("test %d: invalid post-merge filename: want %s, got %s", i, want, got)
+		}
 	}
 }
@@ -103,6 +103,9 @@ func (it *Iterator) BlockAndReceipts() (*types.Block, types.Receipts, error) {
 // TotalDifficulty returns the total difficulty for the iterator's current
 // position.
 func (it *Iterator) TotalDifficulty() (*big.Int, error) {
+	if it.inner.TotalDifficulty == nil {
+		return nil, errors.New("total difficulty not available")
+	}
 	td, err := io.ReadAll(it.inner.TotalDifficulty)
 	if err != nil {
 		return nil, err
@@ -110,7 +113,8 @@ func (it *Iterator) TotalDifficulty() (*big.Int, error) {
 	return new(big.Int).SetBytes(reverseOrder(td)), nil
 }
 
-// RawIterator reads an RLP-encode Era1 entries.
+// RawIterator reads an RLP-encode Era1 entries. For post-merge archives, the
+// TotalDifficulty reader is always nil.
 type RawIterator struct {
 	e    *Era   // backing Era1
 	next uint64 // next block to read
@@ -166,9 +170,11 @@ func (it *RawIterator) Next() bool {
 		return true
 	}
 	off += n
-	if it.TotalDifficulty, _, it.err = it.e.s.ReaderAt(TypeTotalDifficulty, off); it.err != nil {
-		it.clear()
-		return true
+	if !it.e.m.postMerge {
+		if it.TotalDifficulty, _, it.err = it.e.s.ReaderAt(TypeTotalDifficulty, off); it.err != nil {
+			it.clear()
+			return true
+		}
 	}
 	it.next += 1
 	return true
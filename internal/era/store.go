@@ -0,0 +1,170 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package era
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// maxOpenEras is the number of era files kept open by a Store.
+const maxOpenEras = 16
+
+// errStoreClosed is returned when accessing a closed store.
+var errStoreClosed = errors.New("era store closed")
+
+// Store provides access to the blocks and receipts archived in a directory of
+// era files. Files are opened lazily and a limited number of them is kept open.
+type Store struct {
+	dir   string
+	files []string // era files of the directory, indexed by epoch
+	head  uint64   // number of the first block not covered by the files
+
+	mu     sync.Mutex
+	open   lru.BasicLRU[int, *Era]
+	closed bool
+}
+
+// NewStore creates a store over the era files of the given network in dir.
+func NewStore(dir, network string) (*Store, error) {
+	files, err := ReadDir(dir, network)
+	if err != nil {
+		return nil, err
+	}
+	var head uint64
+	if len(files) > 0 {
+		last, err := Open(filepath.Join(dir, files[len(files)-1]))
+		if err != nil {
+			return nil, err
+		}
+		head = last.Start() + last.Count()
+		last.Close()
+	}
+	return &Store{
+		dir:   dir,
+		files: files,
+		head:  head,
+		open:  lru.NewBasicLRU[int, *Era](maxOpenEras),
+	}, nil
+}
+
+// Head returns the number of the first block not covered by the store. All
+// blocks below it are available, as epochs are checked to be contiguous.
+func (s *Store) Head() uint64 {
+	return s.head
+}
+
+// GetBlockByNumber retrieves the block with the given number.
+func (s *Store) GetBlockByNumber(num uint64) (*types.Block, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, err := s.era(num)
+	if err != nil {
+		return nil, err
+	}
+	return e.GetBlockByNumber(num)
+}
+
+// GetReceiptsByNumber retrieves the receipts of the block with the given number.
+// Only the consensus fields of the receipts are populated.
+func (s *Store) GetReceiptsByNumber(num uint64) (types.Receipts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, err := s.era(num)
+	if err != nil {
+		return nil, err
+	}
+	return e.GetReceiptsByNumber(num)
+}
+
+// Verify checks the integrity of the era files covering the blocks from the
+// given number up to, but not including, the end, and that each of these blocks
+// has the hash returned by the canonical function.
+func (s *Store) Verify(from, to uint64, canonical func(number uint64) common.Hash) error {
+	if to > s.head {
+		return fmt.Errorf("block %d not covered by era files", to-1)
+	}
+	for number := from; number < to; {
+		epoch := int(number / uint64(MaxEra1Size))
+		e, err := Open(filepath.Join(s.dir, s.files[epoch]))
+		if err != nil {
+			return err
+		}
+		hashes, err := e.Verify()
+		start := e.Start()
+		e.Close()
+		if err != nil {
+			return fmt.Errorf("invalid era file %s: %w", s.files[epoch], err)
+		}
+		if number < start || number >= start+uint64(len(hashes)) {
+			return fmt.Errorf("block %d not contained in %s", number, s.files[epoch])
+		}
+		for ; number < to && number < start+uint64(len(hashes)); number++ {
+			if want := canonical(number); hashes[number-start] != want {
+				return fmt.Errorf("block %d mismatch in %s: have %x, want %x", number, s.files[epoch], hashes[number-start], want)
+			}
+		}
+	}
+	return nil
+}
+
+// Close closes all open era files.
+func (s *Store) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, epoch := range s.open.Keys() {
+		e, _ := s.open.Peek(epoch)
+		e.Close()
+	}
+	s.open.Purge()
+	s.closed = true
+}
+
+// era returns the opened era file containing the given block, opening it if
+// necessary. The caller must hold the lock.
+func (s *Store) era(num uint64) (*Era, error) {
+	if s.closed {
+		return nil, errStoreClosed
+	}
+	epoch := int(num / uint64(MaxEra1Size))
+	if num >= s.head {
+		return nil, fmt.Errorf("block %d not covered by era files", num)
+	}
+	if e, ok := s.open.Get(epoch); ok {
+		return e, nil
+	}
+	e, err := Open(filepath.Join(s.dir, s.files[epoch]))
+	if err != nil {
+		return nil, err
+	}
+	if s.open.Len() >= maxOpenEras {
+		if _, old, ok := s.open.RemoveOldest(); ok {
+			old.Close()
+		}
+	}
+	s.open.Add(epoch, e)
+	return e, nil
+}
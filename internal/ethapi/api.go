@@ -70,6 +70,11 @@ func NewEthereumAPI(b Backend) *EthereumAPI {
 
 // GasPrice returns a suggestion for a gas price for legacy transactions.
 func (api *EthereumAPI) GasPrice(ctx context.Context) (*hexutil.Big, error) {
+	// Gas is free in the pending block, don't suggest paying for it
+	next := new(big.Int).Add(api.b.CurrentHeader().Number, common.Big1)
+	if !api.b.ChainConfig().IsGasPriceEnabled(next) {
+		return (*hexutil.Big)(new(big.Int)), nil
+	}
 	tipcap, err := api.b.SuggestGasTipCap(ctx)
 	if err != nil {
 		return nil, err
@@ -1268,6 +1273,9 @@ func RPCMarshalHeader(head *types.Header) map[string]interface{} {
 	if head.ParentBeaconRoot != nil {
 		result["parentBeaconBlockRoot"] = head.ParentBeaconRoot
 	}
+	if head.RequestsHash != nil {
+		result["requestsRoot"] = head.RequestsHash
+	}
 	return result
 }
 
@@ -1303,6 +1311,9 @@ func RPCMarshalBlock(block *types.Block, inclTx bool, fullTx bool, config *param
 	if block.Header().WithdrawalsHash != nil {
 		fields["withdrawals"] = block.Withdrawals()
 	}
+	if block.Header().RequestsHash != nil {
+		fields["requests"] = block.Requests()
+	}
 	return fields
 }
 
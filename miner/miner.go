@@ -42,7 +42,7 @@ type Backend interface {
 
 // Config is the configuration parameters of mining.
 type Config struct {
-	Etherbase           common.Address `toml:"-"`          // Deprecated
+	Etherbase           common.Address `toml:"-"`          // Address of the sealing account (QBFT only)
 	PendingFeeRecipient common.Address `toml:"-"`          // Address for pending block rewards.
 	ExtraData           hexutil.Bytes  `toml:",omitempty"` // Block extra data set by the miner
 	GasCeil             uint64         // Target gas ceiling for mined blocks.
@@ -73,6 +73,10 @@ type Miner struct {
 	chain       *core.BlockChain
 	pending     *pending
 	pendingMu   sync.Mutex // Lock protects the pending block
+
+	sealMu   sync.Mutex     // The lock used to protect the sealing loop lifecycle
+	sealExit chan struct{}  // Channel to terminate the sealing loop, nil if not running
+	sealWg   sync.WaitGroup // Tracks the running sealing loop
 }
 
 // New creates a new miner with provided config.
@@ -109,6 +113,13 @@ func (miner *Miner) SetExtra(extra []byte) error {
 	return nil
 }
 
+// SetEtherbase sets the address of the account sealing the blocks.
+func (miner *Miner) SetEtherbase(addr common.Address) {
+	miner.confMu.Lock()
+	miner.config.Etherbase = addr
+	miner.confMu.Unlock()
+}
+
 // SetGasCeil sets the gaslimit to strive for when mining blocks post 1559.
 // For pre-1559 blocks, it sets the ceiling.
 func (miner *Miner) SetGasCeil(ceil uint64) {
@@ -0,0 +1,211 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
+const chainHeadChanSize = 10
+
+// sealTask is a block handed over to the consensus engine for sealing, along
+// with the execution results needed to write it once sealed.
+type sealTask struct {
+	block    *types.Block
+	receipts []*types.Receipt
+	result   *newPayloadResult
+	results  chan *types.Block
+}
+
+// Start starts the sealing loop, which continuously builds blocks on top of the
+// chain head and hands them to the consensus engine for sealing. It is meant for
+// engines producing blocks without a beacon client driving them, e.g. QBFT.
+func (miner *Miner) Start() {
+	miner.sealMu.Lock()
+	defer miner.sealMu.Unlock()
+
+	if miner.sealExit != nil {
+		return
+	}
+	miner.sealExit = make(chan struct{})
+	miner.sealWg.Add(1)
+	go miner.sealLoop(miner.sealExit)
+}
+
+// Stop terminates the sealing loop, aborting the block being sealed if any.
+func (miner *Miner) Stop() {
+	miner.sealMu.Lock()
+	defer miner.sealMu.Unlock()
+
+	if miner.sealExit == nil {
+		return
+	}
+	close(miner.sealExit)
+	miner.sealWg.Wait()
+	miner.sealExit = nil
+}
+
+// Mining returns an indicator whether the sealing loop is running.
+func (miner *Miner) Mining() bool {
+	miner.sealMu.Lock()
+	defer miner.sealMu.Unlock()
+
+	return miner.sealExit != nil
+}
+
+// sealLoop builds a new block whenever the chain head changes and writes the
+// blocks sealed by the consensus engine into the chain.
+func (miner *Miner) sealLoop(exit chan struct{}) {
+	defer miner.sealWg.Done()
+
+	headCh := make(chan core.ChainHeadEvent, chainHeadChanSize)
+	headSub := miner.chain.SubscribeChainHeadEvent(headCh)
+	defer headSub.Unsubscribe()
+
+	var (
+		stop chan struct{}
+		task *sealTask
+	)
+	commit := func() {
+		if stop != nil {
+			close(stop)
+		}
+		stop = make(chan struct{})
+		task = miner.commitSealTask(stop)
+	}
+	defer func() {
+		if stop != nil {
+			close(stop)
+		}
+	}()
+	commit()
+
+	for {
+		var results chan *types.Block
+		if task != nil {
+			results = task.results
+		}
+		select {
+		case <-headCh:
+			commit()
+
+		case block := <-results:
+			if block != nil {
+				miner.writeSealedBlock(task, block)
+			}
+			task = nil
+
+		case <-headSub.Err():
+			return
+
+		case <-exit:
+			return
+		}
+	}
+}
+
+// commitSealTask assembles a new block on top of the current chain head and
+// passes it to the consensus engine for sealing. Nil is returned if the block
+// couldn't be built or the engine refused to seal it.
+func (miner *Miner) commitSealTask(stop <-chan struct{}) *sealTask {
+	miner.confMu.RLock()
+	coinbase := miner.config.Etherbase
+	miner.confMu.RUnlock()
+
+	var (
+		parent    = miner.chain.CurrentBlock()
+		number    = new(big.Int).Add(parent.Number, common.Big1)
+		timestamp = uint64(time.Now().Unix())
+
+		withdrawals types.Withdrawals
+		beaconRoot  *common.Hash
+	)
+	if miner.chainConfig.IsShanghai(number, timestamp) {
+		withdrawals = []*types.Withdrawal{}
+	}
+	if miner.chainConfig.IsCancun(number, timestamp) {
+		beaconRoot = new(common.Hash)
+	}
+	result := miner.generateWork(&generateParams{
+		timestamp:   timestamp,
+		parentHash:  parent.Hash(),
+		coinbase:    coinbase,
+		withdrawals: withdrawals,
+		beaconRoot:  beaconRoot,
+	})
+	if result.err != nil {
+		log.Warn("Failed to build sealing block", "number", number, "err", result.err)
+		return nil
+	}
+	task := &sealTask{
+		block:    result.block,
+		receipts: result.receipts,
+		result:   result,
+		results:  make(chan *types.Block, 1),
+	}
+	if err := miner.engine.Seal(miner.chain, task.block, task.results, stop); err != nil {
+		log.Warn("Block sealing failed", "number", number, "err", err)
+		return nil
+	}
+	log.Debug("Commit new sealing work", "number", number, "txs", len(task.block.Transactions()))
+	return task
+}
+
+// writeSealedBlock writes a block sealed by the consensus engine along with the
+// state and receipts computed while building it, and sets it as the chain head.
+func (miner *Miner) writeSealedBlock(task *sealTask, block *types.Block) {
+	// The committed block might have been imported from the network already.
+	hash := block.Hash()
+	if miner.chain.HasBlock(hash, block.NumberU64()) {
+		return
+	}
+	// The block hash changes while sealing, update the receipts and logs.
+	var (
+		receipts = make([]*types.Receipt, len(task.receipts))
+		logs     []*types.Log
+	)
+	for i, taskReceipt := range task.receipts {
+		receipt := new(types.Receipt)
+		receipts[i] = receipt
+		*receipt = *taskReceipt
+
+		receipt.BlockHash = hash
+		receipt.BlockNumber = block.Number()
+		receipt.TransactionIndex = uint(i)
+
+		receipt.Logs = make([]*types.Log, len(taskReceipt.Logs))
+		for j, taskLog := range taskReceipt.Logs {
+			l := new(types.Log)
+			receipt.Logs[j] = l
+			*l = *taskLog
+			l.BlockHash = hash
+		}
+		logs = append(logs, receipt.Logs...)
+	}
+	if _, err := miner.chain.WriteBlockAndSetHead(block, receipts, logs, task.result.stateDB, true); err != nil {
+		log.Error("Failed writing sealed block to chain", "number", block.Number(), "err", err)
+		return
+	}
+	log.Info("Successfully sealed new block", "number", block.Number(), "hash", hash, "txs", len(block.Transactions()))
+}
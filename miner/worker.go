@@ -106,13 +106,34 @@ func (miner *Miner) generateWork(params *generateParams) *newPayloadResult {
 		}
 	}
 	body := types.Body{Transactions: work.txs, Withdrawals: params.withdrawals}
+
+	// Collect the EIP-7685 requests generated by the block after Prague.
+	if miner.chainConfig.IsPrague(work.header.Number, work.header.Time) {
+		var allLogs []*types.Log
+		for _, r := range work.receipts {
+			allLogs = append(allLogs, r.Logs...)
+		}
+		var (
+			context = core.NewEVMBlockContext(work.header, miner.chain, nil)
+			vmenv   = vm.NewEVM(context, vm.TxContext{}, work.state, miner.chainConfig, vm.Config{})
+		)
+		requests, err := core.ProcessRequests(allLogs, vmenv, work.state)
+		if err != nil {
+			return &newPayloadResult{err: err}
+		}
+		body.Requests = requests
+	}
 	block, err := miner.engine.FinalizeAndAssemble(miner.chain, work.header, work.state, &body, work.receipts)
 	if err != nil {
 		return &newPayloadResult{err: err}
 	}
+	fees := new(big.Int)
+	if miner.chainConfig.IsGasPriceEnabled(block.Number()) {
+		fees = totalFees(block, work.receipts)
+	}
 	return &newPayloadResult{
 		block:    block,
-		fees:     totalFees(block, work.receipts),
+		fees:     fees,
 		sidecars: work.sidecars,
 		stateDB:  work.state,
 		receipts: work.receipts,
@@ -200,6 +221,11 @@ func (miner *Miner) prepareWork(genParams *generateParams) (*environment, error)
 		vmenv := vm.NewEVM(context, vm.TxContext{}, env.state, miner.chainConfig, vm.Config{})
 		core.ProcessBeaconBlockRoot(*header.ParentBeaconRoot, vmenv, env.state)
 	}
+	if miner.chainConfig.IsHistoryStorage(header.Number, header.Time) {
+		context := core.NewEVMBlockContext(header, miner.chain, nil)
+		vmenv := vm.NewEVM(context, vm.TxContext{}, env.state, miner.chainConfig, vm.Config{})
+		core.ProcessParentBlockHash(header.ParentHash, vmenv, env.state)
+	}
 	return env, nil
 }
 
@@ -349,6 +375,13 @@ func (miner *Miner) commitTransactions(env *environment, plainTxs, blobTxs *tran
 			txs.Pop()
 			continue
 		}
+		// Skip transactions exceeding the size limit of the block, as included
+		// in it without the blob sidecar.
+		if limit := miner.chainConfig.GetTransactionSizeLimit(env.header.Number); limit != 0 && tx.WithoutBlobTxSidecar().Size() > limit {
+			log.Trace("Ignoring oversized transaction", "hash", ltx.Hash, "size", tx.WithoutBlobTxSidecar().Size(), "limit", limit)
+			txs.Pop()
+			continue
+		}
 		// Start executing the transaction
 		env.state.SetTxContext(tx.Hash(), env.tcount)
 
@@ -381,12 +414,19 @@ func (miner *Miner) fillTransactions(interrupt *atomic.Int32, env *environment)
 	tip := miner.config.GasPrice
 	miner.confMu.RUnlock()
 
-	// Retrieve the pending transactions pre-filtered by the 1559/4844 dynamic fees
-	filter := txpool.PendingFilter{
-		MinTip: uint256.MustFromBig(tip),
-	}
-	if env.header.BaseFee != nil {
-		filter.BaseFee = uint256.MustFromBig(env.header.BaseFee)
+	// Retrieve the pending transactions pre-filtered by the 1559/4844 dynamic fees,
+	// unless gas is free in which case fees are not considered at all
+	var (
+		filter  txpool.PendingFilter
+		baseFee = env.header.BaseFee
+	)
+	if miner.chainConfig.IsGasPriceEnabled(env.header.Number) {
+		filter.MinTip = uint256.MustFromBig(tip)
+		if baseFee != nil {
+			filter.BaseFee = uint256.MustFromBig(baseFee)
+		}
+	} else {
+		baseFee = nil
 	}
 	if env.header.ExcessBlobGas != nil {
 		filter.BlobFee = uint256.MustFromBig(eip4844.CalcBlobFee(*env.header.ExcessBlobGas))
@@ -413,16 +453,16 @@ func (miner *Miner) fillTransactions(interrupt *atomic.Int32, env *environment)
 	}
 	// Fill the block with all available pending transactions.
 	if len(localPlainTxs) > 0 || len(localBlobTxs) > 0 {
-		plainTxs := newTransactionsByPriceAndNonce(env.signer, localPlainTxs, env.header.BaseFee)
-		blobTxs := newTransactionsByPriceAndNonce(env.signer, localBlobTxs, env.header.BaseFee)
+		plainTxs := newTransactionsByPriceAndNonce(env.signer, localPlainTxs, baseFee)
+		blobTxs := newTransactionsByPriceAndNonce(env.signer, localBlobTxs, baseFee)
 
 		if err := miner.commitTransactions(env, plainTxs, blobTxs, interrupt); err != nil {
 			return err
 		}
 	}
 	if len(remotePlainTxs) > 0 || len(remoteBlobTxs) > 0 {
-		plainTxs := newTransactionsByPriceAndNonce(env.signer, remotePlainTxs, env.header.BaseFee)
-		blobTxs := newTransactionsByPriceAndNonce(env.signer, remoteBlobTxs, env.header.BaseFee)
+		plainTxs := newTransactionsByPriceAndNonce(env.signer, remotePlainTxs, baseFee)
+		blobTxs := newTransactionsByPriceAndNonce(env.signer, remoteBlobTxs, baseFee)
 
 		if err := miner.commitTransactions(env, plainTxs, blobTxs, interrupt); err != nil {
 			return err
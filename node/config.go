@@ -79,6 +79,11 @@ type Config struct {
 	// ExternalSigner specifies an external URI for a clef-type signer.
 	ExternalSigner string `toml:",omitempty"`
 
+	// SignerPlugin specifies the executable of an out-of-process signer plugin,
+	// followed by its arguments, talking the accounts/plugin protocol over
+	// stdin/stdout.
+	SignerPlugin []string `toml:",omitempty"`
+
 	// UseLightweightKDF lowers the memory and CPU requirements of the key store
 	// scrypt KDF at the expense of security.
 	UseLightweightKDF bool `toml:",omitempty"`
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"slices"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -61,6 +62,7 @@ var (
 		GrayGlacierBlock:              big.NewInt(15_050_000),
 		TerminalTotalDifficulty:       MainnetTerminalTotalDifficulty, // 58_750_000_000_000_000_000_000
 		TerminalTotalDifficultyPassed: true,
+		DepositContractAddress:        common.HexToAddress("0x00000000219ab540356cbb839cbe05303d7705fa"),
 		ShanghaiTime:                  newUint64(1681338455),
 		CancunTime:                    newUint64(1710338135),
 		Ethash:                        new(EthashConfig),
@@ -86,6 +88,7 @@ var (
 		TerminalTotalDifficulty:       big.NewInt(0),
 		TerminalTotalDifficultyPassed: true,
 		MergeNetsplitBlock:            nil,
+		DepositContractAddress:        common.HexToAddress("0x4242424242424242424242424242424242424242"),
 		ShanghaiTime:                  newUint64(1696000704),
 		CancunTime:                    newUint64(1707305664),
 		Ethash:                        new(EthashConfig),
@@ -111,6 +114,7 @@ var (
 		TerminalTotalDifficulty:       big.NewInt(17_000_000_000_000_000),
 		TerminalTotalDifficultyPassed: true,
 		MergeNetsplitBlock:            big.NewInt(1735371),
+		DepositContractAddress:        common.HexToAddress("0x7f02c3e3c98b133055b8b348b2ac625669ed295d"),
 		ShanghaiTime:                  newUint64(1677557088),
 		CancunTime:                    newUint64(1706655072),
 		Ethash:                        new(EthashConfig),
@@ -164,6 +168,7 @@ var (
 		ShanghaiTime:                  nil,
 		CancunTime:                    nil,
 		PragueTime:                    nil,
+		OsakaTime:                     nil,
 		VerkleTime:                    nil,
 		TerminalTotalDifficulty:       nil,
 		TerminalTotalDifficultyPassed: true,
@@ -215,6 +220,7 @@ var (
 		ShanghaiTime:                  nil,
 		CancunTime:                    nil,
 		PragueTime:                    nil,
+		OsakaTime:                     nil,
 		VerkleTime:                    nil,
 		TerminalTotalDifficulty:       nil,
 		TerminalTotalDifficultyPassed: false,
@@ -245,6 +251,7 @@ var (
 		ShanghaiTime:                  nil,
 		CancunTime:                    nil,
 		PragueTime:                    nil,
+		OsakaTime:                     nil,
 		VerkleTime:                    nil,
 		TerminalTotalDifficulty:       nil,
 		TerminalTotalDifficultyPassed: false,
@@ -275,6 +282,7 @@ var (
 		ShanghaiTime:                  newUint64(0),
 		CancunTime:                    newUint64(0),
 		PragueTime:                    nil,
+		OsakaTime:                     nil,
 		VerkleTime:                    nil,
 		TerminalTotalDifficulty:       big.NewInt(0),
 		TerminalTotalDifficultyPassed: true,
@@ -305,6 +313,7 @@ var (
 		ShanghaiTime:                  nil,
 		CancunTime:                    nil,
 		PragueTime:                    nil,
+		OsakaTime:                     nil,
 		VerkleTime:                    nil,
 		TerminalTotalDifficulty:       nil,
 		TerminalTotalDifficultyPassed: false,
@@ -356,8 +365,14 @@ type ChainConfig struct {
 	ShanghaiTime *uint64 `json:"shanghaiTime,omitempty"` // Shanghai switch time (nil = no fork, 0 = already on shanghai)
 	CancunTime   *uint64 `json:"cancunTime,omitempty"`   // Cancun switch time (nil = no fork, 0 = already on cancun)
 	PragueTime   *uint64 `json:"pragueTime,omitempty"`   // Prague switch time (nil = no fork, 0 = already on prague)
+	OsakaTime    *uint64 `json:"osakaTime,omitempty"`    // Osaka switch time (nil = no fork, 0 = already on osaka)
 	VerkleTime   *uint64 `json:"verkleTime,omitempty"`   // Verkle switch time (nil = no fork, 0 = already on verkle)
 
+	// HistoryStorageTime is the switch time of EIP-2935, which stores the recent
+	// block hashes in the history storage contract (nil = no fork, 0 = already
+	// activated). It's scheduled independently of the named forks.
+	HistoryStorageTime *uint64 `json:"historyStorageTime,omitempty"`
+
 	// TerminalTotalDifficulty is the amount of total difficulty reached by
 	// the network that triggers the consensus upgrade.
 	TerminalTotalDifficulty *big.Int `json:"terminalTotalDifficulty,omitempty"`
@@ -369,6 +384,10 @@ type ChainConfig struct {
 	// TODO(karalabe): Drop this field eventually (always assuming PoS mode)
 	TerminalTotalDifficultyPassed bool `json:"terminalTotalDifficultyPassed,omitempty"`
 
+	// DepositContractAddress is the address of the beacon chain deposit
+	// contract, whose logs are turned into EIP-6110 deposit requests.
+	DepositContractAddress common.Address `json:"depositContractAddress,omitempty"`
+
 	// Various consensus engines
 	Ethash *EthashConfig `json:"ethash,omitempty"`
 	Clique *CliqueConfig `json:"clique,omitempty"`
@@ -485,9 +504,15 @@ func (c *ChainConfig) Description() string {
 	if c.PragueTime != nil {
 		banner += fmt.Sprintf(" - Prague:                      @%-10v\n", *c.PragueTime)
 	}
+	if c.OsakaTime != nil {
+		banner += fmt.Sprintf(" - Osaka:                       @%-10v\n", *c.OsakaTime)
+	}
 	if c.VerkleTime != nil {
 		banner += fmt.Sprintf(" - Verkle:                      @%-10v\n", *c.VerkleTime)
 	}
+	if c.HistoryStorageTime != nil {
+		banner += fmt.Sprintf(" - History storage (EIP-2935):  @%-10v\n", *c.HistoryStorageTime)
+	}
 	return banner
 }
 
@@ -586,11 +611,22 @@ func (c *ChainConfig) IsPrague(num *big.Int, time uint64) bool {
 	return c.IsLondon(num) && isTimestampForked(c.PragueTime, time)
 }
 
+// IsOsaka returns whether time is either equal to the Osaka fork time or greater.
+func (c *ChainConfig) IsOsaka(num *big.Int, time uint64) bool {
+	return c.IsLondon(num) && isTimestampForked(c.OsakaTime, time)
+}
+
 // IsVerkle returns whether time is either equal to the Verkle fork time or greater.
 func (c *ChainConfig) IsVerkle(num *big.Int, time uint64) bool {
 	return c.IsLondon(num) && isTimestampForked(c.VerkleTime, time)
 }
 
+// IsHistoryStorage returns whether time is either equal to the EIP-2935 switch
+// time or greater.
+func (c *ChainConfig) IsHistoryStorage(num *big.Int, time uint64) bool {
+	return c.IsLondon(num) && isTimestampForked(c.HistoryStorageTime, time)
+}
+
 // IsEIP4762 returns whether eip 4762 has been activated at given block.
 func (c *ChainConfig) IsEIP4762(num *big.Int, time uint64) bool {
 	return c.IsVerkle(num, time)
@@ -650,6 +686,7 @@ func (c *ChainConfig) CheckConfigForkOrder() error {
 		{name: "shanghaiTime", timestamp: c.ShanghaiTime},
 		{name: "cancunTime", timestamp: c.CancunTime, optional: true},
 		{name: "pragueTime", timestamp: c.PragueTime, optional: true},
+		{name: "osakaTime", timestamp: c.OsakaTime, optional: true},
 		{name: "verkleTime", timestamp: c.VerkleTime, optional: true},
 	} {
 		if lastFork.name != "" {
@@ -699,13 +736,15 @@ func (c *ChainConfig) GetTransitionValue(num *big.Int, callback func(transition
 	}
 }
 
-func (c *ChainConfig) GetRewardAccount(num *big.Int, coinbase common.Address) (common.Address, error) {
-	beneficiaryMode := "validator"
+// GetBeneficiaryMode returns the beneficiary mode of the block rewards at the
+// given block, along with the mining beneficiary of the fixed mode.
+func (c *ChainConfig) GetBeneficiaryMode(num *big.Int) (string, common.Address) {
+	beneficiaryMode := BeneficiaryModeValidator
 	miningBeneficiary := common.Address{}
 
 	if c.QBFT != nil && c.QBFT.MiningBeneficiary != nil {
 		miningBeneficiary = *c.QBFT.MiningBeneficiary
-		beneficiaryMode = "fixed"
+		beneficiaryMode = BeneficiaryModeFixed
 	}
 
 	if c.QBFT != nil && c.QBFT.BeneficiaryMode != nil {
@@ -714,24 +753,36 @@ func (c *ChainConfig) GetRewardAccount(num *big.Int, coinbase common.Address) (c
 
 	c.GetTransitionValue(num, func(transition Transition) {
 		if transition.BeneficiaryMode != nil && (*transition.BeneficiaryMode == "validators" || *transition.BeneficiaryMode == "validator") {
-			beneficiaryMode = "validator"
+			beneficiaryMode = BeneficiaryModeValidator
+		}
+		if transition.BeneficiaryMode != nil && *transition.BeneficiaryMode == BeneficiaryModeSplit {
+			beneficiaryMode = BeneficiaryModeSplit
 		}
 		if transition.MiningBeneficiary != nil && (transition.BeneficiaryMode == nil || *transition.BeneficiaryMode == "fixed") {
 			miningBeneficiary = *transition.MiningBeneficiary
-			beneficiaryMode = "fixed"
+			beneficiaryMode = BeneficiaryModeFixed
 		}
 	})
 
-	switch strings.ToLower(beneficiaryMode) {
-	case "fixed":
+	return strings.ToLower(beneficiaryMode), miningBeneficiary
+}
+
+// GetRewardAccount returns the account rewarded for the given block proposed by
+// coinbase. In split mode, it's the account credited with the remainder of the
+// reward shared by the validators.
+func (c *ChainConfig) GetRewardAccount(num *big.Int, coinbase common.Address) (common.Address, error) {
+	beneficiaryMode, miningBeneficiary := c.GetBeneficiaryMode(num)
+
+	switch beneficiaryMode {
+	case BeneficiaryModeFixed:
 		log.Trace("fixed beneficiary mode", "miningBeneficiary", miningBeneficiary)
 		return miningBeneficiary, nil
-	case "validator":
+	case BeneficiaryModeValidator, BeneficiaryModeSplit:
 		log.Trace("validator beneficiary mode", "coinbase", coinbase)
 		return coinbase, nil
 	}
 
-	return common.Address{}, errors.New("BeneficiaryMode must be coinbase|fixed")
+	return common.Address{}, errors.New("BeneficiaryMode must be coinbase|fixed|split")
 }
 
 func (c *ChainConfig) GetBlockReward(num *big.Int) big.Int {
@@ -750,6 +801,71 @@ func (c *ChainConfig) GetBlockReward(num *big.Int) big.Int {
 	return big.Int(blockReward)
 }
 
+// IsGasPriceEnabled returns whether transactions pay for the gas they use at the
+// given block. Gas is free from a transition disabling gas price onwards, until
+// a later transition enables it again.
+func (c *ChainConfig) IsGasPriceEnabled(num *big.Int) bool {
+	enabled := true
+	c.GetTransitionValue(num, func(transition Transition) {
+		if transition.GasPriceEnabled != nil {
+			enabled = *transition.GasPriceEnabled
+		}
+	})
+	return enabled
+}
+
+// GetTransactionSizeLimit returns the maximum size in bytes of a transaction at
+// the given block, or 0 if the pools' default limits apply. The limit is
+// configured in KB.
+func (c *ChainConfig) GetTransactionSizeLimit(num *big.Int) uint64 {
+	var limit uint64
+	c.GetTransitionValue(num, func(transition Transition) {
+		if transition.TransactionSizeLimit != 0 {
+			limit = transition.TransactionSizeLimit * 1024
+		}
+	})
+	return limit
+}
+
+// GetMaxCodeSize returns the maximum size in bytes of the code of a contract at
+// the given block. The limit is configured in KB.
+func (c *ChainConfig) GetMaxCodeSize(num *big.Int) int {
+	maxCodeSize := MaxCodeSize
+	c.GetTransitionValue(num, func(transition Transition) {
+		if transition.ContractSizeLimit != 0 {
+			maxCodeSize = int(transition.ContractSizeLimit) * 1024
+		}
+	})
+	return maxCodeSize
+}
+
+// GetMaxInitCodeSize returns the maximum size in bytes of the init code of a
+// contract at the given block, twice the maximum code size as per EIP-3860.
+func (c *ChainConfig) GetMaxInitCodeSize(num *big.Int) int {
+	return 2 * c.GetMaxCodeSize(num)
+}
+
+// CustomPrecompiles returns the custom precompiled contracts active at the given
+// block number and time. A later transition configuring the same address
+// overrides the earlier ones.
+func (c *ChainConfig) CustomPrecompiles(num *big.Int, time uint64) []PrecompileConfig {
+	var active []PrecompileConfig
+	c.GetTransitionValue(num, func(transition Transition) {
+		for _, precompile := range transition.Precompiles {
+			if precompile.Timestamp != nil && *precompile.Timestamp > time {
+				continue
+			}
+			active = slices.DeleteFunc(active, func(p PrecompileConfig) bool {
+				return p.Address == precompile.Address
+			})
+			if !precompile.Disabled {
+				active = append(active, precompile)
+			}
+		}
+	})
+	return active
+}
+
 // ##END
 
 func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, headNumber *big.Int, headTimestamp uint64) *ConfigCompatError {
@@ -817,9 +933,15 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, headNumber *big.Int,
 	if isForkTimestampIncompatible(c.PragueTime, newcfg.PragueTime, headTimestamp) {
 		return newTimestampCompatError("Prague fork timestamp", c.PragueTime, newcfg.PragueTime)
 	}
+	if isForkTimestampIncompatible(c.OsakaTime, newcfg.OsakaTime, headTimestamp) {
+		return newTimestampCompatError("Osaka fork timestamp", c.OsakaTime, newcfg.OsakaTime)
+	}
 	if isForkTimestampIncompatible(c.VerkleTime, newcfg.VerkleTime, headTimestamp) {
 		return newTimestampCompatError("Verkle fork timestamp", c.VerkleTime, newcfg.VerkleTime)
 	}
+	if isForkTimestampIncompatible(c.HistoryStorageTime, newcfg.HistoryStorageTime, headTimestamp) {
+		return newTimestampCompatError("History storage fork timestamp", c.HistoryStorageTime, newcfg.HistoryStorageTime)
+	}
 	return nil
 }
 
@@ -839,6 +961,8 @@ func (c *ChainConfig) LatestFork(time uint64) forks.Fork {
 	london := c.LondonBlock
 
 	switch {
+	case c.IsOsaka(london, time):
+		return forks.Osaka
 	case c.IsPrague(london, time):
 		return forks.Prague
 	case c.IsCancun(london, time):
@@ -990,8 +1114,10 @@ type Rules struct {
 	IsEIP2929, IsEIP4762                                    bool
 	IsByzantium, IsConstantinople, IsPetersburg, IsIstanbul bool
 	IsBerlin, IsLondon                                      bool
-	IsMerge, IsShanghai, IsCancun, IsPrague                 bool
+	IsMerge, IsShanghai, IsCancun, IsPrague, IsOsaka        bool
 	IsVerkle                                                bool
+
+	Precompiles []PrecompileConfig // Quorum - custom precompiled contracts
 }
 
 // Rules ensures c's ChainID is not nil.
@@ -1020,7 +1146,9 @@ func (c *ChainConfig) Rules(num *big.Int, isMerge bool, timestamp uint64) Rules
 		IsShanghai:       isMerge && c.IsShanghai(num, timestamp),
 		IsCancun:         isMerge && c.IsCancun(num, timestamp),
 		IsPrague:         isMerge && c.IsPrague(num, timestamp),
+		IsOsaka:          isMerge && c.IsOsaka(num, timestamp),
 		IsVerkle:         isVerkle,
 		IsEIP4762:        isVerkle,
+		Precompiles:      c.CustomPrecompiles(num, timestamp),
 	}
 }
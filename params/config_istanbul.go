@@ -17,6 +17,7 @@
 package params
 
 import (
+	"encoding/json"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -43,7 +44,7 @@ type QBFTConfig struct {
 	ProposerPolicy           uint64                `json:"policy"`                            // The policy for proposer selection
 	Ceil2Nby3Block           *big.Int              `json:"ceil2Nby3Block,omitempty"`          // Number of confirmations required to move from one state to next [2F + 1 to Ceil(2N/3)]
 	BlockReward              *math.HexOrDecimal256 `json:"blockReward,omitempty"`             // Reward from start, works only on QBFT consensus protocol
-	BeneficiaryMode          *string               `json:"beneficiaryMode,omitempty"`         // Mode for setting the beneficiary, either: list, besu, validators (beneficiary list is the list of validators)
+	BeneficiaryMode          *string               `json:"beneficiaryMode,omitempty"`         // Mode for setting the beneficiary, either: fixed, validator, split (shared by the validator set of the block)
 	MiningBeneficiary        *common.Address       `json:"miningBeneficiary,omitempty"`       // Wallet address that benefits at every new block (besu mode)
 	ValidatorSelectionMode   *string               `json:"validatorselectionmode,omitempty"`  // Select model for validators
 	Validators               []common.Address      `json:"validators"`                        // Validators list
@@ -59,6 +60,18 @@ const (
 	BlockHeaderMode = "blockheader"
 )
 
+// Beneficiary modes of the QBFT block rewards.
+//
+// In the split mode, every validator in the set validating the block, as given by
+// the snapshot of its parent, gets an equal share, whether it signed the block or
+// not. The committed seals are not used, as the nodes may collect different seals
+// for the same block and these aren't covered by the block hash.
+const (
+	BeneficiaryModeFixed     = "fixed"     // The mining beneficiary is rewarded
+	BeneficiaryModeValidator = "validator" // The proposer of the block is rewarded
+	BeneficiaryModeSplit     = "split"     // The validator set of the block shares the reward
+)
+
 type Transition struct {
 	Block                        *big.Int              `json:"block"`
 	EpochLength                  uint64                `json:"epochlength,omitempty"`                  // Number of blocks that should pass before pending validator votes are reset
@@ -76,7 +89,18 @@ type Transition struct {
 	TwoFPlusOneEnabled           *bool                 `json:"2FPlus1Enabled,omitempty"`               // Ceil(2N/3) is the default you need to explicitly use 2F + 1
 	TransactionSizeLimit         uint64                `json:"transactionSizeLimit,omitempty"`         // Modify TransactionSizeLimit
 	BlockReward                  *math.HexOrDecimal256 `json:"blockReward,omitempty"`                  // validation rewards
-	BeneficiaryMode              *string               `json:"beneficiaryMode,omitempty"`              // Mode for setting the beneficiary, either: list, besu, validators (beneficiary list is the list of validators)
+	BeneficiaryMode              *string               `json:"beneficiaryMode,omitempty"`              // Mode for setting the beneficiary, either: fixed, validator, split (shared by the validator set of the block)
 	MiningBeneficiary            *common.Address       `json:"miningBeneficiary,omitempty"`            // Wallet address that benefits at every new block (besu mode)
 	MaxRequestTimeoutSeconds     *uint64               `json:"maxRequestTimeoutSeconds,omitempty"`     // The max a timeout should be for a round change
+	Precompiles                  []PrecompileConfig    `json:"precompiles,omitempty"`                  // Custom precompiled contracts to activate or deactivate
+}
+
+// PrecompileConfig activates a custom precompiled contract, registered in the
+// EVM under Name, at Address from the block of the transition containing it.
+type PrecompileConfig struct {
+	Name      string          `json:"name"`                // Name the implementation was registered with
+	Address   common.Address  `json:"address"`             // Address the contract is reachable at
+	Timestamp *uint64         `json:"timestamp,omitempty"` // Optional activation time on top of the transition block
+	Disabled  bool            `json:"disabled,omitempty"`  // Deactivates a contract enabled in an earlier transition
+	Config    json.RawMessage `json:"config,omitempty"`    // Implementation specific configuration
 }
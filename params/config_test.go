@@ -22,6 +22,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/stretchr/testify/require"
 )
@@ -155,3 +156,36 @@ func TestTimestampCompatError(t *testing.T) {
 	require.Equal(t, newTimestampCompatError(errWhat, newUint64(0), newUint64(1681338455)).Error(),
 		"mismatching Shanghai fork timestamp in database (have timestamp 0, want timestamp 1681338455, rewindto timestamp 0)")
 }
+
+func TestGetRewardAccount(t *testing.T) {
+	var (
+		coinbase    = common.HexToAddress("0x1")
+		beneficiary = common.HexToAddress("0x2")
+		split       = BeneficiaryModeSplit
+		validator   = BeneficiaryModeValidator
+	)
+	config := &ChainConfig{
+		QBFT: &QBFTConfig{MiningBeneficiary: &beneficiary},
+		Transitions: []Transition{
+			{Block: big.NewInt(10), BeneficiaryMode: &split},
+			{Block: big.NewInt(20), BeneficiaryMode: &validator},
+		},
+	}
+	tests := []struct {
+		number  int64
+		mode    string
+		account common.Address
+	}{
+		{0, BeneficiaryModeFixed, beneficiary},
+		{10, BeneficiaryModeSplit, coinbase},
+		{20, BeneficiaryModeValidator, coinbase},
+	}
+	for _, test := range tests {
+		mode, _ := config.GetBeneficiaryMode(big.NewInt(test.number))
+		require.Equal(t, test.mode, mode, "block %d", test.number)
+
+		account, err := config.GetRewardAccount(big.NewInt(test.number), coinbase)
+		require.NoError(t, err)
+		require.Equal(t, test.account, account, "block %d", test.number)
+	}
+}
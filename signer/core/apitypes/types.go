@@ -300,6 +300,14 @@ var (
 		accounts.MimetypeClique,
 		0x02,
 	}
+	ApplicationQBFTHeader = SigFormat{
+		accounts.MimetypeQBFTHeader,
+		0x03,
+	}
+	ApplicationQBFTMessage = SigFormat{
+		accounts.MimetypeQBFTMessage,
+		0x04,
+	}
 	TextPlain = SigFormat{
 		accounts.MimetypeTextPlain,
 		0x45,
@@ -115,6 +115,21 @@ func (l *AuditLogger) Version(ctx context.Context) (string, error) {
 }
 
 func NewAuditLogger(path string, api ExternalAPI) (*AuditLogger, error) {
+	l, err := OpenAuditLog(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewAuditLoggerWithLog(l, api), nil
+}
+
+// NewAuditLoggerWithLog creates an audit logger writing to an already opened
+// audit log, allowing it to be shared with other components (e.g. ThresholdUI).
+func NewAuditLoggerWithLog(l log.Logger, api ExternalAPI) *AuditLogger {
+	return &AuditLogger{l, api}
+}
+
+// OpenAuditLog opens (or creates) the audit log file at path for appending.
+func OpenAuditLog(path string) (log.Logger, error) {
 	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
 	if err != nil {
 		return nil, err
@@ -123,5 +138,5 @@ func NewAuditLogger(path string, api ExternalAPI) (*AuditLogger, error) {
 	handler := slog.NewTextHandler(f, nil)
 	l := log.NewLogger(handler).With("api", "signer")
 	l.Info("Configured", "audit log", path)
-	return &AuditLogger{l, api}, nil
+	return l, nil
 }
@@ -164,6 +164,59 @@ func (api *SignerAPI) determineSignatureFormat(ctx context.Context, contentType
 		// Clique uses V on the form 0 or 1
 		useEthereumV = false
 		req = &SignDataRequest{ContentType: mediaType, Rawdata: cliqueRlp, Messages: messages, Hash: sighash}
+	case apitypes.ApplicationQBFTHeader.Mime:
+		// QBFT commit seals sign the header without the seals, along with the
+		// round it was committed in
+		qbftData, err := fromHex(data)
+		if err != nil {
+			return nil, useEthereumV, err
+		}
+		header := &types.Header{}
+		if err := rlp.DecodeBytes(qbftData, header); err != nil {
+			return nil, useEthereumV, err
+		}
+		sighash, qbftRlp, round, err := qbftHeaderHashAndRlp(header)
+		if err != nil {
+			return nil, useEthereumV, err
+		}
+		messages := []*apitypes.NameValueType{
+			{
+				Name:  "QBFT header",
+				Typ:   "qbft",
+				Value: fmt.Sprintf("qbft header %d round %d [%#x]", header.Number, round, sighash),
+			},
+		}
+		// QBFT uses V on the form 0 or 1
+		useEthereumV = false
+		req = &SignDataRequest{ContentType: mediaType, Rawdata: qbftRlp, Messages: messages, Hash: sighash}
+	case apitypes.ApplicationQBFTMessage.Mime:
+		// QBFT consensus messages sign the message code along with the payload
+		qbftData, err := fromHex(data)
+		if err != nil {
+			return nil, useEthereumV, err
+		}
+		var msg struct {
+			Code    uint64
+			Payload rlp.RawValue
+		}
+		if err := rlp.DecodeBytes(qbftData, &msg); err != nil {
+			return nil, useEthereumV, fmt.Errorf("invalid qbft message: %v", err)
+		}
+		messages := []*apitypes.NameValueType{
+			{
+				Name:  "QBFT message",
+				Typ:   "qbft",
+				Value: fmt.Sprintf("qbft message code %#x", msg.Code),
+			},
+			{
+				Name:  "Payload",
+				Typ:   "hexdata",
+				Value: fmt.Sprintf("%#x", []byte(msg.Payload)),
+			},
+		}
+		// QBFT uses V on the form 0 or 1
+		useEthereumV = false
+		req = &SignDataRequest{ContentType: mediaType, Rawdata: qbftData, Messages: messages, Hash: crypto.Keccak256(qbftData)}
 	case apitypes.DataTyped.Mime:
 		// EIP-712 conformant typed data
 		var err error
@@ -219,6 +272,24 @@ func cliqueHeaderHashAndRlp(header *types.Header) (hash, rlp []byte, err error)
 	return hash, rlp, err
 }
 
+// qbftHeaderHashAndRlp returns the hash signed by the QBFT commit seals and the
+// rlp encoding it's computed over, rejecting headers which aren't QBFT headers.
+// The committed seals are stripped, the round is kept from the extradata.
+func qbftHeaderHashAndRlp(header *types.Header) (hash, rlpData []byte, round uint32, err error) {
+	if header.MixDigest != types.IstanbulDigest {
+		return nil, nil, 0, errors.New("not a qbft header, invalid mix digest")
+	}
+	extra, err := types.ExtractQBFTExtra(header)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	filtered := types.QBFTFilteredHeaderWithRound(header, extra.Round)
+	if rlpData, err = rlp.EncodeToBytes(filtered); err != nil {
+		return nil, nil, 0, err
+	}
+	return crypto.Keccak256(rlpData), rlpData, extra.Round, nil
+}
+
 // SignTypedData signs EIP-712 conformant typed data
 // hash = keccak256("\x19${byteVersion}${domainSeparator}${hashStruct(message)}")
 // It returns
@@ -31,7 +31,9 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/signer/core"
 	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 )
@@ -248,6 +250,70 @@ func TestSignData(t *testing.T) {
 	}
 }
 
+// Tests that QBFT commit seals and consensus messages are signed over the hashes
+// the QBFT engine verifies, and that anything else is rejected under their types.
+func TestSignQBFTData(t *testing.T) {
+	t.Parallel()
+	api, control := setup(t)
+	createAccount(control, api, t)
+	control.approveCh <- "A"
+	list, err := api.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := common.NewMixedcaseAddress(list[0])
+
+	// Commit seals must sign the header without the seals, in the given round
+	extra, _ := rlp.EncodeToBytes(&types.QBFTExtra{
+		VanityData:    make([]byte, 32),
+		Validators:    []common.Address{list[0]},
+		Round:         3,
+		CommittedSeal: [][]byte{make([]byte, 65)},
+	})
+	header := &types.Header{
+		Number:     big.NewInt(10),
+		Difficulty: big.NewInt(1),
+		Extra:      extra,
+		MixDigest:  types.IstanbulDigest,
+	}
+	blob, _ := rlp.EncodeToBytes(header)
+
+	control.approveCh <- "Y"
+	control.inputCh <- "a_long_password"
+	signature, err := api.SignData(context.Background(), apitypes.ApplicationQBFTHeader.Mime, a, hexutil.Encode(blob))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signature[64] > 1 {
+		t.Errorf("Expected V in 0/1 form, got %d", signature[64])
+	}
+	pubkey, err := crypto.SigToPub(header.QBFTHashWithRoundNumber(3).Bytes(), signature)
+	if err != nil || crypto.PubkeyToAddress(*pubkey) != list[0] {
+		t.Errorf("Seal signer mismatch: %v", err)
+	}
+	// Consensus messages must sign the hash of the code and payload
+	msg, _ := rlp.EncodeToBytes([]interface{}{uint64(0x13), []interface{}{big.NewInt(10), big.NewInt(0), common.Hash{}}})
+
+	control.approveCh <- "Y"
+	control.inputCh <- "a_long_password"
+	if signature, err = api.SignData(context.Background(), apitypes.ApplicationQBFTMessage.Mime, a, hexutil.Encode(msg)); err != nil {
+		t.Fatal(err)
+	}
+	pubkey, err = crypto.SigToPub(crypto.Keccak256(msg), signature)
+	if err != nil || crypto.PubkeyToAddress(*pubkey) != list[0] {
+		t.Errorf("Message signer mismatch: %v", err)
+	}
+	// Headers can't be signed as messages, nor other headers as QBFT headers
+	if _, err := api.SignData(context.Background(), apitypes.ApplicationQBFTMessage.Mime, a, hexutil.Encode(blob)); err == nil {
+		t.Errorf("Expected header to be rejected as QBFT message")
+	}
+	header.MixDigest = common.Hash{}
+	blob, _ = rlp.EncodeToBytes(header)
+	if _, err := api.SignData(context.Background(), apitypes.ApplicationQBFTHeader.Mime, a, hexutil.Encode(blob)); err == nil {
+		t.Errorf("Expected non-QBFT header to be rejected")
+	}
+}
+
 func TestDomainChainId(t *testing.T) {
 	t.Parallel()
 	withoutChainID := apitypes.TypedData{
@@ -0,0 +1,365 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// DefaultThresholdTimeout is the time a pending request waits for approvals if
+// no explicit timeout is configured.
+const DefaultThresholdTimeout = 5 * time.Minute
+
+// Approver is a remote party whose signed approval counts towards the threshold.
+// Pending requests are sent to the UI endpoint, and the approval must be signed
+// by the registered address.
+type Approver struct {
+	Address  common.Address
+	Endpoint string
+}
+
+// ParseApprover parses an approver in the form <address>@<endpoint>.
+func ParseApprover(s string) (Approver, error) {
+	addr, endpoint, found := strings.Cut(s, "@")
+	if !found || endpoint == "" {
+		return Approver{}, fmt.Errorf("invalid approver %q, want <address>@<endpoint>", s)
+	}
+	if !common.IsHexAddress(addr) {
+		return Approver{}, fmt.Errorf("invalid approver address %q", addr)
+	}
+	return Approver{Address: common.HexToAddress(addr), Endpoint: endpoint}, nil
+}
+
+// ThresholdConfig configures the M-of-N approval workflow.
+type ThresholdConfig struct {
+	Approvers []Approver       // Parties allowed to approve requests (N)
+	Threshold int              // Number of distinct approvals required (M)
+	Timeout   time.Duration    // Time to wait for the threshold to be met
+	Accounts  []common.Address // Accounts requiring threshold approval, all if empty
+	ChainID   *big.Int         // Chain id transactions are signed for
+}
+
+// ThresholdApprovalRequest is sent to every approver endpoint when a request
+// requires threshold approval. Exactly one of Transaction and SignData is set.
+//
+// The digest is keccak256(id || hash || deadline), with the deadline encoded as
+// a big endian uint64. For transactions, hash is the signing hash of the
+// transaction for the given chain id, for data it is the hash of the sign data
+// request. Approvers are expected to recompute the digest from the content they
+// checked rather than signing the one provided.
+type ThresholdApprovalRequest struct {
+	ID          common.Hash      `json:"id"`
+	Digest      common.Hash      `json:"digest"`
+	Deadline    uint64           `json:"deadline"`
+	ChainID     *hexutil.Big     `json:"chain_id,omitempty"`
+	Transaction *SignTxRequest   `json:"transaction,omitempty"`
+	SignData    *SignDataRequest `json:"sign_data,omitempty"`
+}
+
+// ThresholdApprovalResponse is the answer of an approver. If the request is
+// approved, Signature must be a personal_sign signature over the request digest
+// made with the approver's registered key.
+type ThresholdApprovalResponse struct {
+	Approved  bool          `json:"approved"`
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+// ThresholdUI is a UIClientAPI which approves signing requests only after a
+// threshold of registered approvers have signed off on them. Signing requests
+// for covered accounts must first be approved by the next UI, so that the local
+// rules and user still vet them. Requests for accounts which are not covered, as
+// well as all non-signing interactions, are only forwarded to the next UI.
+type ThresholdUI struct {
+	next      UIClientAPI
+	threshold int
+	timeout   time.Duration
+	chainID   *big.Int
+	accounts  map[common.Address]struct{}
+	approvers []*thresholdApprover
+	audit     log.Logger
+}
+
+type thresholdApprover struct {
+	address  common.Address
+	endpoint string
+	client   *rpc.Client
+}
+
+// approvalResult is the outcome of a single approver round-trip.
+type approvalResult struct {
+	approver *thresholdApprover
+	response ThresholdApprovalResponse
+	err      error
+}
+
+// NewThresholdUI creates a threshold approval UI in front of next. The audit
+// logger receives a record of every step of the approval workflow; if nil, the
+// root logger is used instead.
+func NewThresholdUI(next UIClientAPI, config ThresholdConfig, audit log.Logger) (*ThresholdUI, error) {
+	if len(config.Approvers) == 0 {
+		return nil, errors.New("no approvers configured")
+	}
+	if config.Threshold <= 0 || config.Threshold > len(config.Approvers) {
+		return nil, fmt.Errorf("invalid threshold %d for %d approvers", config.Threshold, len(config.Approvers))
+	}
+	if config.ChainID == nil {
+		return nil, errors.New("no chain id configured")
+	}
+	if audit == nil {
+		audit = log.Root()
+	}
+	ui := &ThresholdUI{
+		next:      next,
+		threshold: config.Threshold,
+		timeout:   config.Timeout,
+		chainID:   new(big.Int).Set(config.ChainID),
+		accounts:  make(map[common.Address]struct{}),
+		audit:     audit.With("component", "threshold"),
+	}
+	if ui.timeout <= 0 {
+		ui.timeout = DefaultThresholdTimeout
+	}
+	for _, addr := range config.Accounts {
+		ui.accounts[addr] = struct{}{}
+	}
+	seen := make(map[common.Address]bool)
+	for _, a := range config.Approvers {
+		if seen[a.Address] {
+			ui.Close()
+			return nil, fmt.Errorf("duplicate approver %v", a.Address)
+		}
+		seen[a.Address] = true
+
+		client, err := rpc.DialContext(context.Background(), a.Endpoint)
+		if err != nil {
+			ui.Close()
+			return nil, fmt.Errorf("failed to dial approver %v: %w", a.Address, err)
+		}
+		ui.approvers = append(ui.approvers, &thresholdApprover{address: a.Address, endpoint: a.Endpoint, client: client})
+	}
+	ui.audit.Info("Threshold approval configured", "threshold", ui.threshold, "approvers", len(ui.approvers), "timeout", ui.timeout)
+	return ui, nil
+}
+
+// Close terminates the connections to all approver endpoints.
+func (ui *ThresholdUI) Close() {
+	for _, a := range ui.approvers {
+		a.client.Close()
+	}
+}
+
+// covers reports whether requests for the given account require threshold approval.
+func (ui *ThresholdUI) covers(addr common.Address) bool {
+	if len(ui.accounts) == 0 {
+		return true
+	}
+	_, ok := ui.accounts[addr]
+	return ok
+}
+
+// ApprovalDigest computes the digest that approvers have to sign for the request
+// with the given id and deadline, approving the content identified by hash.
+func ApprovalDigest(id common.Hash, hash common.Hash, deadline uint64) common.Hash {
+	var enc [8]byte
+	binary.BigEndian.PutUint64(enc[:], deadline)
+	return crypto.Keccak256Hash(id[:], hash[:], enc[:])
+}
+
+// newApprovalRequest assigns a unique identifier to the request and computes
+// the digest that approvers have to sign for the content identified by hash.
+func newApprovalRequest(hash common.Hash, deadline time.Time) (ThresholdApprovalRequest, error) {
+	var id common.Hash
+	if _, err := rand.Read(id[:]); err != nil {
+		return ThresholdApprovalRequest{}, err
+	}
+	req := ThresholdApprovalRequest{
+		ID:       id,
+		Deadline: uint64(deadline.Unix()),
+	}
+	req.Digest = ApprovalDigest(req.ID, hash, req.Deadline)
+	return req, nil
+}
+
+// verifyApproval checks that the signature over the digest was made by the
+// given approver.
+func verifyApproval(digest common.Hash, sig []byte, approver common.Address) error {
+	if len(sig) != crypto.SignatureLength {
+		return fmt.Errorf("invalid signature length %d", len(sig))
+	}
+	sig = common.CopyBytes(sig)
+	if sig[crypto.RecoveryIDOffset] == 27 || sig[crypto.RecoveryIDOffset] == 28 {
+		sig[crypto.RecoveryIDOffset] -= 27 // Transform yellow paper V from 27/28 to 0/1
+	}
+	pub, err := crypto.SigToPub(accounts.TextHash(digest[:]), sig)
+	if err != nil {
+		return err
+	}
+	if signer := crypto.PubkeyToAddress(*pub); signer != approver {
+		return fmt.Errorf("signed by %v", signer)
+	}
+	return nil
+}
+
+// collect sends the request to all approvers and waits until either the
+// threshold is met, it can no longer be met, or the deadline passes. The deadline
+// is passed separately as the one in the request is truncated to seconds.
+func (ui *ThresholdUI) collect(kind string, account common.Address, req ThresholdApprovalRequest, deadline time.Time) bool {
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	ui.audit.Info("Threshold request pending", "kind", kind, "id", req.ID, "account", account, "digest", req.Digest, "threshold", ui.threshold)
+	ui.next.ShowInfo(fmt.Sprintf("%s request %x for %v awaiting approval by %d of %d approvers", kind, req.ID[:8], account, ui.threshold, len(ui.approvers)))
+
+	results := make(chan approvalResult, len(ui.approvers))
+	for _, a := range ui.approvers {
+		ui.audit.Info("Threshold approver notified", "id", req.ID, "approver", a.address, "endpoint", a.endpoint)
+		go func(a *thresholdApprover) {
+			var resp ThresholdApprovalResponse
+			err := a.client.CallContext(ctx, &resp, "ui_approveThresholdRequest", req)
+			results <- approvalResult{a, resp, err}
+		}(a)
+	}
+	var approvals, rejections int
+	for range ui.approvers {
+		select {
+		case res := <-results:
+			switch {
+			case res.err != nil:
+				rejections++
+				ui.audit.Warn("Threshold approver failed", "id", req.ID, "approver", res.approver.address, "err", res.err)
+			case !res.response.Approved:
+				rejections++
+				ui.audit.Info("Threshold approver rejected", "id", req.ID, "approver", res.approver.address)
+			default:
+				if err := verifyApproval(req.Digest, res.response.Signature, res.approver.address); err != nil {
+					rejections++
+					ui.audit.Warn("Threshold approval invalid", "id", req.ID, "approver", res.approver.address, "err", err)
+					break
+				}
+				approvals++
+				ui.audit.Info("Threshold approver approved", "id", req.ID, "approver", res.approver.address,
+					"signature", res.response.Signature, "approvals", approvals)
+			}
+		case <-ctx.Done():
+			ui.audit.Warn("Threshold request timed out", "id", req.ID, "approvals", approvals, "threshold", ui.threshold)
+			return false
+		}
+		if approvals >= ui.threshold {
+			ui.audit.Info("Threshold request approved", "id", req.ID, "approvals", approvals, "threshold", ui.threshold)
+			return true
+		}
+		if rejections > len(ui.approvers)-ui.threshold {
+			ui.audit.Info("Threshold request rejected", "id", req.ID, "rejections", rejections, "threshold", ui.threshold)
+			return false
+		}
+	}
+	return false
+}
+
+// ApproveTx requests threshold approval for signing a transaction from a
+// covered account, once the next UI approved it. Approvers are shown the
+// transaction as returned by the next UI and can't modify it.
+func (ui *ThresholdUI) ApproveTx(request *SignTxRequest) (SignTxResponse, error) {
+	account := request.Transaction.From.Address()
+	resp, err := ui.next.ApproveTx(request)
+	if err != nil || !resp.Approved || !ui.covers(account) {
+		return resp, err
+	}
+	approved := *request
+	approved.Transaction = resp.Transaction
+
+	tx, err := approved.Transaction.ToTransaction()
+	if err != nil {
+		return SignTxResponse{Transaction: resp.Transaction, Approved: false}, err
+	}
+	deadline := time.Now().Add(ui.timeout)
+	req, err := newApprovalRequest(types.LatestSignerForChainID(ui.chainID).Hash(tx), deadline)
+	if err != nil {
+		return SignTxResponse{Transaction: resp.Transaction, Approved: false}, err
+	}
+	req.ChainID = (*hexutil.Big)(ui.chainID)
+	req.Transaction = &approved
+	return SignTxResponse{Transaction: resp.Transaction, Approved: ui.collect("transaction", account, req, deadline)}, nil
+}
+
+// ApproveSignData requests threshold approval for signing data with a covered
+// account, once the next UI approved it.
+func (ui *ThresholdUI) ApproveSignData(request *SignDataRequest) (SignDataResponse, error) {
+	account := request.Address.Address()
+	resp, err := ui.next.ApproveSignData(request)
+	if err != nil || !resp.Approved || !ui.covers(account) {
+		return resp, err
+	}
+	if len(request.Hash) != common.HashLength {
+		return SignDataResponse{Approved: false}, fmt.Errorf("invalid sign data hash length %d", len(request.Hash))
+	}
+	deadline := time.Now().Add(ui.timeout)
+	req, err := newApprovalRequest(common.BytesToHash(request.Hash), deadline)
+	if err != nil {
+		return SignDataResponse{Approved: false}, err
+	}
+	req.SignData = request
+	return SignDataResponse{Approved: ui.collect("data", account, req, deadline)}, nil
+}
+
+func (ui *ThresholdUI) ApproveListing(request *ListRequest) (ListResponse, error) {
+	return ui.next.ApproveListing(request)
+}
+
+func (ui *ThresholdUI) ApproveNewAccount(request *NewAccountRequest) (NewAccountResponse, error) {
+	return ui.next.ApproveNewAccount(request)
+}
+
+func (ui *ThresholdUI) ShowError(message string) {
+	ui.next.ShowError(message)
+}
+
+func (ui *ThresholdUI) ShowInfo(message string) {
+	ui.next.ShowInfo(message)
+}
+
+func (ui *ThresholdUI) OnApprovedTx(tx ethapi.SignTransactionResult) {
+	ui.next.OnApprovedTx(tx)
+}
+
+func (ui *ThresholdUI) OnSignerStartup(info StartupInfo) {
+	ui.next.OnSignerStartup(info)
+}
+
+func (ui *ThresholdUI) OnInputRequired(info UserInputRequest) (UserInputResponse, error) {
+	return ui.next.OnInputRequired(info)
+}
+
+func (ui *ThresholdUI) RegisterUIServer(api *UIServerAPI) {
+	ui.next.RegisterUIServer(api)
+}
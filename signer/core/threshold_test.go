@@ -0,0 +1,331 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// testApprover is a remote approver UI answering threshold requests.
+type testApprover struct {
+	key     *ecdsa.PrivateKey
+	approve bool
+	forge   bool          // sign with a different key
+	delay   time.Duration // delay before answering
+
+	lock     sync.Mutex
+	requests int                      // number of requests received
+	last     ThresholdApprovalRequest // last request received
+}
+
+func (a *testApprover) ApproveThresholdRequest(req ThresholdApprovalRequest) (ThresholdApprovalResponse, error) {
+	a.lock.Lock()
+	a.requests++
+	a.last = req
+	a.lock.Unlock()
+
+	time.Sleep(a.delay)
+	if !a.approve {
+		return ThresholdApprovalResponse{Approved: false}, nil
+	}
+	digest, err := recomputeDigest(req)
+	if err != nil {
+		return ThresholdApprovalResponse{}, err
+	}
+	if digest != req.Digest {
+		return ThresholdApprovalResponse{}, errors.New("digest mismatch")
+	}
+	key := a.key
+	if a.forge {
+		key, _ = crypto.GenerateKey()
+	}
+	sig, err := crypto.Sign(accounts.TextHash(req.Digest[:]), key)
+	if err != nil {
+		return ThresholdApprovalResponse{}, err
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return ThresholdApprovalResponse{Approved: true, Signature: sig}, nil
+}
+
+// recomputeDigest derives the approval digest from the request content, the
+// way an external approver would.
+func recomputeDigest(req ThresholdApprovalRequest) (common.Hash, error) {
+	var hash []byte
+	switch {
+	case req.Transaction != nil:
+		if req.ChainID == nil {
+			return common.Hash{}, errors.New("missing chain id")
+		}
+		tx, err := req.Transaction.Transaction.ToTransaction()
+		if err != nil {
+			return common.Hash{}, err
+		}
+		sighash := types.LatestSignerForChainID(req.ChainID.ToInt()).Hash(tx)
+		hash = sighash[:]
+	case req.SignData != nil:
+		hash = req.SignData.Hash
+	default:
+		return common.Hash{}, errors.New("empty request")
+	}
+	deadline := binary.BigEndian.AppendUint64(nil, req.Deadline)
+	return crypto.Keccak256Hash(req.ID[:], hash, deadline), nil
+}
+
+// localUI is the UI in front of the threshold approvers, approving or rejecting
+// every request.
+type localUI struct {
+	approve  bool
+	value    *hexutil.Big // value to set on approved transactions, if not nil
+	requests int
+	infos    int
+}
+
+func (ui *localUI) ApproveTx(request *SignTxRequest) (SignTxResponse, error) {
+	ui.requests++
+	tx := request.Transaction
+	if ui.value != nil {
+		tx.Value = *ui.value
+	}
+	return SignTxResponse{tx, ui.approve}, nil
+}
+func (ui *localUI) ApproveSignData(request *SignDataRequest) (SignDataResponse, error) {
+	ui.requests++
+	return SignDataResponse{ui.approve}, nil
+}
+func (ui *localUI) ApproveListing(request *ListRequest) (ListResponse, error) {
+	return ListResponse{}, nil
+}
+func (ui *localUI) ApproveNewAccount(request *NewAccountRequest) (NewAccountResponse, error) {
+	return NewAccountResponse{false}, nil
+}
+func (ui *localUI) ShowError(message string)                     {}
+func (ui *localUI) ShowInfo(message string)                      { ui.infos++ }
+func (ui *localUI) OnApprovedTx(tx ethapi.SignTransactionResult) {}
+func (ui *localUI) OnSignerStartup(info StartupInfo)             {}
+func (ui *localUI) RegisterUIServer(api *UIServerAPI)            {}
+func (ui *localUI) OnInputRequired(info UserInputRequest) (UserInputResponse, error) {
+	return UserInputResponse{}, nil
+}
+
+func newTestThresholdUI(t *testing.T, next *localUI, threshold int, timeout time.Duration, accs []common.Address, approvers ...*testApprover) *ThresholdUI {
+	t.Helper()
+
+	var config = ThresholdConfig{Threshold: threshold, Timeout: timeout, Accounts: accs, ChainID: big.NewInt(1337)}
+	for _, a := range approvers {
+		a.key, _ = crypto.GenerateKey()
+
+		srv := rpc.NewServer()
+		if err := srv.RegisterName("ui", a); err != nil {
+			t.Fatal(err)
+		}
+		httpsrv := httptest.NewServer(srv)
+		t.Cleanup(func() {
+			httpsrv.Close()
+			srv.Stop()
+		})
+		config.Approvers = append(config.Approvers, Approver{
+			Address:  crypto.PubkeyToAddress(a.key.PublicKey),
+			Endpoint: httpsrv.URL,
+		})
+	}
+	ui, err := NewThresholdUI(next, config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(ui.Close)
+	return ui
+}
+
+func testTxRequest(from common.Address) *SignTxRequest {
+	to := common.NewMixedcaseAddress(common.HexToAddress("0x3333333333333333333333333333333333333333"))
+	return &SignTxRequest{Transaction: apitypes.SendTxArgs{
+		From:     common.NewMixedcaseAddress(from),
+		To:       &to,
+		Gas:      21000,
+		GasPrice: (*hexutil.Big)(big.NewInt(1)),
+	}}
+}
+
+func testDataRequest(from common.Address) *SignDataRequest {
+	return &SignDataRequest{
+		Address: common.NewMixedcaseAddress(from),
+		Rawdata: []byte("hello"),
+		Hash:    accounts.TextHash([]byte("hello")),
+	}
+}
+
+func TestThresholdApproval(t *testing.T) {
+	t.Parallel()
+
+	treasury := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	tests := []struct {
+		name      string
+		threshold int
+		approvers []*testApprover
+		want      bool
+	}{
+		{"all approve", 2, []*testApprover{{approve: true}, {approve: true}, {approve: true}}, true},
+		{"threshold met", 2, []*testApprover{{approve: true}, {approve: false}, {approve: true}}, true},
+		{"threshold missed", 2, []*testApprover{{approve: true}, {approve: false}, {approve: false}}, false},
+		{"forged signature", 2, []*testApprover{{approve: true}, {approve: true, forge: true}, {approve: false}}, false},
+		{"timeout", 2, []*testApprover{{approve: true}, {approve: true, delay: time.Second}}, false},
+	}
+	for _, tt := range tests {
+		next := &localUI{approve: true}
+		ui := newTestThresholdUI(t, next, tt.threshold, 200*time.Millisecond, []common.Address{treasury}, tt.approvers...)
+
+		resp, err := ui.ApproveTx(testTxRequest(treasury))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if resp.Approved != tt.want {
+			t.Errorf("%s: tx approval mismatch: have %v, want %v", tt.name, resp.Approved, tt.want)
+		}
+		if next.infos == 0 {
+			t.Errorf("%s: local UI not notified about pending request", tt.name)
+		}
+		data, err := ui.ApproveSignData(testDataRequest(treasury))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if data.Approved != tt.want {
+			t.Errorf("%s: data approval mismatch: have %v, want %v", tt.name, data.Approved, tt.want)
+		}
+	}
+}
+
+func TestThresholdUncoveredAccount(t *testing.T) {
+	t.Parallel()
+
+	treasury := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	next := new(localUI)
+	ui := newTestThresholdUI(t, next, 1, time.Second, []common.Address{treasury}, &testApprover{approve: true})
+
+	// The next UI denies everything, so requests for other accounts must be rejected.
+	resp, err := ui.ApproveTx(testTxRequest(common.HexToAddress("0x2222222222222222222222222222222222222222")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Approved {
+		t.Fatal("uncovered account approved by threshold approvers")
+	}
+	if next.infos != 0 {
+		t.Fatal("uncovered account sent to threshold approvers")
+	}
+}
+
+// Tests that covered requests are vetted by the next UI before the approvers,
+// and that the approvers are shown the transaction approved by it.
+func TestThresholdLocalApproval(t *testing.T) {
+	t.Parallel()
+
+	treasury := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	next := new(localUI)
+	approver := &testApprover{approve: true}
+	ui := newTestThresholdUI(t, next, 1, time.Second, []common.Address{treasury}, approver)
+
+	// Requests rejected locally must not reach the approvers
+	resp, err := ui.ApproveTx(testTxRequest(treasury))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Approved {
+		t.Error("locally rejected transaction approved")
+	}
+	data, err := ui.ApproveSignData(testDataRequest(treasury))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Approved {
+		t.Error("locally rejected data approved")
+	}
+	if next.requests != 2 || next.infos != 0 {
+		t.Errorf("local UI mismatch: have %d requests, %d notifications, want 2 requests, 0 notifications", next.requests, next.infos)
+	}
+	approver.lock.Lock()
+	if approver.requests != 0 {
+		t.Errorf("locally rejected requests sent to approvers: %d", approver.requests)
+	}
+	approver.lock.Unlock()
+	// Requests approved locally are shown to the approvers as modified locally
+	next.approve, next.value = true, (*hexutil.Big)(big.NewInt(42))
+	if resp, err = ui.ApproveTx(testTxRequest(treasury)); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Approved || resp.Transaction.Value.ToInt().Int64() != 42 {
+		t.Errorf("response mismatch: have approved %v, value %v, want approved, value 42", resp.Approved, resp.Transaction.Value.ToInt())
+	}
+	approver.lock.Lock()
+	defer approver.lock.Unlock()
+	if approver.requests != 1 || approver.last.Transaction == nil || approver.last.Transaction.Transaction.Value.ToInt().Int64() != 42 {
+		t.Errorf("approvers not shown the locally approved transaction")
+	}
+}
+
+func TestParseApprover(t *testing.T) {
+	t.Parallel()
+
+	a, err := ParseApprover("0x1111111111111111111111111111111111111111@http://localhost:8550")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Address != common.HexToAddress("0x1111111111111111111111111111111111111111") || a.Endpoint != "http://localhost:8550" {
+		t.Fatalf("wrong approver: %+v", a)
+	}
+	for _, s := range []string{"", "0x1111111111111111111111111111111111111111", "0x11@http://localhost", "http://localhost"} {
+		if _, err := ParseApprover(s); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+}
+
+// Tests that data requests without a valid hash are not sent to the approvers,
+// as there is nothing they could sign.
+func TestThresholdInvalidDataHash(t *testing.T) {
+	t.Parallel()
+
+	treasury := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	approver := &testApprover{approve: true}
+	ui := newTestThresholdUI(t, &localUI{approve: true}, 1, time.Second, nil, approver)
+
+	req := testDataRequest(treasury)
+	req.Hash = req.Hash[:16]
+	if resp, err := ui.ApproveSignData(req); err == nil || resp.Approved {
+		t.Fatalf("truncated hash accepted: approved %v, err %v", resp.Approved, err)
+	}
+	approver.lock.Lock()
+	defer approver.lock.Unlock()
+	if approver.requests != 0 {
+		t.Errorf("invalid request sent to approvers: %d", approver.requests)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rules
+
+import (
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/signer/core"
+)
+
+// qbftUI provides an implementation of UIClientAPI that approves the QBFT commit
+// seals and consensus messages of a validator without user interaction, letting
+// a QBFT node keep its validator key in clef. Any other request is forwarded for
+// manual processing.
+type qbftUI struct {
+	next      core.UIClientAPI // The next handler, for manual processing
+	validator common.Address   // Validator account allowed to seal
+}
+
+// NewQBFTRuleset creates a UI auto-approving the QBFT signing requests of the
+// given validator account.
+func NewQBFTRuleset(next core.UIClientAPI, validator common.Address) core.UIClientAPI {
+	return &qbftUI{next: next, validator: validator}
+}
+
+func (r *qbftUI) RegisterUIServer(api *core.UIServerAPI) {
+	r.next.RegisterUIServer(api)
+}
+
+func (r *qbftUI) ApproveTx(request *core.SignTxRequest) (core.SignTxResponse, error) {
+	return r.next.ApproveTx(request)
+}
+
+// ApproveSignData approves the requests of the validator to sign QBFT headers or
+// messages. The content was checked to be of the type announced before getting
+// here, so a validator seal can't be obtained with any other kind of request.
+func (r *qbftUI) ApproveSignData(request *core.SignDataRequest) (core.SignDataResponse, error) {
+	switch request.ContentType {
+	case accounts.MimetypeQBFTHeader, accounts.MimetypeQBFTMessage:
+		if request.Address.Address() == r.validator {
+			log.Debug("QBFT signing approved", "type", request.ContentType, "hash", request.Hash)
+			return core.SignDataResponse{Approved: true}, nil
+		}
+	}
+	return r.next.ApproveSignData(request)
+}
+
+func (r *qbftUI) ApproveListing(request *core.ListRequest) (core.ListResponse, error) {
+	return r.next.ApproveListing(request)
+}
+
+func (r *qbftUI) ApproveNewAccount(request *core.NewAccountRequest) (core.NewAccountResponse, error) {
+	return r.next.ApproveNewAccount(request)
+}
+
+func (r *qbftUI) ShowError(message string) {
+	r.next.ShowError(message)
+}
+
+func (r *qbftUI) ShowInfo(message string) {
+	r.next.ShowInfo(message)
+}
+
+func (r *qbftUI) OnApprovedTx(tx ethapi.SignTransactionResult) {
+	r.next.OnApprovedTx(tx)
+}
+
+func (r *qbftUI) OnSignerStartup(info core.StartupInfo) {
+	r.next.OnSignerStartup(info)
+}
+
+func (r *qbftUI) OnInputRequired(info core.UserInputRequest) (core.UserInputResponse, error) {
+	return r.next.OnInputRequired(info)
+}
@@ -0,0 +1,64 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rules
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/signer/core"
+)
+
+func TestQBFTRuleset(t *testing.T) {
+	t.Parallel()
+	var (
+		validator = common.HexToAddress("0x694267f14675d7e1b9494fd8d72fefe1755710fa")
+		other     = common.HexToAddress("0x000000000000000000000000000000000000dead")
+	)
+	tests := []struct {
+		mime     string
+		addr     common.Address
+		approved bool
+	}{
+		{accounts.MimetypeQBFTHeader, validator, true},
+		{accounts.MimetypeQBFTMessage, validator, true},
+		{accounts.MimetypeQBFTHeader, other, false},
+		{accounts.MimetypeQBFTMessage, other, false},
+		{accounts.MimetypeClique, validator, false},
+		{accounts.MimetypeTextPlain, validator, false},
+	}
+	for i, tt := range tests {
+		next := &dummyUI{}
+		ui := NewQBFTRuleset(next, validator)
+
+		resp, err := ui.ApproveSignData(&core.SignDataRequest{
+			ContentType: tt.mime,
+			Address:     common.NewMixedcaseAddress(tt.addr),
+		})
+		if resp.Approved != tt.approved {
+			t.Errorf("test %d: approval mismatch: have %v, want %v", i, resp.Approved, tt.approved)
+		}
+		if tt.approved {
+			if err != nil || len(next.calls) != 0 {
+				t.Errorf("test %d: approved request forwarded: err %v, calls %v", i, err, next.calls)
+			}
+		} else if len(next.calls) != 1 || next.calls[0] != "ApproveSignData" {
+			t.Errorf("test %d: request not forwarded: calls %v", i, next.calls)
+		}
+	}
+}
@@ -78,16 +78,20 @@ func execBlockTest(t *testing.T, bt *testMatcher, test *BlockTest) {
 	var (
 		snapshotConf = []bool{false, true}
 		dbschemeConf = []string{rawdb.HashScheme, rawdb.PathScheme}
+		parallelConf = []bool{false, true}
 	)
 	if testing.Short() {
 		snapshotConf = []bool{snapshotConf[rand.Int()%2]}
 		dbschemeConf = []string{dbschemeConf[rand.Int()%2]}
+		parallelConf = []bool{parallelConf[rand.Int()%2]}
 	}
 	for _, snapshot := range snapshotConf {
 		for _, dbscheme := range dbschemeConf {
-			if err := bt.checkFailure(t, test.Run(snapshot, dbscheme, true, nil, nil)); err != nil {
-				t.Errorf("test with config {snapshotter:%v, scheme:%v} failed: %v", snapshot, dbscheme, err)
-				return
+			for _, parallel := range parallelConf {
+				if err := bt.checkFailure(t, test.Run(snapshot, dbscheme, true, parallel, nil, nil)); err != nil {
+					t.Errorf("test with config {snapshotter:%v, scheme:%v, parallel:%v} failed: %v", snapshot, dbscheme, parallel, err)
+					return
+				}
 			}
 		}
 	}
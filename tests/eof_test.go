@@ -0,0 +1,49 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tests
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TestEOFValidation runs the EOF container validation tests.
+func TestEOFValidation(t *testing.T) {
+	t.Parallel()
+
+	et := new(testMatcher)
+	et.walk(t, eofTestDir, func(t *testing.T, name string, test *EOFTest) {
+		if err := et.checkFailure(t, test.Run()); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+// TestExecutionSpecEOF runs the EOF validation fixtures from execution-spec-tests.
+func TestExecutionSpecEOF(t *testing.T) {
+	if !common.FileExist(executionSpecEOFTestDir) {
+		t.Skipf("directory %s does not exist", executionSpecEOFTestDir)
+	}
+	et := new(testMatcher)
+
+	et.walk(t, executionSpecEOFTestDir, func(t *testing.T, name string, test *EOFTest) {
+		if err := et.checkFailure(t, test.Run()); err != nil {
+			t.Error(err)
+		}
+	})
+}
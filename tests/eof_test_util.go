@@ -0,0 +1,65 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tests
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// EOFTest checks the validation of EOF containers.
+type EOFTest struct {
+	Vectors map[string]eofVector `json:"vectors"`
+}
+
+type eofVector struct {
+	Code          hexutil.Bytes        `json:"code"`
+	ContainerKind string               `json:"containerKind"`
+	Results       map[string]eofResult `json:"results"`
+}
+
+type eofResult struct {
+	Result    bool   `json:"result"`
+	Exception string `json:"exception,omitempty"`
+}
+
+var eofJumpTable = vm.NewEOFInstructionSetForTesting()
+
+// Run validates every vector of the test against its expected outcome.
+func (t *EOFTest) Run() error {
+	for name, vector := range t.Vectors {
+		var (
+			container  vm.Container
+			isInitCode = vector.ContainerKind == "INITCODE"
+		)
+		err := container.UnmarshalBinary(vector.Code)
+		if err == nil {
+			err = container.ValidateCode(&eofJumpTable, isInitCode)
+		}
+		for fork, result := range vector.Results {
+			if result.Result && err != nil {
+				return fmt.Errorf("vector %s, fork %s: unexpected error: %v", name, fork, err)
+			}
+			if !result.Result && err == nil {
+				return fmt.Errorf("vector %s, fork %s: expected error %q, got none", name, fork, result.Exception)
+			}
+		}
+	}
+	return nil
+}
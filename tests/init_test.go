@@ -41,8 +41,10 @@ var (
 	transactionTestDir             = filepath.Join(baseDir, "TransactionTests")
 	rlpTestDir                     = filepath.Join(baseDir, "RLPTests")
 	difficultyTestDir              = filepath.Join(baseDir, "BasicTests")
+	eofTestDir                     = filepath.Join(baseDir, "EOFTests")
 	executionSpecBlockchainTestDir = filepath.Join(".", "spec-tests", "fixtures", "blockchain_tests")
 	executionSpecStateTestDir      = filepath.Join(".", "spec-tests", "fixtures", "state_tests")
+	executionSpecEOFTestDir        = filepath.Join(".", "spec-tests", "fixtures", "eof_tests")
 	benchmarksDir                  = filepath.Join(".", "evm-benchmarks", "benchmarks")
 )
 
@@ -54,10 +54,6 @@ func initMatcher(st *testMatcher) {
 	// Uses 1GB RAM per tested fork
 	st.skipLoad(`^stStaticCall/static_Call1MB`)
 
-	// Broken tests:
-	// EOF is not part of cancun
-	st.skipLoad(`^stEOF/`)
-
 	// The tests under Pyspecs are the ones that are published as execution-spec tests.
 	// We run these tests separately, no need to _also_ run them as part of the
 	// reference tests.
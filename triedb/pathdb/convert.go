@@ -0,0 +1,272 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pathdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb/database"
+)
+
+// hashDatabase is a trie node reader over the persisted state of a hash-scheme
+// database, used as the source of the scheme conversion.
+type hashDatabase struct {
+	disk ethdb.KeyValueReader
+}
+
+// Reader implements database.Database, returning a reader of the state with
+// the given root.
+func (db *hashDatabase) Reader(root common.Hash) (database.Reader, error) {
+	return db, nil
+}
+
+// Node implements database.Reader, retrieving the trie node with the given hash.
+func (db *hashDatabase) Node(owner common.Hash, path []byte, hash common.Hash) ([]byte, error) {
+	return rawdb.ReadLegacyTrieNode(db.disk, hash), nil
+}
+
+// stateWalker iterates all the trie nodes and contract codes of a state.
+type stateWalker struct {
+	db     database.Database
+	root   common.Hash
+	nodes  int       // Number of trie nodes iterated
+	logged time.Time // Time of the last progress log
+}
+
+// walk iterates the state, invoking onNode for every non-embedded trie node and
+// onCode for every contract code hash.
+func (w *stateWalker) walk(onNode func(owner common.Hash, path []byte, blob []byte) error, onCode func(hash common.Hash) error) error {
+	tr, err := trie.New(trie.StateTrieID(w.root), w.db)
+	if err != nil {
+		return err
+	}
+	accIter, err := tr.NodeIterator(nil)
+	if err != nil {
+		return err
+	}
+	for accIter.Next(true) {
+		// Embedded nodes are stored within their parents.
+		if accIter.Hash() != (common.Hash{}) {
+			if err := onNode(common.Hash{}, accIter.Path(), accIter.NodeBlob()); err != nil {
+				return err
+			}
+			w.nodes++
+		}
+		if time.Since(w.logged) > 8*time.Second {
+			log.Info("Iterating state", "root", w.root, "nodes", w.nodes, "at", common.Bytes2Hex(accIter.Path()))
+			w.logged = time.Now()
+		}
+		if !accIter.Leaf() {
+			continue
+		}
+		var acc types.StateAccount
+		if err := rlp.DecodeBytes(accIter.LeafBlob(), &acc); err != nil {
+			return err
+		}
+		owner := common.BytesToHash(accIter.LeafKey())
+		if acc.Root != types.EmptyRootHash {
+			storageTrie, err := trie.New(trie.StorageTrieID(w.root, owner, acc.Root), w.db)
+			if err != nil {
+				return err
+			}
+			storageIter, err := storageTrie.NodeIterator(nil)
+			if err != nil {
+				return err
+			}
+			for storageIter.Next(true) {
+				if storageIter.Hash() == (common.Hash{}) {
+					continue
+				}
+				if err := onNode(owner, storageIter.Path(), storageIter.NodeBlob()); err != nil {
+					return err
+				}
+				w.nodes++
+			}
+			if err := storageIter.Error(); err != nil {
+				return err
+			}
+		}
+		if !bytes.Equal(acc.CodeHash, types.EmptyCodeHash.Bytes()) {
+			if err := onCode(common.BytesToHash(acc.CodeHash)); err != nil {
+				return err
+			}
+		}
+	}
+	return accIter.Error()
+}
+
+// ConvertHashScheme converts the persisted hash-scheme state with the given root
+// into the path-scheme layout, initialising the disk layer of the path database
+// on top of it. Contract codes stored in the legacy layout are migrated as well.
+//
+// The root node is written last, so an interrupted conversion leaves the database
+// detected as hash-scheme and can simply be restarted. The legacy trie nodes are
+// left untouched, they can be removed afterwards with DeleteHashSchemeNodes.
+func ConvertHashScheme(db ethdb.Database, root common.Hash) error {
+	if root == types.EmptyRootHash {
+		return errors.New("empty state")
+	}
+	if !rawdb.HasLegacyTrieNode(db, root) {
+		return fmt.Errorf("hash-scheme state %x is not available", root)
+	}
+	if rawdb.HasAccountTrieNode(db, nil) {
+		return errors.New("path-scheme state already exists")
+	}
+	var (
+		start    = time.Now()
+		batch    = db.NewBatch()
+		rootBlob []byte
+		codes    int
+		walker   = &stateWalker{db: &hashDatabase{disk: db}, root: root, logged: time.Now()}
+	)
+	flush := func() error {
+		if batch.ValueSize() < ethdb.IdealBatchSize {
+			return nil
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Reset()
+		return nil
+	}
+	onNode := func(owner common.Hash, path []byte, blob []byte) error {
+		if owner == (common.Hash{}) {
+			if len(path) == 0 {
+				rootBlob = common.CopyBytes(blob)
+				return nil
+			}
+			rawdb.WriteAccountTrieNode(batch, path, blob)
+		} else {
+			rawdb.WriteStorageTrieNode(batch, owner, path, blob)
+		}
+		return flush()
+	}
+	onCode := func(hash common.Hash) error {
+		if rawdb.HasCodeWithPrefix(db, hash) {
+			return nil
+		}
+		code := rawdb.ReadCode(db, hash)
+		if len(code) == 0 {
+			return fmt.Errorf("missing contract code %x", hash)
+		}
+		rawdb.WriteCode(batch, hash, code)
+		codes++
+		return flush()
+	}
+	log.Info("Converting state to path scheme", "root", root)
+	if err := walker.walk(onNode, onCode); err != nil {
+		return err
+	}
+	// Write the root node and initialise the disk layer metadata as the last
+	// step, marking the conversion complete.
+	rawdb.WriteAccountTrieNode(batch, nil, rootBlob)
+	rawdb.DeleteTrieJournal(batch)
+	rawdb.WritePersistentStateID(batch, 0)
+	rawdb.WriteStateID(batch, root, 0)
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Info("Converted state to path scheme", "root", root, "nodes", walker.nodes, "codes", codes, "elapsed", common.PrettyDuration(time.Since(start)))
+	return resetStateFreezer(db)
+}
+
+// resetStateFreezer creates an empty freezer for the state histories, which
+// have to start from the converted disk layer.
+func resetStateFreezer(db ethdb.Database) error {
+	ancient, err := db.AncientDatadir()
+	if err != nil {
+		return nil // ancient store disabled
+	}
+	freezer, err := rawdb.NewStateFreezer(ancient, false)
+	if err != nil {
+		return err
+	}
+	defer freezer.Close()
+
+	return freezer.Reset()
+}
+
+// VerifyPathScheme iterates the entire persisted path-scheme state, ensuring
+// that all trie nodes and contract codes are present and match their hashes.
+func VerifyPathScheme(db ethdb.Database) error {
+	pdb := New(db, &Config{ReadOnly: true}, false)
+	defer pdb.Close()
+
+	var (
+		start  = time.Now()
+		root   = pdb.tree.bottom().rootHash()
+		walker = &stateWalker{db: pdb, root: root, logged: time.Now()}
+	)
+	onNode := func(owner common.Hash, path []byte, blob []byte) error { return nil }
+	onCode := func(hash common.Hash) error {
+		if !rawdb.HasCode(db, hash) {
+			return fmt.Errorf("missing contract code %x", hash)
+		}
+		return nil
+	}
+	if err := walker.walk(onNode, onCode); err != nil {
+		return err
+	}
+	log.Info("Verified path-scheme state", "root", root, "nodes", walker.nodes, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// DeleteHashSchemeNodes removes all the trie nodes and contract codes stored in
+// the legacy hash-scheme layout. It must only be invoked after the conversion
+// completed, since the legacy codes are not accessible afterwards.
+func DeleteHashSchemeNodes(db ethdb.Database) error {
+	var (
+		start = time.Now()
+		count int
+		batch = db.NewBatch()
+		iter  = db.NewIterator(nil, nil)
+	)
+	for iter.Next() {
+		if !rawdb.IsLegacyTrieNode(iter.Key(), iter.Value()) {
+			continue
+		}
+		batch.Delete(iter.Key())
+		count++
+
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				iter.Release()
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Info("Deleted hash-scheme state", "nodes", count, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pathdb
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/internal/testrand"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// writeHashTrie commits the given trie and persists its nodes in the legacy
+// hash-scheme layout.
+func writeHashTrie(t *testing.T, db ethdb.Database, tr *trie.Trie) common.Hash {
+	root, nodes := tr.Commit(false)
+	if nodes == nil {
+		return root
+	}
+	for _, n := range nodes.Nodes {
+		if !n.IsDeleted() {
+			rawdb.WriteLegacyTrieNode(db, n.Hash, n.Blob)
+		}
+	}
+	return root
+}
+
+// newHashState creates a random hash-scheme state with storage and codes.
+func newHashState(t *testing.T, db ethdb.Database) common.Hash {
+	reader := &hashDatabase{disk: db}
+	accTrie := trie.NewEmpty(reader)
+	for i := 0; i < 64; i++ {
+		acc := types.NewEmptyStateAccount()
+		acc.Balance.SetUint64(uint64(i + 1))
+
+		if i%2 == 0 {
+			storageTrie := trie.NewEmpty(reader)
+			for j := 0; j < 16; j++ {
+				val, _ := rlp.EncodeToBytes(testrand.Bytes(16))
+				storageTrie.MustUpdate(testrand.Bytes(32), val)
+			}
+			acc.Root = writeHashTrie(t, db, storageTrie)
+		}
+		if i%3 == 0 {
+			code := testrand.Bytes(32)
+			acc.CodeHash = crypto.Keccak256(code)
+			db.Put(acc.CodeHash, code) // legacy code layout, without prefix
+		}
+		blob, _ := rlp.EncodeToBytes(acc)
+		accTrie.MustUpdate(testrand.Bytes(32), blob)
+	}
+	root := writeHashTrie(t, db, accTrie)
+
+	// Link the state to the genesis header, the scheme is detected with it
+	genesis := &types.Header{Number: common.Big0, Root: root}
+	rawdb.WriteHeader(db, genesis)
+	rawdb.WriteCanonicalHash(db, genesis.Hash(), 0)
+	return root
+}
+
+func TestConvertHashScheme(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	root := newHashState(t, db)
+
+	if scheme := rawdb.ReadStateScheme(db); scheme != rawdb.HashScheme {
+		t.Fatalf("unexpected scheme before conversion: %s", scheme)
+	}
+	if err := ConvertHashScheme(db, root); err != nil {
+		t.Fatalf("conversion failed: %v", err)
+	}
+	if scheme := rawdb.ReadStateScheme(db); scheme != rawdb.PathScheme {
+		t.Fatalf("unexpected scheme after conversion: %s", scheme)
+	}
+	if err := ConvertHashScheme(db, root); err == nil {
+		t.Fatalf("repeated conversion succeeded")
+	}
+	if err := DeleteHashSchemeNodes(db); err != nil {
+		t.Fatalf("failed to delete legacy state: %v", err)
+	}
+	if rawdb.HasLegacyTrieNode(db, root) {
+		t.Fatalf("legacy root left after deletion")
+	}
+	if err := VerifyPathScheme(db); err != nil {
+		t.Fatalf("converted state is incomplete: %v", err)
+	}
+	pdb := New(db, nil, false)
+	defer pdb.Close()
+	if have := pdb.tree.bottom().rootHash(); have != root {
+		t.Fatalf("disk layer root mismatch: have %x, want %x", have, root)
+	}
+	if id := rawdb.ReadPersistentStateID(db); id != 0 {
+		t.Fatalf("unexpected persistent state id: %d", id)
+	}
+}
+
+func TestConvertHashSchemeMissingNode(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	root := newHashState(t, db)
+
+	// Drop a random storage node, the conversion must fail without marking
+	// the database as path-scheme.
+	it := db.NewIterator(nil, nil)
+	for it.Next() {
+		if rawdb.IsLegacyTrieNode(it.Key(), it.Value()) && common.BytesToHash(it.Key()) != root {
+			db.Delete(it.Key())
+			break
+		}
+	}
+	it.Release()
+
+	if err := ConvertHashScheme(db, root); err == nil {
+		t.Fatalf("conversion of incomplete state succeeded")
+	}
+	if scheme := rawdb.ReadStateScheme(db); scheme != rawdb.HashScheme {
+		t.Fatalf("incomplete conversion changed the scheme to %s", scheme)
+	}
+}